@@ -0,0 +1,235 @@
+// Package backup implements the journal command, which exports the journal
+// records to a portable file and imports them back, e.g. to migrate a bin.
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+)
+
+// Export formats of the journal records.
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var (
+	Flags         = flag.NewFlagSet("journal", flag.ExitOnError)
+	format string = FormatNDJSON // format is the encoding of the exported records
+	binary bool   = false        // binary selects Badger's native backup stream instead of the records
+)
+
+func init() {
+	Flags.StringVar(&format, "format", FormatNDJSON, "Format of the exported records: json or ndjson.")
+	Flags.BoolVar(&binary, "binary", false, "Use Badger's native backup stream, internal state included.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish journal exports the journal records to a file and imports them back.\n",
+			"Usage:\n\n",
+			"\trubbish journal export [options] <file>\n",
+			"\trubbish journal import [options] <file>\n\n",
+			"Use - as file for the standard output or input.\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+// MachineOutput reports whether the records are exported to the standard
+// output, in which case nothing else must be written to it. The export file
+// is the last argument, whether the options come before or after export.
+func MachineOutput() bool {
+	args := Flags.Args()
+	return len(args) > 1 && args[0] == "export" && args[len(args)-1] == "-"
+}
+
+// Command dispatches the export and import subcommands. Options may also be
+// given after the subcommand name.
+func Command(args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		Flags.Usage()
+		return fmt.Errorf("no journal subcommand specified (expected export or import)")
+	}
+
+	subcommand := args[0]
+	if err := Flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if Flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one file to %s", subcommand)
+	}
+	file := Flags.Arg(0)
+
+	if !binary && !slices.Contains([]string{FormatJSON, FormatNDJSON}, format) {
+		return fmt.Errorf("unsupported format '%s' (expected json or ndjson)", format)
+	}
+
+	switch subcommand {
+	case "export":
+		return exportJournal(cfg, file)
+	case "import":
+		return importJournal(cfg, file)
+	default:
+		return fmt.Errorf("unknown journal subcommand '%s' (expected export or import)", subcommand)
+	}
+}
+
+// exportJournal writes every record, or the binary backup, to file.
+func exportJournal(cfg *config.Config, file string) (err error) {
+	out := os.Stdout
+	if file != "-" {
+		if out, err = os.Create(file); err != nil {
+			return fmt.Errorf("error creating export file: %w", err)
+		}
+		defer func() {
+			if cerr := out.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("error closing export file: %w", cerr)
+			}
+		}()
+	}
+
+	if binary {
+		if err := cfg.Journal.Backup(out); err != nil {
+			return err
+		}
+		if file != "-" {
+			fmt.Printf("Journal backed up to %s.\n", file)
+		}
+		return nil
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	if err := writeRecords(out, records, format); err != nil {
+		return fmt.Errorf("error writing export file: %w", err)
+	}
+
+	if file != "-" {
+		fmt.Printf("Exported %d records to %s.\n", len(records), file)
+	}
+	return nil
+}
+
+// importJournal loads the records, or the binary backup, of file into the
+// journal. Records whose item is already journaled are skipped.
+func importJournal(cfg *config.Config, file string) error {
+	in := os.Stdin
+	if file != "-" {
+		var err error
+		if in, err = os.Open(file); err != nil {
+			return fmt.Errorf("error opening import file: %w", err)
+		}
+		defer in.Close()
+	}
+
+	if binary {
+		if err := cfg.Journal.Restore(in); err != nil {
+			return err
+		}
+		fmt.Println("Journal backup restored.")
+		return nil
+	}
+
+	records, err := readRecords(in)
+	if err != nil {
+		return fmt.Errorf("error reading import file: %w", err)
+	}
+
+	imported, skipped := 0, 0
+	for _, record := range records {
+		_, err := cfg.Journal.Get(record.Item)
+		if err == nil {
+			skipped++
+			continue
+		}
+		if !errors.Is(err, journal.ErrItemNotFound) {
+			return fmt.Errorf("error checking record %s: %w", record.Item, err)
+		}
+
+		if err := cfg.Journal.AddRecord(record); err != nil {
+			return fmt.Errorf("error importing record %s: %w", record.Item, err)
+		}
+		imported++
+	}
+
+	fmt.Printf("Imported %d records, skipped %d already journaled.\n", imported, skipped)
+	return nil
+}
+
+// writeRecords encodes the records as a json array or one json object per line.
+func writeRecords(w io.Writer, records []*journal.MetaData, format string) error {
+	if format == FormatJSON {
+		if records == nil {
+			records = []*journal.MetaData{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRecords decodes records written by writeRecords, detecting whether they
+// form a json array or a stream of objects.
+func readRecords(r io.Reader) ([]*journal.MetaData, error) {
+	reader := bufio.NewReader(r)
+	decoder := json.NewDecoder(reader)
+
+	var records []*journal.MetaData
+	if first, err := peekNonSpace(reader); err == nil && first == '[' {
+		if err := decoder.Decode(&records); err != nil {
+			return nil, err
+		}
+	} else {
+		for {
+			var record journal.MetaData
+			err := decoder.Decode(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, &record)
+		}
+	}
+
+	for i, record := range records {
+		if record == nil || record.Item == "" {
+			return nil, fmt.Errorf("record %d has no item", i+1)
+		}
+	}
+	return records, nil
+}
+
+// peekNonSpace returns the first byte of the reader which is not a space,
+// without consuming it.
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if b[0] != ' ' && b[0] != '\t' && b[0] != '\n' && b[0] != '\r' {
+			return b[0], nil
+		}
+		reader.ReadByte()
+	}
+}
@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(dir, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &config.Config{ContainerPath: dir, Journal: j, WorkingDir: dir, WipeoutTime: 30}
+}
+
+func seed(t *testing.T, cfg *config.Config, name string) *journal.MetaData {
+	t.Helper()
+	record := &journal.MetaData{
+		Item:        name + "_ABCDEF",
+		Origin:      filepath.Join("/home/user", name),
+		Type:        journal.TypeFile,
+		Size:        42,
+		WipeoutTime: 7,
+		TossedTime:  time.Now().Unix(),
+	}
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		t.Fatalf("add %s: %v", name, err)
+	}
+	return record
+}
+
+// resetFlags restores the package options after a test parsed its own.
+func resetFlags(t *testing.T) {
+	t.Cleanup(func() {
+		format = FormatNDJSON
+		binary = false
+	})
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	for _, args := range [][]string{{"--format=ndjson"}, {"--format=json"}, {"--binary"}} {
+		t.Run(args[0], func(t *testing.T) {
+			resetFlags(t)
+			src := newTestCfg(t)
+			a := seed(t, src, "a.txt")
+			b := seed(t, src, "b.txt")
+
+			file := filepath.Join(t.TempDir(), "journal.export")
+			if err := Command(append([]string{"export"}, append(args, file)...), src); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+
+			dst := newTestCfg(t)
+			if err := Command(append([]string{"import"}, append(args, file)...), dst); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+
+			for _, want := range []*journal.MetaData{a, b} {
+				got, err := dst.Journal.Get(want.Item)
+				if err != nil {
+					t.Fatalf("get %s: %v", want.Item, err)
+				}
+				if got.Origin != want.Origin || got.Size != want.Size || got.WipeoutTime != want.WipeoutTime ||
+					got.TossedTime != want.TossedTime || got.Type != want.Type {
+					t.Errorf("imported %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestImport_SkipsDuplicates(t *testing.T) {
+	resetFlags(t)
+	src := newTestCfg(t)
+	seed(t, src, "a.txt")
+	seed(t, src, "b.txt")
+
+	file := filepath.Join(t.TempDir(), "journal.ndjson")
+	if err := Command([]string{"export", file}, src); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := newTestCfg(t)
+	existing := seed(t, dst, "a.txt")
+	existing.Origin = "/kept/a.txt"
+	if err := dst.Journal.AddRecord(existing); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if err := Command([]string{"import", file}, dst); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	records, err := dst.Journal.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	kept, _ := dst.Journal.Get(existing.Item)
+	if kept.Origin != "/kept/a.txt" {
+		t.Errorf("duplicate overwrote the journaled record: %s", kept.Origin)
+	}
+}
+
+func TestImport_RejectsInvalidInput(t *testing.T) {
+	resetFlags(t)
+	cfg := newTestCfg(t)
+	file := filepath.Join(t.TempDir(), "bad.json")
+
+	for _, content := range []string{`{"Origin": "/x"}`, `[{"Item": "a"`, "not json"} {
+		os.WriteFile(file, []byte(content), 0o644)
+		if err := Command([]string{"import", file}, cfg); err == nil {
+			t.Errorf("import of %q: expected an error", content)
+		}
+	}
+}
+
+func TestCommand_Validation(t *testing.T) {
+	resetFlags(t)
+	cfg := newTestCfg(t)
+	cases := [][]string{
+		{},
+		{"export"},
+		{"copy", "file"},
+		{"export", "--format=xml", "file"},
+	}
+	for _, args := range cases {
+		format = FormatNDJSON
+		if err := Command(args, cfg); err == nil {
+			t.Errorf("Command(%q): expected an error", args)
+		}
+	}
+}
+
+func TestMachineOutput_ExportToStdout(t *testing.T) {
+	resetFlags(t)
+	cases := map[string]bool{
+		"export -":                 true,
+		"--binary export -":        true,
+		"export --format=json -":   true,
+		"export journal.ndjson":    false,
+		"import -":                 false,
+		"--format=json export out": false,
+	}
+	for args, want := range cases {
+		Flags.Parse(strings.Fields(args))
+		if got := MachineOutput(); got != want {
+			t.Errorf("MachineOutput() after %q = %v, want %v", args, got, want)
+		}
+	}
+}
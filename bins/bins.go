@@ -0,0 +1,81 @@
+// Package bins implements the bins command, which lists the default bin and
+// the named bins of the container.
+package bins
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"rubbish/config"
+	"text/tabwriter"
+)
+
+// DefaultName labels the default bin in the listing.
+const DefaultName = "(default)"
+
+var Flags = flag.NewFlagSet("bins", flag.ExitOnError)
+
+func init() {
+	Flags.Usage = func() {
+		fmt.Println("Rubbish bins lists the default bin and the named bins with their items and size.\n",
+			"Usage:\n\n",
+			"\trubbish bins\n\n",
+			"Select a named bin with the --bin option of toss, status, restore and wipe.")
+		Flags.PrintDefaults()
+	}
+}
+
+// binSummary is a line of the bins listing.
+type binSummary struct {
+	Name  string
+	Items int
+	Size  int64
+}
+
+func Command(args []string, cfg *config.Config) error {
+	names, err := config.Bins(cfg)
+	if err != nil {
+		return err
+	}
+
+	summaries := make([]binSummary, 0, len(names)+1)
+	for _, name := range append([]string{""}, names...) {
+		summary, err := summarize(cfg, name)
+		if err != nil {
+			return err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "%s\t%d items\t%s\n", summary.Name, summary.Items, config.ReadableSize(uint64(summary.Size)))
+	}
+	return w.Flush()
+}
+
+// summarize counts the records of the named bin and measures its directory.
+func summarize(cfg *config.Config, name string) (binSummary, error) {
+	bin, err := cfg.ForBin(name)
+	if err != nil {
+		return binSummary{}, err
+	}
+
+	summary := binSummary{Name: name}
+	if name == "" {
+		summary.Name = DefaultName
+	}
+
+	if summary.Items, err = bin.Journal.Count(); err != nil {
+		return summary, fmt.Errorf("error counting items of bin %s: %w", summary.Name, err)
+	}
+
+	// A bin whose items were all restored or wiped may have no directory left
+	if _, err := os.Stat(bin.FilesPath()); os.IsNotExist(err) {
+		return summary, nil
+	}
+	if summary.Size, err = config.BinSize(bin); err != nil {
+		return summary, fmt.Errorf("error measuring bin %s: %w", summary.Name, err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,69 @@
+package bins
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"testing"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestCommand_ListsBins(t *testing.T) {
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	defer j.Close()
+	cfg := &config.Config{ContainerPath: container, Journal: j}
+
+	store := func(bin, item, content string) {
+		binCfg, err := cfg.ForBin(bin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(binCfg.FilesPath(), 0o755)
+		os.WriteFile(binCfg.ItemPath(item), []byte(content), 0o644)
+		binCfg.Journal.AddRecord(&journal.MetaData{Item: item, Origin: "/" + item})
+	}
+	store("", "a_ABCDEF", "a")
+	store("work", "b_ABCDEF", "bb")
+	store("work", "c_ABCDEF", "cc")
+	// A bin without records is still listed
+	os.MkdirAll(filepath.Join(container, config.BinsDir, "empty"), 0o755)
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := [][]string{{DefaultName, "1 items", "1 bytes"}, {"empty", "0 items", "0 bytes"}, {"work", "2 items", "4 bytes"}}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out)
+	}
+	for i, fields := range want {
+		for _, field := range fields {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("line %d %q missing %q", i+1, lines[i], field)
+			}
+		}
+	}
+}
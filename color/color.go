@@ -0,0 +1,76 @@
+// Package color decorates the messages of rubbish with ANSI colors, only when
+// they are written to a terminal and colors were not disabled with --no-color
+// or the NO_COLOR environment variable.
+package color
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ANSI codes of the colors in use.
+const (
+	Red        = "31"
+	Green      = "32"
+	Yellow     = "33"
+	BoldYellow = "33;1"
+)
+
+var (
+	// Disabled turns the colors off whatever the output is. It is set by the
+	// --no-color option and, following no-color.org, by a non-empty NO_COLOR.
+	Disabled = os.Getenv("NO_COLOR") != ""
+
+	// isTerminal reports whether the file is a terminal, replaceable for testing
+	isTerminal = func(file *os.File) bool {
+		info, err := file.Stat()
+		if err != nil {
+			return false
+		}
+		return info.Mode()&os.ModeCharDevice != 0
+	}
+)
+
+// Enabled reports whether colors may be written to w.
+func Enabled(w io.Writer) bool {
+	return !Disabled && Terminal(w)
+}
+
+// Terminal reports whether w is a terminal, colors enabled or not.
+func Terminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && isTerminal(file)
+}
+
+// Paint returns text in the given color when colors are enabled for w, and
+// text unchanged otherwise.
+func Paint(w io.Writer, code string, text string) string {
+	if !Enabled(w) {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// ClearScreen moves the cursor home and clears the screen when w is a
+// terminal, even with colors disabled, and writes nothing otherwise.
+func ClearScreen(w io.Writer) {
+	if Terminal(w) {
+		fmt.Fprint(w, "\033[H\033[2J")
+	}
+}
+
+// Errorf prints an error message to stderr.
+func Errorf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s %s", Paint(os.Stderr, Red, "Error:"), fmt.Sprintf(format, args...))
+}
+
+// Warnf prints a warning message to stderr.
+func Warnf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s %s", Paint(os.Stderr, Yellow, "Warning:"), fmt.Sprintf(format, args...))
+}
+
+// Noticef prints a notice with the given title to stdout.
+func Noticef(title string, format string, args ...any) {
+	fmt.Fprintf(os.Stdout, "%s%s\n", Paint(os.Stdout, BoldYellow, title+":"), Paint(os.Stdout, Yellow, " "+fmt.Sprintf(format, args...)))
+}
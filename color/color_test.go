@@ -0,0 +1,91 @@
+package color
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStderr runs fn with stderr redirected to a pipe and returns what it printed.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	fn()
+	w.Close()
+	os.Stderr = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func stubTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	orig := isTerminal
+	isTerminal = func(*os.File) bool { return terminal }
+	t.Cleanup(func() { isTerminal = orig })
+}
+
+func TestPaint(t *testing.T) {
+	stubTerminal(t, true)
+	if got := Paint(os.Stdout, Green, "Tossed"); got != "\033[32mTossed\033[0m" {
+		t.Errorf("expected colored text on a terminal, got %q", got)
+	}
+
+	var buf bytes.Buffer
+	if got := Paint(&buf, Green, "Tossed"); got != "Tossed" {
+		t.Errorf("expected plain text for a non file writer, got %q", got)
+	}
+
+	Disabled = true
+	defer func() { Disabled = false }()
+	if got := Paint(os.Stdout, Green, "Tossed"); got != "Tossed" {
+		t.Errorf("expected plain text when disabled, got %q", got)
+	}
+}
+
+func TestErrorfWithoutTerminal(t *testing.T) {
+	out := captureStderr(t, func() {
+		Errorf("boom %d\n", 1)
+		Warnf("careful\n")
+	})
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no escape sequences when not writing to a terminal, got %q", out)
+	}
+	if out != "Error: boom 1\nWarning: careful\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestErrorfDisabledOnTerminal(t *testing.T) {
+	stubTerminal(t, true)
+	Disabled = true
+	defer func() { Disabled = false }()
+
+	out := captureStderr(t, func() { Errorf("boom\n") })
+	if out != "Error: boom\n" {
+		t.Errorf("expected plain error with colors disabled, got %q", out)
+	}
+}
+
+func TestClearScreen(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "screen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stubTerminal(t, false)
+	ClearScreen(file)
+	Disabled = true
+	defer func() { Disabled = false }()
+	stubTerminal(t, true)
+	ClearScreen(file)
+
+	if data, _ := os.ReadFile(file.Name()); string(data) != "\033[H\033[2J" {
+		t.Errorf("expected the screen cleared on a terminal only, colors disabled or not, got %q", data)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compatCommands maps the trash-cli executable names rubbish answers to, when
+// installed as a symlink under one of them, to the translation of their
+// arguments into those of the equivalent rubbish command, and the directory
+// to run it from when it isn't the working directory.
+var compatCommands = map[string]func(args []string) (translated []string, dir string, err error){
+	"trash":         trashPut,
+	"trash-put":     trashPut,
+	"trash-list":    trashList,
+	"trash-restore": trashRestore,
+	"trash-empty":   trashEmpty,
+}
+
+// runAs runs rubbish as invoked under the name arg0: the arguments of the
+// trash-cli commands are translated first, any other name runs rubbish as is.
+func runAs(arg0 string, args []string) int {
+	translate, ok := compatCommands[filepath.Base(arg0)]
+	if !ok {
+		return run(args)
+	}
+
+	args, dir, err := translate(args)
+	if err != nil {
+		color.Errorf("%s: %v\n", filepath.Base(arg0), err)
+		return 1
+	}
+	if args == nil {
+		return 0
+	}
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			color.Errorf("%s: cannot run from %s: %v\n", filepath.Base(arg0), dir, err)
+			return 1
+		}
+	}
+	return run(args)
+}
+
+// compatOption is an option of a trash-cli command line.
+type compatOption struct {
+	name  string // name is the option with its dashes, e.g. -f or --trash-dir
+	value string
+}
+
+// parseCompat splits a trash-cli command line into its options and operands
+// as trash-cli's argparse does: short options may be grouped (-rf), long
+// ones take their value as --name=value or as the next argument when listed
+// in valued, and every argument after -- is an operand.
+func parseCompat(args []string, valued ...string) ([]compatOption, []string, error) {
+	var options []compatOption
+	var operands []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return options, append(operands, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg, "=")
+			if !hasValue && slices.Contains(valued, name) {
+				if i+1 == len(args) {
+					return nil, nil, fmt.Errorf("option %s expects a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			options = append(options, compatOption{name, value})
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			for _, short := range arg[1:] {
+				options = append(options, compatOption{name: "-" + string(short)})
+			}
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return options, operands, nil
+}
+
+// compatGlobal translates the options every trash-cli command accepts into
+// the global rubbish options, reporting whether the option was one of them.
+// --help and --version replace the whole command line.
+func compatGlobal(option compatOption, command string, globals *[]string) (handled bool, replaced []string) {
+	switch option.name {
+	case "--trash-dir":
+		*globals = append(*globals, "--container", option.value)
+	case "-h", "--help":
+		return true, []string{"help", command}
+	case "--version":
+		return true, []string{"--version"}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// unsupported reports a trash-cli option rubbish has no equivalent for.
+func unsupported(option compatOption) error {
+	return fmt.Errorf("unsupported option %s", option.name)
+}
+
+// trashPut translates trash-put [-f] [-v] [-r] [--trash-dir DIR] FILE... into
+// toss. The recursion options are accepted for compatibility, directories
+// being always tossed whole, and -f skips the missing files and never asks.
+func trashPut(args []string) ([]string, string, error) {
+	options, operands, err := parseCompat(args, "--trash-dir")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var globals, flags []string
+	force := false
+	for _, option := range options {
+		if handled, replaced := compatGlobal(option, "toss", &globals); handled {
+			if replaced != nil {
+				return replaced, "", nil
+			}
+			continue
+		}
+		switch option.name {
+		case "-d", "--directory", "-r", "-R", "--recursive":
+		case "-f", "--force":
+			force = true
+		case "-v", "--verbose":
+			flags = append(flags, "--verbose")
+		default:
+			return nil, "", unsupported(option)
+		}
+	}
+
+	if force {
+		flags = append(flags, "-y")
+		var existing []string
+		for _, operand := range operands {
+			if _, err := os.Lstat(operand); err == nil {
+				existing = append(existing, operand)
+			}
+		}
+		if len(existing) == 0 {
+			return nil, "", nil
+		}
+		operands = existing
+	}
+	if len(operands) == 0 {
+		return nil, "", fmt.Errorf("missing operand")
+	}
+
+	return join(globals, "toss", flags, operands), "", nil
+}
+
+// trashList translates trash-list [--trash-dir DIR] into list, oldest
+// tossed first.
+func trashList(args []string) ([]string, string, error) {
+	options, operands, err := parseCompat(args, "--trash-dir")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(operands) > 0 {
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(operands, " "))
+	}
+
+	var globals []string
+	for _, option := range options {
+		if handled, replaced := compatGlobal(option, "list", &globals); handled {
+			if replaced != nil {
+				return replaced, "", nil
+			}
+			continue
+		}
+		return nil, "", unsupported(option)
+	}
+
+	return join(globals, "list", []string{"--sort=date"}, nil), "", nil
+}
+
+// trashRestore translates trash-restore [--overwrite] [--trash-dir DIR] [DIR]
+// into restore --interactive-list. As trash-restore offers the items trashed
+// from within DIR, restore is run from DIR.
+func trashRestore(args []string) ([]string, string, error) {
+	options, operands, err := parseCompat(args, "--trash-dir")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var globals, flags []string
+	for _, option := range options {
+		if handled, replaced := compatGlobal(option, "restore", &globals); handled {
+			if replaced != nil {
+				return replaced, "", nil
+			}
+			continue
+		}
+		switch option.name {
+		case "--overwrite":
+			flags = append(flags, "--override")
+		default:
+			return nil, "", unsupported(option)
+		}
+	}
+
+	dir := ""
+	switch len(operands) {
+	case 0:
+	case 1:
+		dir = operands[0]
+	default:
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(operands[1:], " "))
+	}
+
+	return join(globals, "restore", append(flags, "--interactive-list"), nil), dir, nil
+}
+
+// trashEmpty translates trash-empty [-f] [--trash-dir DIR] [DAYS] into wipe:
+// the items tossed more than DAYS days ago are wiped, the whole bin when no
+// DAYS is given. -f wipes without asking, -i asks as rubbish does anyway.
+func trashEmpty(args []string) ([]string, string, error) {
+	options, operands, err := parseCompat(args, "--trash-dir")
+	if err != nil {
+		return nil, "", err
+	}
+
+	var globals, flags []string
+	for _, option := range options {
+		if handled, replaced := compatGlobal(option, "wipe", &globals); handled {
+			if replaced != nil {
+				return replaced, "", nil
+			}
+			continue
+		}
+		switch option.name {
+		case "-f":
+			flags = append(flags, "-y")
+		case "-i", "--interactive":
+		default:
+			return nil, "", unsupported(option)
+		}
+	}
+
+	switch len(operands) {
+	case 0:
+		flags = append(flags, "--empty")
+	case 1:
+		days, err := strconv.Atoi(operands[0])
+		if err != nil || days < 0 {
+			return nil, "", fmt.Errorf("invalid number of days '%s'", operands[0])
+		}
+		flags = append(flags, "-g", "--older-than="+strconv.Itoa(days)+"d")
+	default:
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(operands[1:], " "))
+	}
+
+	return join(globals, "wipe", flags, nil), "", nil
+}
+
+// join assembles a rubbish command line, the operands after -- so none is
+// taken for an option.
+func join(globals []string, command string, flags []string, operands []string) []string {
+	args := append(append(globals, command), flags...)
+	if len(operands) > 0 {
+		args = append(append(args, "--"), operands...)
+	}
+	return args
+}
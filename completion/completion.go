@@ -0,0 +1,161 @@
+// Package completion generates the shell completion scripts of rubbish and
+// computes the candidates they ask for through the hidden __complete command.
+package completion
+
+import (
+	"flag"
+	"fmt"
+	"rubbish/config"
+	"slices"
+	"strings"
+)
+
+var (
+	Flags = flag.NewFlagSet("completion", flag.ExitOnError)
+
+	// Shells lists the shells a completion script can be generated for
+	Shells = []string{"bash", "zsh", "fish"}
+)
+
+func init() {
+	Flags.Usage = func() {
+		fmt.Println("Rubbish completion prints a shell completion script.\n",
+			"Usage:\n\n",
+			"\trubbish completion bash|zsh|fish\n\n",
+			"Examples:\n\n",
+			"\tsource <(rubbish completion bash)\n",
+			"\trubbish completion fish > ~/.config/fish/completions/rubbish.fish")
+		Flags.PrintDefaults()
+	}
+}
+
+// Spec describes what can be completed after a command name.
+type Spec struct {
+	// Name is the command name
+	Name string
+
+	// Options holds the flags accepted by the command
+	Options *flag.FlagSet
+
+	// Items reports whether the command takes item keys as arguments
+	Items bool
+
+	// Args lists fixed argument values, e.g. the command names for help
+	Args []string
+}
+
+// Command prints the completion script of the shell given as argument.
+func Command(args []string, cfg *config.Config) error {
+	if len(args) != 1 {
+		Flags.Usage()
+		return fmt.Errorf("expected exactly one shell (%s)", strings.Join(Shells, ", "))
+	}
+
+	script, ok := scripts[args[0]]
+	if !ok {
+		return fmt.Errorf("unsupported shell '%s' (expected %s)", args[0], strings.Join(Shells, ", "))
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+// Complete returns the candidates for the last of the words typed after the
+// program name, which may be empty. Global flags preceding the command are
+// skipped along with their values. Item keys are only requested from items
+// for commands taking them.
+func Complete(words []string, globals *flag.FlagSet, specs []Spec, items func() ([]string, error)) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	typed := words[:len(words)-1]
+
+	// locate the command name, skipping the global options and their values
+	command := -1
+	for i := 0; i < len(typed); i++ {
+		if !strings.HasPrefix(typed[i], "-") {
+			command = i
+			break
+		}
+		if takesValue(globals, typed[i]) {
+			i++
+		}
+	}
+
+	if command == -1 {
+		if strings.HasPrefix(current, "-") {
+			return matching(flagNames(globals), current)
+		}
+		names := make([]string, len(specs))
+		for i, spec := range specs {
+			names[i] = spec.Name
+		}
+		return matching(names, current)
+	}
+
+	index := slices.IndexFunc(specs, func(spec Spec) bool { return spec.Name == typed[command] })
+	if index == -1 {
+		return nil
+	}
+	spec := specs[index]
+
+	if strings.HasPrefix(current, "-") {
+		return matching(flagNames(spec.Options), current)
+	}
+
+	candidates := spec.Args
+	if spec.Items && items != nil {
+		keys, err := items()
+		if err != nil {
+			return nil
+		}
+		candidates = append(slices.Clone(candidates), keys...)
+	}
+	return matching(candidates, current)
+}
+
+// takesValue reports whether the flag token consumes the following word as
+// its value, i.e. it is a known non boolean flag without an inline value.
+func takesValue(flags *flag.FlagSet, token string) bool {
+	if flags == nil || strings.Contains(token, "=") {
+		return false
+	}
+	f := flags.Lookup(strings.TrimLeft(token, "-"))
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// flagNames returns the flags of the set as typed on the command line, single
+// letter flags with one dash and the others with two.
+func flagNames(flags *flag.FlagSet) []string {
+	var names []string
+	if flags == nil {
+		return names
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			names = append(names, "-"+f.Name)
+		} else {
+			names = append(names, "--"+f.Name)
+		}
+	})
+	return names
+}
+
+// matching returns the candidates starting with prefix, sorted.
+func matching(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	slices.Sort(matches)
+	return matches
+}
@@ -0,0 +1,73 @@
+package completion
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"testing"
+)
+
+func testSpecs() (*flag.FlagSet, []Spec) {
+	globals := flag.NewFlagSet("rubbish", flag.ContinueOnError)
+	globals.String("container", "", "")
+	globals.Bool("utc", false, "")
+
+	restore := flag.NewFlagSet("restore", flag.ContinueOnError)
+	restore.Bool("override", false, "")
+	restore.Bool("g", false, "")
+
+	return globals, []Spec{
+		{Name: "help", Args: []string{"restore", "toss"}},
+		{Name: "restore", Options: restore, Items: true},
+		{Name: "toss", Options: flag.NewFlagSet("toss", flag.ContinueOnError)},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	globals, specs := testSpecs()
+	items := func() ([]string, error) { return []string{"notes.txt_K3P9QZ", "report.pdf_A1B2C3"}, nil }
+
+	cases := []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"help", "restore", "toss"}},
+		{[]string{"re"}, []string{"restore"}},
+		{[]string{"--"}, []string{"--container", "--utc"}},
+		{[]string{"--container", "/tmp/bin", "t"}, []string{"toss"}},
+		{[]string{"--utc", "re"}, []string{"restore"}},
+		{[]string{"restore", ""}, []string{"notes.txt_K3P9QZ", "report.pdf_A1B2C3"}},
+		{[]string{"restore", "-g", "rep"}, []string{"report.pdf_A1B2C3"}},
+		{[]string{"restore", "-"}, []string{"--override", "-g"}},
+		{[]string{"toss", ""}, nil},
+		{[]string{"help", "r"}, []string{"restore"}},
+		{[]string{"nope", ""}, nil},
+	}
+
+	for _, c := range cases {
+		got := Complete(c.words, globals, specs, items)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("Complete(%q) = %q, want %q", c.words, got, c.want)
+		}
+	}
+}
+
+func TestComplete_ItemsError(t *testing.T) {
+	globals, specs := testSpecs()
+	items := func() ([]string, error) { return nil, errors.New("journal locked") }
+
+	if got := Complete([]string{"restore", ""}, globals, specs, items); len(got) != 0 {
+		t.Errorf("expected no candidates when the journal fails, got %q", got)
+	}
+}
+
+func TestCommand_Shells(t *testing.T) {
+	for _, shell := range Shells {
+		if scripts[shell] == "" {
+			t.Errorf("missing %s script", shell)
+		}
+	}
+	if err := Command([]string{"powershell"}, nil); err == nil {
+		t.Error("expected error for an unsupported shell")
+	}
+}
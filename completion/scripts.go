@@ -0,0 +1,33 @@
+package completion
+
+// The scripts delegate every completion to the hidden __complete command,
+// passing the words typed so far, the last one being the word to complete.
+var scripts = map[string]string{
+	"bash": `# bash completion for rubbish
+_rubbish() {
+	local IFS=$'\n'
+	COMPREPLY=($(rubbish __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _rubbish rubbish
+`,
+	"zsh": `#compdef rubbish
+# zsh completion for rubbish
+_rubbish() {
+	local -a candidates
+	candidates=("${(@f)$(rubbish __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n ${candidates[1]} ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef _rubbish rubbish
+`,
+	"fish": `# fish completion for rubbish
+function __rubbish_complete
+	set -l words (commandline -opc)
+	rubbish __complete $words[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c rubbish -a '(__rubbish_complete)'
+`,
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// BinsDir is the directory of the container holding the named bins.
+const BinsDir = "bins"
+
+// PendingDir is the directory of the container staging the wiped items.
+const PendingDir = ".trash-pending"
+
+const (
+	// PendingItem is the name of the staged item in its pending entry
+	PendingItem = "item"
+
+	// PendingRecord is the name of the file holding the staged item's record
+	PendingRecord = "record.json"
+)
+
+// reservedEntries are the container entries which are never items of the
+// default bin.
+var reservedEntries = []string{".journal", BinsDir, PendingDir, LockFile}
+
+// Reserved reports whether the item, a path relative to the directory of the
+// items, lies in one of the reserved entries. Only the native default bin
+// shares its directory with them.
+func (config *Config) Reserved(item string) bool {
+	if config.Bin != "" || config.XDG() {
+		return false
+	}
+
+	first, _, _ := strings.Cut(filepath.ToSlash(item), "/")
+	for _, entry := range reservedEntries {
+		if first == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// ForBin returns a copy of the configuration using the named bin: its items
+// are stored in a subdirectory of the container and its records in a
+// separate namespace of the journal. The empty name is the default bin.
+func (config *Config) ForBin(name string) (*Config, error) {
+	if err := ValidateBinName(name); err != nil {
+		return nil, err
+	}
+	if name != "" && config.XDG() {
+		return nil, fmt.Errorf("named bins are not supported in %s trash mode", TrashModeXDG)
+	}
+
+	bin := *config
+	bin.Bin = name
+	if config.Journal != nil {
+		bin.Journal = config.Journal.InBin(name)
+	}
+	return &bin, nil
+}
+
+// ValidateBinName checks the name can be used as a bin directory.
+func ValidateBinName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid bin name '%s'", name)
+	}
+	return nil
+}
+
+// Bins returns the sorted names of the named bins, those with a directory in
+// the container or records in the journal.
+func Bins(cfg *Config) ([]string, error) {
+	bins, err := cfg.Journal.Bins()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving bins from journal: %w", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(cfg.ContainerPath, BinsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading bins directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !slices.Contains(bins, entry.Name()) {
+			bins = append(bins, entry.Name())
+		}
+	}
+
+	slices.Sort(bins)
+	return bins, nil
+}
+
+// PendingSize returns the size of the items wiped from the bin which are
+// still staged for undo: they stay on disk until purged. BinSize counts them.
+func PendingSize(cfg *Config) (int64, error) {
+	return pendingSize(cfg, &linkSet{})
+}
+
+// pendingSize sums the staged items of the bin, each entry of a pending
+// batch telling in its record the bin it was wiped from. Hard linked files
+// already in seen are not counted again.
+func pendingSize(cfg *Config, seen *linkSet) (int64, error) {
+	entries, err := filepath.Glob(filepath.Join(cfg.PendingPath(), "*", "*"))
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(entry, PendingRecord))
+		if err != nil {
+			continue
+		}
+		var staged struct {
+			Bin string `json:"bin"`
+		}
+		if json.Unmarshal(data, &staged) != nil || staged.Bin != cfg.Bin {
+			continue
+		}
+
+		err = filepath.Walk(filepath.Join(entry, PendingItem), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && seen.first(info) {
+				size += info.Size()
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+	return size, nil
+}
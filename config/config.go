@@ -2,12 +2,19 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"math/bits"
 	"os"
 	"path"
 	"path/filepath"
+	"rubbish/fsutil"
 	"rubbish/journal"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-ini/ini"
 )
@@ -24,10 +31,62 @@ type Config struct {
 	// ContainerPath is the absolute path where trashed files are stored
 	ContainerPath string `ini:"container_path"`
 
+	// TrashMode selects the container layout: native keeps the items at the
+	// root of the container, xdg follows the FreeDesktop.org Trash spec
+	TrashMode string `ini:"trash_mode"`
+
+	// Layout selects how native mode stores the items: flat at the root of
+	// the container, or mirrored under the path of their origin
+	Layout string `ini:"layout"`
+
 	// MaxRetention is the maximum number of days any file can remain in trash
 	// regardless of individual wipeout time settings
 	MaxRetention int `ini:"max_retention"`
 
+	// Timezone is the IANA name of the zone displayed times are rendered in,
+	// the system local time when empty
+	Timezone string `ini:"timezone"`
+
+	// Location is the zone resolved from Timezone, nil for the local time
+	Location *time.Location `ini:"-"`
+
+	// MaxSize is the largest size, in bytes, the bin may reach before the
+	// oldest wipeable items are evicted. Zero disables the cap. It is read
+	// from the human readable max_size setting (e.g. 2GB).
+	MaxSize uint64 `ini:"-"`
+
+	// MaxSizeSetting is the raw max_size setting, parsed into MaxSize
+	MaxSizeSetting string `ini:"max_size"`
+
+	// MaxFilesWarn is the number of files above which tossing a directory
+	// asks for confirmation. Zero disables the check.
+	MaxFilesWarn int `ini:"max_files_warn"`
+
+	// MaxBytesWarn is the size, in bytes, above which tossing a directory
+	// asks for confirmation. Zero disables the check. It is read from the
+	// human readable max_bytes_warn setting (e.g. 1GB).
+	MaxBytesWarn uint64 `ini:"-"`
+
+	// MaxBytesWarnSetting is the raw max_bytes_warn setting, parsed into MaxBytesWarn
+	MaxBytesWarnSetting string `ini:"max_bytes_warn"`
+
+	// DefaultShred makes wipe overwrite the files with random bytes before
+	// removing them, as with --shred
+	DefaultShred bool `ini:"default_shred"`
+
+	// Compress stores the tossed files and directories as gzip compressed
+	// archives, but for the formats which are already compressed
+	Compress bool `ini:"compress"`
+
+	// AutoWipe enables the wipe of the expired items, run by any invocation
+	// once CleanupInterval days have passed since the previous one
+	AutoWipe bool `ini:"auto_wipe"`
+
+	// UndoWindow is how long, in minutes, wiped items are staged in the
+	// pending directory of the container so wipe --undo can bring them back.
+	// Zero removes the items at once.
+	UndoWindow int `ini:"undo_window"`
+
 	// CleanupInterval is how often (in days) the cleanup process should run
 	// to remove expired files from trash
 	CleanupInterval int `ini:"cleanup_interval"`
@@ -44,10 +103,32 @@ type Config struct {
 		Timeout int `ini:"timeout"`
 	} `ini:"notifications"`
 
+	// Policies maps the retention policy names of the [policies] section to
+	// their wipeout time in days, selected per toss with --policy
+	Policies map[string]int `ini:"-"`
+
 	// Journal is the database instance used to track metadata for trashed items
 	Journal *journal.Journal
 
+	// Bin is the named bin the items are stored in, empty for the default bin.
+	// It is selected with ForBin.
+	Bin string `ini:"-"`
+
+	// Files are the configuration files the settings were read from, in
+	// order, the missing ones left out
+	Files []string `ini:"-"`
+
+	// JournalPath overrides the location of the journal database, which
+	// defaults to the .journal directory inside the container
+	JournalPath string `ini:"-"`
+
+	// ReadOnly opens the journal without write access, for the commands which
+	// only read the bin. The trash info files are not synchronized then.
+	ReadOnly bool `ini:"-"`
+
 	WorkingDir string // workingDir is the current working directory of the application
+
+	lockFile *os.File // lockFile holds the container lock taken by Lock, nil when released
 }
 
 // Load reads configuration from the specified INI file paths and initializes
@@ -65,25 +146,51 @@ type Config struct {
 // Returns a fully initialized Config struct with journal database ready,
 // or an error if configuration loading, mapping, or journal initialization fails.
 func Load(paths []string) (*Config, error) {
-	cfg, err := ini.Load(paths[0])
-
+	config, err := Read(paths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load configuration: %w", err)
+		return nil, err
 	}
-	// Load complemetary file from user's home directory
-	if len(paths) > 1 {
-		err = cfg.Append(paths[1])
-		if err != nil && !os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to append user configuration: %w", err)
+
+	if err := config.Initialize(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Read parses the configuration files into a Config struct with default values
+// applied, without opening the journal. It allows callers to adjust settings
+// (e.g. command line overrides) before calling Initialize.
+//
+// Missing files are skipped, so a fresh install without any configuration
+// runs on the defaults; a file which exists but cannot be read or parsed is
+// an error.
+func Read(paths []string) (*Config, error) {
+	cfg := ini.Empty()
+
+	var files []string
+	for i, file := range paths {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		if err := cfg.Append(file); err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("failed to load configuration %s: %w", file, err)
+			}
+			return nil, fmt.Errorf("failed to append user configuration %s: %w", file, err)
 		}
+		files = append(files, file)
 	}
 
 	// Creating a default configuration if the file is empty
 	config := &Config{
 		WipeoutTime:     30,
-		ContainerPath:   ".local/share/rubbish",
+		ContainerPath:   DefaultContainerPath,
+		TrashMode:       TrashModeNative,
+		Layout:          LayoutFlat,
 		MaxRetention:    365,
 		CleanupInterval: 3,
+		UndoWindow:      60,
 		Notification: struct {
 			Enabled       bool `ini:"enabled"`
 			DaysInAdvance int  `ini:"days_in_advance"`
@@ -95,27 +202,187 @@ func Load(paths []string) (*Config, error) {
 		},
 	}
 	// Map the configuration file to the Config struct
-	err = cfg.MapTo(config)
+	err := cfg.MapTo(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to map configuration: %w", err)
 	}
+	config.Files = files
+
+	switch config.TrashMode {
+	case TrashModeNative:
+	case TrashModeXDG:
+		if config.ContainerPath == DefaultContainerPath {
+			config.ContainerPath = DefaultXDGContainerPath
+		}
+	default:
+		return nil, fmt.Errorf("invalid trash_mode '%s', expected %s or %s", config.TrashMode, TrashModeNative, TrashModeXDG)
+	}
+
+	switch config.Layout {
+	case LayoutFlat:
+	case LayoutMirrored:
+		if config.XDG() {
+			return nil, fmt.Errorf("layout %s is not supported in %s trash mode", LayoutMirrored, TrashModeXDG)
+		}
+	default:
+		return nil, fmt.Errorf("invalid layout '%s', expected %s or %s", config.Layout, LayoutFlat, LayoutMirrored)
+	}
+
+	if config.Compress && config.XDG() {
+		return nil, fmt.Errorf("compress is not supported in %s trash mode", TrashModeXDG)
+	}
+
+	if config.Timezone != "" {
+		if config.Location, err = time.LoadLocation(config.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s': %w", config.Timezone, err)
+		}
+	}
+
+	if config.MaxSizeSetting != "" {
+		if config.MaxSize, err = ParseSize(config.MaxSizeSetting); err != nil {
+			return nil, fmt.Errorf("invalid max_size: %w", err)
+		}
+	}
+
+	if config.MaxBytesWarnSetting != "" {
+		if config.MaxBytesWarn, err = ParseSize(config.MaxBytesWarnSetting); err != nil {
+			return nil, fmt.Errorf("invalid max_bytes_warn: %w", err)
+		}
+	}
+
+	if config.Policies, err = readPolicies(cfg); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// PoliciesSection is the INI section naming the retention policies.
+const PoliciesSection = "policies"
+
+// readPolicies parses the [policies] section, each key naming a policy and
+// its value the number of days the items tossed with it are kept. It returns
+// nil without the section.
+func readPolicies(cfg *ini.File) (map[string]int, error) {
+	section, err := cfg.GetSection(PoliciesSection)
+	if err != nil {
+		return nil, nil
+	}
+
+	policies := make(map[string]int, len(section.Keys()))
+	for _, key := range section.Keys() {
+		days, err := key.Int()
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid retention policy %s '%s', expected a number of days", key.Name(), key.String())
+		}
+		policies[key.Name()] = days
+	}
+	return policies, nil
+}
+
+// Policy returns the wipeout time, in days, of the named retention policy.
+func (config *Config) Policy(name string) (int, error) {
+	days, ok := config.Policies[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(config.Policies))
+		if len(names) == 0 {
+			return 0, fmt.Errorf("unknown retention policy '%s', no policy is defined in the [%s] section", name, PoliciesSection)
+		}
+		return 0, fmt.Errorf("unknown retention policy '%s' (expected %s)", name, strings.Join(names, ", "))
+	}
+	return days, nil
+}
+
+// Initialize normalizes the container path, opens the journal database stored
+// inside the container and resolves the current working directory.
+func (config *Config) Initialize() error {
+	var err error
 
 	config.ContainerPath = NormalizePath(config.ContainerPath)
 
+	journalPath := config.JournalPath
+	if journalPath == "" {
+		journalPath = config.DefaultJournalPath()
+	}
+
 	config.Journal = &journal.Journal{
-		Path: path.Join(config.ContainerPath, ".journal"),
+		Path:     journalPath,
+		ReadOnly: config.ReadOnly,
 	}
 
 	if err := config.Journal.Load(); err != nil {
-		return nil, fmt.Errorf("failed to load journal: %w", err)
+		return fmt.Errorf("failed to load journal: %w", err)
 	}
 
 	config.WorkingDir, err = os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("error getting current working directory: %w", err)
+		return fmt.Errorf("error getting current working directory: %w", err)
 	}
 
-	return config, nil
+	if config.XDG() && !config.ReadOnly {
+		if err := config.syncTrashInfo(); err != nil {
+			return fmt.Errorf("failed to read trash info: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Zone returns the location displayed times are rendered in.
+func (config *Config) Zone() *time.Location {
+	if config.Location == nil {
+		return time.Local
+	}
+	return config.Location
+}
+
+// DisplayTime converts a Unix timestamp into the configured display zone.
+func (config *Config) DisplayTime(unix int64) time.Time {
+	return time.Unix(unix, 0).In(config.Zone())
+}
+
+// ParseDate parses a YYYY-MM-DD date as the start of that day in the display
+// zone, so dates match the ones printed by the commands.
+func (config *Config) ParseDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation(time.DateOnly, value, config.Zone())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD: %w", value, err)
+	}
+	return date, nil
+}
+
+// TossedRange parses the --after and --before dates filtering items by their
+// toss time. Empty values leave that side of the range open.
+func (config *Config) TossedRange(after string, before string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if after != "" {
+		if from, err = config.ParseDate(after); err != nil {
+			return from, to, fmt.Errorf("--after: %w", err)
+		}
+	}
+	if before != "" {
+		if to, err = config.ParseDate(before); err != nil {
+			return from, to, fmt.Errorf("--before: %w", err)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("empty date range: --after %s is not before --before %s", after, before)
+	}
+
+	return from, to, nil
+}
+
+// DefaultJournalPath returns the location of the journal inside the container.
+func (config *Config) DefaultJournalPath() string {
+	return path.Join(config.ContainerPath, ".journal")
+}
+
+// PendingPath returns the directory of the container staging the wiped items
+// until the undo window is over.
+func (config *Config) PendingPath() string {
+	return path.Join(config.ContainerPath, PendingDir)
 }
 
 // Expands the user's home directory if it's a relative path and returns the absolute path to the container directory.
@@ -136,27 +403,282 @@ func NormalizePath(container_path string) string {
 	return path.Join(userHomeDir, container_path)
 }
 
+// BinSize returns the size of the files stored in the bin, including the
+// wiped items still staged for undo. The journal directory and the other
+// reserved entries of the container are not counted.
+// The top-level entries are walked in parallel by a bounded pool of workers.
 func BinSize(cfg *Config) (int64, error) {
-	var size int64
-	err := filepath.Walk(cfg.ContainerPath, func(path string, info os.FileInfo, err error) error {
+	size, err := concurrentBinSize(cfg, runtime.NumCPU())
+	if err != nil {
+		fmt.Printf("Error calculating rubbish size: %v\n", err)
+		return 0, err
+	}
+	return size, nil
+}
+
+// binJournalPath returns the journal directory BinSize leaves out.
+func binJournalPath(cfg *Config) string {
+	if cfg.JournalPath != "" {
+		return filepath.Clean(cfg.JournalPath)
+	}
+	return filepath.Clean(cfg.DefaultJournalPath())
+}
+
+// linkSet records the inodes of the hard linked files already counted, so
+// deduplicated items sharing their content are counted once.
+type linkSet struct {
+	mu     sync.Mutex
+	inodes map[uint64]bool
+}
+
+// first reports whether the file is seen for the first time: it has a single
+// link or none of its other links was seen yet.
+func (s *linkSet) first(info os.FileInfo) bool {
+	inode, links := fsutil.HardLinks(info)
+	if links <= 1 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inodes == nil {
+		s.inodes = make(map[uint64]bool)
+	}
+	if s.inodes[inode] {
+		return false
+	}
+	s.inodes[inode] = true
+	return true
+}
+
+// sizeWalker returns the filepath.WalkFunc summing into size the files of the
+// bin, skipping the journal and the reserved entries. Hard linked files are
+// counted once across the walks sharing seen.
+func sizeWalker(cfg *Config, journalPath string, size *int64, seen *linkSet) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if strings.Contains(path, ".journal") {
-			return nil
+		if info.IsDir() && filepath.Clean(path) == journalPath {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(cfg.FilesPath(), path); err == nil && rel != "." && info.IsDir() && cfg.Reserved(rel) {
+			return filepath.SkipDir
 		}
 
-		if !info.IsDir() {
-			size += info.Size()
+		if !info.IsDir() && seen.first(info) {
+			*size += info.Size()
 		}
 		return nil
-	})
+	}
+}
 
+// sequentialBinSize computes BinSize with a single walk of the bin.
+func sequentialBinSize(cfg *Config) (int64, error) {
+	var size int64
+	seen := &linkSet{}
+	if err := filepath.Walk(cfg.FilesPath(), sizeWalker(cfg, binJournalPath(cfg), &size, seen)); err != nil {
+		return 0, err
+	}
+	pending, err := pendingSize(cfg, seen)
+	return size + pending, err
+}
+
+// concurrentBinSize computes BinSize with the given number of workers, each
+// walking whole top-level entries of the bin. The total doesn't depend on
+// the order the entries are walked in. A worker stops at its first error,
+// which fails the whole count.
+func concurrentBinSize(cfg *Config, workers int) (int64, error) {
+	root := cfg.FilesPath()
+	entries, err := os.ReadDir(root)
 	if err != nil {
-		fmt.Printf("Error calculating rubbish size: %v\n", err)
 		return 0, err
 	}
-	return size, nil
+
+	// At least one worker runs, each sending its size and error once the
+	// paths are drained, so the channels must hold them all before wg.Wait
+	workers = max(workers, 1)
+	paths := make(chan string)
+	sizes := make(chan int64, workers)
+	errs := make(chan error, workers)
+	journalPath := binJournalPath(cfg)
+	seen := &linkSet{}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var size int64
+			var failed error
+			for path := range paths {
+				if failed == nil {
+					failed = filepath.Walk(path, sizeWalker(cfg, journalPath, &size, seen))
+				}
+			}
+			sizes <- size
+			errs <- failed
+		}()
+	}
+
+	for _, entry := range entries {
+		paths <- filepath.Join(root, entry.Name())
+	}
+	close(paths)
+	wg.Wait()
+	close(sizes)
+	close(errs)
+
+	var total int64
+	for size := range sizes {
+		total += size
+	}
+	for err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	pending, err := pendingSize(cfg, seen)
+	if err != nil {
+		return 0, err
+	}
+	return total + pending, nil
+}
+
+// ContainerItems returns the names of the entries stored in the container,
+// excluding the journal directory. In the mirrored layout the names are paths
+// relative to the container.
+func ContainerItems(cfg *Config) ([]string, error) {
+	if cfg.Mirrored() {
+		return cfg.containerItems()
+	}
+
+	entries, err := os.ReadDir(cfg.FilesPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	for _, entry := range entries {
+		if entry.Name() == ".journal" || cfg.Reserved(entry.Name()) {
+			continue
+		}
+		items = append(items, entry.Name())
+	}
+	return items, nil
+}
+
+// Orphans returns the entries of the container which have no journal record,
+// e.g. left behind by a crash between the move and the journal write.
+func Orphans(cfg *Config) ([]string, error) {
+	items, err := ContainerItems(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rubbish container: %w", err)
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	known := make(map[string]bool, len(records))
+	for _, record := range records {
+		known[record.Item] = true
+	}
+
+	var orphans []string
+	for _, item := range items {
+		if !known[item] {
+			orphans = append(orphans, item)
+		}
+	}
+	return orphans, nil
+}
+
+// ItemSize returns the disk usage of an item stored in the container, summing
+// the content of directories.
+func ItemSize(cfg *Config, item string) (int64, error) {
+	return fsutil.TreeSize(cfg.ItemPath(item))
+}
+
+// RecordSize returns the size of the record's item as measured at toss time.
+// Compressed items, and records journaled before sizes were tracked, are
+// measured in the container; missing items count as empty.
+func RecordSize(cfg *Config, record *journal.MetaData) int64 {
+	if record.Size > 0 && !record.Compressed {
+		return record.Size
+	}
+	size, _ := ItemSize(cfg, record.Item)
+	return size
+}
+
+// sizeUnits maps the accepted size suffixes to their multiplier. As in
+// ReadableSize, units are powers of 1024 whether written KB or KiB.
+var sizeUnits = map[string]uint64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+}
+
+// ParseSize parses a human readable size such as "512", "100KB", "1.5 GiB" or
+// "2g" into a number of bytes.
+func ParseSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	split := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split < 0 {
+		split = len(value)
+	}
+
+	number, unit := value[:split], strings.ToUpper(strings.TrimSpace(value[split:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit '%s' in '%s'", unit, value)
+	}
+
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+
+	return uint64(amount * float64(multiplier)), nil
+}
+
+// ageUnits maps the day based age suffixes, which time.ParseDuration lacks,
+// to their length.
+var ageUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseAge parses an age such as "30d", "2w", "1.5d" or "12h" into a
+// duration. Besides days and weeks, every unit of time.ParseDuration is
+// accepted.
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	for suffix, unit := range ageUnits {
+		number, found := strings.CutSuffix(value, suffix)
+		if !found {
+			continue
+		}
+		amount, err := strconv.ParseFloat(number, 64)
+		if err != nil || amount < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", value)
+		}
+		return time.Duration(amount * float64(unit)), nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age '%s', expected e.g. 30d, 2w or 12h", value)
+	}
+	return age, nil
 }
 
 func ReadableSize(size uint64) string {
@@ -2,10 +2,16 @@ package config_test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"rubbish/config"
+	"rubbish/journal"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Helper to create a temporary INI file with given content
@@ -77,10 +83,43 @@ days_in_advance = 5
 	}
 }
 
-func TestLoad_ErrorCases(t *testing.T) {
-	// Non-existent file should error
-	if _, err := config.Load([]string{"/no/such/file.ini", "/no/such/user.ini"}); err == nil {
-		t.Error("expected error for missing config file")
+func TestRead_MissingFilesUseDefaults(t *testing.T) {
+	cfg, err := config.Read([]string{"/no/such/file.ini", "/no/such/user.ini"})
+	if err != nil {
+		t.Fatalf("missing files must fall back to the defaults, got %v", err)
+	}
+	if cfg.WipeoutTime != 30 || cfg.ContainerPath != config.DefaultContainerPath || cfg.MaxRetention != 365 {
+		t.Errorf("expected the defaults, got %+v", cfg)
+	}
+}
+
+func TestRead_OnlyUserFile(t *testing.T) {
+	user := createTempINI(t, "wipeout_time = 7\n")
+	cfg, err := config.Read([]string{"/no/such/file.ini", user})
+	if err != nil {
+		t.Fatalf("a missing system file must be skipped, got %v", err)
+	}
+	if cfg.WipeoutTime != 7 || cfg.CleanupInterval != 3 {
+		t.Errorf("expected the user setting over the defaults, got wipeout %d, cleanup %d", cfg.WipeoutTime, cfg.CleanupInterval)
+	}
+}
+
+func TestRead_CorruptFilesFail(t *testing.T) {
+	corrupt := createTempINI(t, "[unclosed\nwipeout_time = 7\n")
+	if _, err := config.Read([]string{corrupt, "/no/such/user.ini"}); err == nil {
+		t.Error("expected error for a corrupt system file")
+	}
+	if _, err := config.Read([]string{"/no/such/file.ini", corrupt}); err == nil {
+		t.Error("expected error for a corrupt user file")
+	}
+
+	// A file which exists but cannot be read is not taken for a missing one
+	if os.Getuid() != 0 {
+		unreadable := createTempINI(t, "wipeout_time = 7\n")
+		os.Chmod(unreadable, 0o000)
+		if _, err := config.Read([]string{unreadable}); err == nil {
+			t.Error("expected error for an unreadable system file")
+		}
 	}
 }
 
@@ -207,9 +246,421 @@ func TestBinSize_CalculatesAndIgnoresJournal(t *testing.T) {
 	}
 }
 
+func TestBinSize_CountsItemsNamedLikeTheJournal(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".journal"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".journal", "data"), bytes.Repeat([]byte{'a'}, 1000), 0o644)
+
+	// Tossed items whose path merely contains .journal are part of the bin
+	os.WriteFile(filepath.Join(dir, "notes.journal"), bytes.Repeat([]byte{'a'}, 10), 0o644)
+	os.MkdirAll(filepath.Join(dir, "project_ABCDEF", ".journal"), 0o755)
+	os.WriteFile(filepath.Join(dir, "project_ABCDEF", ".journal", "log"), bytes.Repeat([]byte{'a'}, 20), 0o644)
+
+	cfg := &config.Config{ContainerPath: dir}
+	got, err := config.BinSize(cfg)
+	if err != nil {
+		t.Fatalf("BinSize returned error: %v", err)
+	}
+	if want := int64(30); got != want {
+		t.Errorf("BinSize = %d, want %d", got, want)
+	}
+}
+
+// syntheticBin fills a container with dirs directories of files files each,
+// of varying sizes, plus a journal to leave out.
+func syntheticBin(tb testing.TB, dirs int, files int) *config.Config {
+	tb.Helper()
+	dir := tb.TempDir()
+	for d := range dirs {
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d_ABCDEF", d), "nested")
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := range files {
+			os.WriteFile(filepath.Join(sub, fmt.Sprintf("f%d", f)), bytes.Repeat([]byte{'a'}, d+f), 0o644)
+		}
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d_ABCDEF", d)), bytes.Repeat([]byte{'a'}, d), 0o644)
+	}
+	os.MkdirAll(filepath.Join(dir, ".journal"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".journal", "data"), bytes.Repeat([]byte{'a'}, 1000), 0o644)
+	return &config.Config{ContainerPath: dir}
+}
+
+func TestBinSize_ConcurrentMatchesSequential(t *testing.T) {
+	cfg := syntheticBin(t, 20, 30)
+
+	want, err := config.SequentialBinSize(cfg)
+	if err != nil {
+		t.Fatalf("sequential walk: %v", err)
+	}
+	for _, workers := range []int{0, 1, 3, 16} {
+		got, err := config.ConcurrentBinSize(cfg, workers)
+		if err != nil || got != want {
+			t.Errorf("%d workers: got %d, %v, want %d", workers, got, err, want)
+		}
+	}
+	if got, _ := config.BinSize(cfg); got != want {
+		t.Errorf("BinSize = %d, want %d", got, want)
+	}
+}
+
+func BenchmarkBinSize(b *testing.B) {
+	cfg := syntheticBin(b, 50, 100)
+
+	b.Run("sequential", func(b *testing.B) {
+		for b.Loop() {
+			config.SequentialBinSize(cfg)
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for b.Loop() {
+			config.ConcurrentBinSize(cfg, runtime.NumCPU())
+		}
+	})
+}
+
 func TestBinSize_MissingContainerPathErrors(t *testing.T) {
 	cfg := &config.Config{ContainerPath: filepath.Join(t.TempDir(), "does-not-exist")}
 	if _, err := config.BinSize(cfg); err == nil {
 		t.Fatalf("expected error for missing container path")
 	}
 }
+
+func TestItemSize_SumsDirectoryContent(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tree_ABCDEF", "sub"), 0o755)
+	os.WriteFile(filepath.Join(dir, "tree_ABCDEF", "a"), bytes.Repeat([]byte{'a'}, 10), 0o644)
+	os.WriteFile(filepath.Join(dir, "tree_ABCDEF", "sub", "b"), bytes.Repeat([]byte{'b'}, 5), 0o644)
+
+	cfg := &config.Config{ContainerPath: dir}
+	got, err := config.ItemSize(cfg, "tree_ABCDEF")
+	if err != nil {
+		t.Fatalf("ItemSize error: %v", err)
+	}
+	if got != 15 {
+		t.Errorf("ItemSize = %d, want 15", got)
+	}
+	if _, err := config.ItemSize(cfg, "missing"); err == nil {
+		t.Error("expected error for missing item")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]uint64{
+		"512":     512,
+		"0":       0,
+		"100B":    100,
+		"1KB":     1024,
+		"2k":      2048,
+		"1.5 MiB": 1536 * 1024,
+		"2GB":     2 << 30,
+		" 1tb ":   1 << 40,
+	}
+	for in, want := range cases {
+		got, err := config.ParseSize(in)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "GB", "12XB", "-1KB", "1.2.3MB"} {
+		if _, err := config.ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"1.5d":  36 * time.Hour,
+		" 90m ": 90 * time.Minute,
+		"0d":    0,
+	}
+	for in, want := range cases {
+		got, err := config.ParseAge(in)
+		if err != nil {
+			t.Errorf("ParseAge(%q) error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseAge(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "d", "30", "-2d", "1y", "twod"} {
+		if _, err := config.ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) expected error", in)
+		}
+	}
+}
+
+func TestRead_MaxSize(t *testing.T) {
+	cfg, err := config.Read([]string{createTempINI(t, "max_size = 2GB\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if cfg.MaxSize != 2<<30 {
+		t.Errorf("MaxSize = %d, want %d", cfg.MaxSize, uint64(2<<30))
+	}
+
+	if _, err := config.Read([]string{createTempINI(t, "max_size = lots\n")}); err == nil {
+		t.Error("expected error for an invalid max_size")
+	}
+}
+
+func TestRead_Timezone(t *testing.T) {
+	cfg, err := config.Read([]string{createTempINI(t, "timezone = Asia/Tokyo\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if cfg.Zone().String() != "Asia/Tokyo" {
+		t.Errorf("Zone = %s, want Asia/Tokyo", cfg.Zone())
+	}
+
+	if _, err := config.Read([]string{createTempINI(t, "timezone = Mars/Olympus\n")}); err == nil {
+		t.Error("expected error for an unknown timezone")
+	}
+}
+
+func TestRead_TrashMode(t *testing.T) {
+	cfg, err := config.Read([]string{createTempINI(t, "trash_mode = xdg\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !cfg.XDG() || cfg.ContainerPath != config.DefaultXDGContainerPath {
+		t.Errorf("xdg mode must default to the home trash, got %s", cfg.ContainerPath)
+	}
+
+	cfg, err = config.Read([]string{createTempINI(t, "trash_mode = xdg\ncontainer_path = /tmp/trash\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if cfg.ContainerPath != "/tmp/trash" || cfg.ItemPath("a") != "/tmp/trash/files/a" {
+		t.Errorf("explicit container must be kept, got %s and item path %s", cfg.ContainerPath, cfg.ItemPath("a"))
+	}
+
+	if _, err := config.Read([]string{createTempINI(t, "trash_mode = kde\n")}); err == nil {
+		t.Error("expected error for an unknown trash_mode")
+	}
+}
+
+func TestInitialize_XDGReadsTrashInfo(t *testing.T) {
+	container := t.TempDir()
+	for _, dir := range []string{"files", "info"} {
+		if err := os.Mkdir(filepath.Join(container, dir), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(container, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("files/report.pdf", "pdf")
+	write("info/report.pdf.trashinfo", "[Trash Info]\nPath=/home/user/report.pdf\nDeletionDate=2024-01-15T09:30:00\n")
+	write("info/gone.txt.trashinfo", "[Trash Info]\nPath=/home/user/gone.txt\nDeletionDate=2024-01-15T09:30:00\n")
+
+	cfg, err := config.Read([]string{createTempINI(t, "trash_mode = xdg\ncontainer_path = "+container+"\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := cfg.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer cfg.Journal.Close()
+
+	record, err := cfg.Journal.Get("report.pdf")
+	if err != nil {
+		t.Fatalf("item trashed elsewhere must be journaled: %v", err)
+	}
+	if record.Origin != "/home/user/report.pdf" || record.Size != 3 || record.WipeoutTime != cfg.WipeoutTime {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if record.TossedTime != time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local).Unix() {
+		t.Errorf("TossedTime must come from the DeletionDate, got %d", record.TossedTime)
+	}
+	if _, err := os.Stat(filepath.Join(container, "info", "gone.txt.trashinfo")); !os.IsNotExist(err) {
+		t.Error("info file without its item must be removed")
+	}
+}
+
+func TestRead_Layout(t *testing.T) {
+	cfg, err := config.Read([]string{createTempINI(t, "")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if cfg.Layout != config.LayoutFlat || cfg.Mirrored() {
+		t.Errorf("layout must default to flat, got %s", cfg.Layout)
+	}
+
+	cfg, err = config.Read([]string{createTempINI(t, "layout = mirrored\n")})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !cfg.Mirrored() {
+		t.Errorf("expected the mirrored layout, got %s", cfg.Layout)
+	}
+
+	for _, content := range []string{"layout = tree\n", "layout = mirrored\ntrash_mode = xdg\n"} {
+		if _, err := config.Read([]string{createTempINI(t, content)}); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestRead_Compress(t *testing.T) {
+	cfg, err := config.Read([]string{createTempINI(t, "compress = true\n")})
+	if err != nil || !cfg.Compress {
+		t.Fatalf("expected compress to be enabled, got %v, %v", cfg, err)
+	}
+
+	if _, err := config.Read([]string{createTempINI(t, "compress = true\ntrash_mode = xdg\n")}); err == nil {
+		t.Error("expected compress to be rejected in xdg mode")
+	}
+}
+
+func TestMirrored_ContainerItemsAndPruning(t *testing.T) {
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	defer j.Close()
+	cfg := &config.Config{ContainerPath: container, Layout: config.LayoutMirrored, Journal: j}
+
+	if item := config.MirroredItem("/home/user/Downloads/file.txt"); item != "home/user/Downloads/file.txt" {
+		t.Fatalf("MirroredItem = %s", item)
+	}
+
+	for _, item := range []string{"home/user/a.txt", "home/user/docs/b.txt"} {
+		os.MkdirAll(filepath.Dir(cfg.ItemPath(item)), 0o755)
+		os.WriteFile(cfg.ItemPath(item), []byte(item), 0o644)
+		j.AddRecord(&journal.MetaData{Item: item, Origin: "/" + item})
+	}
+	// A journaled directory is one item, whatever it holds
+	os.MkdirAll(cfg.ItemPath("home/user/project/src"), 0o755)
+	j.AddRecord(&journal.MetaData{Item: "home/user/project", Origin: "/home/user/project", Type: journal.TypeDirectory})
+	os.WriteFile(cfg.ItemPath("home/user/stray.txt"), []byte("stray"), 0o644)
+
+	items, err := config.ContainerItems(cfg)
+	if err != nil {
+		t.Fatalf("ContainerItems: %v", err)
+	}
+	want := []string{"home/user/a.txt", "home/user/docs/b.txt", "home/user/project", "home/user/stray.txt"}
+	if !slices.Equal(items, want) {
+		t.Errorf("ContainerItems = %v, want %v", items, want)
+	}
+
+	orphans, err := config.Orphans(cfg)
+	if err != nil || !slices.Equal(orphans, []string{"home/user/stray.txt"}) {
+		t.Errorf("Orphans = %v, %v", orphans, err)
+	}
+
+	os.Remove(cfg.ItemPath("home/user/docs/b.txt"))
+	cfg.PruneItemParents("home/user/docs/b.txt")
+	if _, err := os.Stat(cfg.ItemPath("home/user/docs")); !os.IsNotExist(err) {
+		t.Errorf("empty parent must be pruned, got %v", err)
+	}
+	if _, err := os.Stat(cfg.ItemPath("home/user")); err != nil {
+		t.Errorf("parent holding other items must be kept: %v", err)
+	}
+	if _, err := os.Stat(container); err != nil {
+		t.Errorf("container must be kept: %v", err)
+	}
+}
+
+func TestMirrored_ContainerItemsPastTheLockFile(t *testing.T) {
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	defer j.Close()
+	cfg := &config.Config{ContainerPath: container, Layout: config.LayoutMirrored, Journal: j}
+
+	// The lock file is sorted before the entries of the container root
+	os.WriteFile(filepath.Join(container, config.LockFile), nil, 0o600)
+	os.WriteFile(filepath.Join(container, "orphan.bin"), []byte("orphan"), 0o644)
+	os.MkdirAll(filepath.Join(container, "tmp"), 0o755)
+	os.WriteFile(filepath.Join(container, "tmp", "stray"), []byte("stray"), 0o644)
+
+	orphans, err := config.Orphans(cfg)
+	if err != nil || !slices.Equal(orphans, []string{"orphan.bin", "tmp/stray"}) {
+		t.Errorf("Orphans = %v, %v", orphans, err)
+	}
+}
+
+func TestForBin(t *testing.T) {
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	defer j.Close()
+	cfg := &config.Config{ContainerPath: container, Journal: j}
+
+	work, err := cfg.ForBin("work")
+	if err != nil {
+		t.Fatalf("ForBin: %v", err)
+	}
+	if work.FilesPath() != filepath.Join(container, "bins", "work") || work.Journal.Bin != "work" {
+		t.Errorf("work bin stores into %s, journal bin %q", work.FilesPath(), work.Journal.Bin)
+	}
+	if cfg.Bin != "" || cfg.Journal.Bin != "" {
+		t.Error("ForBin must not change the default configuration")
+	}
+
+	os.MkdirAll(work.ItemPath("a_ABCDEF"), 0o755)
+	os.WriteFile(cfg.ItemPath("b_ABCDEF"), []byte("b"), 0o644)
+	if items, err := config.ContainerItems(cfg); err != nil || !slices.Equal(items, []string{"b_ABCDEF"}) {
+		t.Errorf("default bin items = %v, %v; the bins directory is not an item", items, err)
+	}
+	if size, err := config.BinSize(cfg); err != nil || size != 1 {
+		t.Errorf("default bin size = %d, %v; want the bins excluded", size, err)
+	}
+	if bins, err := config.Bins(cfg); err != nil || !slices.Equal(bins, []string{"work"}) {
+		t.Errorf("Bins = %v, %v", bins, err)
+	}
+
+	for _, name := range []string{"..", "a/b", "."} {
+		if _, err := cfg.ForBin(name); err == nil {
+			t.Errorf("expected error for bin name %q", name)
+		}
+	}
+	cfg.TrashMode = config.TrashModeXDG
+	if _, err := cfg.ForBin("work"); err == nil {
+		t.Error("expected error for a named bin in xdg mode")
+	}
+}
+
+func TestRead_Policies(t *testing.T) {
+	system := createTempINI(t, "wipeout_time = 30\n\n[policies]\ntemp = 1\narchive = 90\n")
+	user := createTempINI(t, "[policies]\narchive = 180\n")
+	cfg, err := config.Read([]string{system, user})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(cfg.Policies) != 2 || cfg.Policies["temp"] != 1 || cfg.Policies["archive"] != 180 {
+		t.Errorf("expected the user policy over the system one, got %v", cfg.Policies)
+	}
+
+	if days, err := cfg.Policy("archive"); err != nil || days != 180 {
+		t.Errorf("Policy(archive) = %d, %v", days, err)
+	}
+	if _, err := cfg.Policy("forever"); err == nil || !strings.Contains(err.Error(), "archive, temp") {
+		t.Errorf("expected an unknown policy to list the known ones, got %v", err)
+	}
+
+	for _, content := range []string{"[policies]\ntemp = soon\n", "[policies]\ntemp = -1\n"} {
+		if _, err := config.Read([]string{createTempINI(t, content)}); err == nil {
+			t.Errorf("expected an invalid policy to be rejected: %q", content)
+		}
+	}
+}
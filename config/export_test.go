@@ -0,0 +1,7 @@
+package config
+
+// Export the size walkers to compare them in the tests of package config_test.
+var (
+	SequentialBinSize = sequentialBinSize
+	ConcurrentBinSize = concurrentBinSize
+)
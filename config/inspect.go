@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"syscall"
+
+	"github.com/go-ini/ini"
+)
+
+// SourceDefault is the source of the settings no configuration file sets.
+const SourceDefault = "default"
+
+// accessWrite is the W_OK mode of access(2), checking write permission.
+const accessWrite = 0x2
+
+// Setting is a configuration key with its effective value and the file it
+// comes from.
+type Setting struct {
+	Section string // Section is the INI section of the key, empty for the top-level keys
+	Key     string
+	Value   string
+	Source  string // Source is the last file setting the key, SourceDefault when none does
+
+	kind reflect.Kind // kind is the type the value is mapped to
+}
+
+// Name returns the key, prefixed by its section for sectioned keys.
+func (s Setting) Name() string {
+	if s.Section == "" {
+		return s.Key
+	}
+	return s.Section + "." + s.Key
+}
+
+// Report describes the configuration read from a set of files: the effective
+// value and source of every setting, and the problems found along the way.
+type Report struct {
+	Config   *Config
+	Settings []Setting
+	Warnings []string
+}
+
+// Inspect reads the configuration files as Read does and reports, besides the
+// resulting configuration, where each setting comes from. It warns about the
+// keys the configuration doesn't know, the values which can't be parsed and
+// are silently replaced by the default and the out of range values. The
+// container is checked apart, by CheckContainer, as its path may be
+// overridden. Files Read rejects are an error.
+func Inspect(paths []string) (*Report, error) {
+	cfg, err := Read(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Config: cfg, Settings: settingsOf(cfg)}
+	known := make(map[string]*Setting, len(report.Settings))
+	for i := range report.Settings {
+		known[report.Settings[i].Name()] = &report.Settings[i]
+	}
+
+	for _, file := range paths {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		parsed, err := ini.Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configuration %s: %w", file, err)
+		}
+
+		for _, section := range parsed.Sections() {
+			name := section.Name()
+			if name == ini.DefaultSection {
+				name = ""
+			}
+			for _, key := range section.Keys() {
+				setting, ok := known[Setting{Section: name, Key: key.Name()}.Name()]
+				if !ok {
+					report.warn("%s: unknown key %s", file, Setting{Section: name, Key: key.Name()}.Name())
+					continue
+				}
+				setting.Source = file
+				if err := checkValue(setting.kind, key); err != nil {
+					report.warn("%s: invalid %s '%s' is ignored: %v", file, setting.Name(), key.String(), err)
+				}
+			}
+		}
+	}
+
+	report.checkRanges()
+	return report, nil
+}
+
+// warn adds a problem to the report.
+func (report *Report) warn(format string, args ...any) {
+	report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// settingsOf lists the settings mapped by the ini tags of the configuration,
+// in declaration order, then the retention policies, with their value in
+// cfg.
+func settingsOf(cfg *Config) []Setting {
+	var settings []Setting
+	var walk func(value reflect.Value, section string)
+	walk = func(value reflect.Value, section string) {
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			tag := field.Tag.Get("ini")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(value.Field(i), tag)
+				continue
+			}
+			settings = append(settings, Setting{
+				Section: section,
+				Key:     tag,
+				Value:   fmt.Sprint(value.Field(i).Interface()),
+				Source:  SourceDefault,
+				kind:    field.Type.Kind(),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+
+	// Policies have no default, Inspect sets the file each one comes from
+	for _, name := range slices.Sorted(maps.Keys(cfg.Policies)) {
+		settings = append(settings, Setting{
+			Section: PoliciesSection,
+			Key:     name,
+			Value:   fmt.Sprint(cfg.Policies[name]),
+			kind:    reflect.Int,
+		})
+	}
+	return settings
+}
+
+// checkValue checks the raw value of the key can be mapped to the kind of its
+// setting, the mapping silently ignoring the values which can't.
+func checkValue(kind reflect.Kind, key *ini.Key) error {
+	var err error
+	switch kind {
+	case reflect.Int:
+		_, err = key.Int()
+	case reflect.Bool:
+		_, err = key.Bool()
+	}
+	return err
+}
+
+// checkRanges warns about the values the settings can hold but make no sense.
+func (report *Report) checkRanges() {
+	for _, setting := range report.Settings {
+		if setting.kind == reflect.Int && setting.Value != "" && setting.Value[0] == '-' {
+			report.warn("%s can't be negative, got %s", setting.Name(), setting.Value)
+		}
+	}
+
+	cfg := report.Config
+	if cfg.MaxRetention > 0 && cfg.WipeoutTime > cfg.MaxRetention {
+		report.warn("wipeout_time %d exceeds max_retention %d, items are evicted after %d days",
+			cfg.WipeoutTime, cfg.MaxRetention, cfg.MaxRetention)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Policies)) {
+		if cfg.MaxRetention > 0 && cfg.Policies[name] > cfg.MaxRetention {
+			report.warn("policy %s of %d days exceeds max_retention %d, items are evicted after %d days",
+				name, cfg.Policies[name], cfg.MaxRetention, cfg.MaxRetention)
+		}
+	}
+}
+
+// ErrContainerMissing is returned, wrapped, by CheckContainer for a missing
+// container which can be created on first use.
+var ErrContainerMissing = errors.New("does not exist yet, it is created on first use")
+
+// CheckContainer checks the container is a writable directory or, when
+// missing, can be created in its closest existing ancestor. A missing
+// container which can be created is reported with ErrContainerMissing.
+func CheckContainer(container string) error {
+	info, err := os.Stat(container)
+	switch {
+	case os.IsNotExist(err):
+		parent := filepath.Dir(container)
+		for _, err := os.Stat(parent); os.IsNotExist(err); _, err = os.Stat(parent) {
+			parent = filepath.Dir(parent)
+		}
+		if syscall.Access(parent, accessWrite) != nil {
+			return fmt.Errorf("%s does not exist and can't be created in %s", container, parent)
+		}
+		return fmt.Errorf("%s %w", container, ErrContainerMissing)
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return fmt.Errorf("%s is not a directory", container)
+	case syscall.Access(container, accessWrite) != nil:
+		return fmt.Errorf("%s is not writable", container)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// hasWarning reports whether one of the warnings contains all the parts.
+func hasWarning(warnings []string, parts ...string) bool {
+	return slices.ContainsFunc(warnings, func(warning string) bool {
+		for _, part := range parts {
+			if !strings.Contains(warning, part) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestInspect_Sources(t *testing.T) {
+	container := t.TempDir()
+	system := createTempINI(t, "wipeout_time = 10\ncontainer_path = "+container+"\n[notifications]\ntimeout = 9\n")
+	user := createTempINI(t, "wipeout_time = 20\n[policies]\ntemp = 1\n")
+
+	report, err := config.Inspect([]string{system, "/no/such/file.cfg", user})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(report.Warnings) != 0 {
+		t.Errorf("expected no warning, got %v", report.Warnings)
+	}
+
+	want := map[string][2]string{
+		"wipeout_time":          {"20", user},
+		"container_path":        {container, system},
+		"max_retention":         {"365", config.SourceDefault},
+		"notifications.timeout": {"9", system},
+		"policies.temp":         {"1", user},
+	}
+	for _, setting := range report.Settings {
+		if w, ok := want[setting.Name()]; ok {
+			if setting.Value != w[0] || setting.Source != w[1] {
+				t.Errorf("%s: got %s from %s, want %s from %s", setting.Name(), setting.Value, setting.Source, w[0], w[1])
+			}
+			delete(want, setting.Name())
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("settings missing from the report: %v", want)
+	}
+}
+
+func TestInspect_UnknownKeys(t *testing.T) {
+	file := createTempINI(t, "container_path = "+t.TempDir()+"\nwipout_time = 7\n[notifications]\nenable = true\n[extras]\ncolor = red\n")
+
+	report, err := config.Inspect([]string{file})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	for _, key := range []string{"wipout_time", "notifications.enable", "extras.color"} {
+		if !hasWarning(report.Warnings, "unknown key "+key) {
+			t.Errorf("expected %s to be reported, got %v", key, report.Warnings)
+		}
+	}
+	if len(report.Warnings) != 3 {
+		t.Errorf("expected 3 warnings, got %v", report.Warnings)
+	}
+}
+
+func TestInspect_BadValues(t *testing.T) {
+	file := createTempINI(t, "container_path = "+t.TempDir()+"\nwipeout_time = notANumber\nmax_retention = -5\nauto_wipe = sometimes\nundo_window = -1\n")
+
+	report, err := config.Inspect([]string{file})
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	cases := [][]string{
+		{"invalid wipeout_time 'notANumber' is ignored"},
+		{"invalid auto_wipe 'sometimes' is ignored"},
+		{"max_retention can't be negative"},
+		{"undo_window can't be negative"},
+	}
+	for _, parts := range cases {
+		if !hasWarning(report.Warnings, parts...) {
+			t.Errorf("expected a warning with %v, got %v", parts, report.Warnings)
+		}
+	}
+}
+
+func TestInspect_RetentionAboveMaximum(t *testing.T) {
+	file := createTempINI(t, "container_path = "+t.TempDir()+"\nwipeout_time = 60\nmax_retention = 30\n")
+
+	report, _ := config.Inspect([]string{file})
+	if !hasWarning(report.Warnings, "wipeout_time 60 exceeds max_retention 30") {
+		t.Errorf("expected the retention above the maximum to be reported, got %v", report.Warnings)
+	}
+}
+
+func TestCheckContainer_ReadOnlyContainer(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can write anywhere")
+	}
+	readOnly := t.TempDir()
+	os.Chmod(readOnly, 0o500)
+	defer os.Chmod(readOnly, 0o700)
+
+	for _, container := range []string{readOnly, filepath.Join(readOnly, "missing", "bin")} {
+		err := config.CheckContainer(container)
+		if err == nil || errors.Is(err, config.ErrContainerMissing) || !strings.Contains(err.Error(), container) {
+			t.Errorf("expected %s to be reported, got %v", container, err)
+		}
+	}
+
+	if err := config.CheckContainer(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, config.ErrContainerMissing) {
+		t.Errorf("expected a container created on first use, got %v", err)
+	}
+}
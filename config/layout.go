@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"rubbish/journal"
+	"strings"
+)
+
+const (
+	// LayoutFlat stores every item at the root of the container, under the
+	// base name of its origin and a random suffix
+	LayoutFlat = "flat"
+
+	// LayoutMirrored stores every item under a path mirroring its origin,
+	// e.g. home/user/Downloads/file.txt
+	LayoutMirrored = "mirrored"
+)
+
+// Mirrored reports whether the items are stored under their origin path.
+func (config *Config) Mirrored() bool {
+	return config.Layout == LayoutMirrored
+}
+
+// MirroredItem returns the item key of an absolute origin in the mirrored
+// layout, its path relative to the root of the container.
+func MirroredItem(origin string) string {
+	return strings.TrimLeft(filepath.ToSlash(filepath.Clean(origin)), "/")
+}
+
+// containerItems returns the entries of a mirrored container: the journaled
+// items, which are not descended into, and the files or empty directories
+// left without a record. The directories mirroring the origins are not items.
+func (config *Config) containerItems() ([]string, error) {
+	root := config.FilesPath()
+	var items []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == ".journal" || config.Reserved(rel) {
+			// SkipDir returned for a file would skip the rest of its directory
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		item := filepath.ToSlash(rel)
+
+		if _, err := config.Journal.Get(item); err == nil {
+			items = append(items, item)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if !errors.Is(err, journal.ErrItemNotFound) {
+			return err
+		}
+
+		if !entry.IsDir() {
+			items = append(items, item)
+			return nil
+		}
+		if entries, err := os.ReadDir(path); err == nil && len(entries) == 0 {
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// PruneItemParents removes the directories above an item which were left
+// empty once it went out of the container, up to the root of the container.
+// It does nothing in the flat layout, where items have no parent of their own.
+func (config *Config) PruneItemParents(item string) {
+	if !config.Mirrored() {
+		return
+	}
+
+	root := config.FilesPath()
+	for dir := filepath.Dir(config.ItemPath(item)); dir != root && journal.IsWithin(dir, root); dir = filepath.Dir(dir) {
+		// A directory still holding other items ends the walk
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+}
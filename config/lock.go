@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"rubbish/journal"
+	"syscall"
+)
+
+// LockFile is the file of the container locked by the running rubbish process.
+const LockFile = ".lock"
+
+// LockPath returns the lock file of the container.
+func (config *Config) LockPath() string {
+	return path.Join(config.ContainerPath, LockFile)
+}
+
+// Lock takes the advisory lock of the container, so a single rubbish process
+// uses it at a time. It fails with journal.ErrBusy when another process holds
+// the lock. The returned function releases it, as does the process exit.
+func (config *Config) Lock() (func(), error) {
+	file, err := os.OpenFile(config.LockPath(), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, journal.ErrBusy
+		}
+		return nil, fmt.Errorf("error locking %s: %w", config.LockPath(), err)
+	}
+
+	config.lockFile = file
+	return config.unlock, nil
+}
+
+// unlock releases the container lock, closing the file holding it.
+func (config *Config) unlock() {
+	if config.lockFile != nil {
+		config.lockFile.Close()
+		config.lockFile = nil
+	}
+}
+
+// Release closes the journal and releases the container lock, so other
+// rubbish processes can use the bin until Reacquire takes them back.
+func (config *Config) Release() error {
+	config.unlock()
+	return config.Journal.Close()
+}
+
+// Reacquire takes back the container lock and reopens the journal after
+// Release, seeing the changes other processes made meanwhile. It fails with
+// journal.ErrBusy, holding nothing, when another process uses the bin.
+func (config *Config) Reacquire() error {
+	if _, err := config.Lock(); err != nil {
+		return err
+	}
+	if err := config.Journal.Load(); err != nil {
+		config.unlock()
+		return err
+	}
+	return nil
+}
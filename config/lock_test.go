@@ -0,0 +1,66 @@
+package config_test
+
+import (
+	"errors"
+	"rubbish/config"
+	"rubbish/journal"
+	"testing"
+)
+
+func TestLock_SecondAttemptFails(t *testing.T) {
+	cfg := &config.Config{ContainerPath: t.TempDir()}
+
+	release, err := cfg.Lock()
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	if _, err := cfg.Lock(); !errors.Is(err, journal.ErrBusy) {
+		t.Fatalf("expected the held lock to report a busy container, got %v", err)
+	}
+
+	release()
+	again, err := cfg.Lock()
+	if err != nil {
+		t.Fatalf("the released lock must be available again: %v", err)
+	}
+	again()
+
+	if !cfg.Reserved(config.LockFile) {
+		t.Error("the lock file must not be taken for an item")
+	}
+}
+
+func TestRelease_LetsAnotherProcessIn(t *testing.T) {
+	container := t.TempDir()
+	open := func() *config.Config {
+		cfg := &config.Config{ContainerPath: container, Journal: &journal.Journal{Path: container + "/.journal"}}
+		if _, err := cfg.Lock(); err != nil {
+			t.Fatalf("Lock: %v", err)
+		}
+		if err := cfg.Journal.Load(); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return cfg
+	}
+
+	watcher := open()
+	if err := watcher.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	other := open()
+	if err := watcher.Reacquire(); !errors.Is(err, journal.ErrBusy) {
+		t.Fatalf("expected the bin in use to be busy, got %v", err)
+	}
+	other.Journal.AddRecord(&journal.MetaData{Item: "new_ABCDEF", Origin: "/tmp/new"})
+	other.Release()
+
+	if err := watcher.Reacquire(); err != nil {
+		t.Fatalf("Reacquire: %v", err)
+	}
+	defer watcher.Release()
+	if _, err := watcher.Journal.Get("new_ABCDEF"); err != nil {
+		t.Errorf("expected the record added meanwhile to be seen, got %v", err)
+	}
+}
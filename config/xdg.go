@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"rubbish/journal"
+	"rubbish/trashinfo"
+	"strings"
+	"time"
+)
+
+const (
+	// TrashModeNative keeps the items at the root of the container, tracked
+	// by the journal only
+	TrashModeNative = "native"
+
+	// TrashModeXDG lays the container out as a FreeDesktop.org trash, so
+	// file managers and rubbish see each other's items
+	TrashModeXDG = "xdg"
+
+	// DefaultContainerPath is the container used in native mode, relative to
+	// the user's home directory
+	DefaultContainerPath = ".local/share/rubbish"
+
+	// DefaultXDGContainerPath is the home trash of the FreeDesktop.org spec
+	DefaultXDGContainerPath = ".local/share/Trash"
+)
+
+// XDG reports whether the container follows the FreeDesktop.org Trash spec.
+func (config *Config) XDG() bool {
+	return config.TrashMode == TrashModeXDG
+}
+
+// FilesPath returns the directory holding the tossed items: the container
+// itself in native mode, its files directory in xdg mode, or the directory of
+// the selected named bin.
+func (config *Config) FilesPath() string {
+	if config.Bin != "" {
+		return filepath.Join(config.ContainerPath, BinsDir, config.Bin)
+	}
+	if config.XDG() {
+		return filepath.Join(config.ContainerPath, "files")
+	}
+	return config.ContainerPath
+}
+
+// InfoPath returns the directory holding the .trashinfo files in xdg mode.
+func (config *Config) InfoPath() string {
+	return filepath.Join(config.ContainerPath, "info")
+}
+
+// ItemPath returns the location of a tossed item inside the container.
+func (config *Config) ItemPath(item string) string {
+	return filepath.Join(config.FilesPath(), item)
+}
+
+// WriteTrashInfo describes the record in a .trashinfo file. It does nothing
+// in native mode.
+func (config *Config) WriteTrashInfo(record *journal.MetaData) error {
+	if !config.XDG() {
+		return nil
+	}
+	return trashinfo.Write(config.InfoPath(), record.Item, trashinfo.Info{
+		Path:         record.Origin,
+		DeletionDate: time.Unix(record.TossedTime, 0),
+	})
+}
+
+// RemoveTrashInfo deletes the .trashinfo file of an item leaving the
+// container. It does nothing in native mode.
+func (config *Config) RemoveTrashInfo(item string) error {
+	if !config.XDG() {
+		return nil
+	}
+	return trashinfo.Remove(config.InfoPath(), item)
+}
+
+// syncTrashInfo reconciles the journal with the .trashinfo files, which other
+// applications may have added or left behind. Items trashed elsewhere are
+// journaled with the default wipeout time, and info files whose item is gone
+// are removed.
+func (config *Config) syncTrashInfo() error {
+	for _, dir := range []string{config.FilesPath(), config.InfoPath()} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(config.InfoPath())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		item, found := strings.CutSuffix(entry.Name(), trashinfo.Extension)
+		if !found || entry.IsDir() {
+			continue
+		}
+
+		if _, err := os.Lstat(config.ItemPath(item)); os.IsNotExist(err) {
+			if err := trashinfo.Remove(config.InfoPath(), item); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := config.Journal.Get(item); !errors.Is(err, journal.ErrItemNotFound) {
+			continue
+		}
+
+		info, err := trashinfo.Read(filepath.Join(config.InfoPath(), entry.Name()), filepath.Dir(config.ContainerPath))
+		if err != nil {
+			color.Warnf("skipping unreadable trash info: %v\n", err)
+			continue
+		}
+
+		record, err := journal.GenerateMetadata(item, config.ItemPath(item), config.WipeoutTime)
+		if err != nil {
+			return err
+		}
+		record.Origin = info.Path
+		record.TossedTime = info.DeletionDate.Unix()
+
+		if err := config.Journal.AddRecord(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
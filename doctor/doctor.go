@@ -0,0 +1,230 @@
+// Package doctor implements the doctor command, which diagnoses the
+// configuration, the container and the journal of the default bin.
+//
+// Unlike the other commands, doctor runs before the configuration is loaded,
+// so it can report the very problems that would stop rubbish from starting.
+package doctor
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"rubbish/verify"
+	"strings"
+)
+
+// ErrUnhealthy is returned when at least one check failed.
+var ErrUnhealthy = errors.New("rubbish is unhealthy")
+
+// lowSpacePercent is the share of free space on the container filesystem
+// below which the disk check warns.
+const lowSpacePercent = 5
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	Pass Status = "PASS"
+	Warn Status = "WARN"
+	Fail Status = "FAIL"
+)
+
+// Check is the outcome of one diagnostic, with a description of what was found.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Options locate what doctor examines, as the global options of rubbish do.
+type Options struct {
+	Paths     []string // Paths are the configuration files, in load order
+	Explicit  bool     // Explicit tells the only path was given with --config, so it must exist
+	Container string   // Container overrides the configured container path when not empty
+	Journal   string   // Journal overrides the journal path when not empty
+}
+
+var Flags = flag.NewFlagSet("doctor", flag.ExitOnError)
+
+func init() {
+	Flags.Usage = func() {
+		fmt.Println("Rubbish doctor checks that the configuration files parse, the container\n",
+			"exists and is writable, the journal opens, the container filesystem has\n",
+			"free space and the journal agrees with the container.\n",
+			"It fails when any check fails, so it can be used as a check.\n\n",
+			"Usage:\n\n",
+			"\trubbish doctor")
+	}
+}
+
+// Command prints the diagnosis and returns ErrUnhealthy when a check failed.
+func Command(args []string, opts Options) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	failed := 0
+	for _, check := range Diagnose(opts) {
+		fmt.Printf("%s %s: %s\n", color.Paint(os.Stdout, statusColor(check.Status), "["+string(check.Status)+"]"), check.Name, check.Detail)
+		if check.Status == Fail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d checks failed", ErrUnhealthy, failed)
+	}
+	return nil
+}
+
+// Diagnose runs the checks in order. The checks depending on an earlier one
+// are left out when it failed, e.g. nothing is checked past an unreadable
+// configuration.
+func Diagnose(opts Options) []Check {
+	checks, cfg := checkConfig(opts)
+	if cfg == nil {
+		return checks
+	}
+
+	container := checkContainer(cfg.ContainerPath)
+	checks = append(checks, container, checkDiskSpace(cfg.ContainerPath))
+	if container.Status == Fail {
+		return checks
+	}
+
+	checks = append(checks, checkJournal(cfg))
+	if cfg.Journal == nil {
+		return checks
+	}
+	defer cfg.Journal.Close()
+
+	return append(checks, checkConsistency(cfg))
+}
+
+// checkConfig reads the configuration files, applies the overrides and
+// returns the resulting configuration, nil when it can't be read.
+func checkConfig(opts Options) ([]Check, *config.Config) {
+	var found []string
+	for _, path := range opts.Paths {
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		} else if opts.Explicit || !os.IsNotExist(err) {
+			return []Check{{"configuration", Fail, err.Error()}}, nil
+		}
+	}
+
+	report, err := config.Inspect(opts.Paths)
+	if err != nil {
+		return []Check{{"configuration", Fail, err.Error()}}, nil
+	}
+
+	var checks []Check
+	if len(found) == 0 {
+		checks = append(checks, Check{"configuration", Pass, "no configuration file, the defaults apply"})
+	} else {
+		checks = append(checks, Check{"configuration", Pass, "read " + strings.Join(found, ", ")})
+	}
+	for _, warning := range report.Warnings {
+		checks = append(checks, Check{"configuration", Warn, warning})
+	}
+
+	cfg := report.Config
+	if opts.Container != "" {
+		if cfg.ContainerPath, err = filepath.Abs(opts.Container); err != nil {
+			return append(checks, Check{"configuration", Fail, err.Error()}), nil
+		}
+	}
+	if opts.Journal != "" {
+		if cfg.JournalPath, err = filepath.Abs(opts.Journal); err != nil {
+			return append(checks, Check{"configuration", Fail, err.Error()}), nil
+		}
+	}
+	cfg.ContainerPath = config.NormalizePath(cfg.ContainerPath)
+	return checks, cfg
+}
+
+// checkContainer checks the container is a writable directory. A missing
+// container passes with a warning when it can be created on first use.
+func checkContainer(container string) Check {
+	err := config.CheckContainer(container)
+	switch {
+	case errors.Is(err, config.ErrContainerMissing):
+		return Check{"container", Warn, err.Error()}
+	case err != nil:
+		return Check{"container", Fail, err.Error()}
+	}
+	return Check{"container", Pass, container}
+}
+
+// checkDiskSpace warns when the container filesystem is almost full.
+func checkDiskSpace(container string) Check {
+	free, total, err := fsutil.FreeSpace(container)
+	if err != nil {
+		return Check{"disk space", Fail, err.Error()}
+	}
+
+	detail := fmt.Sprintf("%s free of %s", config.ReadableSize(free), config.ReadableSize(total))
+	if total > 0 && free*100 < total*lowSpacePercent {
+		return Check{"disk space", Warn, detail + fmt.Sprintf(", less than %d%% left", lowSpacePercent)}
+	}
+	return Check{"disk space", Pass, detail}
+}
+
+// checkJournal opens the journal. It is left closed, with cfg.Journal nil,
+// when it doesn't exist yet, is in use or fails to open.
+func checkJournal(cfg *config.Config) Check {
+	path := cfg.JournalPath
+	if path == "" {
+		path = cfg.DefaultJournalPath()
+	}
+	// Opening a missing journal would create it
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Check{"journal", Warn, fmt.Sprintf("%s does not exist yet, it is created on first use", path)}
+	}
+
+	err := cfg.Initialize()
+	switch {
+	case errors.Is(err, journal.ErrBusy):
+		cfg.Journal = nil
+		return Check{"journal", Warn, fmt.Sprintf("%s is in use by another rubbish process, not checked", path)}
+	case err != nil:
+		if cfg.Journal != nil {
+			cfg.Journal.Close()
+			cfg.Journal = nil
+		}
+		return Check{"journal", Fail, err.Error()}
+	}
+	return Check{"journal", Pass, path}
+}
+
+// checkConsistency counts the dangling records and the orphans, which
+// verify --fix repairs.
+func checkConsistency(cfg *config.Config) Check {
+	dangling, orphans, err := verify.Inconsistencies(cfg)
+	if err != nil {
+		return Check{"consistency", Fail, err.Error()}
+	}
+	if len(dangling) > 0 || len(orphans) > 0 {
+		return Check{"consistency", Warn, fmt.Sprintf("%d dangling records, %d orphans, run 'rubbish verify --fix' to repair them",
+			len(dangling), len(orphans))}
+	}
+	return Check{"consistency", Pass, "the journal agrees with the container"}
+}
+
+// statusColor returns the color of the status label.
+func statusColor(status Status) string {
+	switch status {
+	case Pass:
+		return color.Green
+	case Warn:
+		return color.Yellow
+	default:
+		return color.Red
+	}
+}
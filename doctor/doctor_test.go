@@ -0,0 +1,153 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// newTestOptions writes a configuration file pointing to a new container
+// holding an empty journal.
+func newTestOptions(t *testing.T) (Options, string) {
+	t.Helper()
+	container := t.TempDir()
+	file := filepath.Join(t.TempDir(), "rubbish.cfg")
+	if err := os.WriteFile(file, []byte("container_path = "+container+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	j.Close()
+	return Options{Paths: []string{file}, Explicit: true}, container
+}
+
+// statusOf returns the status of the named check, empty when it didn't run.
+func statusOf(checks []Check, name string) Status {
+	var status Status
+	for _, check := range checks {
+		if check.Name == name && status != Fail {
+			status = check.Status
+		}
+	}
+	return status
+}
+
+func TestDiagnose_Healthy(t *testing.T) {
+	opts, _ := newTestOptions(t)
+
+	checks := Diagnose(opts)
+	for _, name := range []string{"configuration", "container", "disk space", "journal", "consistency"} {
+		if status := statusOf(checks, name); status == "" || status == Fail {
+			t.Errorf("expected %s to pass, got %q in %+v", name, status, checks)
+		}
+	}
+	if statusOf(checks, "consistency") != Pass {
+		t.Errorf("expected an empty bin to be consistent, got %+v", checks)
+	}
+}
+
+func TestDiagnose_ReadOnlyContainerFails(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	opts, container := newTestOptions(t)
+	if err := os.Chmod(container, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(container, 0o700) })
+
+	checks := Diagnose(opts)
+	if statusOf(checks, "container") != Fail {
+		t.Errorf("expected the container check to fail, got %+v", checks)
+	}
+	if statusOf(checks, "journal") != "" {
+		t.Errorf("expected the journal not to be checked past the container, got %+v", checks)
+	}
+
+	if err := Command(nil, opts); !errors.Is(err, ErrUnhealthy) {
+		t.Errorf("expected ErrUnhealthy, got %v", err)
+	}
+	if status := statusOf(checks, "configuration"); status != Pass {
+		t.Errorf("the container must only be reported by its own check, got %+v", checks)
+	}
+
+	// The overriding container is the one checked
+	opts.Container = t.TempDir()
+	checks = Diagnose(opts)
+	if statusOf(checks, "container") != Pass || statusOf(checks, "configuration") != Pass {
+		t.Errorf("expected the writable override to pass, got %+v", checks)
+	}
+}
+
+func TestDiagnose_InvalidConfigFails(t *testing.T) {
+	opts, _ := newTestOptions(t)
+	if err := os.WriteFile(opts.Paths[0], []byte("[broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := Diagnose(opts)
+	if len(checks) != 1 || statusOf(checks, "configuration") != Fail {
+		t.Errorf("expected only a failed configuration check, got %+v", checks)
+	}
+}
+
+func TestDiagnose_MissingConfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.cfg")
+
+	checks := Diagnose(Options{Paths: []string{missing}, Explicit: true})
+	if statusOf(checks, "configuration") != Fail {
+		t.Errorf("expected a missing --config file to fail, got %+v", checks)
+	}
+
+	// The hierarchy files are optional
+	checks = Diagnose(Options{Paths: []string{missing}, Container: t.TempDir()})
+	if statusOf(checks, "configuration") != Pass {
+		t.Errorf("expected a missing hierarchy file to pass, got %+v", checks)
+	}
+}
+
+func TestDiagnose_CorruptJournalFails(t *testing.T) {
+	opts, container := newTestOptions(t)
+	journalPath := filepath.Join(container, ".journal")
+	os.RemoveAll(journalPath)
+	if err := os.WriteFile(journalPath, []byte("not a journal"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := Diagnose(opts)
+	if statusOf(checks, "journal") != Fail {
+		t.Errorf("expected the journal check to fail, got %+v", checks)
+	}
+	if statusOf(checks, "consistency") != "" {
+		t.Errorf("expected the consistency not to be checked without a journal, got %+v", checks)
+	}
+}
+
+func TestDiagnose_InconsistenciesWarn(t *testing.T) {
+	opts, container := newTestOptions(t)
+	cfg, err := config.Read(opts.Paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(cfg.FilesPath(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.ItemPath("orphan"), []byte("orphan"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := Diagnose(opts)
+	if statusOf(checks, "consistency") != Warn {
+		t.Errorf("expected the orphan in %s to warn, got %+v", container, checks)
+	}
+	if err := Command(nil, opts); err != nil {
+		t.Errorf("expected warnings not to fail, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package find
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	Flags      *flag.FlagSet = flag.NewFlagSet("find", flag.ExitOnError)
+	useRegex   bool          = false // useRegex interprets the pattern as a regular expression instead of a glob
+	ignoreCase bool          = false // ignoreCase matches the pattern regardless of letter case
+)
+
+func init() {
+	Flags.BoolVar(&useRegex, "regex", false, "Interpret the pattern as a regular expression instead of a glob.")
+	Flags.BoolVar(&ignoreCase, "ignore-case", false, "Match the pattern regardless of letter case.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish find searches every item in the journal by its original or stored name.\n",
+			"Usage:\n\n",
+			"\trubbish find [options] <pattern>\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+// Command prints the items whose original basename or stored item name match
+// the pattern, with their full item key so they can be passed to restore or wipe.
+func Command(args []string, cfg *config.Config) error {
+	if len(args) < 1 {
+		return fmt.Errorf("search pattern is required")
+	}
+
+	match, err := newMatcher(args[0], useRegex, ignoreCase)
+	if err != nil {
+		return err
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	found := filterRecords(records, match)
+	if len(found) == 0 {
+		fmt.Println("No matching items found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tORIGIN\tTOSSED")
+	for _, record := range found {
+		fmt.Fprintf(w, "%s\t%s\t%s\n",
+			record.Item,
+			record.Origin,
+			cfg.DisplayTime(record.TossedTime).Format(time.DateTime),
+		)
+	}
+	w.Flush()
+
+	fmt.Printf("Found: %d\n", len(found))
+	return nil
+}
+
+// newMatcher compiles the pattern into a function reporting whether a name matches it.
+func newMatcher(pattern string, regex bool, foldCase bool) (func(string) bool, error) {
+	if regex {
+		if foldCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s': %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if foldCase {
+		pattern = strings.ToLower(pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+
+	return func(name string) bool {
+		if foldCase {
+			name = strings.ToLower(name)
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
+
+// filterRecords returns the records whose origin basename or item name match.
+func filterRecords(records []*journal.MetaData, match func(string) bool) []*journal.MetaData {
+	var found []*journal.MetaData
+	for _, record := range records {
+		if match(path.Base(record.Origin)) || match(record.Item) {
+			found = append(found, record)
+		}
+	}
+	return found
+}
@@ -0,0 +1,116 @@
+package find
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(dir, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	for item, origin := range map[string]string{
+		"Report.pdf_ABCDEF": "/home/u/docs/Report.pdf",
+		"notes.txt_QWERTY":  "/home/u/notes.txt",
+		"notes.txt_ZXCVBN":  "/tmp/other/notes.txt",
+		"photo.jpg_POIUYT":  "/home/u/pics/photo.jpg",
+	} {
+		j.AddRecord(&journal.MetaData{Item: item, Origin: origin, WipeoutTime: 30, TossedTime: time.Now().Unix()})
+	}
+	return &config.Config{ContainerPath: dir, Journal: j}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestCommand_GlobMatchesOriginAcrossDirectories(t *testing.T) {
+	cfg := newTestCfg(t)
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{"notes.*"}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, item := range []string{"notes.txt_QWERTY", "notes.txt_ZXCVBN"} {
+		if !strings.Contains(out, item) {
+			t.Errorf("expected %s in output: %s", item, out)
+		}
+	}
+	if strings.Contains(out, "photo.jpg") || !strings.Contains(out, "Found: 2") {
+		t.Errorf("unexpected matches: %s", out)
+	}
+}
+
+func TestCommand_GlobMatchesItemKey(t *testing.T) {
+	cfg := newTestCfg(t)
+
+	out := captureStdout(t, func() { Command([]string{"*_POIUYT"}, cfg) })
+	if !strings.Contains(out, "photo.jpg_POIUYT") || !strings.Contains(out, "Found: 1") {
+		t.Errorf("expected the item key to match: %s", out)
+	}
+}
+
+func TestCommand_RegexIgnoreCase(t *testing.T) {
+	cfg := newTestCfg(t)
+	useRegex, ignoreCase = true, true
+	defer func() { useRegex, ignoreCase = false, false }()
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{`^report\.`}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Report.pdf_ABCDEF") || !strings.Contains(out, "Found: 1") {
+		t.Errorf("expected case insensitive regex match: %s", out)
+	}
+}
+
+func TestCommand_CaseSensitiveByDefault(t *testing.T) {
+	cfg := newTestCfg(t)
+
+	out := captureStdout(t, func() { Command([]string{"report*"}, cfg) })
+	if !strings.Contains(out, "No matching items found.") {
+		t.Errorf("glob must be case sensitive without --ignore-case: %s", out)
+	}
+}
+
+func TestCommand_InvalidPatterns(t *testing.T) {
+	cfg := newTestCfg(t)
+
+	if err := Command(nil, cfg); err == nil {
+		t.Error("expected error without a pattern")
+	}
+	if err := Command([]string{"[abc"}, cfg); err == nil {
+		t.Error("expected error for a malformed glob")
+	}
+
+	useRegex = true
+	defer func() { useRegex = false }()
+	if err := Command([]string{"(abc"}, cfg); err == nil {
+		t.Error("expected error for a malformed regular expression")
+	}
+}
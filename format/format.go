@@ -0,0 +1,68 @@
+// Package format renders records through the user templates given with the
+// --format option of the commands, in the text/template syntax.
+package format
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"rubbish/config"
+	"rubbish/journal"
+	"text/template"
+	"time"
+)
+
+// Record is what a --format template is executed against: the fields of the
+// journal record, plus values computed from it. The computed WipeableAt
+// replaces the raw timestamp of the record.
+type Record struct {
+	*journal.MetaData
+
+	Tossed        time.Time // Tossed is when the item was tossed, in the display zone
+	WipeableAt    time.Time // WipeableAt is when the item becomes wipeable, in the display zone
+	Wipeable      bool      // Wipeable tells the item can be wiped now
+	RemainingDays int       // RemainingDays is the number of days, rounded up, before the item is wipeable
+	TypeName      string    // TypeName is the name of the item type: file, dir, symlink or other
+	HumanSize     string    // HumanSize is the readable size the item takes in the bin
+}
+
+// NewRecord computes the template view of the record.
+func NewRecord(record *journal.MetaData, cfg *config.Config) Record {
+	remaining := max(record.RemainingTime(), 0)
+	return Record{
+		MetaData:      record,
+		Tossed:        cfg.DisplayTime(record.TossedTime),
+		WipeableAt:    record.WipeoutDate().In(cfg.Zone()),
+		Wipeable:      record.IsWipeable(),
+		RemainingDays: int(math.Ceil(remaining.Hours() / 24)),
+		TypeName:      journal.TypeName(record.Type),
+		HumanSize:     config.ReadableSize(uint64(config.RecordSize(cfg, record))),
+	}
+}
+
+// Parse parses the template given with --format and tries it on an empty
+// record, so an invalid template, or one naming an unknown field, fails
+// before anything is printed.
+func Parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(text)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, Record{MetaData: &journal.MetaData{}})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Write executes the template against each record, one per line.
+func Write(w io.Writer, tmpl *template.Template, records []*journal.MetaData, cfg *config.Config) error {
+	for _, record := range records {
+		if err := tmpl.Execute(w, NewRecord(record, cfg)); err != nil {
+			return fmt.Errorf("error formatting %s: %w", record.Item, err)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
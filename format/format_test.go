@@ -0,0 +1,48 @@
+package format
+
+import (
+	"bytes"
+	"rubbish/config"
+	"rubbish/journal"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	cfg := &config.Config{ContainerPath: t.TempDir(), Location: time.UTC}
+	tossed := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	records := []*journal.MetaData{
+		{Item: "a.txt_ABCDEF", Origin: "/home/me/a.txt", Type: journal.TypeFile, WipeoutTime: 30, TossedTime: tossed.Unix(), Size: 2048},
+		{Item: "later_ABCDEF", Origin: "/home/me/later", Type: journal.TypeDirectory, WipeoutTime: 1, TossedTime: time.Now().Add(-time.Hour).Unix(), Size: 10},
+	}
+
+	tomorrow := time.Now().Add(23 * time.Hour).UTC().Format(time.DateOnly)
+	cases := []struct{ text, want string }{
+		{"{{.Item}} {{.Origin}}", "a.txt_ABCDEF /home/me/a.txt\nlater_ABCDEF /home/me/later\n"},
+		{`{{.Item}} {{.TypeName}} {{.HumanSize}} {{.WipeableAt.Format "2006-01-02"}} {{.Wipeable}}`,
+			"a.txt_ABCDEF file 2.0 KB 2024-03-31 true\nlater_ABCDEF dir 10 bytes " + tomorrow + " false\n"},
+		{"{{.RemainingDays}}", "0\n1\n"},
+	}
+	for _, c := range cases {
+		text, want := c.text, c.want
+		tmpl, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", text, err)
+		}
+		var out bytes.Buffer
+		if err := Write(&out, tmpl, records, cfg); err != nil {
+			t.Fatalf("Write(%q): %v", text, err)
+		}
+		if out.String() != want {
+			t.Errorf("%q: got %q, want %q", text, out.String(), want)
+		}
+	}
+}
+
+func TestParse_RejectsInvalidTemplates(t *testing.T) {
+	for _, text := range []string{"{{.Item", "{{.Unknown}}", "{{.Origin.Bad}}"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotCompressible reports an entry Compress cannot archive, such as a
+// device or a socket.
+var ErrNotCompressible = errors.New("not compressible")
+
+// Compress writes to dst a gzip compressed tar archive of the file or
+// directory tree at src, symlinks included as links. The root is archived as
+// "." so Decompress can recreate it under any name. src is left untouched and
+// dst only appears once the archive is complete.
+func Compress(src string, dst string) error {
+	staging := filepath.Join(filepath.Dir(dst), ".partial_"+filepath.Base(dst))
+
+	if err := writeArchive(src, staging); err != nil {
+		os.Remove(staging)
+		return err
+	}
+
+	if err := os.Rename(staging, dst); err != nil {
+		os.Remove(staging)
+		return fmt.Errorf("error committing archive of %s: %w", src, err)
+	}
+	return nil
+}
+
+// writeArchive archives the tree at src into the new file dst.
+func writeArchive(src string, dst string) error {
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	tw := tar.NewWriter(zw)
+
+	err = filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return archiveEntry(tw, src, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// archiveEntry writes the header, and the content of regular files, of the
+// entry at path of the tree rooted at root.
+func archiveEntry(tw *tar.Writer, root string, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	case info.IsDir(), info.Mode().IsRegular():
+	default:
+		return fmt.Errorf("%w: %s", ErrNotCompressible, path)
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(rel)
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// Decompress recreates at dst the file or directory tree archived into src
+// by Compress, with its permissions and modification times. src is left
+// untouched and dst only appears once the whole tree is extracted.
+func Decompress(src string, dst string) error {
+	staging := filepath.Join(filepath.Dir(dst), ".partial_"+filepath.Base(dst))
+
+	if err := extractArchive(src, staging); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("error decompressing %s: %w", src, err)
+	}
+
+	if err := os.Rename(staging, dst); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("error committing decompressed %s: %w", src, err)
+	}
+	return nil
+}
+
+// extractArchive extracts the archive src into the new path dst.
+func extractArchive(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	// Directories get their final mode and time once their content is written
+	var dirs []*tar.Header
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) && name != "." {
+			return fmt.Errorf("invalid archive entry %s", header.Name)
+		}
+		path := filepath.Join(dst, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.Mkdir(path, 0o700); err != nil {
+				return err
+			}
+			dirs = append(dirs, header)
+			continue
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+			continue
+		case tar.TypeReg:
+			if err := extractFile(tr, path, header); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported archive entry %s", header.Name)
+		}
+
+		if err := os.Chtimes(path, header.ModTime, header.ModTime); err != nil {
+			return err
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(dst, filepath.FromSlash(dirs[i].Name))
+		if err := os.Chmod(path, dirs[i].FileInfo().Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(path, dirs[i].ModTime, dirs[i].ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractFile writes the content of the current archive entry to the new file path.
+func extractFile(r io.Reader, path string, header *tar.Header) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// compressedExtensions are the extensions of the formats which are already
+// compressed, not worth compressing again.
+var compressedExtensions = []string{
+	".gz", ".tgz", ".bz2", ".xz", ".zst", ".lz4", ".zip", ".7z", ".rar",
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic",
+	".mp3", ".ogg", ".flac", ".m4a", ".mp4", ".mkv", ".webm", ".avi", ".mov",
+	".docx", ".xlsx", ".pptx", ".odt", ".ods", ".jar", ".apk", ".deb", ".rpm",
+}
+
+// AlreadyCompressed reports whether the file name has the extension of an
+// already compressed format.
+func AlreadyCompressed(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, compressed := range compressedExtensions {
+		if ext == compressed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCompress_FileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.log")
+	content := bytes.Repeat([]byte("GET /index.html 200\n"), 1000)
+	os.WriteFile(src, content, 0o640)
+	mtime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	os.Chtimes(src, mtime, mtime)
+
+	archive := filepath.Join(dir, "archive")
+	if err := Compress(src, archive); err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if info, err := os.Stat(archive); err != nil || info.Size() >= int64(len(content)) {
+		t.Fatalf("expected a smaller archive, got %v, %v", info, err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("the source must be left untouched: %v", err)
+	}
+
+	restored := filepath.Join(dir, "restored.log")
+	if err := Decompress(archive, restored); err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	data, err := os.ReadFile(restored)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("content differs after the round trip: %v", err)
+	}
+	info, _ := os.Stat(restored)
+	if info.Mode().Perm() != 0o640 || !info.ModTime().Equal(mtime) {
+		t.Errorf("mode %v and time %v not kept", info.Mode(), info.ModTime())
+	}
+}
+
+func TestCompress_DirectoryRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "project")
+	os.MkdirAll(filepath.Join(src, "sub", "empty"), 0o755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644)
+	os.WriteFile(filepath.Join(src, "sub", "b.sh"), []byte("#!/bin/sh"), 0o755)
+	os.Symlink("a.txt", filepath.Join(src, "link"))
+	os.Chmod(filepath.Join(src, "sub"), 0o750)
+
+	archive := filepath.Join(dir, "archive")
+	if err := Compress(src, archive); err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+
+	restored := filepath.Join(dir, "restored")
+	if err := Decompress(archive, restored); err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(restored, "a.txt")); string(data) != "a" {
+		t.Errorf("a.txt = %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(restored, "sub", "b.sh")); err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("sub/b.sh missing or mode not kept: %v, %v", info, err)
+	}
+	if info, err := os.Stat(filepath.Join(restored, "sub")); err != nil || info.Mode().Perm() != 0o750 {
+		t.Errorf("sub mode not kept: %v, %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(restored, "sub", "empty")); err != nil {
+		t.Errorf("empty directory not kept: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(restored, "link")); err != nil || target != "a.txt" {
+		t.Errorf("symlink not kept: %q, %v", target, err)
+	}
+}
+
+func TestCompress_RejectsSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "tree")
+	os.Mkdir(src, 0o755)
+	if err := syscall.Mkfifo(filepath.Join(src, "fifo"), 0o644); err != nil {
+		t.Skipf("cannot create a fifo: %v", err)
+	}
+
+	archive := filepath.Join(dir, "archive")
+	if err := Compress(src, archive); !errors.Is(err, ErrNotCompressible) {
+		t.Fatalf("expected ErrNotCompressible, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("a failed archive must leave nothing behind, got %d entries", len(entries))
+	}
+}
+
+func TestAlreadyCompressed(t *testing.T) {
+	for name, want := range map[string]bool{"a.log": false, "photo.JPG": true, "b.tar.gz": true, "notes": false, "x.zip": true} {
+		if got := AlreadyCompressed(name); got != want {
+			t.Errorf("AlreadyCompressed(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ProgressEvery is the number of files TreeStats counts between two calls of Progress.
+const ProgressEvery = 1000
+
+// Progress, when set, is called by TreeStats every ProgressEvery files with
+// the root of the walk and the files and bytes counted so far, so long walks
+// can be reported.
+var Progress func(root string, files int, size int64)
+
+// DeviceOf returns the identifier of the device holding the given path.
+// When the path does not exist anymore (e.g. the origin of a tossed item),
+// the closest existing ancestor is examined instead, as that is the
+// filesystem the path would be recreated on.
+func DeviceOf(path string) (uint64, error) {
+	current := filepath.Clean(path)
+
+	for {
+		info, err := os.Lstat(current)
+		if err == nil {
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				return 0, fmt.Errorf("cannot get detailed file information for %s", current)
+			}
+			return uint64(stat.Dev), nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, fmt.Errorf("cannot access %s: %w", current, err)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return 0, fmt.Errorf("no existing ancestor for %s: %w", path, err)
+		}
+		current = parent
+	}
+}
+
+// CrossDevice reports whether the origin would be restored into a different
+// filesystem than the one holding the container. It is false when either
+// device can't be determined.
+func CrossDevice(origin string, container string) bool {
+	originDev, err := DeviceOf(origin)
+	if err != nil {
+		return false
+	}
+	containerDev, err := DeviceOf(container)
+	if err != nil {
+		return false
+	}
+	return originDev != containerDev
+}
+
+// FreeSpace returns the bytes available to the user and the total size of
+// the filesystem holding the given path, or its closest existing ancestor
+// when the path does not exist yet.
+func FreeSpace(path string) (free uint64, total uint64, err error) {
+	current := filepath.Clean(path)
+
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(current, &stat)
+		if err == nil {
+			return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, 0, fmt.Errorf("cannot get filesystem information for %s: %w", current, err)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return 0, 0, fmt.Errorf("no existing ancestor for %s: %w", path, err)
+		}
+		current = parent
+	}
+}
+
+// HardLinks returns the inode of the file described by info and its number
+// of hard links, one for a file sharing its content with no other path.
+func HardLinks(info os.FileInfo) (inode uint64, links uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 1
+	}
+	return uint64(stat.Ino), uint64(stat.Nlink)
+}
+
+// SameAttributes reports whether both files have the same mode, owner and
+// modification time, so a hard link to one passes for the other.
+func SameAttributes(a os.FileInfo, b os.FileInfo) bool {
+	if a.Mode() != b.Mode() || !a.ModTime().Equal(b.ModTime()) {
+		return false
+	}
+	statA, okA := a.Sys().(*syscall.Stat_t)
+	statB, okB := b.Sys().(*syscall.Stat_t)
+	return okA && okB && statA.Uid == statB.Uid && statA.Gid == statB.Gid
+}
+
+// FileHash returns the hex encoded SHA-256 digest of the content of the file.
+func FileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// TreeSize returns the size of the file at path or, for directories, the sum
+// of the sizes of every file below it. Symlinks count as themselves. Like
+// TreeStats, it measures what it can read.
+func TreeSize(path string) (int64, error) {
+	_, size, err := TreeStats(path)
+	return size, err
+}
+
+// TreeStats returns the number of files at path, directories excluded, along
+// with their total size as computed by TreeSize. The measure is best effort:
+// the entries below path which can't be read are skipped, only path itself
+// missing is an error.
+func TreeStats(path string) (int, int64, error) {
+	var (
+		files int
+		size  int64
+	)
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil && info == nil && file == path {
+			return err
+		}
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			files++
+			size += info.Size()
+			if Progress != nil && files%ProgressEvery == 0 {
+				Progress(path, files, size)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+	return files, size, nil
+}
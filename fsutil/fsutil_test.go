@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestDeviceOf_MissingPathUsesAncestor(t *testing.T) {
+	dir := t.TempDir()
+
+	want, err := DeviceOf(dir)
+	if err != nil {
+		t.Fatalf("DeviceOf(%s): %v", dir, err)
+	}
+
+	got, err := DeviceOf(filepath.Join(dir, "gone", "file.txt"))
+	if err != nil {
+		t.Fatalf("DeviceOf on missing path: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected device %d of the ancestor, got %d", want, got)
+	}
+}
+
+func TestDeviceOf_ExistingFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(file, []byte("x"), 0o644)
+
+	if _, err := DeviceOf(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCrossDevice_SameFilesystem(t *testing.T) {
+	dir := t.TempDir()
+
+	if CrossDevice(filepath.Join(dir, "gone", "file.txt"), filepath.Join(dir, "container")) {
+		t.Error("expected paths of one filesystem not to be cross device")
+	}
+}
+
+func TestTreeStats_SkipsUnreadableEntries(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "readable"), []byte("12345"), 0o644)
+	locked := filepath.Join(dir, "locked")
+	os.Mkdir(locked, 0o755)
+	os.WriteFile(filepath.Join(locked, "hidden"), []byte("123"), 0o644)
+	os.Chmod(locked, 0)
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	files, size, err := TreeStats(dir)
+	if err != nil || files != 1 || size != 5 {
+		t.Errorf("expected the readable part measured, got %d, %d, %v", files, size, err)
+	}
+
+	if _, _, err := TreeStats(filepath.Join(dir, "missing")); err == nil {
+		t.Error("a missing path must be an error")
+	}
+}
+
+func TestTreeStats_ReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	for i := range ProgressEvery*2 + 1 {
+		if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(i)), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls []int
+	Progress = func(root string, files int, size int64) {
+		if root != dir || int64(files) != size {
+			t.Errorf("unexpected progress %s: %d files, %d bytes", root, files, size)
+		}
+		calls = append(calls, files)
+	}
+	defer func() { Progress = nil }()
+
+	files, size, err := TreeStats(dir)
+	if err != nil || files != ProgressEvery*2+1 || size != int64(files) {
+		t.Fatalf("TreeStats = %d, %d, %v", files, size, err)
+	}
+	if len(calls) != 2 || calls[0] != ProgressEvery || calls[1] != ProgressEvery*2 {
+		t.Errorf("progress reported at %v", calls)
+	}
+}
@@ -0,0 +1,363 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+)
+
+// Rename is the function Move tries first, replaceable for testing (e.g. to
+// simulate a move across devices)
+var Rename = os.Rename
+
+// ErrSourceLeft is returned, wrapped, when a move across devices completed
+// dst but could not remove all of src afterwards. The move itself succeeded:
+// only leftovers of src remain, which callers report rather than undo.
+var ErrSourceLeft = errors.New("source left after the copy")
+
+// Skipped, when set, is called for each special file (device, socket or named
+// pipe) found in a directory copied across devices. Those can't be copied as
+// regular files, so they are left in place at the source.
+var Skipped func(path string, mode os.FileMode)
+
+// Move moves src to dst, falling back to a copy-then-delete strategy when
+// both paths live on different filesystems and a plain rename is not possible.
+// src is only removed once dst is complete, so a failed move can be retried.
+// An error wrapping ErrSourceLeft tells dst is complete nonetheless, e.g.
+// when special files had to be left in place; MoveExcluding reports those.
+func Move(src string, dst string) error {
+	err := Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, syscall.EXDEV) {
+		left, err := moveCrossDevice(src, dst)
+		if err == nil && len(left) > 0 {
+			return fmt.Errorf("%w: %d special files can't be moved across devices", ErrSourceLeft, len(left))
+		}
+		return err
+	}
+
+	return err
+}
+
+// Relink moves the symlink at src to dst by creating at dst a new link to
+// target, the target src was recorded with, then removing src. Unlike Move it
+// needs no copy across devices and recreates the link even if src is gone.
+func Relink(src string, dst string, target string) error {
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// Shared reports whether the regular file at path has other hard links, as
+// deduplicated items do.
+func Shared(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	_, links := HardLinks(info)
+	return links > 1
+}
+
+// Detach moves src to dst as a copy, then removes src, like a move across
+// devices. Unlike a rename, dst shares no content with the other hard links
+// of src, so changing it leaves them untouched.
+func Detach(src string, dst string) error {
+	_, err := moveCrossDevice(src, dst)
+	return err
+}
+
+// MoveExcluding moves the directory src to dst like Move, but leaves in place
+// the entries for which exclude, given their slash separated path relative to
+// src, returns true; exclude may be nil. Special files are left in place too
+// when src is copied across devices. The rest of the tree is copied into a
+// staging sibling of dst, committed at once, then removed from src, whose
+// directories holding entries left in place are kept. It returns the
+// relative paths of the entries left in place; when there are none, src is
+// moved as a whole.
+func MoveExcluding(src string, dst string, exclude func(rel string) bool) ([]string, error) {
+	var excluded []string
+	if exclude != nil {
+		err := filepath.WalkDir(src, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(src, file)
+			if rel != "." && exclude(filepath.ToSlash(rel)) {
+				excluded = append(excluded, filepath.ToSlash(rel))
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(excluded) == 0 {
+		err := Rename(src, dst)
+		if errors.Is(err, syscall.EXDEV) {
+			return moveCrossDevice(src, dst)
+		}
+		return nil, err
+	}
+
+	staging := filepath.Join(filepath.Dir(dst), ".partial_"+filepath.Base(dst))
+	var copied, special []string
+	if err := copyExcluding(src, staging, ".", exclude, &copied, &special); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("error copying %s: %w", src, err)
+	}
+	if err := os.Rename(staging, dst); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("error committing copy of %s: %w", src, err)
+	}
+
+	excluded = append(excluded, special...)
+	return excluded, removeCopied(src, copied)
+}
+
+// copyExcluding copies the tree at src, whose path relative to the moved
+// root is rel, into dst, skipping the excluded entries and special files. The
+// relative path of every copied entry is appended to copied, directories
+// before their content, and the one of every special file to special, which
+// is reported to Skipped. exclude may be nil.
+func copyExcluding(src string, dst string, rel string, exclude func(rel string) bool, copied *[]string, special *[]string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err := copyTree(src, dst); err != nil {
+			return err
+		}
+		*copied = append(*copied, rel)
+		return nil
+	}
+
+	if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+		return err
+	}
+	*copied = append(*copied, rel)
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		child := path.Join(rel, entry.Name())
+		if exclude != nil && exclude(child) {
+			continue
+		}
+		if Special(entry.Type()) {
+			if Skipped != nil {
+				Skipped(filepath.Join(src, entry.Name()), entry.Type())
+			}
+			*special = append(*special, child)
+			continue
+		}
+		if err := copyExcluding(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), child, exclude, copied, special); err != nil {
+			return err
+		}
+	}
+	return copyAttributes(dst, info)
+}
+
+// removeCopied removes from src the entries copied out of it, listed by
+// relative path with directories before their content. Removing backwards
+// empties the directories first; those still holding entries left out of
+// the copy stay.
+func removeCopied(src string, copied []string) error {
+	for i := len(copied) - 1; i >= 0; i-- {
+		file := filepath.Join(src, filepath.FromSlash(copied[i]))
+		if err := os.Remove(file); err != nil && !isDirNotEmpty(err) {
+			return fmt.Errorf("%w: error removing %s after copying it: %w", ErrSourceLeft, file, err)
+		}
+	}
+	return nil
+}
+
+// isDirNotEmpty reports whether err tells a directory could not be removed
+// as it still has entries.
+func isDirNotEmpty(err error) bool {
+	return errors.Is(err, syscall.ENOTEMPTY) || errors.Is(err, syscall.EEXIST)
+}
+
+// Merge moves the entries of the directory src into the existing directory
+// dst, recursing into the directories present on both sides, then removes
+// src. An entry of src whose name is taken in dst by anything but a
+// directory is an error, the entries merged so far staying in dst. Entries
+// leaving leftovers in src are merged nonetheless, ErrSourceLeft being
+// returned once they all are.
+func Merge(src string, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	var leftover error
+
+	for _, entry := range entries {
+		from, to := filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())
+		info, err := os.Lstat(to)
+		switch {
+		case err == nil && entry.IsDir() && info.IsDir():
+			if err := Merge(from, to); errors.Is(err, ErrSourceLeft) {
+				leftover = err
+			} else if err != nil {
+				return err
+			}
+		case err == nil:
+			return fmt.Errorf("error merging %s: %s already exists", from, to)
+		case !os.IsNotExist(err):
+			return err
+		default:
+			if err := Move(from, to); errors.Is(err, ErrSourceLeft) {
+				leftover = err
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	if leftover != nil {
+		return leftover
+	}
+	return os.Remove(src)
+}
+
+// moveCrossDevice copies src recursively into dst and removes what was copied
+// from src afterwards. The copy is performed into a temporary sibling of dst
+// which is only renamed to its final name once the whole tree was copied, so
+// a failure mid-copy never leaves a partial entry behind. Permissions,
+// modification times and, when possible, ownership are preserved. The
+// special files within src are left in place, their relative paths returned.
+func moveCrossDevice(src string, dst string) ([]string, error) {
+	staging := filepath.Join(filepath.Dir(dst), ".partial_"+filepath.Base(dst))
+
+	var copied, special []string
+	if err := copyExcluding(src, staging, ".", nil, &copied, &special); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("error copying %s across devices: %w", src, err)
+	}
+
+	if err := os.Rename(staging, dst); err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("error committing copy of %s: %w", src, err)
+	}
+
+	return special, removeCopied(src, copied)
+}
+
+// copyTree copies the file or symlink at src to dst without following
+// symlinks, which are recreated as links, so a symlink loop is copied as is.
+// Directories are copied by copyExcluding; a special file is an error.
+func copyTree(src string, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+
+	case info.Mode().IsRegular():
+		if err := copyFile(src, dst, info.Mode().Perm(), info.Size()); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unsupported file type %s for %s", SpecialKind(info.Mode()), src)
+	}
+
+	return copyAttributes(dst, info)
+}
+
+// Special reports whether the mode is the one of a special file: anything but
+// a regular file, a directory or a symlink.
+func Special(mode os.FileMode) bool {
+	return mode&(os.ModeDir|os.ModeSymlink) == 0 && !mode.IsRegular()
+}
+
+// SpecialKind names the kind of special file of the mode, for messages.
+func SpecialKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	}
+	return "special file"
+}
+
+func copyFile(src string, dst string, perm os.FileMode, size int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := copyContents(out, in, size); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyDense copies every byte of src into dst, from their current offsets.
+func copyDense(dst *os.File, src *os.File) error {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+// copyAttributes applies the ownership, permissions and modification time of info to dst.
+// Ownership is best effort, as only privileged users can give files away.
+func copyAttributes(dst string, info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Lchown(dst, int(stat.Uid), int(stat.Gid))
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
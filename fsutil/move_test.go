@@ -0,0 +1,186 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestMove_FallsBackToCopyAcrossDevices(t *testing.T) {
+	Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { Rename = os.Rename }()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	os.WriteFile(src, []byte("data"), 0o640)
+	dst := filepath.Join(t.TempDir(), "dst.txt")
+
+	if err := Move(src, dst); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("destination missing or mode not kept: %v %v", info, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source must be removed after the copy")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 1 {
+		t.Errorf("no staging copy must be left, got %d entries", len(entries))
+	}
+}
+
+func TestMove_CrossDeviceReportsSourceLeft(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can remove entries of read-only directories")
+	}
+	Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { Rename = os.Rename }()
+
+	src := filepath.Join(t.TempDir(), "tree")
+	os.MkdirAll(filepath.Join(src, "locked"), 0o755)
+	os.WriteFile(filepath.Join(src, "locked", "a.txt"), []byte("data"), 0o644)
+	os.Chmod(filepath.Join(src, "locked"), 0o555)
+	t.Cleanup(func() { os.Chmod(filepath.Join(src, "locked"), 0o755) })
+
+	dst := filepath.Join(t.TempDir(), "tree")
+	t.Cleanup(func() { os.Chmod(filepath.Join(dst, "locked"), 0o755) })
+	err := Move(src, dst)
+	if !errors.Is(err, ErrSourceLeft) {
+		t.Fatalf("expected ErrSourceLeft, got %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "locked", "a.txt")); err != nil || string(data) != "data" {
+		t.Errorf("the destination must be complete, got %q, %v", data, err)
+	}
+}
+
+func TestMove_OtherErrorsAreReturned(t *testing.T) {
+	if err := Move(filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "dst")); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
+
+func TestMove_CrossDeviceKeepsLinksAndLeavesSpecialFiles(t *testing.T) {
+	Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { Rename = os.Rename }()
+
+	var skipped []string
+	Skipped = func(path string, mode os.FileMode) {
+		skipped = append(skipped, SpecialKind(mode)+" "+filepath.Base(path))
+	}
+	defer func() { Skipped = nil }()
+
+	src := filepath.Join(t.TempDir(), "tree")
+	os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("data"), 0o644)
+	os.Symlink("..", filepath.Join(src, "sub", "loop"))
+	os.Symlink("self", filepath.Join(src, "self"))
+	if err := syscall.Mkfifo(filepath.Join(src, "pipe"), 0o644); err != nil {
+		t.Skipf("named pipes not supported: %v", err)
+	}
+
+	if files, _, err := TreeStats(src); err != nil || files != 4 {
+		t.Errorf("the size walk must not follow the loops, got %d files, %v", files, err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "tree")
+	left, err := MoveExcluding(src, dst, nil)
+	if err != nil {
+		t.Fatalf("MoveExcluding error: %v", err)
+	}
+
+	if target, err := os.Readlink(filepath.Join(dst, "sub", "loop")); err != nil || target != ".." {
+		t.Errorf("the loop must be recreated as a link, got %q, %v", target, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "self")); err != nil || target != "self" {
+		t.Errorf("the self link must be recreated as a link, got %q, %v", target, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "pipe")); !os.IsNotExist(err) {
+		t.Errorf("the named pipe must be left out, got %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != "named pipe pipe" {
+		t.Errorf("expected the named pipe reported, got %v", skipped)
+	}
+	if len(left) != 1 || left[0] != "pipe" {
+		t.Errorf("expected the named pipe left in place, got %v", left)
+	}
+	if _, err := os.Lstat(filepath.Join(src, "pipe")); err != nil {
+		t.Errorf("the named pipe must stay at the source: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(src, "sub")); !os.IsNotExist(err) {
+		t.Errorf("the copied entries must be removed from the source, got %v", err)
+	}
+}
+
+func TestMove_CrossDeviceReportsSpecialFilesLeft(t *testing.T) {
+	Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { Rename = os.Rename }()
+
+	src := filepath.Join(t.TempDir(), "tree")
+	os.MkdirAll(src, 0o755)
+	if err := syscall.Mkfifo(filepath.Join(src, "pipe"), 0o644); err != nil {
+		t.Skipf("named pipes not supported: %v", err)
+	}
+
+	if err := Move(src, filepath.Join(t.TempDir(), "tree")); !errors.Is(err, ErrSourceLeft) {
+		t.Errorf("expected ErrSourceLeft, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(src, "pipe")); err != nil {
+		t.Errorf("the named pipe must stay at the source: %v", err)
+	}
+}
+
+func TestMove_CrossDeviceRejectsSpecialFileItem(t *testing.T) {
+	Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { Rename = os.Rename }()
+
+	src := filepath.Join(t.TempDir(), "pipe")
+	if err := syscall.Mkfifo(src, 0o644); err != nil {
+		t.Skipf("named pipes not supported: %v", err)
+	}
+
+	if err := Move(src, filepath.Join(t.TempDir(), "pipe")); err == nil {
+		t.Fatal("expected a named pipe item to be rejected")
+	}
+	if _, err := os.Lstat(src); err != nil {
+		t.Errorf("the source must be kept: %v", err)
+	}
+}
+
+func TestMoveExcluding_ReportsSourceLeft(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can remove entries of read-only directories")
+	}
+	src := filepath.Join(t.TempDir(), "tree")
+	os.MkdirAll(filepath.Join(src, "locked"), 0o755)
+	os.WriteFile(filepath.Join(src, "locked", "a.txt"), []byte("data"), 0o644)
+	os.WriteFile(filepath.Join(src, "run.log"), []byte("log"), 0o644)
+	os.Chmod(filepath.Join(src, "locked"), 0o555)
+	t.Cleanup(func() { os.Chmod(filepath.Join(src, "locked"), 0o755) })
+
+	dst := filepath.Join(t.TempDir(), "tree")
+	t.Cleanup(func() { os.Chmod(filepath.Join(dst, "locked"), 0o755) })
+	excluded, err := MoveExcluding(src, dst, func(rel string) bool { return rel == "run.log" })
+	if !errors.Is(err, ErrSourceLeft) {
+		t.Fatalf("expected ErrSourceLeft, got %v", err)
+	}
+	if len(excluded) != 1 || excluded[0] != "run.log" {
+		t.Errorf("expected the exclusions to be returned with the leftovers, got %v", excluded)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "locked", "a.txt")); err != nil || string(data) != "data" {
+		t.Errorf("the destination must be complete, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build linux
+
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// lseek whence values locating the data and hole regions of a file, see lseek(2).
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// copyContents copies the data regions of src into dst, skipping the holes of
+// sparse files so they are not materialized as zeros. Filesystems without
+// SEEK_DATA/SEEK_HOLE support get a plain dense copy.
+func copyContents(dst *os.File, src *os.File, size int64) error {
+	var offset int64
+
+	for offset < size {
+		data, err := src.Seek(offset, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			break // only a trailing hole is left
+		}
+		if err != nil {
+			if offset == 0 && errors.Is(err, syscall.EINVAL) {
+				return copyDense(dst, src)
+			}
+			return err
+		}
+
+		hole, err := src.Seek(data, seekHole)
+		if err != nil {
+			return err
+		}
+
+		section := io.NewSectionReader(src, data, hole-data)
+		if _, err := io.Copy(io.NewOffsetWriter(dst, data), section); err != nil {
+			return err
+		}
+		offset = hole
+	}
+
+	// Extending the file is what creates the holes, including a trailing one.
+	return dst.Truncate(size)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fsutil
+
+import "os"
+
+// copyContents copies src into dst, sparse files are only preserved on linux.
+func copyContents(dst *os.File, src *os.File, size int64) error {
+	return copyDense(dst, src)
+}
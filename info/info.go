@@ -1,40 +1,76 @@
 package info
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"math"
+	"os"
 	"path/filepath"
 	"rubbish/config"
+	"rubbish/format"
+	"rubbish/fsutil"
 	"rubbish/journal"
-	"slices"
+	"strings"
+	"text/template"
 	"time"
 )
 
 var (
-	Flags      *flag.FlagSet = flag.NewFlagSet("info", flag.ExitOnError)
-	byPosition int           = 0
+	Flags        *flag.FlagSet = flag.NewFlagSet("info", flag.ExitOnError)
+	byPosition   int           = 0
+	globalLookup bool          = false // globalLookup numbers the items of the whole rubbish with -p
+	checkDevice  bool          = false
+	formatText   string        = ""    // formatText is the --format template printing the item, empty for the default details
+	headMode     bool          = false // headMode previews the first lines of a text item, see printPreview
+	headLines    int           = 10    // headLines is the number of lines previewed by --head
+
+	// crossDevice tells an origin restored into another filesystem than the
+	// container, replaceable for testing
+	crossDevice = fsutil.CrossDevice
 )
 
 func init() {
-	Flags.IntVar(&byPosition, "p", 0, "The position of the item (1-based).")
+	Flags.IntVar(&byPosition, "p", 0, "The position of the item, oldest tossed first (1-based, negative from the newest).")
+	Flags.BoolVar(&globalLookup, "g", false, "Number the items of the whole rubbish with -p instead of those of the current directory.")
+	Flags.BoolVar(&checkDevice, "check-device", false, "Show whether the origin is on a different device than the container.")
+	Flags.StringVar(&formatText, "format", "", "Print the item through the given Go `template`, e.g. '{{.Origin}} {{.WipeableAt}}'.")
+	Flags.BoolVar(&headMode, "head", false, "Preview the first --lines of the item when it is a small text file.")
+	Flags.IntVar(&headLines, "lines", 10, "Number of `N` lines previewed by --head.")
 
 	Flags.Usage = func() {
 		fmt.Println("Rubbish info shows the rubbish item details.\n",
 			"Usage:\n\n",
 			"\trubbish info <item>\n",
-			"\trubbish info -p=<position>\n\n",
+			"\trubbish info [-g] -p=<position>\n",
+			"\trubbish info --head [--lines=N] <item>\n\n",
 			"Options:")
 		Flags.PrintDefaults()
 	}
 }
 
+// MachineOutput reports whether the output is meant for scripts, in which
+// case nothing else must be written to stdout.
+func MachineOutput() bool {
+	return formatText != ""
+}
+
 func Command(args []string, cfg *config.Config) error {
 	var (
 		record *journal.MetaData
+		tmpl   *template.Template
 		err    error
 	)
 
+	if formatText != "" {
+		if tmpl, err = format.Parse(formatText); err != nil {
+			return err
+		}
+	}
+
+	if headMode && headLines < 1 {
+		return fmt.Errorf("invalid --lines %d, expected at least 1", headLines)
+	}
+
 	if byPosition != 0 {
 		record, err = retrieveByPosition(byPosition, cfg)
 		if err != nil {
@@ -54,12 +90,27 @@ func Command(args []string, cfg *config.Config) error {
 		return fmt.Errorf("item not found: %s", args[0])
 	}
 
-	ttime := time.Unix(record.TossedTime, 0)
-	wtime := ttime.Add(time.Duration(record.WipeoutTime*24) * time.Hour)
+	if tmpl != nil {
+		return format.Write(os.Stdout, tmpl, []*journal.MetaData{record}, cfg)
+	}
+
+	ttime := cfg.DisplayTime(record.TossedTime)
+	wtime := record.WipeoutDate().In(cfg.Zone())
 	rtime := record.RemainingTime()
 
 	fmt.Printf("Item: %s\n", record.Item)
 	fmt.Printf("Origin: %s\n", record.Origin)
+	if record.Compressed {
+		fmt.Printf("Size: %s (%s compressed)\n", config.ReadableSize(uint64(record.Size)), config.ReadableSize(uint64(config.RecordSize(cfg, record))))
+	} else {
+		fmt.Printf("Size: %s\n", config.ReadableSize(uint64(config.RecordSize(cfg, record))))
+	}
+	if record.Policy != "" {
+		fmt.Printf("Policy: %s (%d days)\n", record.Policy, record.WipeoutTime)
+	}
+	if len(record.Excluded) > 0 {
+		fmt.Printf("Excluded: %s (left at the origin)\n", strings.Join(record.Excluded, ", "))
+	}
 	fmt.Printf("Tossed At: %v\n", ttime)
 	fmt.Printf("Wipeable At: %s\n", wtime.Format(time.DateOnly)) //time.Date(wtime.Year(), wtime.Month(), wtime.Day(), 0, 0, 0, 0, wtime.Location()))
 
@@ -69,41 +120,58 @@ func Command(args []string, cfg *config.Config) error {
 		fmt.Printf("Remaining: %s (overdue)\n", (rtime * -1).Round(time.Second))
 	}
 
-	return nil
-}
+	fmt.Printf("Origin parent exists: %s\n", yesNo(record.OriginParentExists()))
+	fmt.Printf("Conflict at origin: %s\n", yesNo(record.OriginTaken()))
 
-func retrieveByPosition(byPosition int, cfg *config.Config) (*journal.MetaData, error) {
-	list, err := cfg.Journal.List()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list items: %w", err)
+	if checkDevice {
+		if crossDevice(record.Origin, cfg.ContainerPath) {
+			fmt.Println("Cross Device: yes (restore will copy across filesystems)")
+		} else {
+			fmt.Println("Cross Device: no")
+		}
 	}
 
-	i := int(math.Abs(float64(byPosition)))
-
-	if i > len(list) {
-		return nil, fmt.Errorf("invalid item position: %d", byPosition)
+	if headMode {
+		return printPreview(record, cfg, headLines)
 	}
+	return nil
+}
 
-	i-- // Convert to zero-based index
-
-	if byPosition < 0 {
-		slices.Reverse(list)
-		record := list[i]
-		return record, nil
+func yesNo(b bool) string {
+	if b {
+		return "yes"
 	}
+	return "no"
+}
 
-	record := list[i]
-	return record, nil
-
+// retrieveByPosition returns the item at the given position among those tossed
+// from the working directory or, with -g, the whole rubbish, ordered oldest
+// tossed first: 1 is the oldest item and -1 the newest.
+func retrieveByPosition(byPosition int, cfg *config.Config) (*journal.MetaData, error) {
+	dir := cfg.WorkingDir
+	if globalLookup {
+		dir = ""
+	}
+	return cfg.Journal.RecordAtPosition(dir, byPosition)
 }
 
+// retrieveByName returns the item whose key is the base of name or, failing
+// that, the single item whose key starts with it or whose original basename
+// it is.
 func retrieveByName(name string, cfg *config.Config) (*journal.MetaData, error) {
 	itemName := filepath.Base(name)
-	// Fetch and display item details using itemName
 
 	record, err := cfg.Journal.Get(itemName)
-	if err != nil {
+	if err == nil {
+		return record, nil
+	}
+	if !errors.Is(err, journal.ErrItemNotFound) {
 		return nil, fmt.Errorf("failed to get item: %w", err)
 	}
-	return record, nil
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	return journal.Resolve(records, itemName)
 }
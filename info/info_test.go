@@ -2,6 +2,7 @@ package info
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -22,7 +23,8 @@ func newTestCfg(t *testing.T) *config.Config {
 	if err := j.Load(); err != nil {
 		t.Fatalf("failed to load journal: %v", err)
 	}
-	return &config.Config{ContainerPath: dir, Journal: j, WipeoutTime: 2}
+	// Positions number the items tossed from within the working directory
+	return &config.Config{ContainerPath: dir, Journal: j, WipeoutTime: 2, WorkingDir: "/"}
 }
 
 func captureStdout(t *testing.T, fn func()) string {
@@ -87,8 +89,16 @@ func TestCommand_MissingArg(t *testing.T) {
 func TestCommand_ByName_NotFound(t *testing.T) {
 	cfg := newTestCfg(t)
 	err := Command([]string{"ghost.txt"}, cfg)
-	if err == nil || !strings.Contains(err.Error(), "failed to get item") {
-		t.Fatalf("expected failed to get item error, got: %v", err)
+	if !errors.Is(err, journal.ErrItemNotFound) || !strings.Contains(err.Error(), "no such item 'ghost.txt'") {
+		t.Fatalf("expected no such item error, got: %v", err)
+	}
+}
+
+func TestCommand_ByName_JournalError(t *testing.T) {
+	cfg := &config.Config{Journal: &journal.Journal{}}
+	err := Command([]string{"ghost.txt"}, cfg)
+	if err == nil || errors.Is(err, journal.ErrItemNotFound) || !strings.Contains(err.Error(), "failed to get item") {
+		t.Fatalf("expected internal error, got: %v", err)
 	}
 }
 
@@ -132,6 +142,47 @@ func TestCommand_ByPosition_NegativeSelectsFromEnd(t *testing.T) {
 	}
 }
 
+func TestCommand_ByPosition_OrderedByTossedTime(t *testing.T) {
+	cfg := newTestCfg(t)
+	// Item keys sort the other way round from the toss order
+	cfg.Journal.AddRecord(md("a.txt", "/o/a.txt", 2, time.Minute))
+	cfg.Journal.AddRecord(md("b.txt", "/o/b.txt", 2, 2*time.Hour))
+	cfg.Journal.AddRecord(md("c.txt", "/o/c.txt", 2, 3*time.Hour))
+	defer func() { byPosition = 0 }()
+
+	for position, want := range map[int]string{1: "c.txt", 2: "b.txt", -1: "a.txt", -3: "c.txt"} {
+		byPosition = position
+		out := captureStdout(t, func() {
+			if err := Command(nil, cfg); err != nil {
+				t.Fatalf("command error: %v", err)
+			}
+		})
+		if !strings.Contains(out, "Item: "+want) {
+			t.Errorf("position %d: expected %s, got: %s", position, want, out)
+		}
+	}
+}
+
+func TestCommand_ByPosition_ScopedToWorkingDir(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Journal.AddRecord(md("a1.txt", "/a/a1.txt", 2, 2*time.Hour))
+	cfg.Journal.AddRecord(md("b1.txt", "/b/b1.txt", 2, time.Hour))
+	cfg.WorkingDir = "/b"
+	defer func() { byPosition, globalLookup = 0, false }()
+
+	for global, want := range map[bool]string{false: "b1.txt", true: "a1.txt"} {
+		byPosition, globalLookup = 1, global
+		out := captureStdout(t, func() {
+			if err := Command(nil, cfg); err != nil {
+				t.Fatalf("command error: %v", err)
+			}
+		})
+		if !strings.Contains(out, "Item: "+want) {
+			t.Errorf("-g=%v: expected %s, got: %s", global, want, out)
+		}
+	}
+}
+
 func TestCommand_ByPosition_IndexOutOfRange(t *testing.T) {
 	cfg := newTestCfg(t)
 	if err := cfg.Journal.AddRecord(md("only.txt", "/o/only.txt", 2, time.Hour)); err != nil {
@@ -156,6 +207,59 @@ func TestCommand_ByPosition_ListError(t *testing.T) {
 	}
 }
 
+func TestCommand_OriginState(t *testing.T) {
+	cfg := newTestCfg(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "taken.txt"), []byte("new"), 0o644)
+	cfg.Journal.AddRecord(md("taken.txt", filepath.Join(dir, "taken.txt"), 2, time.Hour))
+	cfg.Journal.AddRecord(md("free.txt", filepath.Join(dir, "free.txt"), 2, time.Hour))
+	cfg.Journal.AddRecord(md("gone.txt", filepath.Join(dir, "gone", "gone.txt"), 2, time.Hour))
+
+	cases := map[string][2]string{
+		"taken.txt": {"yes", "yes"},
+		"free.txt":  {"yes", "no"},
+		"gone.txt":  {"no", "no"},
+	}
+	for item, want := range cases {
+		out := captureStdout(t, func() {
+			if err := Command([]string{item}, cfg); err != nil {
+				t.Fatalf("command error: %v", err)
+			}
+		})
+		if !strings.Contains(out, "Origin parent exists: "+want[0]+"\n") {
+			t.Errorf("%s: expected parent exists %s, got: %s", item, want[0], out)
+		}
+		if !strings.Contains(out, "Conflict at origin: "+want[1]+"\n") {
+			t.Errorf("%s: expected conflict %s, got: %s", item, want[1], out)
+		}
+	}
+}
+
+func TestCommand_ByPrefix(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Journal.AddRecord(md("file.txt_AB12CD", "/o/file.txt", 2, time.Hour))
+	cfg.Journal.AddRecord(md("file.txt_ABZZZZ", "/o/docs/file.txt", 2, time.Hour))
+	cfg.Journal.AddRecord(md("report.pdf_QW12ER", "/o/report.pdf", 2, time.Hour))
+
+	for name, want := range map[string]string{"file.txt_AB1": "file.txt_AB12CD", "report.pdf": "report.pdf_QW12ER"} {
+		out := captureStdout(t, func() {
+			if err := Command([]string{name}, cfg); err != nil {
+				t.Fatalf("command error: %v", err)
+			}
+		})
+		if !strings.Contains(out, "Item: "+want+"\n") {
+			t.Errorf("%s: expected %s, got: %s", name, want, out)
+		}
+	}
+
+	if err := Command([]string{"file.txt_AB"}, cfg); !errors.Is(err, journal.ErrAmbiguousItem) {
+		t.Errorf("expected an ambiguous prefix to fail, got %v", err)
+	}
+	if err := Command([]string{"nothing"}, cfg); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("expected no match to be not found, got %v", err)
+	}
+}
+
 func TestCommand_OverdueRemaining(t *testing.T) {
 	cfg := newTestCfg(t)
 	rec := md("old.txt", "/o/old.txt", 1, 72*time.Hour) // 3 days ago, wipe after 1 -> overdue
@@ -167,3 +271,97 @@ func TestCommand_OverdueRemaining(t *testing.T) {
 		t.Fatalf("expected overdue annotation, got: %s", out)
 	}
 }
+
+func TestCommand_CheckDevice(t *testing.T) {
+	cfg := newTestCfg(t)
+	checkDevice = true
+	defer func() { checkDevice = false }()
+
+	origCrossDevice := crossDevice
+	crossDevice = func(origin, container string) bool {
+		return strings.HasPrefix(origin, "/mnt/")
+	}
+	defer func() { crossDevice = origCrossDevice }()
+
+	cfg.Journal.AddRecord(md("local.txt", "/home/local.txt", 3, time.Hour))
+	cfg.Journal.AddRecord(md("remote.txt", "/mnt/usb/remote.txt", 3, time.Hour))
+
+	out := captureStdout(t, func() { _ = Command([]string{"local.txt"}, cfg) })
+	if !strings.Contains(out, "Cross Device: no") {
+		t.Errorf("expected same device marker, got: %s", out)
+	}
+
+	out = captureStdout(t, func() { _ = Command([]string{"remote.txt"}, cfg) })
+	if !strings.Contains(out, "Cross Device: yes") {
+		t.Errorf("expected cross device marker, got: %s", out)
+	}
+}
+
+func TestCommand_RendersTimesInConfiguredZone(t *testing.T) {
+	// late evening in UTC, already the next day in Tokyo
+	tossed := time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)
+	cases := []struct {
+		zone, tossed, wipeable string
+	}{
+		{"Asia/Tokyo", "Tossed At: 2024-01-16 08:30:00 +0900 JST", "Wipeable At: 2024-01-17"},
+		{"America/New_York", "Tossed At: 2024-01-15 18:30:00 -0500 EST", "Wipeable At: 2024-01-16"},
+	}
+
+	for _, c := range cases {
+		loc, err := time.LoadLocation(c.zone)
+		if err != nil {
+			t.Skipf("timezone database unavailable: %v", err)
+		}
+		cfg := newTestCfg(t)
+		cfg.Location = loc
+		cfg.Journal.AddRecord(&journal.MetaData{Item: "a.txt", Origin: "/o/a.txt", WipeoutTime: 1, TossedTime: tossed.Unix()})
+
+		out := captureStdout(t, func() { _ = Command([]string{"a.txt"}, cfg) })
+		if !strings.Contains(out, c.tossed) || !strings.Contains(out, c.wipeable) {
+			t.Errorf("%s: expected %q and %q, got: %s", c.zone, c.tossed, c.wipeable, out)
+		}
+	}
+}
+
+func TestCommand_Format(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "a.txt_ABCDEF", Origin: "/home/me/a.txt", WipeoutTime: 3, TossedTime: time.Now().Unix()})
+	formatText = "{{.Origin}} in {{.RemainingDays}} days"
+	defer func() { formatText = "" }()
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{"a.txt_ABCDEF"}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if out != "/home/me/a.txt in 3 days\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	formatText = "{{.Nope}}"
+	if err := Command([]string{"a.txt_ABCDEF"}, cfg); err == nil {
+		t.Error("expected an unknown field to be rejected")
+	}
+}
+
+func TestCommand_ShowsPolicy(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := md("temp.txt", "/o/temp.txt", 1, time.Hour)
+	record.Policy = "temp"
+	cfg.Journal.AddRecord(record)
+	cfg.Journal.AddRecord(md("plain.txt", "/o/plain.txt", 30, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{"temp.txt"}, cfg); err != nil {
+			t.Fatalf("command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Policy: temp (1 days)\n") {
+		t.Errorf("expected the policy shown, got: %s", out)
+	}
+
+	out = captureStdout(t, func() { Command([]string{"plain.txt"}, cfg) })
+	if strings.Contains(out, "Policy:") {
+		t.Errorf("expected no policy line without a policy, got: %s", out)
+	}
+}
@@ -0,0 +1,97 @@
+package info
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// previewMaxSize is the size above which --head doesn't preview a file
+	previewMaxSize = 1 << 20
+
+	// sniffSize is the number of leading bytes examined to tell text from binary
+	sniffSize = 8000
+)
+
+// printPreview prints the first lines of the item's content, when it is a
+// regular text file of at most previewMaxSize bytes stored uncompressed.
+// Anything else is skipped with a message.
+func printPreview(record *journal.MetaData, cfg *config.Config, lines int) error {
+	path := cfg.ItemPath(record.Item)
+	stat, err := os.Lstat(path)
+	switch {
+	case err != nil:
+		return fmt.Errorf("error reading %s: %w", record.Item, err)
+	case stat.IsDir():
+		fmt.Println("Content: not previewed, the item is a directory")
+		return nil
+	case !stat.Mode().IsRegular():
+		fmt.Println("Content: not previewed, the item is not a regular file")
+		return nil
+	case record.Compressed:
+		fmt.Println("Content: not previewed, the item is stored compressed")
+		return nil
+	case stat.Size() > previewMaxSize:
+		fmt.Printf("Content: not previewed, the item is larger than %s\n", config.ReadableSize(previewMaxSize))
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", record.Item, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, sniffSize)
+	sniff, err := reader.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error reading %s: %w", record.Item, err)
+	}
+	if binary(sniff, len(sniff) == sniffSize) {
+		fmt.Println("Content: not previewed, the item is a binary file")
+		return nil
+	}
+
+	fmt.Printf("Content (first %d lines):\n", lines)
+	for range lines {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			fmt.Println("  " + strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", record.Item, err)
+		}
+	}
+	if _, err := reader.Peek(1); err == nil {
+		fmt.Println("  …")
+	}
+	return nil
+}
+
+// binary reports whether the leading bytes of a file look like binary
+// content: holding a NUL byte or not being UTF-8. A truncated sniff may end
+// in the middle of a character, which is not held against it.
+func binary(sniff []byte, truncated bool) bool {
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return true
+	}
+	if truncated {
+		for cut := 0; cut < utf8.UTFMax && len(sniff) > 0; cut++ {
+			if utf8.Valid(sniff) {
+				return false
+			}
+			sniff = sniff[:len(sniff)-1]
+		}
+	}
+	return !utf8.Valid(sniff)
+}
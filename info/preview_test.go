@@ -0,0 +1,82 @@
+package info
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withHead enables --head with the given number of lines for the test.
+func withHead(t *testing.T, lines int) {
+	t.Helper()
+	headMode, headLines = true, lines
+	t.Cleanup(func() { headMode, headLines = false, 10 })
+}
+
+func TestCommand_HeadPreviewsText(t *testing.T) {
+	cfg := newTestCfg(t)
+	withHead(t, 2)
+	cfg.Journal.AddRecord(md("notes.txt_ABCDEF", "/home/u/notes.txt", 3, time.Hour))
+	os.WriteFile(cfg.ItemPath("notes.txt_ABCDEF"), []byte("first line\r\nsecond line\nthird line\n"), 0o644)
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{"notes.txt_ABCDEF"}, cfg); err != nil {
+			t.Fatalf("command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Content (first 2 lines):\n  first line\n  second line\n  …\n") {
+		t.Errorf("expected the first two lines and a continuation mark, got: %s", out)
+	}
+	if strings.Contains(out, "third line") {
+		t.Errorf("expected the preview to stop at --lines, got: %s", out)
+	}
+}
+
+func TestCommand_HeadSkipsBinary(t *testing.T) {
+	cfg := newTestCfg(t)
+	withHead(t, 10)
+	cfg.Journal.AddRecord(md("image.png_ABCDEF", "/home/u/image.png", 3, time.Hour))
+	os.WriteFile(cfg.ItemPath("image.png_ABCDEF"), []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0o644)
+
+	out := captureStdout(t, func() { Command([]string{"image.png_ABCDEF"}, cfg) })
+	if !strings.Contains(out, "Content: not previewed, the item is a binary file") || strings.Contains(out, "PNG") {
+		t.Errorf("expected the binary file to be skipped, got: %s", out)
+	}
+}
+
+func TestCommand_HeadSkipsDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	withHead(t, 10)
+	cfg.Journal.AddRecord(md("project_ABCDEF", "/home/u/project", 3, time.Hour))
+	os.Mkdir(cfg.ItemPath("project_ABCDEF"), 0o755)
+
+	out := captureStdout(t, func() { Command([]string{"project_ABCDEF"}, cfg) })
+	if !strings.Contains(out, "Content: not previewed, the item is a directory") {
+		t.Errorf("expected the directory to be skipped, got: %s", out)
+	}
+}
+
+func TestCommand_HeadSkipsLargeFile(t *testing.T) {
+	cfg := newTestCfg(t)
+	withHead(t, 10)
+	cfg.Journal.AddRecord(md("big.log_ABCDEF", "/home/u/big.log", 3, time.Hour))
+	os.WriteFile(cfg.ItemPath("big.log_ABCDEF"), bytes.Repeat([]byte("log line\n"), previewMaxSize/8), 0o644)
+
+	out := captureStdout(t, func() { Command([]string{"big.log_ABCDEF"}, cfg) })
+	if !strings.Contains(out, "Content: not previewed, the item is larger than") {
+		t.Errorf("expected the large file to be skipped, got: %s", out)
+	}
+}
+
+func TestBinary_TruncatedCharacter(t *testing.T) {
+	text := []byte(strings.Repeat("a", 10) + "é")
+	if binary(text[:len(text)-1], true) {
+		t.Error("expected a sniff cut within a character to stay text")
+	}
+	if !binary(text[:len(text)-1], false) {
+		t.Error("expected a whole file ending within a character to be binary")
+	}
+}
@@ -0,0 +1,35 @@
+package journal
+
+import (
+	"fmt"
+	"io"
+)
+
+// maxPendingWrites bounds the writes Restore keeps in flight while loading a backup
+const maxPendingWrites = 256
+
+// Backup writes a dump of the whole journal, internal state included, in
+// Badger's native backup format.
+func (j *Journal) Backup(w io.Writer) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	if _, err := j.db.Backup(w, 0); err != nil {
+		return fmt.Errorf("error backing up journal: %w", err)
+	}
+	return nil
+}
+
+// Restore loads a dump written by Backup. Records and state already in the
+// journal are replaced by the ones of the dump sharing their key.
+func (j *Journal) Restore(r io.Reader) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	if err := j.db.Load(r, maxPendingWrites); err != nil {
+		return fmt.Errorf("error restoring journal backup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package journal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// binPrefix marks the keys of the records of named bins. It is followed by
+// the bin name and a NUL byte, then the item.
+const binPrefix = "\x00bin:"
+
+// InBin returns a view of the journal holding the records of the named bin,
+// sharing the same database. The empty name is the default bin.
+func (j *Journal) InBin(name string) *Journal {
+	return &Journal{Path: j.Path, Bin: name, db: j.db}
+}
+
+// key returns the database key of an item of the journal's bin.
+func (j *Journal) key(item string) []byte {
+	if j.Bin == "" {
+		return []byte(item)
+	}
+	return []byte(binPrefix + j.Bin + "\x00" + item)
+}
+
+// eachRecord calls fn with every record of the journal's bin, skipping the
+// internal state and, for the default bin, the records of the named bins.
+func (j *Journal) eachRecord(txn *badger.Txn, fn func(*badger.Item) error) error {
+	options := badger.DefaultIteratorOptions
+	if j.Bin != "" {
+		options.Prefix = []byte(binPrefix + j.Bin + "\x00")
+	}
+
+	it := txn.NewIterator(options)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if j.Bin == "" && isInternalKey(it.Item().Key()) {
+			continue
+		}
+		if err := fn(it.Item()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bins returns the sorted names of the named bins holding records.
+func (j *Journal) Bins() ([]string, error) {
+	if j.db == nil {
+		return nil, fmt.Errorf("journal database is not initialized")
+	}
+
+	var bins []string
+	err := j.db.View(func(txn *badger.Txn) error {
+		options := badger.DefaultIteratorOptions
+		options.Prefix = []byte(binPrefix)
+		options.PrefetchValues = false
+		it := txn.NewIterator(options)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().Key()[len(binPrefix):]
+			end := bytes.IndexByte(key, 0)
+			if end < 0 {
+				continue
+			}
+			if name := string(key[:end]); !slices.Contains(bins, name) {
+				bins = append(bins, name)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(bins)
+	return bins, nil
+}
@@ -2,24 +2,47 @@ package journal
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	badger "github.com/dgraph-io/badger/v4"
 )
 
+// ErrItemNotFound is returned when the requested item has no record in the journal.
+// It wraps badger.ErrKeyNotFound.
+var ErrItemNotFound error = itemNotFound{}
+
+// ErrItemExists is returned when a record would replace the record of another item.
+var ErrItemExists = errors.New("item already exists")
+
+// ErrBusy is returned when another process holds the journal or its container.
+var ErrBusy = errors.New("another rubbish process is running")
+
+// badgerLocked is the message of the error Badger opens a locked database with
+const badgerLocked = "Another process is using this Badger database"
+
+type itemNotFound struct{}
+
+func (itemNotFound) Error() string { return "no such item" }
+func (itemNotFound) Unwrap() error { return badger.ErrKeyNotFound }
+
 // Journal represents a persistent storage system for tracking metadata
 // of files that have been moved to trash. It uses BadgerDB as the underlying
 // storage engine to maintain a record of all trash operations.
 type Journal struct {
-	Path string     // Path to the directory where the journal database is stored
-	db   *badger.DB // BadgerDB instance for persistent storage
+	Path     string     // Path to the directory where the journal database is stored
+	Bin      string     // Bin is the named bin whose records are accessed, empty for the default bin
+	ReadOnly bool       // ReadOnly opens the database without write access
+	db       *badger.DB // BadgerDB instance for persistent storage
 }
 
 // Load initializes the journal database at the specified path.
 // It opens a BadgerDB instance and prepares it for operations.
-// Returns an error if the path is not set or if the database cannot be opened.
+// Returns an error if the path is not set or if the database cannot be opened,
+// ErrBusy when another process has it open.
 func (j *Journal) Load() error {
 	var err error
 
@@ -30,8 +53,11 @@ func (j *Journal) Load() error {
 
 	if j.db == nil {
 
-		j.db, err = badger.Open(badger.DefaultOptions(j.Path).WithLoggingLevel(badger.ERROR))
+		j.db, err = badger.Open(badger.DefaultOptions(j.Path).WithLoggingLevel(badger.ERROR).WithReadOnly(j.ReadOnly))
 
+		if err != nil && strings.Contains(err.Error(), badgerLocked) {
+			return fmt.Errorf("%w, journal %s is locked", ErrBusy, j.Path)
+		}
 		if err != nil {
 			return fmt.Errorf("error opening badger database: %w", err)
 		}
@@ -51,11 +77,16 @@ func (m *MetaData) marshalBinary() ([]byte, error) {
 // Close safely closes the journal database connection.
 // This should be called when the journal is no longer needed to ensure
 // proper cleanup of database resources and prevent data corruption.
-// Returns an error if the database close operation fails.
+// Returns an error if the database close operation fails. A closed journal
+// can be loaded again.
 func (j *Journal) Close() error {
 	if j.db != nil {
-		j.db.Sync()
-		return j.db.Close()
+		db := j.db
+		j.db = nil
+		if !j.ReadOnly {
+			db.Sync()
+		}
+		return db.Close()
 	}
 	return nil
 }
@@ -76,9 +107,9 @@ func (j *Journal) AddFileByName(item string, file string, wipeoutTime int) error
 	if err != nil {
 		return fmt.Errorf("error getting absolute path for file %s: %w", file, err)
 	}
-	metadata := GenerateMetadata(item, path, wipeoutTime)
-	if metadata == nil {
-		return fmt.Errorf("error generating metadata for item: %s", item)
+	metadata, err := GenerateMetadata(item, path, wipeoutTime)
+	if err != nil {
+		return fmt.Errorf("error generating metadata for item %s: %w", item, err)
 	}
 
 	return j.register(metadata)
@@ -99,7 +130,7 @@ func (j *Journal) register(metadata *MetaData) error {
 	}
 
 	return j.db.Update(func(txn *badger.Txn) error {
-		key := []byte(metadata.Item)
+		key := j.key(metadata.Item)
 		value, err := metadata.marshalBinary()
 		if err != nil {
 			return fmt.Errorf("error marshaling metadata: %w", err)
@@ -116,8 +147,8 @@ func (j *Journal) register(metadata *MetaData) error {
 // Parameters:
 //   - item: The unique identifier of the item to retrieve
 //
-// Returns the MetaData struct for the item, or an error if the database
-// is not initialized, the item is not found, or unmarshaling fails.
+// Returns the MetaData struct for the item, ErrItemNotFound if the item has no
+// record, or an error if the database is not initialized or unmarshaling fails.
 func (j *Journal) Get(item string) (*MetaData, error) {
 	if j.db == nil {
 		return nil, fmt.Errorf("journal database is not initialized")
@@ -125,13 +156,19 @@ func (j *Journal) Get(item string) (*MetaData, error) {
 
 	var metadata MetaData
 	err := j.db.View(func(txn *badger.Txn) error {
-		key := []byte(item)
-		item, err := txn.Get(key)
+		key := j.key(item)
+		entry, err := txn.Get(key)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w '%s' in the rubbish", ErrItemNotFound, item)
+		}
 		if err != nil {
 			return fmt.Errorf("error getting metadata: %w", err)
 		}
-		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &metadata)
+		return entry.Value(func(val []byte) error {
+			if err := json.Unmarshal(val, &metadata); err != nil {
+				return fmt.Errorf("error decoding metadata of %s: %w", item, err)
+			}
+			return nil
 		})
 	})
 
@@ -150,17 +187,32 @@ func (j *Journal) Get(item string) (*MetaData, error) {
 // or an error if the database is not initialized or if any unmarshaling
 // operation fails during iteration.
 func (j *Journal) List() ([]*MetaData, error) {
+	return j.filter(func(*MetaData) bool { return true })
+}
+
+// ListSorted retrieves all metadata entries like List, ordered by item key
+// with SortByItem or oldest tossed first with SortByTossed.
+func (j *Journal) ListSorted(by string) ([]*MetaData, error) {
+	records, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	if err := SortRecords(records, by); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// filter iterates over the item records of the journal's bin, skipping the
+// internal state keys, and returns those accepted by the keep function.
+func (j *Journal) filter(keep func(*MetaData) bool) ([]*MetaData, error) {
 	if j.db == nil {
 		return nil, fmt.Errorf("journal database is not initialized")
 	}
 
 	var metadataList []*MetaData
 	err := j.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
+		return j.eachRecord(txn, func(item *badger.Item) error {
 			var metadata MetaData
 			err := item.Value(func(val []byte) error {
 				return json.Unmarshal(val, &metadata)
@@ -168,9 +220,11 @@ func (j *Journal) List() ([]*MetaData, error) {
 			if err != nil {
 				return fmt.Errorf("error unmarshaling metadata: %w", err)
 			}
-			metadataList = append(metadataList, &metadata)
-		}
-		return nil
+			if keep(&metadata) {
+				metadataList = append(metadataList, &metadata)
+			}
+			return nil
+		})
 	})
 
 	if err != nil {
@@ -194,11 +248,87 @@ func (j *Journal) Delete(item string) error {
 	}
 
 	return j.db.Update(func(txn *badger.Txn) error {
-		key := []byte(item)
+		key := j.key(item)
 		return txn.Delete(key)
 	})
 }
 
+// Update applies fn to the record of an item and stores the result, reading
+// and writing it in a single transaction. fn must not change the Item of the
+// record. Returns ErrItemNotFound if the item has no record.
+func (j *Journal) Update(item string, fn func(*MetaData)) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	return j.db.Update(func(txn *badger.Txn) error {
+		entry, err := txn.Get(j.key(item))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w '%s' in the rubbish", ErrItemNotFound, item)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting metadata: %w", err)
+		}
+
+		var metadata MetaData
+		if err := entry.Value(func(val []byte) error { return json.Unmarshal(val, &metadata) }); err != nil {
+			return fmt.Errorf("error decoding metadata of %s: %w", item, err)
+		}
+
+		fn(&metadata)
+		if metadata.Item != item {
+			return fmt.Errorf("updating %s must not rename it to %s", item, metadata.Item)
+		}
+
+		value, err := metadata.marshalBinary()
+		if err != nil {
+			return fmt.Errorf("error marshaling metadata: %w", err)
+		}
+		return txn.Set(j.key(item), value)
+	})
+}
+
+// Rename moves the record of an item under a new item name in a single
+// transaction, updating its Item field. The record is left untouched when
+// the old item has no record (ErrItemNotFound) or the new name is already
+// taken (ErrItemExists).
+func (j *Journal) Rename(oldItem string, newItem string) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	return j.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(j.key(newItem)); err == nil {
+			return fmt.Errorf("%w: '%s' in the rubbish", ErrItemExists, newItem)
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("error getting metadata: %w", err)
+		}
+
+		entry, err := txn.Get(j.key(oldItem))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w '%s' in the rubbish", ErrItemNotFound, oldItem)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting metadata: %w", err)
+		}
+
+		var metadata MetaData
+		if err := entry.Value(func(val []byte) error { return json.Unmarshal(val, &metadata) }); err != nil {
+			return fmt.Errorf("error decoding metadata of %s: %w", oldItem, err)
+		}
+		metadata.Item = newItem
+
+		value, err := metadata.marshalBinary()
+		if err != nil {
+			return fmt.Errorf("error marshaling metadata: %w", err)
+		}
+		if err := txn.Set(j.key(newItem), value); err != nil {
+			return err
+		}
+		return txn.Delete(j.key(oldItem))
+	})
+}
+
 // Clear removes all entries from the journal database.
 // This method performs a complete cleanup of the journal, removing
 // metadata for all items. Use with caution as this operation cannot
@@ -212,16 +342,12 @@ func (j *Journal) Clear() error {
 	}
 
 	return j.db.Update(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			if err := txn.Delete(item.Key()); err != nil {
+		return j.eachRecord(txn, func(item *badger.Item) error {
+			if err := txn.Delete(item.KeyCopy(nil)); err != nil {
 				return fmt.Errorf("error deleting metadata: %w", err)
 			}
-		}
-		return nil
+			return nil
+		})
 	})
 }
 
@@ -238,13 +364,10 @@ func (j *Journal) Count() (int, error) {
 
 	count := 0
 	err := j.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
+		return j.eachRecord(txn, func(*badger.Item) error {
 			count++
-		}
-		return nil
+			return nil
+		})
 	})
 
 	if err != nil {
@@ -266,14 +389,10 @@ func (j *Journal) GetSize() (int64, error) {
 
 	var size int64
 	err := j.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
+		return j.eachRecord(txn, func(item *badger.Item) error {
 			size += item.ValueSize()
-		}
-		return nil
+			return nil
+		})
 	})
 
 	if err != nil {
@@ -290,66 +409,135 @@ func (j *Journal) AddRecord(record *MetaData) error {
 	return j.register(record)
 }
 
+// FilterPath returns the records whose origin is path itself or lies below
+// it. Sibling directories sharing a name prefix, such as docs2 for docs, are
+// not matched.
 func (j *Journal) FilterPath(path string) ([]*MetaData, error) {
+	return j.filter(func(metadata *MetaData) bool {
+		return IsWithin(metadata.Origin, path)
+	})
+}
+
+// IsWithin reports whether origin is dir or one of its descendants, comparing
+// whole path elements.
+func IsWithin(origin, dir string) bool {
+	origin, dir = filepath.Clean(origin), filepath.Clean(dir)
+	if origin == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(origin, dir)
+}
+
+func (j *Journal) FilterWipeable() ([]*MetaData, error) {
+	return j.filter(func(metadata *MetaData) bool {
+		return metadata.IsWipeable()
+	})
+}
+
+// expiry holds the fields of a record deciding its wipeability, so they can
+// be decoded without the rest of the record.
+type expiry struct {
+	WipeoutTime int
+	TossedTime  int64
+	WipeableAt  int64
+}
+
+// CountWipeable returns the number of wipeable records of the journal's bin.
+// Unlike FilterWipeable it builds no list and only decodes the expiry fields
+// of each record.
+func (j *Journal) CountWipeable() (int, error) {
 	if j.db == nil {
-		return nil, fmt.Errorf("journal database is not initialized")
+		return 0, fmt.Errorf("journal database is not initialized")
 	}
 
-	var metadataList []*MetaData
+	count := 0
 	err := j.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			var metadata MetaData
+		return j.eachRecord(txn, func(item *badger.Item) error {
+			var fields expiry
 			err := item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &metadata)
+				return json.Unmarshal(val, &fields)
 			})
 			if err != nil {
 				return fmt.Errorf("error unmarshaling metadata: %w", err)
 			}
-			if strings.Contains(metadata.Origin, path) {
-				metadataList = append(metadataList, &metadata)
+
+			record := MetaData{WipeoutTime: fields.WipeoutTime, TossedTime: fields.TossedTime, WipeableAt: fields.WipeableAt}
+			if record.IsWipeable() {
+				count++
 			}
-		}
-		return nil
+			return nil
+		})
 	})
 
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return metadataList, nil
+	return count, nil
 }
 
-func (j *Journal) FilterWipeable() ([]*MetaData, error) {
+// Stats aggregates the records of the journal's bin in a single pass: their
+// number, how many are wipeable, the sum of their recorded sizes and the
+// toss times of the oldest and newest ones, zero for an empty bin. Like
+// CountWipeable it only decodes the fields it needs.
+func (j *Journal) Stats() (total int, wipeable int, totalSize int64, oldest, newest time.Time, err error) {
 	if j.db == nil {
-		return nil, fmt.Errorf("journal database is not initialized")
+		return 0, 0, 0, time.Time{}, time.Time{}, fmt.Errorf("journal database is not initialized")
 	}
 
-	var metadataList []*MetaData
-	err := j.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			var metadata MetaData
+	var first, last int64
+	err = j.db.View(func(txn *badger.Txn) error {
+		return j.eachRecord(txn, func(item *badger.Item) error {
+			var fields struct {
+				expiry
+				Size int64
+			}
 			err := item.Value(func(val []byte) error {
-				return json.Unmarshal(val, &metadata)
+				return json.Unmarshal(val, &fields)
 			})
 			if err != nil {
 				return fmt.Errorf("error unmarshaling metadata: %w", err)
 			}
-			if metadata.IsWipeable() {
-				metadataList = append(metadataList, &metadata)
+
+			record := MetaData{WipeoutTime: fields.WipeoutTime, TossedTime: fields.TossedTime, WipeableAt: fields.WipeableAt}
+			if record.IsWipeable() {
+				wipeable++
 			}
-		}
-		return nil
+			if total == 0 || fields.TossedTime < first {
+				first = fields.TossedTime
+			}
+			if total == 0 || fields.TossedTime > last {
+				last = fields.TossedTime
+			}
+			total++
+			totalSize += fields.Size
+			return nil
+		})
 	})
 
 	if err != nil {
-		return nil, err
+		return 0, 0, 0, time.Time{}, time.Time{}, err
 	}
-	return metadataList, nil
+	if total > 0 {
+		oldest, newest = time.Unix(first, 0), time.Unix(last, 0)
+	}
+	return total, wipeable, totalSize, oldest, newest, nil
+}
+
+// FilterByHash returns the records of the deduplicated files holding the
+// content of the given hash.
+func (j *Journal) FilterByHash(hash string) ([]*MetaData, error) {
+	return j.filter(func(metadata *MetaData) bool {
+		return metadata.Hash == hash
+	})
+}
+
+// FilterByType returns the records of the given type, one of the Type
+// constants.
+func (j *Journal) FilterByType(t uint) ([]*MetaData, error) {
+	return j.filter(func(metadata *MetaData) bool {
+		return metadata.Type == t
+	})
 }
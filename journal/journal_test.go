@@ -0,0 +1,407 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+func newTestJournal(t *testing.T) *Journal {
+	t.Helper()
+	j := &Journal{Path: filepath.Join(t.TempDir(), ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func TestState_KeptApartFromRecords(t *testing.T) {
+	j := newTestJournal(t)
+
+	if value, err := j.State("missing"); err != nil || value != nil {
+		t.Fatalf("expected nil state for unset name, got %q, %v", value, err)
+	}
+
+	j.AddRecord(&MetaData{Item: "a.txt_ABCDEF", Origin: "/a.txt", TossedTime: time.Now().Unix()})
+	if err := j.SetState("marker", []byte("42")); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	if value, _ := j.State("marker"); string(value) != "42" {
+		t.Errorf("expected state 42, got %q", value)
+	}
+
+	records, err := j.List()
+	if err != nil || len(records) != 1 {
+		t.Fatalf("expected only the item record, got %d (%v)", len(records), err)
+	}
+	if count, _ := j.Count(); count != 1 {
+		t.Errorf("state must not be counted, got %d", count)
+	}
+
+	if err := j.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if count, _ := j.Count(); count != 0 {
+		t.Errorf("expected empty journal after clear, got %d", count)
+	}
+	if value, _ := j.State("marker"); string(value) != "42" {
+		t.Errorf("clear must keep internal state, got %q", value)
+	}
+}
+
+func TestGet_MissingItemIsNotFound(t *testing.T) {
+	j := newTestJournal(t)
+
+	_, err := j.Get("ghost.txt_ABCDEF")
+	if !errors.Is(err, ErrItemNotFound) || !errors.Is(err, badger.ErrKeyNotFound) {
+		t.Fatalf("expected ErrItemNotFound wrapping badger.ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestGet_CorruptRecordIsNotNotFound(t *testing.T) {
+	j := newTestJournal(t)
+	j.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("broken_ABCDEF"), []byte("{not json"))
+	})
+
+	_, err := j.Get("broken_ABCDEF")
+	if err == nil || errors.Is(err, ErrItemNotFound) {
+		t.Fatalf("expected a decoding error distinct from ErrItemNotFound, got %v", err)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the decoding error to be wrapped, got %v", err)
+	}
+}
+
+func TestFilterByType(t *testing.T) {
+	j := newTestJournal(t)
+	types := map[string]uint{"file": TypeFile, "dir": TypeDirectory, "symlink": TypeSymlink, "other": TypeOther}
+	for name, itemType := range types {
+		j.AddRecord(&MetaData{Item: name + "_ABCDEF", Origin: "/" + name, Type: itemType})
+	}
+	j.AddRecord(&MetaData{Item: "second_file_ABCDEF", Origin: "/second", Type: TypeFile})
+
+	for name, itemType := range types {
+		records, err := j.FilterByType(itemType)
+		if err != nil {
+			t.Fatalf("FilterByType(%s): %v", name, err)
+		}
+		want := 1
+		if itemType == TypeFile {
+			want = 2
+		}
+		if len(records) != want {
+			t.Errorf("FilterByType(%s) returned %d records, want %d", name, len(records), want)
+		}
+		for _, record := range records {
+			if record.Type != itemType {
+				t.Errorf("FilterByType(%s) returned %s of type %d", name, record.Item, record.Type)
+			}
+		}
+
+		parsed, err := ParseType(name)
+		if err != nil || parsed != itemType || TypeName(itemType) != name {
+			t.Errorf("ParseType(%s) = %d, %v; TypeName = %s", name, parsed, err, TypeName(itemType))
+		}
+	}
+
+	if _, err := ParseType("fifo"); err == nil {
+		t.Error("expected error for an unknown type name")
+	}
+}
+
+func TestFilterPath_RespectsPathBoundaries(t *testing.T) {
+	j := newTestJournal(t)
+	origins := map[string]string{
+		"docs_ABCDEF":    "/home/user/docs",
+		"report_ABCDEF":  "/home/user/docs/report.txt",
+		"deep_ABCDEF":    "/home/user/docs/a/b/deep.txt",
+		"docs2_ABCDEF":   "/home/user/docs2/report.txt",
+		"sibling_ABCDEF": "/home/user/docs.bak",
+		"other_ABCDEF":   "/srv/home/user/docs/report.txt",
+	}
+	for item, origin := range origins {
+		j.AddRecord(&MetaData{Item: item, Origin: origin})
+	}
+
+	for _, dir := range []string{"/home/user/docs", "/home/user/docs/"} {
+		records, err := j.FilterPath(dir)
+		if err != nil {
+			t.Fatalf("FilterPath(%s): %v", dir, err)
+		}
+		got := map[string]bool{}
+		for _, record := range records {
+			got[record.Item] = true
+		}
+		want := []string{"docs_ABCDEF", "report_ABCDEF", "deep_ABCDEF"}
+		if len(got) != len(want) {
+			t.Errorf("FilterPath(%s) returned %v, want %v", dir, got, want)
+		}
+		for _, item := range want {
+			if !got[item] {
+				t.Errorf("FilterPath(%s) missed %s", dir, item)
+			}
+		}
+	}
+
+	root, err := j.FilterPath("/")
+	if err != nil || len(root) != len(origins) {
+		t.Errorf("FilterPath(/) returned %d records, %v; want %d", len(root), err, len(origins))
+	}
+}
+
+func TestInBin_KeepsRecordsApart(t *testing.T) {
+	j := newTestJournal(t)
+	work := j.InBin("work")
+	j.SetState("marker", []byte("x"))
+	j.AddRecord(&MetaData{Item: "a_ABCDEF", Origin: "/a"})
+	work.AddRecord(&MetaData{Item: "a_ABCDEF", Origin: "/work/a"})
+	work.AddRecord(&MetaData{Item: "b_ABCDEF", Origin: "/work/b"})
+	j.InBin("photos").AddRecord(&MetaData{Item: "c_ABCDEF", Origin: "/c"})
+
+	if records, _ := j.List(); len(records) != 1 || records[0].Origin != "/a" {
+		t.Errorf("default bin lists %v", records)
+	}
+	if count, _ := work.Count(); count != 2 {
+		t.Errorf("work bin counts %d records, want 2", count)
+	}
+	if record, err := work.Get("a_ABCDEF"); err != nil || record.Origin != "/work/a" {
+		t.Errorf("work bin Get = %v, %v", record, err)
+	}
+	if _, err := work.Get("c_ABCDEF"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("records of other bins must not be found, got %v", err)
+	}
+
+	bins, err := j.Bins()
+	if err != nil || !slices.Equal(bins, []string{"photos", "work"}) {
+		t.Errorf("Bins = %v, %v", bins, err)
+	}
+
+	if err := work.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if count, _ := j.Count(); count != 1 {
+		t.Errorf("clearing a named bin must keep the default bin, got %d records", count)
+	}
+	if count, _ := j.InBin("photos").Count(); count != 1 {
+		t.Errorf("clearing a named bin must keep the other bins, got %d records", count)
+	}
+}
+
+func TestCountWipeable(t *testing.T) {
+	j := newTestJournal(t)
+	now := time.Now()
+	j.AddRecord(&MetaData{Item: "due_ABCDEF", WipeoutTime: 1, TossedTime: now.Add(-48 * time.Hour).Unix()})
+	j.AddRecord(&MetaData{Item: "kept_ABCDEF", WipeoutTime: 30, TossedTime: now.Unix()})
+	j.AddRecord(&MetaData{Item: "until_ABCDEF", WipeoutTime: 30, TossedTime: now.Unix(), WipeableAt: now.Add(-time.Minute).Unix()})
+	j.SetState("marker", []byte("not a record"))
+	j.InBin("work").AddRecord(&MetaData{Item: "other_ABCDEF", TossedTime: now.Add(-time.Hour).Unix()})
+
+	count, err := j.CountWipeable()
+	if err != nil {
+		t.Fatalf("CountWipeable: %v", err)
+	}
+	wipeable, _ := j.FilterWipeable()
+	if count != 2 || count != len(wipeable) {
+		t.Errorf("CountWipeable = %d, FilterWipeable = %d, want 2", count, len(wipeable))
+	}
+}
+
+// benchmarkJournal returns a journal of 10k records, half of them wipeable.
+func benchmarkJournal(b *testing.B) *Journal {
+	b.Helper()
+	j := &Journal{Path: filepath.Join(b.TempDir(), ".journal")}
+	if err := j.Load(); err != nil {
+		b.Fatalf("failed to load journal: %v", err)
+	}
+	b.Cleanup(func() { j.Close() })
+
+	now := time.Now()
+	for i := range 10000 {
+		j.AddRecord(&MetaData{
+			Item:        fmt.Sprintf("item%05d_ABCDEF", i),
+			Origin:      fmt.Sprintf("/home/user/projects/some/deep/directory/item%05d", i),
+			WipeoutTime: 1 + (i%2)*60,
+			TossedTime:  now.Add(-48 * time.Hour).Unix(),
+			Batch:       "20240115-093000-K3P9",
+			Size:        int64(i),
+		})
+	}
+	return j
+}
+
+func BenchmarkFilterWipeableCount(b *testing.B) {
+	j := benchmarkJournal(b)
+	for b.Loop() {
+		if records, err := j.FilterWipeable(); err != nil || len(records) != 5000 {
+			b.Fatalf("FilterWipeable = %d, %v", len(records), err)
+		}
+	}
+}
+
+func BenchmarkCountWipeable(b *testing.B) {
+	j := benchmarkJournal(b)
+	for b.Loop() {
+		if count, err := j.CountWipeable(); err != nil || count != 5000 {
+			b.Fatalf("CountWipeable = %d, %v", count, err)
+		}
+	}
+}
+
+func BenchmarkStats(b *testing.B) {
+	j := benchmarkJournal(b)
+	for b.Loop() {
+		if total, wipeable, _, _, _, err := j.Stats(); err != nil || total != 10000 || wipeable != 5000 {
+			b.Fatalf("Stats = %d, %d, %v", total, wipeable, err)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	j := newTestJournal(t)
+	if total, _, _, oldest, newest, err := j.Stats(); err != nil || total != 0 || !oldest.IsZero() || !newest.IsZero() {
+		t.Fatalf("expected empty stats, got %d, %v, %v, %v", total, oldest, newest, err)
+	}
+
+	now := time.Now()
+	for i := range 20 {
+		j.AddRecord(&MetaData{
+			Item:        fmt.Sprintf("item%02d", i),
+			Origin:      fmt.Sprintf("/item%02d", i),
+			WipeoutTime: 1 + (i%3)*30,
+			TossedTime:  now.Add(-time.Duration(i*7) * time.Hour).Unix(),
+			Size:        int64(i * 100),
+		})
+	}
+	j.SetState("last_status_seen", []byte("1"))
+
+	records, _ := j.List()
+	var (
+		wantWipeable int
+		wantSize     int64
+		wantOldest   = records[0].TossedTime
+		wantNewest   = records[0].TossedTime
+	)
+	for _, record := range records {
+		if record.IsWipeable() {
+			wantWipeable++
+		}
+		wantSize += record.Size
+		wantOldest = min(wantOldest, record.TossedTime)
+		wantNewest = max(wantNewest, record.TossedTime)
+	}
+
+	total, wipeable, size, oldest, newest, err := j.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if total != len(records) || wipeable != wantWipeable || size != wantSize {
+		t.Errorf("Stats = %d, %d, %d, want %d, %d, %d", total, wipeable, size, len(records), wantWipeable, wantSize)
+	}
+	if oldest.Unix() != wantOldest || newest.Unix() != wantNewest {
+		t.Errorf("Stats span = %v - %v, want %d - %d", oldest, newest, wantOldest, wantNewest)
+	}
+}
+
+func TestRename(t *testing.T) {
+	j := newTestJournal(t)
+	j.AddRecord(&MetaData{Item: "a.txt_ABCDEF", Origin: "/a.txt", TossedTime: 42})
+	j.AddRecord(&MetaData{Item: "b.txt_ABCDEF", Origin: "/b.txt", TossedTime: 43})
+
+	if err := j.Rename("a.txt_ABCDEF", "b.txt_ABCDEF"); !errors.Is(err, ErrItemExists) {
+		t.Fatalf("expected ErrItemExists, got %v", err)
+	}
+	if err := j.Rename("missing", "c.txt"); !errors.Is(err, ErrItemNotFound) {
+		t.Fatalf("expected ErrItemNotFound, got %v", err)
+	}
+
+	if err := j.Rename("a.txt_ABCDEF", "report"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if _, err := j.Get("a.txt_ABCDEF"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("old key must be gone, got %v", err)
+	}
+	record, err := j.Get("report")
+	if err != nil || record.Item != "report" || record.Origin != "/a.txt" || record.TossedTime != 42 {
+		t.Errorf("expected the record under its new name, got %+v, %v", record, err)
+	}
+	if count, _ := j.Count(); count != 2 {
+		t.Errorf("expected 2 records, got %d", count)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	j := newTestJournal(t)
+	j.AddRecord(&MetaData{Item: "a.txt_ABCDEF", Origin: "/a.txt", WipeoutTime: 30, TossedTime: 42})
+
+	if err := j.Update("a.txt_ABCDEF", func(m *MetaData) { m.WipeoutTime = 60 }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if record, err := j.Get("a.txt_ABCDEF"); err != nil || record.WipeoutTime != 60 || record.TossedTime != 42 {
+		t.Errorf("expected only the wipeout time changed, got %+v, %v", record, err)
+	}
+
+	if err := j.Update("missing", func(m *MetaData) {}); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+	if err := j.Update("a.txt_ABCDEF", func(m *MetaData) { m.Item = "b" }); err == nil {
+		t.Error("expected a renaming update to be rejected")
+	}
+	if _, err := j.Get("b"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("a rejected update must not store anything, got %v", err)
+	}
+}
+
+func TestListSorted(t *testing.T) {
+	j := newTestJournal(t)
+	now := time.Now().Unix()
+	j.AddRecord(&MetaData{Item: "a.txt_ABCDEF", TossedTime: now})
+	j.AddRecord(&MetaData{Item: "b.txt_ABCDEF", TossedTime: now - 60})
+	j.AddRecord(&MetaData{Item: "c.txt_ABCDEF", TossedTime: now - 3600})
+	j.AddRecord(&MetaData{Item: "d.txt_ABCDEF", TossedTime: now - 60})
+
+	items := func(records []*MetaData) []string {
+		var names []string
+		for _, record := range records {
+			names = append(names, record.Item)
+		}
+		return names
+	}
+
+	byTossed, err := j.ListSorted(SortByTossed)
+	if err != nil {
+		t.Fatalf("ListSorted: %v", err)
+	}
+	if want := []string{"c.txt_ABCDEF", "b.txt_ABCDEF", "d.txt_ABCDEF", "a.txt_ABCDEF"}; !slices.Equal(items(byTossed), want) {
+		t.Errorf("by tossed time got %v, want %v", items(byTossed), want)
+	}
+
+	byItem, _ := j.ListSorted(SortByItem)
+	if want := []string{"a.txt_ABCDEF", "b.txt_ABCDEF", "c.txt_ABCDEF", "d.txt_ABCDEF"}; !slices.Equal(items(byItem), want) {
+		t.Errorf("by item got %v, want %v", items(byItem), want)
+	}
+
+	if _, err := j.ListSorted("size"); err == nil {
+		t.Error("expected an unknown order to be rejected")
+	}
+}
+
+func TestLoad_LockedJournalIsBusy(t *testing.T) {
+	j := newTestJournal(t)
+
+	other := &Journal{Path: j.Path}
+	err := other.Load()
+	if !errors.Is(err, ErrBusy) {
+		other.Close()
+		t.Fatalf("expected ErrBusy opening a journal in use, got %v", err)
+	}
+}
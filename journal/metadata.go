@@ -1,7 +1,10 @@
 package journal
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"rubbish/fsutil"
 	"time"
 )
 
@@ -28,6 +31,43 @@ type MetaData struct {
 	// TossedTime is the Unix timestamp (seconds since epoch) when the item
 	// was originally moved to trash
 	TossedTime int64
+
+	// WipeableAt is an optional absolute Unix timestamp at which the item
+	// becomes eligible for wipeout, taking precedence over WipeoutTime.
+	// Zero means the expiry is relative to TossedTime.
+	WipeableAt int64
+
+	// Batch identifies the toss invocation the item was part of, so items
+	// tossed together can be reviewed and restored as a unit.
+	// Empty for items tossed before batches existed.
+	Batch string
+
+	// Size is the number of bytes of the item, summing the content of
+	// directories. It is measured once at toss time, as the item becomes
+	// opaque once moved into the container.
+	Size int64
+
+	// Compressed tells the item is stored in the container as a gzip
+	// compressed tar archive, extracted again on restore.
+	Compressed bool `json:",omitempty"`
+
+	// LinkTarget is the target of a tossed symlink, as read at toss time,
+	// so restore can recreate the link pointing to the same place.
+	LinkTarget string `json:",omitempty"`
+
+	// Hash is the hex encoded SHA-256 digest of a regular file tossed with
+	// --dedupe, so later tosses of the same content can share its storage.
+	Hash string `json:",omitempty"`
+
+	// Policy is the name of the retention policy the item was tossed with,
+	// empty when its retention came from wipeout_time, -r or --until.
+	Policy string `json:",omitempty"`
+
+	// Excluded lists the paths, relative to a directory tossed with
+	// --exclude, which were left at the origin, along with the special files
+	// a move across devices can't copy. Only the rest of the tree is stored,
+	// restore merges it back around them.
+	Excluded []string `json:",omitempty"`
 }
 
 // File system type constants for categorizing trashed items.
@@ -49,6 +89,34 @@ const (
 	TypeOther
 )
 
+// typeNames are the names of the type constants, as accepted by the --type
+// filters of the commands.
+var typeNames = map[uint]string{
+	TypeFile:      "file",
+	TypeDirectory: "dir",
+	TypeSymlink:   "symlink",
+	TypeOther:     "other",
+}
+
+// TypeName returns the name of the type constant, or "unknown" for records
+// journaled without a type.
+func TypeName(t uint) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseType returns the type constant named file, dir, symlink or other.
+func ParseType(name string) (uint, error) {
+	for t, typeName := range typeNames {
+		if typeName == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid type '%s' (expected file, dir, symlink or other)", name)
+}
+
 // getType determines the filesystem type of the item at the given path.
 // It uses os.Lstat to examine the file without following symbolic links,
 // allowing proper identification of symlinks themselves.
@@ -63,30 +131,34 @@ const (
 //   - path: The filesystem path to examine
 //
 // Returns the appropriate type constant (TypeFile, TypeDirectory, TypeSymlink, or TypeOther).
-// If an error occurs during file examination, TypeOther is returned.
-func getType(path string) uint {
+// If an error occurs during file examination, TypeOther is returned along with the error.
+func getType(path string) (uint, error) {
 	info, err := os.Lstat(path)
 	if err != nil {
-		return TypeOther
+		return TypeOther, err
 	}
 
-	if info.Mode()&os.ModeSymlink != 0 {
-		return TypeSymlink
-	}
-	if info.IsDir() {
-		return TypeDirectory
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return TypeSymlink, nil
+	case info.IsDir():
+		return TypeDirectory, nil
+	case info.Mode().IsRegular():
+		return TypeFile, nil
 	}
-	return TypeFile
+	return TypeOther, nil
 }
 
 // GenerateMetadata creates a new MetaData struct with the provided information
 // and automatically fills in the current timestamp and filesystem type.
 // This function is the primary way to create metadata entries for items
-// being moved to trash.
+// being moved to trash, and must be called while the original still exists.
 //
 // The function automatically:
 // - Sets the current Unix timestamp as the TossedTime
-// - Determines the filesystem type by examining the item path
+// - Determines the filesystem type by examining the original path
+// - Measures the item size, skipping the entries which can't be read
+// - Reads the target of symlinks
 // - Initializes all fields with the provided values
 //
 // Parameters:
@@ -94,16 +166,39 @@ func getType(path string) uint {
 //   - path: Absolute path to the original location of the file/directory
 //   - wipeoutTime: Number of days the item should remain in trash before cleanup
 //
-// Returns a pointer to a newly created MetaData struct with all fields populated.
-// Note: The Type field is determined by examining the 'item' parameter, not the 'path'.
-func GenerateMetadata(item string, path string, wipeoutTime int) *MetaData {
+// Returns a pointer to a newly created MetaData struct with all fields populated,
+// or an error if the item is empty or the original path cannot be examined.
+func GenerateMetadata(item string, path string, wipeoutTime int) (*MetaData, error) {
+	if item == "" {
+		return nil, fmt.Errorf("item name is empty")
+	}
+
+	itemType, err := getType(path)
+	if err != nil {
+		return nil, fmt.Errorf("error examining %s: %w", path, err)
+	}
+
+	size, err := fsutil.TreeSize(path)
+	if err != nil {
+		return nil, fmt.Errorf("error measuring %s: %w", path, err)
+	}
+
+	var linkTarget string
+	if itemType == TypeSymlink {
+		if linkTarget, err = os.Readlink(path); err != nil {
+			return nil, fmt.Errorf("error reading link %s: %w", path, err)
+		}
+	}
+
 	return &MetaData{
 		Item:        item,
 		Origin:      path,
-		Type:        getType(item),
+		Type:        itemType,
 		WipeoutTime: wipeoutTime,
 		TossedTime:  time.Now().Unix(),
-	}
+		Size:        size,
+		LinkTarget:  linkTarget,
+	}, nil
 }
 
 func (m *MetaData) TossElapsed() time.Duration {
@@ -111,12 +206,39 @@ func (m *MetaData) TossElapsed() time.Duration {
 	return time.Since(time.Unix(m.TossedTime, 0))
 }
 
+// WipeoutDate returns the moment the item becomes eligible for wipeout, either
+// the absolute WipeableAt or WipeoutTime days after it was tossed.
+func (m *MetaData) WipeoutDate() time.Time {
+	if m.WipeableAt != 0 {
+		return time.Unix(m.WipeableAt, 0)
+	}
+	return time.Unix(m.TossedTime, 0).Add(time.Duration(m.WipeoutTime*24) * time.Hour)
+}
+
+// OriginParentExists reports whether the directory the item was tossed from
+// still exists.
+func (m *MetaData) OriginParentExists() bool {
+	info, err := os.Stat(filepath.Dir(m.Origin))
+	return err == nil && info.IsDir()
+}
+
+// OriginTaken reports whether something now occupies the origin of the item,
+// so restoring it there would conflict. The directory left at the origin of
+// an item tossed with --exclude, holding the excluded paths, is no conflict.
+func (m *MetaData) OriginTaken() bool {
+	info, err := os.Lstat(m.Origin)
+	if err == nil && len(m.Excluded) > 0 && info.IsDir() {
+		return false
+	}
+	return err == nil
+}
+
 func (m *MetaData) IsWipeable() bool {
-	// Check if the item is eligible for wipeout based on its WipeoutTime
-	return m.TossElapsed().Hours()/24.0 >= float64(m.WipeoutTime)
+	// Check if the item is eligible for wipeout based on its wipeout date
+	return m.RemainingTime() <= 0
 }
 
 func (m *MetaData) RemainingTime() time.Duration {
 	// Calculate the remaining time before the item is eligible for wipeout
-	return (time.Duration(m.WipeoutTime*24) * time.Hour) - m.TossElapsed()
+	return time.Until(m.WipeoutDate())
 }
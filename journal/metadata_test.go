@@ -0,0 +1,115 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGenerateMetadata_ValidPath(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(file, []byte("x"), 0o644)
+
+	md, err := GenerateMetadata("file.txt_ABCDEF", file, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.Item != "file.txt_ABCDEF" || md.Origin != file || md.WipeoutTime != 7 {
+		t.Errorf("unexpected metadata: %+v", md)
+	}
+	if md.Type != TypeFile {
+		t.Errorf("expected TypeFile, got %d", md.Type)
+	}
+	if md.TossedTime == 0 {
+		t.Error("expected tossed time to be set")
+	}
+}
+
+func TestGenerateMetadata_MeasuresDirectoryContent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tree")
+	os.MkdirAll(filepath.Join(dir, "sub"), 0o755)
+	os.WriteFile(filepath.Join(dir, "a.txt"), make([]byte, 100), 0o644)
+	os.WriteFile(filepath.Join(dir, "sub", "b.txt"), make([]byte, 23), 0o644)
+
+	md, err := GenerateMetadata("tree_ABCDEF", dir, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.Type != TypeDirectory || md.Size != 123 {
+		t.Errorf("expected a directory of 123 bytes, got type %d size %d", md.Type, md.Size)
+	}
+}
+
+func TestGenerateMetadata_UnreadableSubdirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tree")
+	locked := filepath.Join(dir, "locked")
+	os.MkdirAll(locked, 0o755)
+	os.WriteFile(filepath.Join(dir, "a.txt"), make([]byte, 100), 0o644)
+	os.WriteFile(filepath.Join(locked, "b.txt"), make([]byte, 23), 0o644)
+	os.Chmod(locked, 0)
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	md, err := GenerateMetadata("tree_ABCDEF", dir, 7)
+	if err != nil {
+		t.Fatalf("an unreadable subdirectory must not fail the metadata: %v", err)
+	}
+	if md.Size != 100 {
+		t.Errorf("expected the readable 100 bytes, got %d", md.Size)
+	}
+}
+
+func TestGenerateMetadata_MissingPath(t *testing.T) {
+	if _, err := GenerateMetadata("ghost_ABCDEF", filepath.Join(t.TempDir(), "ghost"), 7); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
+
+func TestGenerateMetadata_EmptyItem(t *testing.T) {
+	if _, err := GenerateMetadata("", t.TempDir(), 7); err == nil {
+		t.Fatal("expected error for empty item")
+	}
+}
+
+func TestMetaData_WipeableAtTakesPrecedence(t *testing.T) {
+	now := time.Now()
+	md := &MetaData{WipeoutTime: 1, TossedTime: now.Add(-48 * time.Hour).Unix()}
+	if !md.IsWipeable() {
+		t.Fatal("expected relative expiry to be wipeable")
+	}
+
+	md.WipeableAt = now.Add(72 * time.Hour).Unix()
+	if md.IsWipeable() {
+		t.Error("absolute expiry in the future must not be wipeable")
+	}
+	if !md.WipeoutDate().Equal(time.Unix(md.WipeableAt, 0)) {
+		t.Errorf("unexpected wipeout date %v", md.WipeoutDate())
+	}
+}
+
+func TestGenerateMetadata_TypeOfOrigin(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	os.WriteFile(file, []byte("x"), 0o644)
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0o755)
+	link := filepath.Join(dir, "link")
+	os.Symlink(file, link)
+	fifo := filepath.Join(dir, "fifo")
+	if err := syscall.Mkfifo(fifo, 0o644); err != nil {
+		t.Fatalf("mkfifo: %v", err)
+	}
+
+	cases := map[string]uint{file: TypeFile, sub: TypeDirectory, link: TypeSymlink, fifo: TypeOther}
+	for path, want := range cases {
+		// the item is the container name, which does not exist yet
+		md, err := GenerateMetadata(filepath.Base(path)+"_ABCDEF", path, 7)
+		if err != nil {
+			t.Fatalf("GenerateMetadata(%s): %v", path, err)
+		}
+		if md.Type != want {
+			t.Errorf("%s: type %s, want %s", path, TypeName(md.Type), TypeName(want))
+		}
+	}
+}
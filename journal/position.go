@@ -0,0 +1,72 @@
+package journal
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Orders accepted by ListSorted and SortRecords.
+const (
+	SortByItem   = "item"   // SortByItem orders the records by item key, the journal's own order
+	SortByTossed = "tossed" // SortByTossed orders the records oldest tossed first
+)
+
+// SortRecords orders records in place by the given order. Records tossed in
+// the same second keep a stable order, by item key.
+func SortRecords(records []*MetaData, by string) error {
+	switch by {
+	case SortByItem:
+		slices.SortFunc(records, func(a, b *MetaData) int { return strings.Compare(a.Item, b.Item) })
+	case SortByTossed:
+		slices.SortFunc(records, func(a, b *MetaData) int {
+			return cmp.Or(cmp.Compare(a.TossedTime, b.TossedTime), strings.Compare(a.Item, b.Item))
+		})
+	default:
+		return fmt.Errorf("invalid sort order %q, expected %s or %s", by, SortByItem, SortByTossed)
+	}
+	return nil
+}
+
+// AtPosition returns the record at the given 1-based position of records,
+// negative positions count from the end of the list (-1 being the last one).
+func AtPosition(records []*MetaData, position int) (*MetaData, error) {
+	i := position
+	if i < 0 {
+		i = len(records) + i + 1
+	}
+
+	if position == 0 || i < 1 || i > len(records) {
+		return nil, fmt.Errorf("invalid item position: %d", position)
+	}
+
+	return records[i-1], nil
+}
+
+// ByPosition returns the record at the given position of records once
+// ordered oldest tossed first, the numbering info, restore and wipe share:
+// 1 is the oldest record and -1 the newest. records keeps its order.
+func ByPosition(records []*MetaData, position int) (*MetaData, error) {
+	sorted := slices.Clone(records)
+	SortRecords(sorted, SortByTossed)
+	return AtPosition(sorted, position)
+}
+
+// RecordAtPosition returns the record at the given position, as numbered by
+// ByPosition, among the records tossed from dir or, when dir is empty, every
+// record of the bin. info, restore and wipe resolve their -p option with it,
+// so the same position designates the same item for the three of them.
+func (j *Journal) RecordAtPosition(dir string, position int) (*MetaData, error) {
+	var records []*MetaData
+	var err error
+	if dir == "" {
+		records, err = j.List()
+	} else {
+		records, err = j.FilterPath(dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	return ByPosition(records, position)
+}
@@ -0,0 +1,73 @@
+package journal
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAtPosition(t *testing.T) {
+	records := []*MetaData{{Item: "a"}, {Item: "b"}, {Item: "c"}}
+
+	cases := map[int]string{1: "a", 3: "c", -1: "c", -3: "a"}
+	for position, want := range cases {
+		record, err := AtPosition(records, position)
+		if err != nil {
+			t.Errorf("AtPosition(%d) error: %v", position, err)
+			continue
+		}
+		if record.Item != want {
+			t.Errorf("AtPosition(%d) = %s, want %s", position, record.Item, want)
+		}
+	}
+
+	for _, position := range []int{0, 4, -4} {
+		if _, err := AtPosition(records, position); err == nil || err.Error() != "invalid item position: "+strconv.Itoa(position) {
+			t.Errorf("AtPosition(%d) expected invalid position error, got %v", position, err)
+		}
+	}
+}
+
+func TestByPosition(t *testing.T) {
+	records := []*MetaData{{Item: "newest", TossedTime: 30}, {Item: "oldest", TossedTime: 10}, {Item: "middle", TossedTime: 20}}
+
+	cases := map[int]string{1: "oldest", 2: "middle", -1: "newest"}
+	for position, want := range cases {
+		record, err := ByPosition(records, position)
+		if err != nil || record.Item != want {
+			t.Errorf("ByPosition(%d) = %v, %v, want %s", position, record, err, want)
+		}
+	}
+	if records[0].Item != "newest" {
+		t.Errorf("expected the records to keep their order, got %s first", records[0].Item)
+	}
+	if _, err := ByPosition(records, 4); err == nil {
+		t.Error("expected an out of range position to fail")
+	}
+}
+
+func TestRecordAtPosition(t *testing.T) {
+	j := newTestJournal(t)
+	j.AddRecord(&MetaData{Item: "elsewhere", Origin: "/elsewhere/a", TossedTime: 10})
+	j.AddRecord(&MetaData{Item: "old", Origin: "/dir/b", TossedTime: 20})
+	j.AddRecord(&MetaData{Item: "new", Origin: "/dir/sub/c", TossedTime: 30})
+
+	cases := []struct {
+		dir      string
+		position int
+		want     string
+	}{
+		{"/dir", 1, "old"},
+		{"/dir", -1, "new"},
+		{"", 1, "elsewhere"},
+		{"", -1, "new"},
+	}
+	for _, c := range cases {
+		record, err := j.RecordAtPosition(c.dir, c.position)
+		if err != nil || record.Item != c.want {
+			t.Errorf("RecordAtPosition(%q, %d) = %v, %v, want %s", c.dir, c.position, record, err, c.want)
+		}
+	}
+	if _, err := j.RecordAtPosition("/dir", 3); err == nil {
+		t.Error("expected a position beyond the directory items to fail")
+	}
+}
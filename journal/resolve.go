@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAmbiguousItem is returned when a name designates several items.
+var ErrAmbiguousItem = errors.New("ambiguous item")
+
+// Match returns the records designated by name: the record whose item key is
+// name, else those whose key starts with name, else those whose original
+// basename is name.
+func Match(records []*MetaData, name string) []*MetaData {
+	var prefixed, named []*MetaData
+	for _, record := range records {
+		switch {
+		case record.Item == name:
+			return []*MetaData{record}
+		case strings.HasPrefix(record.Item, name):
+			prefixed = append(prefixed, record)
+		case filepath.Base(record.Origin) == name:
+			named = append(named, record)
+		}
+	}
+
+	if len(prefixed) > 0 {
+		return prefixed
+	}
+	return named
+}
+
+// Resolve returns the single record designated by name among records, as
+// Match finds them. It fails with ErrItemNotFound when none matches and with
+// ErrAmbiguousItem, listing the candidates, when several do.
+func Resolve(records []*MetaData, name string) (*MetaData, error) {
+	matches := Match(records, name)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w '%s' in the rubbish", ErrItemNotFound, name)
+	case 1:
+		return matches[0], nil
+	}
+
+	keys := make([]string, len(matches))
+	for i, match := range matches {
+		keys[i] = match.Item
+	}
+	return nil, fmt.Errorf("%w '%s' matches %s", ErrAmbiguousItem, name, strings.Join(keys, ", "))
+}
+
+// ResolvePrefix returns the item keys of the journal's bin designated by
+// prefix: the key itself, the keys starting with it or, failing that, the
+// keys of the items whose original basename it is.
+func (j *Journal) ResolvePrefix(prefix string) ([]string, error) {
+	records, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, record := range Match(records, prefix) {
+		keys = append(keys, record.Item)
+	}
+	return keys, nil
+}
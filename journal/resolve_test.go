@@ -0,0 +1,77 @@
+package journal
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	records := []*MetaData{
+		{Item: "file.txt_AB12CD", Origin: "/home/u/file.txt"},
+		{Item: "file.txt_ABZZZZ", Origin: "/home/u/docs/file.txt"},
+		{Item: "notes.md_QW12ER", Origin: "/home/u/notes.md"},
+		{Item: "notes.md", Origin: "/home/u/old/notes.md"},
+	}
+
+	found := map[string]string{
+		"file.txt_AB12CD": "file.txt_AB12CD", // the key itself
+		"file.txt_AB1":    "file.txt_AB12CD", // a unique prefix
+		"notes.md_Q":      "notes.md_QW12ER",
+		"notes.md":        "notes.md", // an exact key wins over the longer keys it prefixes
+	}
+	for name, want := range found {
+		record, err := Resolve(records, name)
+		if err != nil || record.Item != want {
+			t.Errorf("Resolve(%s) = %v, %v, want %s", name, record, err, want)
+		}
+	}
+
+	_, err := Resolve(records, "file.txt_AB")
+	if !errors.Is(err, ErrAmbiguousItem) || !strings.Contains(err.Error(), "file.txt_AB12CD, file.txt_ABZZZZ") {
+		t.Errorf("expected the candidates of an ambiguous prefix, got %v", err)
+	}
+
+	if _, err := Resolve(records, "missing"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+}
+
+func TestResolve_OriginalBasename(t *testing.T) {
+	records := []*MetaData{
+		{Item: "a_AB12CD", Origin: "/home/u/report.pdf"},
+		{Item: "b_QW12ER", Origin: "/home/u/notes.md"},
+		{Item: "c_ZX12CV", Origin: "/home/u/old/notes.md"},
+	}
+
+	if record, err := Resolve(records, "report.pdf"); err != nil || record.Item != "a_AB12CD" {
+		t.Errorf("expected the item tossed as report.pdf, got %v, %v", record, err)
+	}
+	if _, err := Resolve(records, "notes.md"); !errors.Is(err, ErrAmbiguousItem) {
+		t.Errorf("expected a basename shared by two items to be ambiguous, got %v", err)
+	}
+}
+
+func TestResolvePrefix(t *testing.T) {
+	j := newTestJournal(t)
+	j.AddRecord(&MetaData{Item: "file.txt_AB12CD", Origin: "/file.txt"})
+	j.AddRecord(&MetaData{Item: "file.txt_ABZZZZ", Origin: "/file.txt"})
+	j.AddRecord(&MetaData{Item: "photo.jpg_QW12ER", Origin: "/photo.jpg"})
+
+	cases := map[string][]string{
+		"file.txt_AB1": {"file.txt_AB12CD"},
+		"file.txt_AB":  {"file.txt_AB12CD", "file.txt_ABZZZZ"},
+		"photo.jpg":    {"photo.jpg_QW12ER"},
+		"missing":      nil,
+	}
+	for prefix, want := range cases {
+		keys, err := j.ResolvePrefix(prefix)
+		if err != nil {
+			t.Fatalf("ResolvePrefix(%s): %v", prefix, err)
+		}
+		if !slices.Equal(keys, want) {
+			t.Errorf("ResolvePrefix(%s) = %v, want %v", prefix, keys, want)
+		}
+	}
+}
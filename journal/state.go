@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// statePrefix marks the keys holding internal state (timestamps, markers)
+// rather than item metadata. A NUL byte can never be part of a file name, so
+// these keys never collide with an item.
+const statePrefix = "\x00state:"
+
+// isInternalKey reports whether the key holds internal state or a record of
+// a named bin instead of an item of the default bin. Both start with a NUL byte.
+func isInternalKey(key []byte) bool {
+	return len(key) > 0 && key[0] == statePrefix[0]
+}
+
+// SetState stores an internal value under the given name. State values are
+// kept apart from the item records and never returned by List or the filters.
+func (j *Journal) SetState(name string, value []byte) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	return j.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(statePrefix+name), value)
+	})
+}
+
+// ClearState removes the internal value stored under the given name, if any.
+func (j *Journal) ClearState(name string) error {
+	if j.db == nil {
+		return fmt.Errorf("journal database is not initialized")
+	}
+
+	return j.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(statePrefix + name))
+	})
+}
+
+// State retrieves the internal value stored under the given name.
+// Returns nil without error when the value was never set.
+func (j *Journal) State(name string) ([]byte, error) {
+	if j.db == nil {
+		return nil, fmt.Errorf("journal database is not initialized")
+	}
+
+	var value []byte
+	err := j.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(statePrefix + name))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting state %s: %w", name, err)
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,20 @@
+package journal
+
+import "time"
+
+// TossedBetween returns the records tossed at or after the after time and
+// strictly before the before time, keeping their order. A zero bound is not
+// applied.
+func TossedBetween(records []*MetaData, after time.Time, before time.Time) []*MetaData {
+	var selected []*MetaData
+	for _, record := range records {
+		if !after.IsZero() && record.TossedTime < after.Unix() {
+			continue
+		}
+		if !before.IsZero() && record.TossedTime >= before.Unix() {
+			continue
+		}
+		selected = append(selected, record)
+	}
+	return selected
+}
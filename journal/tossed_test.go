@@ -0,0 +1,39 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTossedBetween(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	records := []*MetaData{
+		{Item: "a", TossedTime: day(10).Unix()},
+		{Item: "b", TossedTime: day(15).Add(time.Hour).Unix()},
+		{Item: "c", TossedTime: day(20).Unix()},
+	}
+
+	cases := []struct {
+		name          string
+		after, before time.Time
+		want          []string
+	}{
+		{"open", time.Time{}, time.Time{}, []string{"a", "b", "c"}},
+		{"after is inclusive", day(15), time.Time{}, []string{"b", "c"}},
+		{"before is exclusive", time.Time{}, day(20), []string{"a", "b"}},
+		{"range", day(11), day(16), []string{"b"}},
+	}
+
+	for _, c := range cases {
+		got := TossedBetween(records, c.after, c.before)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %d records, want %v", c.name, len(got), c.want)
+			continue
+		}
+		for i, record := range got {
+			if record.Item != c.want[i] {
+				t.Errorf("%s: record %d = %s, want %s", c.name, i, record.Item, c.want[i])
+			}
+		}
+	}
+}
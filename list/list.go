@@ -0,0 +1,140 @@
+package list
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Sorting criteria accepted by the --sort flag.
+const (
+	SortName      = "name"
+	SortDate      = "date"
+	SortSize      = "size"
+	SortRemaining = "remaining"
+)
+
+var (
+	Flags         *flag.FlagSet = flag.NewFlagSet("list", flag.ExitOnError)
+	sortBy        string        = SortName // sortBy is the criteria used to order the listed items
+	reverseOrder  bool          = false    // reverseOrder inverts the sorting order
+	tossedAfter   string        = ""       // tossedAfter lists only the items tossed on or after this date
+	tossedBefore  string        = ""       // tossedBefore lists only the items tossed before this date
+	typeFilter    string        = ""       // typeFilter lists only the items of this type name
+	limit         int           = 0        // limit is the number of items listed, 0 listing them all
+	sortCriterias               = []string{SortName, SortDate, SortSize, SortRemaining}
+)
+
+func init() {
+	Flags.StringVar(&sortBy, "sort", SortName, "Sort items by name, date, size or remaining time.")
+	Flags.BoolVar(&reverseOrder, "reverse", false, "Reverse the sorting order.")
+	Flags.StringVar(&tossedAfter, "after", "", "List only the items tossed on or after the given date (YYYY-MM-DD).")
+	Flags.StringVar(&tossedBefore, "before", "", "List only the items tossed before the given date (YYYY-MM-DD).")
+	Flags.StringVar(&typeFilter, "type", "", "List only the items of the given type: file, dir, symlink or other.")
+	Flags.IntVar(&limit, "limit", 0, "List only the first `N` items once sorted, the total still covering them all.")
+	Flags.IntVar(&limit, "n", 0, "Alias of --limit.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish list shows every item in the journal, regardless of the working directory.\n",
+			"Usage:\n\n",
+			"\trubbish list [options]\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+// Command prints all the journaled items with their origin, tossed date and
+// wipeout date as aligned columns, ordered by the requested criteria.
+func Command(args []string, cfg *config.Config) error {
+	if !slices.Contains(sortCriterias, sortBy) {
+		return fmt.Errorf("invalid sort criteria '%s' (expected one of %v)", sortBy, sortCriterias)
+	}
+
+	if limit < 0 {
+		return fmt.Errorf("invalid --limit %d, expected 0 or more", limit)
+	}
+
+	after, before, err := cfg.TossedRange(tossedAfter, tossedBefore)
+	if err != nil {
+		return err
+	}
+
+	var records []*journal.MetaData
+	if typeFilter != "" {
+		var itemType uint
+		if itemType, err = journal.ParseType(typeFilter); err != nil {
+			return err
+		}
+		records, err = cfg.Journal.FilterByType(itemType)
+	} else {
+		records, err = cfg.Journal.List()
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+	records = journal.TossedBetween(records, after, before)
+
+	if len(records) == 0 {
+		fmt.Println("No rubbish found.")
+		return nil
+	}
+
+	sizes := make(map[string]int64, len(records))
+	for _, record := range records {
+		sizes[record.Item] = config.RecordSize(cfg, record)
+	}
+
+	sortRecords(records, sortBy, sizes)
+	if reverseOrder {
+		slices.Reverse(records)
+	}
+
+	shown := records
+	if limit > 0 && len(records) > limit {
+		shown = records[:limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tORIGIN\tTOSSED\tWIPEOUT\tSIZE")
+	for _, record := range shown {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			record.Item,
+			record.Origin,
+			cfg.DisplayTime(record.TossedTime).Format(time.DateTime),
+			record.WipeoutDate().In(cfg.Zone()).Format(time.DateOnly),
+			config.ReadableSize(uint64(sizes[record.Item])),
+		)
+	}
+	w.Flush()
+
+	if hidden := len(records) - len(shown); hidden > 0 {
+		fmt.Printf("…and %d more\n", hidden)
+	}
+	fmt.Printf("Total: %d\n", len(records))
+	return nil
+}
+
+// sortRecords orders the records in place. Names sort alphabetically, dates from
+// the oldest toss, sizes from the largest item and remaining time from the item
+// closest to its wipeout.
+func sortRecords(records []*journal.MetaData, by string, sizes map[string]int64) {
+	slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+		switch by {
+		case SortDate:
+			return cmp.Compare(a.TossedTime, b.TossedTime)
+		case SortSize:
+			return cmp.Compare(sizes[b.Item], sizes[a.Item])
+		case SortRemaining:
+			return cmp.Compare(a.RemainingTime(), b.RemainingTime())
+		default:
+			return strings.Compare(a.Item, b.Item)
+		}
+	})
+}
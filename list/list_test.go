@@ -0,0 +1,177 @@
+package list
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(dir, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &config.Config{ContainerPath: dir, Journal: j, WorkingDir: filepath.Join(dir, "work")}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+// seed journals an item of the given size tossed some time ago from anywhere on disk.
+func seed(t *testing.T, cfg *config.Config, item string, size int, tossedAgo time.Duration, wipeDays int) {
+	t.Helper()
+	os.WriteFile(filepath.Join(cfg.ContainerPath, item), bytes.Repeat([]byte{'x'}, size), 0o644)
+	err := cfg.Journal.AddRecord(&journal.MetaData{
+		Item:        item,
+		Origin:      "/somewhere/" + item,
+		WipeoutTime: wipeDays,
+		TossedTime:  time.Now().Add(-tossedAgo).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("add %s: %v", item, err)
+	}
+}
+
+// itemOrder returns the items in the order they appear in the output.
+func itemOrder(out string, items ...string) []string {
+	var order []string
+	for _, line := range strings.Split(out, "\n") {
+		for _, item := range items {
+			if strings.HasPrefix(line, item+" ") {
+				order = append(order, item)
+			}
+		}
+	}
+	return order
+}
+
+func runList(t *testing.T, cfg *config.Config, by string, reverse bool) string {
+	t.Helper()
+	sortBy, reverseOrder = by, reverse
+	defer func() { sortBy, reverseOrder = SortName, false }()
+	return captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+}
+
+func TestCommand_ListsAllItemsSorted(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "b.txt", 10, 3*time.Hour, 1)
+	seed(t, cfg, "a.txt", 300, 1*time.Hour, 30)
+	seed(t, cfg, "c.txt", 20, 48*time.Hour, 10)
+
+	cases := []struct {
+		by      string
+		reverse bool
+		want    string
+	}{
+		{SortName, false, "a.txt b.txt c.txt"},
+		{SortName, true, "c.txt b.txt a.txt"},
+		{SortDate, false, "c.txt b.txt a.txt"},
+		{SortSize, false, "a.txt c.txt b.txt"},
+		{SortRemaining, false, "b.txt c.txt a.txt"},
+	}
+	for _, c := range cases {
+		out := runList(t, cfg, c.by, c.reverse)
+		got := strings.Join(itemOrder(out, "a.txt", "b.txt", "c.txt"), " ")
+		if got != c.want {
+			t.Errorf("sort=%s reverse=%v: got %q, want %q\n%s", c.by, c.reverse, got, c.want, out)
+		}
+	}
+}
+
+func TestCommand_Columns(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt", 2048, time.Hour, 30)
+
+	out := runList(t, cfg, SortName, false)
+	for _, want := range []string{"ITEM", "ORIGIN", "TOSSED", "WIPEOUT", "SIZE", "/somewhere/a.txt", "2.0 KB", "Total: 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output: %s", want, out)
+		}
+	}
+}
+
+func TestCommand_Limit(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "b.txt", 10, 3*time.Hour, 1)
+	seed(t, cfg, "a.txt", 300, 1*time.Hour, 30)
+	seed(t, cfg, "c.txt", 20, 48*time.Hour, 10)
+	limit = 2
+	defer func() { limit = 0 }()
+
+	out := runList(t, cfg, SortRemaining, false)
+	if got := strings.Join(itemOrder(out, "a.txt", "b.txt", "c.txt"), " "); got != "b.txt c.txt" {
+		t.Errorf("expected the 2 items closest to wipeout, got %q\n%s", got, out)
+	}
+	if !strings.Contains(out, "…and 1 more\nTotal: 3") {
+		t.Errorf("expected the footer and the full total: %s", out)
+	}
+
+	limit = 5
+	if out := runList(t, cfg, SortName, false); strings.Contains(out, "more") {
+		t.Errorf("no footer expected when every item fits: %s", out)
+	}
+}
+
+func TestCommand_InvalidSort(t *testing.T) {
+	cfg := newTestCfg(t)
+	sortBy = "color"
+	defer func() { sortBy = SortName }()
+	if err := Command(nil, cfg); err == nil {
+		t.Fatal("expected error for invalid sort criteria")
+	}
+}
+
+func TestCommand_TossedDateRange(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "old.txt", 1, 10*24*time.Hour, 30)
+	seed(t, cfg, "new.txt", 1, time.Hour, 30)
+
+	tossedBefore = time.Now().Add(-24 * time.Hour).Format(time.DateOnly)
+	defer func() { tossedBefore = "" }()
+
+	out := runList(t, cfg, SortName, false)
+	if got := itemOrder(out, "old.txt", "new.txt"); len(got) != 1 || got[0] != "old.txt" {
+		t.Errorf("expected only old.txt, got %v\n%s", got, out)
+	}
+	if !strings.Contains(out, "Total: 1") {
+		t.Errorf("total must reflect the filtered items: %s", out)
+	}
+}
+
+func TestCommand_TypeFilter(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt", 1, time.Hour, 30)
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "docs", Origin: "/somewhere/docs", Type: journal.TypeDirectory, TossedTime: time.Now().Unix()})
+
+	typeFilter = "dir"
+	defer func() { typeFilter = "" }()
+
+	out := runList(t, cfg, SortName, false)
+	if got := itemOrder(out, "a.txt", "docs"); len(got) != 1 || got[0] != "docs" {
+		t.Errorf("expected only docs, got %v\n%s", got, out)
+	}
+}
@@ -1,51 +1,210 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"rubbish/backup"
+	"rubbish/bins"
+	"rubbish/color"
+	"rubbish/completion"
 	"rubbish/config"
+	"rubbish/doctor"
+	"rubbish/find"
+	"rubbish/fsutil"
 	"rubbish/info"
+	"rubbish/journal"
+	"rubbish/list"
+	"rubbish/notify"
+	"rubbish/rename"
 	"rubbish/restorer"
+	"rubbish/service"
+	"rubbish/settings"
 	"rubbish/status"
 	"rubbish/tosser"
+	"rubbish/touch"
+	"rubbish/verify"
 	"rubbish/wipe"
 	"runtime/debug"
 	"slices"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// systemConfigPath is the location of the system wide configuration file.
+var systemConfigPath = "/etc/rubbish/config.cfg"
+
+// globalOptions holds the flags given before the command name. They are
+// resolved once into the configuration shared by every command.
+type globalOptions struct {
+	configFile string // configFile replaces the system and user configuration files
+	container  string // container overrides the configured container path
+	journal    string // journal overrides the journal location derived from the container
+	version    bool   // version requests the build version to be displayed
+	utc        bool   // utc displays times in UTC instead of the configured timezone
+	timezone   string // timezone displays times in the given IANA zone instead of the configured one
+	noColor    bool   // noColor disables the colored output, even on a terminal
+	readOnly   bool   // readOnly opens the journal read only, it is not an option
+}
+
+// newGlobalFlags creates the flag set for the options accepted before the command name.
+func newGlobalFlags(opts *globalOptions) *flag.FlagSet {
+	globals := flag.NewFlagSet("rubbish", flag.ContinueOnError)
+	globals.BoolVar(&opts.version, "version", false, "Show version information")
+	globals.StringVar(&opts.configFile, "config", "", "Read the given configuration file instead of the system and user ones")
+	globals.StringVar(&opts.container, "container", "", "Use the given container path instead of the configured one")
+	globals.StringVar(&opts.journal, "journal-path", "", "Use the given journal instead of the one inside the container")
+	globals.BoolVar(&opts.utc, "utc", false, "Display times in UTC")
+	globals.StringVar(&opts.timezone, "tz", "", "Display times in the given IANA timezone (e.g. Europe/Madrid)")
+	globals.BoolVar(&opts.noColor, "no-color", false, "Disable colored output (also disabled by the NO_COLOR variable)")
+	globals.Usage = printGeneralHelp
+	return globals
+}
+
 // loadConfig loads the application configuration from system and user configuration files.
 // It attempts to load a system-wide configuration first, then appends user-specific
 // configuration from the user's home directory, allowing for personalized overrides.
+// The global options are applied before the journal is opened, so every command
+// observes the same resolved settings.
 //
 // The configuration loading follows this hierarchy:
 // 1. System default: /etc/rubbish/config.cfg
 // 2. User override: ~/.config/rubbish.cfg
+// 3. Global command line options (e.g. --container, --tz)
+//
+// The --config option replaces the first two levels with the given file, the
+// defaults still applying to the settings it leaves out.
 //
 // Returns a fully initialized Config struct with default values and user overrides
 // applied, or an error if the user home directory cannot be determined or if
 // configuration loading fails.
-func loadConfig() (*config.Config, error) {
-	// Define the paths for the configuration files
-	defaultConfigPath := "/etc/rubbish/config.cfg"
-	homedir, err := os.UserHomeDir()
-
+func loadConfig(opts *globalOptions) (*config.Config, error) {
+	paths, err := configPaths(opts)
 	if err != nil {
-		return nil, fmt.Errorf("error getting user home directory: %w", err)
+		return nil, err
+	}
+	if opts.configFile != "" {
+		// Unlike the hierarchy files, an explicit configuration must exist
+		if _, err := os.Stat(opts.configFile); err != nil {
+			return nil, fmt.Errorf("error loading configuration: %w", err)
+		}
 	}
-	userConfigPath := filepath.Join(homedir, ".config", "rubbish.cfg")
 
 	// Load the configuration
-	cfg, err := config.Load([]string{defaultConfigPath, userConfigPath})
+	cfg, err := config.Read(paths)
 	if err != nil {
 		return nil, fmt.Errorf("error loading configuration: %w", err)
 	}
 
+	if opts.container != "" {
+		if cfg.ContainerPath, err = filepath.Abs(opts.container); err != nil {
+			return nil, fmt.Errorf("error resolving container path %s: %w", opts.container, err)
+		}
+	}
+
+	if opts.journal != "" {
+		if cfg.JournalPath, err = filepath.Abs(opts.journal); err != nil {
+			return nil, fmt.Errorf("error resolving journal path %s: %w", opts.journal, err)
+		}
+	}
+
+	switch {
+	case opts.utc && opts.timezone != "":
+		return nil, fmt.Errorf("the --utc and --tz options cannot be combined")
+	case opts.utc:
+		cfg.Location = time.UTC
+	case opts.timezone != "":
+		if cfg.Location, err = time.LoadLocation(opts.timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s': %w", opts.timezone, err)
+		}
+	}
+
+	cfg.ReadOnly = opts.readOnly
+	if err := cfg.Initialize(); err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+
+	if warning := journalMismatch(cfg); warning != "" {
+		color.Warnf("%s\n", warning)
+	}
+
 	return cfg, nil
 }
 
+// configPaths returns the configuration files read, in load order: the file
+// given with --config, the system and user files otherwise.
+func configPaths(opts *globalOptions) ([]string, error) {
+	if opts.configFile != "" {
+		return []string{opts.configFile}, nil
+	}
+
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("error getting user home directory: %w", err)
+	}
+	return []string{systemConfigPath, filepath.Join(homedir, ".config", "rubbish.cfg")}, nil
+}
+
+// runDoctor runs the doctor command on the files and paths the global
+// options select, without loading the configuration: its failures are what
+// doctor reports.
+func runDoctor(opts *globalOptions, args []string) int {
+	paths, err := configPaths(opts)
+	if err != nil {
+		color.Errorf("%v\n", err)
+		return 1
+	}
+
+	cmdDoctor.Options.Parse(args)
+	err = doctor.Command(cmdDoctor.Options.Args(), doctor.Options{
+		Paths:     paths,
+		Explicit:  opts.configFile != "",
+		Container: opts.container,
+		Journal:   opts.journal,
+	})
+	if err != nil {
+		color.Errorf("%v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// runComplete runs the hidden __complete command. Its output is read by the
+// shell as candidates, so it runs before the container is created and
+// locked, reading the journal read only. No item is offered when the bin
+// can't be read, e.g. while another rubbish process holds the journal.
+func runComplete(opts *globalOptions, words []string) int {
+	opts.readOnly = true
+	cfg, err := loadConfig(opts)
+	if err == nil {
+		defer cfg.Journal.Close()
+	} else {
+		cfg = nil
+	}
+
+	// the words are completed as typed, so they are not parsed as options
+	if err := cmdComplete.Action(words, cfg); err != nil {
+		return 2
+	}
+	return 0
+}
+
+// journalMismatch describes the inconsistency between the container and an
+// explicit journal path pointing elsewhere, or returns an empty string when
+// the journal belongs to the container.
+func journalMismatch(cfg *config.Config) string {
+	if cfg.JournalPath == "" || filepath.Clean(cfg.JournalPath) == filepath.Clean(cfg.DefaultJournalPath()) {
+		return ""
+	}
+	return fmt.Sprintf("journal '%s' does not belong to container '%s', listed items may not exist in the container",
+		cfg.JournalPath, cfg.ContainerPath)
+}
+
 // Command represents a command that can be executed by the rubbish utility.
 // It encapsulates the command name, description, and the function that
 // implements the command's functionality. This structure enables a clean
@@ -63,7 +222,30 @@ type Command struct {
 	// if the operation fails.
 	Action func(args []string, cfg *config.Config) error
 
+	// ContextAction, when set, is run instead of Action with a context
+	// cancelled on SIGINT or SIGTERM, for the commands handling many items to
+	// stop cleanly between two of them. Optional.
+	ContextAction func(ctx context.Context, args []string, cfg *config.Config) error
+
 	Options *flag.FlagSet // Optional flags for the command
+
+	// Quiet reports, once the options are parsed, whether the command output is
+	// meant for scripts and must not be mixed with notices. Optional.
+	Quiet func() bool
+
+	// CompleteItems reports whether the shell completion offers the item keys
+	// of the working directory as arguments of the command
+	CompleteItems bool
+
+	// AutoWipe reports, once the options are parsed, whether the run may
+	// auto-wipe the expired items before its action. Only the commands
+	// changing the bin do, never those reading it, restoring items or
+	// running dry. Optional, no auto-wipe without it.
+	AutoWipe func() bool
+
+	// EnforceQuota tells the bin limits are applied once the action ran, as
+	// for the commands adding items to the bin
+	EnforceQuota bool
 }
 
 // commands defines all available commands in the rubbish utility.
@@ -71,35 +253,146 @@ type Command struct {
 // from the appropriate module (tosser, restorer, cleaner, status).
 var (
 	cmdToss *Command = &Command{
-		Name:        "toss",
-		Description: "Move files to the trash",
-		Action:      tosser.Command, // Assuming tosser.Command is a function that handles the "toss" command
-		Options:     tosser.Flags,   // Optional
+		Name:          "toss",
+		Description:   "Move files to the trash",
+		Action:        tosser.Command, // Assuming tosser.Command is a function that handles the "toss" command
+		ContextAction: tosser.CommandContext,
+		Options:       tosser.Flags, // Optional
+		AutoWipe:      func() bool { return true },
+		EnforceQuota:  true,
 	}
 	cmdRestore *Command = &Command{
-		Name:        "restore",
-		Description: "Restore files from the trash",
-		Action:      restorer.Command, // Assuming restorer.Command is a function that handles the "restore" command
-		Options:     restorer.Flags,
+		Name:          "restore",
+		Description:   "Restore files from the trash",
+		Action:        restorer.Command, // Assuming restorer.Command is a function that handles the "restore" command
+		Options:       restorer.Flags,
+		CompleteItems: true,
 	}
 	cmdWipe *Command = &Command{
-		Name:        "wipe",
-		Description: "Clean up the rubbish",
-		Action:      wipe.Command, // Assuming cleaner.Command is a function that handles the "wipe" command
-		Options:     wipe.Flags,   // Optional
+		Name:          "wipe",
+		Description:   "Clean up the rubbish",
+		Action:        wipe.Command, // Assuming cleaner.Command is a function that handles the "wipe" command
+		ContextAction: wipe.CommandContext,
+		Options:       wipe.Flags, // Optional
+		CompleteItems: true,
+		AutoWipe:      func() bool { return !wipe.DryRun() },
 	}
 	// cmdStatus is the command for showing the status of the trash
 	cmdStatus *Command = &Command{
-		Name:        "status",
-		Description: "Show the status of the trash",
-		Action:      status.Command, // Assuming status.Command is a function that handles the "status" command
-		Options:     status.Flags,
+		Name:          "status",
+		Description:   "Show the status of the trash",
+		Action:        status.Command, // Assuming status.Command is a function that handles the "status" command
+		ContextAction: status.CommandContext,
+		Options:       status.Flags,
+		Quiet:         status.MachineOutput,
+	}
+	cmdList *Command = &Command{
+		Name:        "list",
+		Description: "List every item in the trash",
+		Action:      list.Command,
+		Options:     list.Flags,
 	}
 	cmdInfo *Command = &Command{
-		Name:        "info",
-		Description: "Show information about a rubbish item",
-		Action:      info.Command,
-		Options:     info.Flags,
+		Name:          "info",
+		Description:   "Show information about a rubbish item",
+		Action:        info.Command,
+		Options:       info.Flags,
+		Quiet:         info.MachineOutput,
+		CompleteItems: true,
+	}
+	cmdFind *Command = &Command{
+		Name:        "find",
+		Description: "Search the trash by file name pattern",
+		Action:      find.Command,
+		Options:     find.Flags,
+	}
+	cmdRename *Command = &Command{
+		Name:          "rename",
+		Description:   "Give a rubbish item a new name",
+		Action:        rename.Command,
+		Options:       rename.Flags,
+		CompleteItems: true,
+	}
+	cmdTouch *Command = &Command{
+		Name:          "touch",
+		Description:   "Change when rubbish items become wipeable",
+		Action:        touch.Command,
+		Options:       touch.Flags,
+		CompleteItems: true,
+	}
+	cmdBins *Command = &Command{
+		Name:        "bins",
+		Description: "List the default and named rubbish bins",
+		Action:      bins.Command,
+		Options:     bins.Flags,
+	}
+	cmdVerify *Command = &Command{
+		Name:        "verify",
+		Description: "Check the journal against the container",
+		Action:      verify.Command,
+		Options:     verify.Flags,
+	}
+	cmdJournal *Command = &Command{
+		Name:        "journal",
+		Description: "Export or import the journal records",
+		Action:      backup.Command,
+		Options:     backup.Flags,
+		Quiet:       backup.MachineOutput,
+	}
+	cmdService *Command = &Command{
+		Name:        "service",
+		Description: "Install or remove the periodic wipe service",
+		Action:      service.Command,
+		Options:     service.Flags,
+	}
+	// cmdDoctor is dispatched by run before the configuration is loaded, see
+	// runDoctor, so it has no action
+	cmdDoctor *Command = &Command{
+		Name:        "doctor",
+		Description: "Diagnose the configuration, container and journal",
+		Options:     doctor.Flags,
+	}
+	cmdConfig *Command = &Command{
+		Name:        "config",
+		Description: "Show or validate the configuration",
+		Action:      settings.Command,
+		Options:     settings.Flags,
+		Quiet:       func() bool { return true },
+	}
+	cmdCompletion *Command = &Command{
+		Name:        "completion",
+		Description: "Print a shell completion script",
+		Action:      completion.Command,
+		Options:     completion.Flags,
+		Quiet:       func() bool { return true },
+	}
+	// cmdComplete is the hidden command the completion scripts call to list
+	// the candidates for the word being typed
+	cmdComplete *Command = &Command{
+		Name:        "__complete",
+		Description: "List the completion candidates of the given words",
+		Action: func(args []string, cfg *config.Config) error {
+			items := func() ([]string, error) {
+				if cfg == nil {
+					return nil, nil
+				}
+				records, err := cfg.Journal.FilterPath(cfg.WorkingDir)
+				if err != nil {
+					return nil, err
+				}
+				keys := make([]string, len(records))
+				for i, record := range records {
+					keys[i] = record.Item
+				}
+				return keys, nil
+			}
+
+			for _, candidate := range completion.Complete(args, newGlobalFlags(&globalOptions{}), completionSpecs(), items) {
+				fmt.Println(candidate)
+			}
+			return nil
+		},
+		Options: flag.NewFlagSet("__complete", flag.ContinueOnError),
 	}
 	cmdHelp *Command = &Command{
 		Name:        "help",
@@ -127,14 +420,40 @@ var (
 		Options: flag.NewFlagSet("help", flag.ExitOnError), // No specific flags for help, but can be extended
 	}
 
-	commands    []*Command = []*Command{cmdToss, cmdRestore, cmdStatus, cmdInfo, cmdWipe}
+	commands    []*Command = []*Command{cmdToss, cmdRestore, cmdStatus, cmdList, cmdInfo, cmdFind, cmdRename, cmdTouch, cmdWipe, cmdBins, cmdVerify, cmdJournal, cmdService, cmdConfig, cmdDoctor, cmdCompletion}
 	helpCommand *Command
 )
 
+// completionSpecs describes the visible commands, help included, for the
+// shell completion.
+func completionSpecs() []completion.Spec {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+
+	specs := []completion.Spec{{Name: cmdHelp.Name, Options: cmdHelp.Options, Args: names}}
+	for _, cmd := range commands {
+		spec := completion.Spec{Name: cmd.Name, Options: cmd.Options, Items: cmd.CompleteItems}
+		if cmd == cmdCompletion {
+			spec.Args = completion.Shells
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
 func printGeneralHelp() {
 	fmt.Print("Rubbish is a tool to manage your trash effectively.\n\n",
 		"Usage:\n\n",
-		"  rubbish <command> [options]\n\n",
+		"  rubbish [global options] <command> [options]\n\n",
+		"Global options:\n\n",
+		"\t--container <path>\tUse the given container path instead of the configured one\n",
+		"\t--journal-path <path>\tUse the given journal instead of the one inside the container\n",
+		"\t--utc\t\t\tDisplay times in UTC\n",
+		"\t--tz <zone>\t\tDisplay times in the given IANA timezone\n",
+		"\t--no-color\t\tDisable colored output, also disabled by NO_COLOR\n",
+		"\t--version\t\tShow version information\n\n",
 		"Available commands:\n\n")
 
 	for _, cmd := range commands {
@@ -152,24 +471,23 @@ func displayVersion() {
 	}
 }
 
-func init() {
-	flag.BoolFunc("version", "Show version information", func(s string) error {
-		displayVersion()
-		os.Exit(0)
-		return nil
-	})
+// main is the entry point for the rubbish trash management utility.
+// It delegates to runAs, which answers to the trash-cli command names too, and
+// terminates the process with the resulting exit code.
+func main() {
+	os.Exit(runAs(os.Args[0], os.Args[1:]))
 }
 
-// main is the entry point for the rubbish trash management utility.
-// It orchestrates the entire application flow including configuration loading,
-// directory validation, command parsing, and execution.
+// run orchestrates the entire application flow including global flag parsing,
+// configuration loading, directory validation, command parsing, and execution.
 //
-// The main function performs these key operations:
-// 1. Loads configuration from system and user files
-// 2. Validates and creates the trash container directory if needed
-// 3. Parses command-line arguments to determine the requested operation
-// 4. Dispatches to the appropriate command handler
-// 5. Handles errors with colored output and appropriate exit codes
+// The run function performs these key operations:
+// 1. Parses the global flags given before the command name
+// 2. Loads configuration from system and user files, applying the global flags
+// 3. Validates and creates the trash container directory if needed
+// 4. Parses command-line arguments to determine the requested operation
+// 5. Dispatches to the appropriate command handler
+// 6. Handles errors with colored output and appropriate exit codes
 //
 // The function ensures proper error handling and user feedback throughout
 // the application lifecycle, using ANSI color codes for enhanced readability
@@ -177,82 +495,212 @@ func init() {
 //
 // Exit codes:
 //   - 0: Successful operation
-//   - 1: Configuration error, directory creation failure, invalid command,
-//     or command execution error
-func main() {
-	flag.Parse()
+//   - 1: Configuration error, directory creation failure or invalid command
+//   - 2: Command execution error
+//   - 3: The requested item does not exist in the rubbish
+//   - 4: status --quiet found more wipeable items than its threshold
+//   - 5: Another rubbish process is running
+//   - 130: A toss or wipe was interrupted by SIGINT or SIGTERM, after
+//     completing the item in flight
+func run(args []string) int {
+	opts := &globalOptions{}
+	globals := newGlobalFlags(opts)
+
+	if err := globals.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
 
-	cfg, err := loadConfig()
+	if opts.noColor {
+		color.Disabled = true
+	}
+
+	fsutil.Skipped = warnSkipped
+
+	if opts.version {
+		displayVersion()
+		return 0
+	}
+
+	if cmdDoctor.Name == globals.Arg(0) {
+		return runDoctor(opts, globals.Args()[1:])
+	}
+
+	if cmdComplete.Name == globals.Arg(0) {
+		return runComplete(opts, globals.Args()[1:])
+	}
+
+	cfg, err := loadConfig(opts)
+	if errors.Is(err, journal.ErrBusy) {
+		color.Errorf("%v\n", journal.ErrBusy)
+		return 5
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m %v\n", err)
-		os.Exit(1)
-		return
+		color.Errorf("%v\n", err)
+		return 1
 	}
 
 	defer cfg.Journal.Close()
 
 	// Validate if the container path exists
 	if _, err := os.Stat(cfg.ContainerPath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m Container path '%s' does not exist. Please check your configuration.\n", cfg.ContainerPath)
+		color.Errorf("Container path '%s' does not exist. Please check your configuration.\n", cfg.ContainerPath)
 		if err := os.MkdirAll(cfg.ContainerPath, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m Failed to create container directory '%s': %v\n", cfg.ContainerPath, err)
-			os.Exit(1)
-			return
+			color.Errorf("Failed to create container directory '%s': %v\n", cfg.ContainerPath, err)
+			return 1
 		}
 		fmt.Printf("Created container directory: %s\n", cfg.ContainerPath)
 	}
 
-	//Validate command line arguments
-	// if len(flag.Args()) < 1 {
-	// 	printGeneralHelp()
-	// 	os.Exit(0)
-	// 	return
-	// }
+	release, err := cfg.Lock()
+	if errors.Is(err, journal.ErrBusy) {
+		color.Errorf("%v\n", err)
+		return 5
+	}
+	if err != nil {
+		color.Errorf("%v\n", err)
+		return 1
+	}
+	defer release()
 
-	if cmdHelp.Name == flag.Arg(0) {
-		cmdHelp.Options.Parse(flag.Args()[1:])
+	if cmdHelp.Name == globals.Arg(0) {
+		cmdHelp.Options.Parse(globals.Args()[1:])
 		err := cmdHelp.Action(cmdHelp.Options.Args(), cfg)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m %v\n", err)
+			color.Errorf("%v\n", err)
 			printGeneralHelp()
-			os.Exit(2)
-			return
+			return 2
 		}
-		return
+		return 0
 	}
 
 	if !slices.ContainsFunc(commands, func(c *Command) bool {
-		return c.Name == flag.Arg(0)
+		return c.Name == globals.Arg(0)
 	}) {
-		if flag.Arg(0) == "" {
-			fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m Unknown command\n\n")
+		if globals.Arg(0) == "" {
+			color.Errorf("Unknown command\n\n")
 		} else {
-			fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m Unknown command '%s'\n\n", flag.Arg(0))
+			color.Errorf("Unknown command '%s'\n\n", globals.Arg(0))
 		}
 
 		printGeneralHelp()
-		os.Exit(1)
-		return
+		return 1
 	}
 
-	notifyExistingWipeables(cfg) // Notify about wipeable items in the dumpster
-
 	for _, cmd := range commands {
-		if cmd.Name == flag.Arg(0) {
-			cmd.Options.Parse(flag.Args()[1:])
+		if cmd.Name == globals.Arg(0) {
+			cmd.Options.Parse(globals.Args()[1:])
+
+			quiet := cmd.Quiet != nil && cmd.Quiet()
+			if cmd.AutoWipe != nil && cmd.AutoWipe() {
+				autoWipe(cfg, quiet)
+			}
+
+			if !quiet {
+				notifyExistingWipeables(cfg) // Notify about wipeable items in the dumpster
+			}
 
-			err := cmd.Action(cmd.Options.Args(), cfg)
+			err := runAction(cmd, cfg)
+			if cmd.EnforceQuota {
+				enforceQuota(cfg)
+			}
+			if errors.Is(err, context.Canceled) {
+				color.Errorf("%v\n", err)
+				return 130
+			}
+			if errors.Is(err, status.ErrWipeablePending) {
+				return 4
+			}
+			if errors.Is(err, journal.ErrItemNotFound) {
+				color.Errorf("%v\n", err)
+				return 3
+			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError:\033[0m %v\n", err)
-				os.Exit(2)
-				return
+				color.Errorf("%v\n", err)
+				return 2
 			}
 		}
 	}
+
+	return 0
+}
+
+// runAction runs the action of the command, with a context cancelled by
+// SIGINT or SIGTERM when the command supports it. The journal is closed by
+// run once the action returns.
+func runAction(cmd *Command, cfg *config.Config) error {
+	if cmd.ContextAction == nil {
+		return cmd.Action(cmd.Options.Args(), cfg)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	return cmd.ContextAction(ctx, cmd.Options.Args(), cfg)
+}
+
+// interruptContext returns a context cancelled by the first SIGINT or
+// SIGTERM. The signals then get their default handling back, so a second one
+// ends the process at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			color.Warnf("%v received, stopping after the current item (repeat to abort at once)\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// autoWipe runs the opt-in wipe of the expired items when it is due, summing
+// up what was removed unless the command output must stay clean.
+func autoWipe(cfg *config.Config, quiet bool) {
+	wiped, err := wipe.AutoWipe(cfg, time.Now())
+	if err != nil {
+		color.Warnf("auto-wipe: %v\n", err)
+	}
+	if len(wiped) == 0 || quiet {
+		return
+	}
+
+	var size int64
+	for _, record := range wiped {
+		size += record.Size
+	}
+	color.Noticef("Auto-wipe", "Wiped %d expired items (%s)", len(wiped), config.ReadableSize(uint64(size)))
+}
+
+// enforceQuota applies max_retention and max_size to the bin, each eviction
+// being reported on stderr.
+func enforceQuota(cfg *config.Config) {
+	if _, err := wipe.EnforceQuota(cfg); err != nil {
+		color.Warnf("error enforcing the bin quota: %v\n", err)
+	}
+}
+
+// warnSkipped reports a special file left out of a move across devices.
+func warnSkipped(path string, mode os.FileMode) {
+	color.Warnf("skipped %s %s, it can't be moved across devices\n", fsutil.SpecialKind(mode), path)
 }
 
 func notifyExistingWipeables(cfg *config.Config) {
-	if stats, err := cfg.Journal.FilterWipeable(); err == nil {
-		fmt.Printf("\033[33;1mNotice:\033[0m\033[33m Wipeable items in dumpster: %d\033[0m\n", len(stats))
+	if total, wipeable, _, _, _, err := cfg.Journal.Stats(); err == nil {
+		color.Noticef("Notice", "Wipeable items in dumpster: %d of %d", wipeable, total)
+	}
+
+	if err := notify.Run(cfg); err != nil {
+		color.Errorf("%v\n", err)
 	}
 }
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"rubbish/journal"
+	"rubbish/list"
+	"rubbish/status"
+	"rubbish/tosser"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupEnv points the configuration hierarchy at temporary files so tests never
+// touch the real system or user configuration.
+func setupEnv(t *testing.T, systemCfg string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	sys := filepath.Join(t.TempDir(), "config.cfg")
+	if err := os.WriteFile(sys, []byte(systemCfg), 0o644); err != nil {
+		t.Fatalf("write system config: %v", err)
+	}
+	orig := systemConfigPath
+	systemConfigPath = sys
+	t.Cleanup(func() { systemConfigPath = orig })
+
+	return home
+}
+
+func TestRun_GlobalContainerBeforeCommandIsHonored(t *testing.T) {
+	configured := filepath.Join(t.TempDir(), "configured")
+	setupEnv(t, "container_path = "+configured)
+	override := filepath.Join(t.TempDir(), "override")
+	defer status.Flags.Parse([]string{"-s=false"})
+
+	if code := run([]string{"--container", override, "status", "-s"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	if _, err := os.Stat(filepath.Join(override, ".journal")); err != nil {
+		t.Errorf("expected journal in overridden container: %v", err)
+	}
+	if _, err := os.Stat(configured); !os.IsNotExist(err) {
+		t.Errorf("configured container should not be used, got err=%v", err)
+	}
+}
+
+func TestLoadConfig_AppliesGlobalOptions(t *testing.T) {
+	setupEnv(t, "wipeout_time = 12")
+	override := t.TempDir()
+
+	cfg, err := loadConfig(&globalOptions{container: override})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	defer cfg.Journal.Close()
+
+	if cfg.ContainerPath != override {
+		t.Errorf("expected container %s, got %s", override, cfg.ContainerPath)
+	}
+	if cfg.WipeoutTime != 12 {
+		t.Errorf("expected configured wipeout time to be kept, got %d", cfg.WipeoutTime)
+	}
+}
+
+func TestLoadConfig_ConfigFileReplacesHierarchy(t *testing.T) {
+	home := setupEnv(t, "wipeout_time = 12\nmax_retention = 90")
+	os.MkdirAll(filepath.Join(home, ".config"), 0o755)
+	os.WriteFile(filepath.Join(home, ".config", "rubbish.cfg"), []byte("cleanup_interval = 9"), 0o644)
+
+	container := filepath.Join(t.TempDir(), "profile")
+	profile := filepath.Join(t.TempDir(), "profile.cfg")
+	os.WriteFile(profile, []byte("wipeout_time = 5\ncontainer_path = "+container), 0o644)
+	os.MkdirAll(container, 0o755)
+
+	cfg, err := loadConfig(&globalOptions{configFile: profile})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	defer cfg.Journal.Close()
+
+	if cfg.WipeoutTime != 5 || cfg.ContainerPath != container {
+		t.Errorf("expected the profile settings, got wipeout %d and container %s", cfg.WipeoutTime, cfg.ContainerPath)
+	}
+	if cfg.MaxRetention != 365 || cfg.CleanupInterval != 3 {
+		t.Errorf("the system and user files must be ignored in favour of the defaults, got max_retention %d, cleanup_interval %d",
+			cfg.MaxRetention, cfg.CleanupInterval)
+	}
+	if _, err := os.Stat(filepath.Join(container, ".journal")); err != nil {
+		t.Errorf("expected the journal in the profile container: %v", err)
+	}
+
+	if _, err := loadConfig(&globalOptions{configFile: filepath.Join(t.TempDir(), "missing.cfg")}); err == nil {
+		t.Error("expected a missing --config file to fail")
+	}
+}
+
+func TestRun_ConfigAndContainerOverride(t *testing.T) {
+	setupEnv(t, "")
+	profile := filepath.Join(t.TempDir(), "profile.cfg")
+	os.WriteFile(profile, []byte("container_path = "+filepath.Join(t.TempDir(), "unused")), 0o644)
+	override := filepath.Join(t.TempDir(), "override")
+	defer status.Flags.Parse([]string{"-s=false"})
+
+	if code := run([]string{"--config", profile, "--container", override, "status", "-s"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(override, ".journal")); err != nil {
+		t.Errorf("expected the journal in the overridden container: %v", err)
+	}
+}
+
+func TestLoadConfig_TimezoneOptions(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir()+"\ntimezone = Asia/Tokyo")
+
+	cfg, err := loadConfig(&globalOptions{utc: true})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	cfg.Journal.Close()
+	if cfg.Zone() != time.UTC {
+		t.Errorf("--utc must override the configured timezone, got %s", cfg.Zone())
+	}
+
+	if _, err := loadConfig(&globalOptions{utc: true, timezone: "Europe/Madrid"}); err == nil {
+		t.Error("expected error combining --utc and --tz")
+	}
+	if _, err := loadConfig(&globalOptions{timezone: "Nowhere/Land"}); err == nil {
+		t.Error("expected error for an unknown --tz zone")
+	}
+}
+
+func TestRun_UnknownGlobalFlag(t *testing.T) {
+	setupEnv(t, "")
+	if code := run([]string{"--bogus", "status"}); code != 1 {
+		t.Errorf("expected exit code 1 for unknown global flag, got %d", code)
+	}
+}
+
+func TestRun_MissingItemExitCode(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	if code := run([]string{"info", "ghost.txt_ABCDEF"}); code != 3 {
+		t.Errorf("expected exit code 3 for a missing item, got %d", code)
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	if code := run([]string{"nope"}); code != 1 {
+		t.Errorf("expected exit code 1 for unknown command, got %d", code)
+	}
+}
+
+func TestRun_MachineOutputSuppressesNotice(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	defer status.Flags.Parse([]string{"--output=text"})
+
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	code := run([]string{"status", "-s=false", "--output=json"})
+	w.Close()
+	os.Stdout = orig
+
+	out, _ := io.ReadAll(r)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !json.Valid(out) {
+		t.Errorf("expected pure json on stdout, got: %s", out)
+	}
+}
+
+func TestLoadConfig_ContainerAloneRederivesJournal(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	override := t.TempDir()
+
+	cfg, err := loadConfig(&globalOptions{container: override})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	defer cfg.Journal.Close()
+
+	if cfg.Journal.Path != filepath.Join(override, ".journal") {
+		t.Errorf("expected journal inside the overridden container, got %s", cfg.Journal.Path)
+	}
+	if warning := journalMismatch(cfg); warning != "" {
+		t.Errorf("unexpected warning: %s", warning)
+	}
+}
+
+func TestLoadConfig_MismatchedJournalWarns(t *testing.T) {
+	setupEnv(t, "")
+	container := t.TempDir()
+	elsewhere := filepath.Join(t.TempDir(), "journal")
+
+	cfg, err := loadConfig(&globalOptions{container: container, journal: elsewhere})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	defer cfg.Journal.Close()
+
+	if cfg.Journal.Path != elsewhere {
+		t.Errorf("expected explicit journal path, got %s", cfg.Journal.Path)
+	}
+	if warning := journalMismatch(cfg); warning == "" {
+		t.Error("expected a warning for a journal outside the container")
+	}
+
+	cfg.JournalPath = filepath.Join(container, ".journal")
+	if warning := journalMismatch(cfg); warning != "" {
+		t.Errorf("matching explicit journal must not warn: %s", warning)
+	}
+}
+
+func TestRun_CompleteItemsOfWorkingDir(t *testing.T) {
+	container := t.TempDir()
+	setupEnv(t, "container_path = "+container)
+	work := t.TempDir()
+	t.Chdir(work)
+
+	cfg, err := loadConfig(&globalOptions{})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	for _, record := range []*journal.MetaData{
+		{Item: "here.txt_ABCDEF", Origin: filepath.Join(work, "here.txt")},
+		{Item: "there.txt_ABCDEF", Origin: "/elsewhere/there.txt"},
+	} {
+		if err := cfg.Journal.AddRecord(record); err != nil {
+			t.Fatalf("add %s: %v", record.Item, err)
+		}
+	}
+	cfg.Journal.Close()
+
+	complete := func(words ...string) string {
+		orig := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		code := run(append([]string{"__complete"}, words...))
+		w.Close()
+		os.Stdout = orig
+		out, _ := io.ReadAll(r)
+		if code != 0 {
+			t.Fatalf("__complete %q exited with %d", words, code)
+		}
+		return string(out)
+	}
+
+	if out := complete("restore", ""); out != "here.txt_ABCDEF\n" {
+		t.Errorf("expected the working directory items only, got %q", out)
+	}
+	if out := complete("comp"); out != "completion\n" {
+		t.Errorf("expected the completion command, got %q", out)
+	}
+	if out := complete("completion", "f"); out != "fish\n" {
+		t.Errorf("expected the fish shell, got %q", out)
+	}
+}
+
+func TestRun_CompleteNeitherCreatesNorLocksTheContainer(t *testing.T) {
+	container := filepath.Join(t.TempDir(), "missing")
+	setupEnv(t, "container_path = "+container)
+
+	complete := func(words ...string) string {
+		orig := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		code := run(append([]string{"__complete"}, words...))
+		w.Close()
+		os.Stdout = orig
+		out, _ := io.ReadAll(r)
+		if code != 0 {
+			t.Fatalf("__complete %q exited with %d", words, code)
+		}
+		return string(out)
+	}
+
+	if out := complete("comp"); out != "completion\n" {
+		t.Errorf("expected the completion command alone, got %q", out)
+	}
+	if _, err := os.Stat(container); !os.IsNotExist(err) {
+		t.Errorf("expected the container not to be created, got %v", err)
+	}
+
+	// Another rubbish process holds the bin
+	cfg, err := loadConfig(&globalOptions{})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	defer cfg.Journal.Close()
+	release, err := cfg.Lock()
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	defer release()
+
+	if out := complete("completion", "f"); out != "fish\n" {
+		t.Errorf("expected the fish shell while the bin is busy, got %q", out)
+	}
+}
+
+func TestRun_NoColorPrintsPlainErrors(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	defer func() { color.Disabled = false }()
+
+	orig := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	code := run([]string{"--no-color", "nope"})
+	w.Close()
+	os.Stderr = orig
+
+	out, _ := io.ReadAll(r)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !color.Disabled {
+		t.Error("--no-color must disable colors")
+	}
+	if strings.Contains(string(out), "\033[") || !strings.Contains(string(out), "Error: Unknown command 'nope'") {
+		t.Errorf("expected a plain error, got %q", out)
+	}
+}
+
+func TestCompatCommands_TranslateArguments(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "existing.txt")
+	os.WriteFile(existing, []byte("x"), 0o644)
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	cases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"trash-put", []string{"-rv", "a.txt", "--", "-b.txt"}, []string{"toss", "--verbose", "--", "a.txt", "-b.txt"}},
+		{"trash-put", []string{"--trash-dir", "/bin2", "a.txt"}, []string{"--container", "/bin2", "toss", "--", "a.txt"}},
+		{"trash-put", []string{"-f", existing, missing}, []string{"toss", "-y", "--", existing}},
+		{"trash-put", []string{"-f", missing}, nil},
+		{"trash-put", []string{"--help"}, []string{"help", "toss"}},
+		{"trash-list", []string{"--trash-dir=/bin2"}, []string{"--container", "/bin2", "list", "--sort=date"}},
+		{"trash-restore", []string{"--overwrite"}, []string{"restore", "--override", "--interactive-list"}},
+		{"trash-empty", nil, []string{"wipe", "--empty"}},
+		{"trash-empty", []string{"-f", "30"}, []string{"wipe", "-y", "-g", "--older-than=30d"}},
+		{"trash-empty", []string{"--version"}, []string{"--version"}},
+	}
+	for _, c := range cases {
+		got, dir, err := compatCommands[c.name](c.args)
+		if err != nil || dir != "" {
+			t.Errorf("%s %v: unexpected error %v, or directory %q", c.name, c.args, err, dir)
+			continue
+		}
+		if strings.Join(got, " ") != strings.Join(c.want, " ") || (got == nil) != (c.want == nil) {
+			t.Errorf("%s %v: expected %q, got %q", c.name, c.args, c.want, got)
+		}
+	}
+
+	for name, args := range map[string][]string{
+		"trash-put":   {"-i", "a.txt"},
+		"trash-list":  {"extra"},
+		"trash-empty": {"soon"},
+	} {
+		if _, _, err := compatCommands[name](args); err == nil {
+			t.Errorf("%s %v: expected an error", name, args)
+		}
+	}
+}
+
+func TestCompatCommands_TrashRestoreRunsFromDirectory(t *testing.T) {
+	work, dir := t.TempDir(), t.TempDir()
+	t.Chdir(work)
+
+	got, runDir, err := compatCommands["trash-restore"]([]string{dir})
+	if err != nil || runDir != dir || strings.Join(got, " ") != "restore --interactive-list" {
+		t.Errorf("expected restore --interactive-list from %s, got %q from %q, %v", dir, got, runDir, err)
+	}
+	if wd, _ := os.Getwd(); wd != work {
+		t.Errorf("expected the translation to leave the working directory alone, got %s", wd)
+	}
+
+	if code := runAs("trash-restore", []string{filepath.Join(dir, "missing")}); code != 1 {
+		t.Errorf("expected a missing directory to fail, got exit code %d", code)
+	}
+}
+
+func TestRunAs_TrashPutTossesIntoContainer(t *testing.T) {
+	container := t.TempDir()
+	setupEnv(t, "container_path = "+container)
+	defer tosser.Flags.Parse([]string{"-y=false"})
+	defer list.Flags.Parse([]string{"--sort=name"})
+
+	file := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(file, []byte("notes"), 0o644)
+
+	if code := runAs("/usr/local/bin/trash-put", []string{"-f", file, file + ".missing"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be tossed, got err=%v", file, err)
+	}
+
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	code := runAs("trash-list", nil)
+	w.Close()
+	os.Stdout = orig
+
+	out, _ := io.ReadAll(r)
+	if code != 0 {
+		t.Errorf("expected trash-list to exit with 0, got %d", code)
+	}
+	if !strings.Contains(string(out), "notes.txt") {
+		t.Errorf("expected trash-list to list the tossed file, got:\n%s", out)
+	}
+}
+
+func TestRunAs_TrashPutMissingOperand(t *testing.T) {
+	setupEnv(t, "container_path = "+t.TempDir())
+	if code := runAs("trash-put", nil); code != 1 {
+		t.Errorf("expected exit code 1 without a file, got %d", code)
+	}
+	if code := runAs("trash-put", []string{"-f", filepath.Join(t.TempDir(), "missing")}); code != 0 {
+		t.Errorf("expected trash-put -f to ignore missing files, got %d", code)
+	}
+}
+
+func TestRun_RestoreDoesNotAutoWipe(t *testing.T) {
+	container := t.TempDir()
+	setupEnv(t, "container_path = "+container+"\nauto_wipe = true\ncleanup_interval = 1")
+	work := t.TempDir()
+	t.Chdir(work)
+
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatal(err)
+	}
+	record := &journal.MetaData{
+		Item:        "expired.txt_ABCDEF",
+		Origin:      filepath.Join(work, "expired.txt"),
+		Type:        journal.TypeFile,
+		WipeoutTime: 1,
+		TossedTime:  time.Now().Add(-48 * time.Hour).Unix(),
+	}
+	os.WriteFile(filepath.Join(container, record.Item), []byte("keep me"), 0o644)
+	j.AddRecord(record)
+	j.Close()
+
+	if code := run([]string{"restore", record.Item}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if data, err := os.ReadFile(record.Origin); err != nil || string(data) != "keep me" {
+		t.Errorf("the expired item must be restored, not auto-wiped: %q, %v", data, err)
+	}
+}
+
+func TestRun_TossEnforcesQuota(t *testing.T) {
+	container := t.TempDir()
+	setupEnv(t, "container_path = "+container+"\nmax_size = 10B\nundo_window = 0")
+	defer tosser.Flags.Parse([]string{"-y=false"})
+
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatal(err)
+	}
+	record := &journal.MetaData{
+		Item:        "old.txt_ABCDEF",
+		Origin:      filepath.Join(t.TempDir(), "old.txt"),
+		Type:        journal.TypeFile,
+		WipeoutTime: 1,
+		TossedTime:  time.Now().Add(-48 * time.Hour).Unix(),
+	}
+	os.WriteFile(filepath.Join(container, record.Item), []byte("older than the quota"), 0o644)
+	j.AddRecord(record)
+	j.Close()
+
+	file := filepath.Join(t.TempDir(), "new.txt")
+	os.WriteFile(file, []byte("new"), 0o644)
+	if code := run([]string{"toss", "-y", file}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(container, record.Item)); !os.IsNotExist(err) {
+		t.Errorf("expected the wipeable item to be evicted once over max_size, got err=%v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"time"
+)
+
+// notifiedState is the journal state holding the items already notified
+const notifiedState = "notified"
+
+var (
+	// notifySend is the desktop notification command, replaceable for testing
+	notifySend = "notify-send"
+)
+
+// Pending returns the items that are wipeable or will become wipeable within
+// the configured number of days in advance.
+func Pending(cfg *config.Config) ([]*journal.MetaData, error) {
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	horizon := time.Duration(cfg.Notification.DaysInAdvance*24) * time.Hour
+
+	var pending []*journal.MetaData
+	for _, record := range records {
+		if record.RemainingTime() <= horizon {
+			pending = append(pending, record)
+		}
+	}
+	return pending, nil
+}
+
+// Run notifies the user about the pending items that were not notified in a
+// previous invocation. It does nothing unless notifications are enabled.
+// Notifications are sent to the desktop and fall back to stdout when no
+// desktop notification service is available.
+func Run(cfg *config.Config) error {
+	if !cfg.Notification.Enabled {
+		return nil
+	}
+
+	pending, err := Pending(cfg)
+	if err != nil {
+		return err
+	}
+
+	notified, err := loadNotified(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Only keep track of items still pending, so restored or wiped items
+	// get notified again if they are ever tossed back.
+	current := make(map[string]int64, len(pending))
+	var fresh []*journal.MetaData
+	for _, record := range pending {
+		if at, ok := notified[record.Item]; ok {
+			current[record.Item] = at
+			continue
+		}
+		current[record.Item] = time.Now().Unix()
+		fresh = append(fresh, record)
+	}
+
+	if len(fresh) > 0 {
+		send("Rubbish", message(fresh), cfg.Notification.Timeout)
+	}
+
+	return saveNotified(cfg, current)
+}
+
+// message describes the items about to be wiped, one per line.
+func message(records []*journal.MetaData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d items will be wiped soon:", len(records))
+	for _, record := range records {
+		remaining := record.RemainingTime()
+		if remaining <= 0 {
+			fmt.Fprintf(&b, "\n%s (wipeable)", record.Item)
+		} else {
+			fmt.Fprintf(&b, "\n%s (in %.1fd)", record.Item, remaining.Hours()/24)
+		}
+	}
+	return b.String()
+}
+
+// send displays the notification on the desktop for timeout seconds,
+// printing it to stdout if the desktop notification fails.
+func send(title string, body string, timeout int) {
+	err := exec.Command(notifySend, "-a", "rubbish", "-t", fmt.Sprint(timeout*1000), title, body).Run()
+	if err != nil {
+		color.Noticef(title, "%s", body)
+	}
+}
+
+func loadNotified(cfg *config.Config) (map[string]int64, error) {
+	notified := map[string]int64{}
+
+	data, err := cfg.Journal.State(notifiedState)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notification state: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &notified); err != nil {
+			return nil, fmt.Errorf("error decoding notification state: %w", err)
+		}
+	}
+	return notified, nil
+}
+
+func saveNotified(cfg *config.Config, notified map[string]int64) error {
+	data, err := json.Marshal(notified)
+	if err != nil {
+		return fmt.Errorf("error encoding notification state: %w", err)
+	}
+	return cfg.Journal.SetState(notifiedState, data)
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(dir, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	cfg := &config.Config{ContainerPath: dir, Journal: j}
+	cfg.Notification.Enabled = true
+	cfg.Notification.DaysInAdvance = 3
+	cfg.Notification.Timeout = 5
+	return cfg
+}
+
+// stubNotifier replaces notify-send by a script appending its arguments to a log file.
+func stubNotifier(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls.log")
+	script := filepath.Join(dir, "notify-send")
+	os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+log+"\n"), 0o755)
+
+	orig := notifySend
+	notifySend = script
+	t.Cleanup(func() { notifySend = orig })
+	return log
+}
+
+func add(t *testing.T, cfg *config.Config, item string, wipeDays int, tossedAgo time.Duration) {
+	t.Helper()
+	err := cfg.Journal.AddRecord(&journal.MetaData{
+		Item: item, Origin: "/o/" + item, WipeoutTime: wipeDays,
+		TossedTime: time.Now().Add(-tossedAgo).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("add %s: %v", item, err)
+	}
+}
+
+func TestPending_SelectsWipeableAndApproaching(t *testing.T) {
+	cfg := newTestCfg(t)
+	add(t, cfg, "overdue.txt", 1, 48*time.Hour)
+	add(t, cfg, "soon.txt", 2, time.Hour)
+	add(t, cfg, "later.txt", 30, time.Hour)
+
+	pending, err := Pending(cfg)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+
+	var names []string
+	for _, record := range pending {
+		names = append(names, record.Item)
+	}
+	got := strings.Join(names, ",")
+	if got != "overdue.txt,soon.txt" {
+		t.Errorf("unexpected pending items: %s", got)
+	}
+}
+
+func TestRun_NotifiesOnceAndHonorsTimeout(t *testing.T) {
+	cfg := newTestCfg(t)
+	log := stubNotifier(t)
+	add(t, cfg, "soon.txt", 2, time.Hour)
+	add(t, cfg, "later.txt", 30, time.Hour)
+
+	if err := Run(cfg); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	if !strings.Contains(string(data), "-t 5000") || !strings.Contains(string(data), "soon.txt") {
+		t.Errorf("unexpected notification: %s", data)
+	}
+	if strings.Contains(string(data), "later.txt") {
+		t.Errorf("item out of the notification window was notified: %s", data)
+	}
+
+	// second run must not notify the same item again
+	if err := Run(cfg); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, _ = os.ReadFile(log)
+	if calls := strings.Count(string(data), "soon.txt"); calls != 1 {
+		t.Errorf("expected a single notification, got %d", calls)
+	}
+
+	// a newly approaching item triggers a new notification with only that item
+	add(t, cfg, "new.txt", 1, time.Hour)
+	Run(cfg)
+	data, _ = os.ReadFile(log)
+	if !strings.Contains(string(data), "new.txt") || strings.Count(string(data), "soon.txt") != 1 {
+		t.Errorf("unexpected notifications: %s", data)
+	}
+}
+
+func TestRun_FallsBackToStdout(t *testing.T) {
+	cfg := newTestCfg(t)
+	notifySend = filepath.Join(t.TempDir(), "missing")
+	defer func() { notifySend = "notify-send" }()
+	add(t, cfg, "soon.txt", 1, time.Hour)
+
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := Run(cfg)
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(buf.String(), "soon.txt") {
+		t.Errorf("expected stdout fallback, got: %s", buf.String())
+	}
+}
+
+func TestRun_Disabled(t *testing.T) {
+	cfg := newTestCfg(t)
+	log := stubNotifier(t)
+	cfg.Notification.Enabled = false
+	add(t, cfg, "soon.txt", 1, time.Hour)
+
+	Run(cfg)
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Error("no notification expected when disabled")
+	}
+}
@@ -0,0 +1,167 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is returned when no answer arrives within Timeout.
+var ErrTimeout = errors.New("no answer before the confirmation timeout")
+
+var (
+	// Input is the source of the user answers, replaceable for testing
+	Input io.Reader = os.Stdin
+
+	// Interactive reports whether the user can answer prompts, replaceable for testing
+	Interactive = isTerminal
+
+	// Timeout is how long to wait for an answer, zero waits forever
+	Timeout time.Duration
+
+	lines       chan answer
+	linesErr    error
+	linesSource io.Reader
+)
+
+// answer is a line read from Input, or the error which ended the input.
+type answer struct {
+	line string
+	err  error
+}
+
+// isTerminal reports whether the standard input is attached to a terminal.
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ReadLine prints the question and returns the next line typed by the user,
+// without the trailing newline. Lines are read in the background and kept
+// between calls so scripted inputs holding several answers are consumed line by
+// line, and so the wait can be abandoned with ErrTimeout once Timeout elapses.
+func ReadLine(question string) (string, error) {
+	if lines == nil || linesSource != Input {
+		lines = readLines(Input)
+		linesErr = nil
+		linesSource = Input
+	}
+
+	fmt.Print(question)
+
+	var timeout <-chan time.Time
+	if Timeout > 0 {
+		timer := time.NewTimer(Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case answer, ok := <-lines:
+		if !ok {
+			return "", linesErr
+		}
+		if answer.err != nil {
+			linesErr = answer.err
+		}
+		return answer.line, answer.err
+	case <-timeout:
+		fmt.Println()
+		return "", ErrTimeout
+	}
+}
+
+// readLines sends every line of r on the returned channel, followed by the
+// error which ended the input, after which the channel is closed.
+func readLines(r io.Reader) chan answer {
+	ch := make(chan answer)
+
+	go func() {
+		defer close(ch)
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				ch <- answer{err: err}
+				return
+			}
+			ch <- answer{line: strings.TrimRight(line, "\r\n")}
+		}
+	}()
+
+	return ch
+}
+
+// Confirm asks a yes/no question, only an explicit "y" or "Y" is taken as a yes.
+// Without an answer before Timeout, the question is declined.
+func Confirm(question string) (bool, error) {
+	answer, err := ReadLine(question + " [y/N]: ")
+	if errors.Is(err, ErrTimeout) {
+		fmt.Println("No answer received in time, declining.")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	answer = strings.TrimSpace(answer)
+	return answer == "y" || answer == "Y", nil
+}
+
+// ParseSelection parses a list of 1-based indices and ranges such as "1,3,5-7"
+// or "2 4" into a sorted list of unique indices, all within [1, max].
+func ParseSelection(input string, max int) ([]int, error) {
+	seen := map[int]bool{}
+
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection")
+	}
+
+	for _, field := range fields {
+		from, to, isRange := strings.Cut(field, "-")
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection '%s'", field)
+		}
+		end := start
+
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection '%s'", field)
+			}
+		}
+
+		if start > end {
+			return nil, fmt.Errorf("invalid range '%s'", field)
+		}
+		if start < 1 || end > max {
+			return nil, fmt.Errorf("selection '%s' out of range 1-%d", field, max)
+		}
+
+		for i := start; i <= end; i++ {
+			seen[i] = true
+		}
+	}
+
+	selection := make([]int, 0, len(seen))
+	for i := range seen {
+		selection = append(selection, i)
+	}
+	sort.Ints(selection)
+
+	return selection, nil
+}
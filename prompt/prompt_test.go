@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSelection(t *testing.T) {
+	cases := []struct {
+		in   string
+		max  int
+		want []int
+	}{
+		{"1", 3, []int{1}},
+		{"1,3,5-7", 7, []int{1, 3, 5, 6, 7}},
+		{"2 4", 5, []int{2, 4}},
+		{"3-3, 1,1", 3, []int{1, 3}},
+	}
+	for _, c := range cases {
+		got, err := ParseSelection(c.in, c.max)
+		if err != nil {
+			t.Errorf("ParseSelection(%q) error: %v", c.in, err)
+			continue
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("ParseSelection(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseSelection_Invalid(t *testing.T) {
+	for _, in := range []string{"", "a", "0", "4", "3-1", "1-x", "2-9"} {
+		if _, err := ParseSelection(in, 3); err == nil {
+			t.Errorf("ParseSelection(%q) expected error", in)
+		}
+	}
+}
+
+func TestReadLineAndConfirm(t *testing.T) {
+	orig := Input
+	Input = strings.NewReader("first\ny\nno\n")
+	defer func() { Input = orig }()
+
+	line, err := ReadLine("")
+	if err != nil || line != "first" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+	if ok, _ := Confirm("sure?"); !ok {
+		t.Error("expected confirmation for 'y'")
+	}
+	if ok, _ := Confirm("sure?"); ok {
+		t.Error("expected no confirmation for 'no'")
+	}
+	if _, err := Confirm("sure?"); err == nil {
+		t.Error("expected error once input is exhausted")
+	}
+}
+
+func TestConfirm_TimeoutDeclines(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	orig := Input
+	Input = r
+	Timeout = 50 * time.Millisecond
+	defer func() { Input, Timeout = orig, 0 }()
+
+	start := time.Now()
+	ok, err := Confirm("sure?")
+	if err != nil || ok {
+		t.Fatalf("expected the safe default on timeout, got %v, %v", ok, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("confirmation waited %v despite the timeout", elapsed)
+	}
+
+	if _, err := ReadLine(""); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout from ReadLine, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package rename implements the rename command, which gives a tossed item a
+// memorable name in the container.
+package rename
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"strings"
+)
+
+var (
+	Flags   *flag.FlagSet = flag.NewFlagSet("rename", flag.ExitOnError)
+	binName string        = "" // binName is the named bin holding the item, empty for the default bin
+)
+
+func init() {
+	Flags.StringVar(&binName, "bin", "", "Rename an item of the named bin instead of the default one.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish rename gives a tossed item a new name, to restore or wipe it by that name later.\n",
+			"Usage:\n\n",
+			"\trubbish rename [options] <item> <new-name>\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+func Command(args []string, cfg *config.Config) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected the item and its new name, got %d arguments", len(args))
+	}
+
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	if err := Rename(cfg, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s to %s.\n", args[0], args[1])
+	return nil
+}
+
+// validateName checks the new name can be used as an item at the root of the
+// container.
+func validateName(name string, cfg *config.Config) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid item name '%s'", name)
+	}
+	if cfg.Reserved(name) {
+		return fmt.Errorf("item name '%s' is reserved", name)
+	}
+	return nil
+}
+
+// Rename moves an item and its record under a new name. The record is moved
+// first, then the item; a failed move of the item moves the record back, so
+// both always agree.
+func Rename(cfg *config.Config, oldItem string, newItem string) error {
+	if err := validateName(newItem, cfg); err != nil {
+		return err
+	}
+
+	if _, err := cfg.Journal.Get(oldItem); err != nil {
+		return err
+	}
+	if _, err := cfg.Journal.Get(newItem); err == nil {
+		return fmt.Errorf("%w: '%s' in the rubbish", journal.ErrItemExists, newItem)
+	} else if !errors.Is(err, journal.ErrItemNotFound) {
+		return err
+	}
+	if _, err := os.Lstat(cfg.ItemPath(newItem)); err == nil {
+		return fmt.Errorf("%s already exists in the container", newItem)
+	}
+
+	if err := cfg.Journal.Rename(oldItem, newItem); err != nil {
+		return fmt.Errorf("error renaming record of %s: %w", oldItem, err)
+	}
+
+	if err := fsutil.Rename(cfg.ItemPath(oldItem), cfg.ItemPath(newItem)); err != nil {
+		if rerr := cfg.Journal.Rename(newItem, oldItem); rerr != nil {
+			return fmt.Errorf("error renaming %s: %v (record left as %s: %v)", oldItem, err, newItem, rerr)
+		}
+		return fmt.Errorf("error renaming %s: %w", oldItem, err)
+	}
+	cfg.PruneItemParents(oldItem)
+
+	if cfg.XDG() {
+		record, err := cfg.Journal.Get(newItem)
+		if err != nil {
+			return err
+		}
+		if err := cfg.WriteTrashInfo(record); err != nil {
+			return fmt.Errorf("error writing trash info of %s: %w", newItem, err)
+		}
+		return cfg.RemoveTrashInfo(oldItem)
+	}
+	return nil
+}
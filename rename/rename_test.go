@@ -0,0 +1,86 @@
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"testing"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &config.Config{ContainerPath: container, Journal: j}
+}
+
+func store(t *testing.T, cfg *config.Config, item string) {
+	t.Helper()
+	os.WriteFile(cfg.ItemPath(item), []byte(item), 0o644)
+	if err := cfg.Journal.AddRecord(&journal.MetaData{Item: item, Origin: "/tmp/" + item}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	cfg := newTestCfg(t)
+	store(t, cfg, "a.txt_ABCDEF")
+
+	if err := Rename(cfg, "a.txt_ABCDEF", "keep-me"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if data, err := os.ReadFile(cfg.ItemPath("keep-me")); err != nil || string(data) != "a.txt_ABCDEF" {
+		t.Errorf("expected the item under its new name, got %q, %v", data, err)
+	}
+	if _, err := os.Lstat(cfg.ItemPath("a.txt_ABCDEF")); !os.IsNotExist(err) {
+		t.Errorf("old item must be gone, got %v", err)
+	}
+	if record, err := cfg.Journal.Get("keep-me"); err != nil || record.Origin != "/tmp/a.txt_ABCDEF" {
+		t.Errorf("expected the record under its new name, got %+v, %v", record, err)
+	}
+}
+
+func TestRename_RejectsCollisions(t *testing.T) {
+	cfg := newTestCfg(t)
+	store(t, cfg, "a.txt_ABCDEF")
+	store(t, cfg, "b.txt_ABCDEF")
+	os.WriteFile(cfg.ItemPath("orphan"), nil, 0o644)
+
+	for _, name := range []string{"b.txt_ABCDEF", "orphan", "sub/name", "..", ".journal", ""} {
+		if err := Rename(cfg, "a.txt_ABCDEF", name); err == nil {
+			t.Errorf("renaming to %q must fail", name)
+		}
+	}
+	if err := Rename(cfg, "missing", "c.txt"); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+	if _, err := cfg.Journal.Get("a.txt_ABCDEF"); err != nil {
+		t.Errorf("failed renames must keep the record: %v", err)
+	}
+}
+
+func TestRename_RollsBackOnFailure(t *testing.T) {
+	cfg := newTestCfg(t)
+	store(t, cfg, "a.txt_ABCDEF")
+
+	orig := fsutil.Rename
+	fsutil.Rename = func(string, string) error { return errors.New("device busy") }
+	defer func() { fsutil.Rename = orig }()
+
+	if err := Rename(cfg, "a.txt_ABCDEF", "keep-me"); err == nil {
+		t.Fatal("expected the failed move to be reported")
+	}
+	if _, err := cfg.Journal.Get("a.txt_ABCDEF"); err != nil {
+		t.Errorf("the record must be moved back: %v", err)
+	}
+	if _, err := cfg.Journal.Get("keep-me"); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("the new key must not remain, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package restorer
+
+import (
+	"fmt"
+	"time"
+
+	"rubbish/config"
+)
+
+// restoreAllItems restores every item tossed from the working directory tree to
+// its origin. Older versions of an origin tossed several times, and items
+// whose origin lies outside the working directory, are skipped.
+func restoreAllItems(cfg *config.Config) error {
+	local_rubbish, err := cfg.Journal.FilterPath(cfg.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error retrieving local rubbish: %v", err)
+	}
+
+	records := snapshotRecords(local_rubbish, cfg.WorkingDir, time.Time{})
+
+	restored, err := restoreToOrigins(records, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !silent && !dryRun {
+		fmt.Printf("Restored: %d | Skipped: %d\n", restored, len(local_rubbish)-restored)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/journal"
+)
+
+func TestCommand_AllRestoresEveryLocalItem(t *testing.T) {
+	cfg := newTestCfg(t)
+	nested := seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "sub", "deep", "a.txt"), time.Now().AddDate(0, 0, -2))
+	top := seedTossed(t, cfg, "b.txt", filepath.Join(cfg.WorkingDir, "b.txt"), time.Now())
+	older := seedTossed(t, cfg, "b_old.txt", top.Origin, time.Now().AddDate(0, 0, -1))
+	outside := seedTossed(t, cfg, "c.txt", filepath.Join(t.TempDir(), "c.txt"), time.Now())
+
+	restoreAll = true
+	defer func() { restoreAll = false }()
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, record := range []*journal.MetaData{nested, top} {
+		if data, err := os.ReadFile(record.Origin); err != nil || !strings.HasPrefix(record.Item, string(data)) {
+			t.Errorf("%s not restored to its origin: %v", record.Item, err)
+		}
+	}
+	for _, record := range []*journal.MetaData{older, outside} {
+		if _, err := cfg.Journal.Get(record.Item); err != nil {
+			t.Errorf("%s must stay in the journal: %v", record.Item, err)
+		}
+	}
+	if !strings.Contains(out, "Restored: 2 | Skipped: 1") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+}
+
+func TestCommand_AllAbortsOnConflict(t *testing.T) {
+	cfg := newTestCfg(t)
+	first := seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "a.txt"), time.Now())
+	taken := seedTossed(t, cfg, "z.txt", filepath.Join(cfg.WorkingDir, "z.txt"), time.Now())
+	os.WriteFile(taken.Origin, []byte("mine"), 0o644)
+
+	restoreAll = true
+	defer func() { restoreAll = false }()
+
+	err := Command(nil, cfg)
+	if err == nil || !strings.Contains(err.Error(), taken.Origin) {
+		t.Fatalf("expected conflict error naming %s, got %v", taken.Origin, err)
+	}
+	if _, err := os.Stat(first.Origin); err == nil {
+		t.Error("nothing must be restored when a conflict is found")
+	}
+
+	override = true
+	defer func() { override = false }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error with override: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(taken.Origin); string(data) != "z.txt" {
+		t.Errorf("conflicting file not replaced in override mode, got %q", data)
+	}
+}
+
+func TestCommand_AllSilent(t *testing.T) {
+	cfg := newTestCfg(t)
+	seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "a.txt"), time.Now())
+
+	restoreAll, silent = true, true
+	defer func() { restoreAll, silent = false, false }()
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if strings.Contains(out, "Restoring file") || strings.Contains(out, "Restored:") {
+		t.Errorf("silent mode must not report restored items, got:\n%s", out)
+	}
+}
@@ -0,0 +1,34 @@
+package restorer
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"sort"
+)
+
+// restoreBatchItems restores every item tossed in the given batch to its origin,
+// wherever it was tossed from.
+func restoreBatchItems(batch string, cfg *config.Config) error {
+	all, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %v", err)
+	}
+
+	var records []*journal.MetaData
+	for _, record := range all {
+		if record.Batch == batch {
+			records = append(records, record)
+		}
+	}
+
+	if len(records) == 0 {
+		return fmt.Errorf("no items found in batch %s", batch)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Origin < records[j].Origin
+	})
+	_, err = restoreToOrigins(records, cfg)
+	return err
+}
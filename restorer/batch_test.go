@@ -0,0 +1,67 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rubbish/tosser"
+)
+
+func TestCommand_BatchRestoresItemsTossedTogether(t *testing.T) {
+	cfg := newTestCfg(t)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		os.WriteFile(filepath.Join(cfg.WorkingDir, name), []byte(name), 0o644)
+	}
+
+	tosser.Flags.Parse([]string{"-s"})
+	defer tosser.Flags.Parse([]string{"-s=false"})
+	if err := tosser.Command([]string{"a.txt", "b.txt"}, cfg); err != nil {
+		t.Fatalf("toss a, b: %v", err)
+	}
+	if err := tosser.Command([]string{"c.txt"}, cfg); err != nil {
+		t.Fatalf("toss c: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	batches := map[string]string{}
+	for _, record := range records {
+		batches[filepath.Base(record.Origin)] = record.Batch
+	}
+	if batches["a.txt"] == "" || batches["a.txt"] != batches["b.txt"] {
+		t.Fatalf("items tossed together must share a batch: %v", batches)
+	}
+	if batches["c.txt"] == batches["a.txt"] {
+		t.Fatalf("separate tosses must get their own batch: %v", batches)
+	}
+
+	restoreBatch = batches["a.txt"]
+	defer func() { restoreBatch = "" }()
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if data, err := os.ReadFile(filepath.Join(cfg.WorkingDir, name)); err != nil || string(data) != name {
+			t.Errorf("%s not restored: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDir, "c.txt")); err == nil {
+		t.Error("c.txt belongs to another batch and must stay tossed")
+	}
+	if count, _ := cfg.Journal.Count(); count != 1 {
+		t.Errorf("expected only c.txt left in the journal, got %d", count)
+	}
+}
+
+func TestCommand_BatchUnknown(t *testing.T) {
+	cfg := newTestCfg(t)
+	restoreBatch = "20240115-093000-NONE"
+	defer func() { restoreBatch = "" }()
+
+	if err := Command(nil, cfg); err == nil {
+		t.Fatal("expected error for an unknown batch")
+	}
+}
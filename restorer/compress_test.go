@@ -0,0 +1,68 @@
+package restorer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rubbish/config"
+	"rubbish/tosser"
+)
+
+func TestCommand_CompressedRoundTrip(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Compress = true
+
+	content := bytes.Repeat([]byte("2024-01-15 INFO request served\n"), 2000)
+	os.WriteFile("app.log", content, 0o640)
+	os.MkdirAll(filepath.Join("logs", "old"), 0o755)
+	os.WriteFile(filepath.Join("logs", "old", "a.log"), content, 0o644)
+	os.WriteFile("photo.jpg", []byte("not really a jpeg"), 0o644)
+
+	for _, item := range []string{"app.log", "logs", "photo.jpg"} {
+		if err := tosser.Toss(item, cfg); err != nil {
+			t.Fatalf("Toss %s: %v", item, err)
+		}
+	}
+
+	records, _ := cfg.Journal.List()
+	var keys []string
+	for _, record := range records {
+		keys = append(keys, record.Item)
+		if want := filepath.Base(record.Origin) != "photo.jpg"; record.Compressed != want {
+			t.Errorf("%s: Compressed = %v, want %v", record.Origin, record.Compressed, want)
+		}
+		if record.Compressed && config.RecordSize(cfg, record) >= record.Size {
+			t.Errorf("%s must take less room compressed: %d of %d bytes", record.Origin, config.RecordSize(cfg, record), record.Size)
+		}
+	}
+	if size, _ := config.BinSize(cfg); size >= int64(len(content)) {
+		t.Errorf("the bin size must reflect the compressed items, got %d", size)
+	}
+
+	if err := Flags.Parse(keys); err != nil {
+		t.Fatal(err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+
+	if data, err := os.ReadFile("app.log"); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("app.log not restored intact: %v", err)
+	}
+	if info, err := os.Stat("app.log"); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("app.log mode not kept: %v, %v", info, err)
+	}
+	if data, err := os.ReadFile(filepath.Join("logs", "old", "a.log")); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("logs/old/a.log not restored intact: %v", err)
+	}
+	if data, err := os.ReadFile("photo.jpg"); err != nil || string(data) != "not really a jpeg" {
+		t.Errorf("photo.jpg not restored: %v", err)
+	}
+	if items, _ := config.ContainerItems(cfg); len(items) != 0 {
+		t.Errorf("the container must be empty after the restore, got %v", items)
+	}
+}
@@ -0,0 +1,109 @@
+package restorer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"slices"
+	"strings"
+)
+
+// Policies applied by --on-conflict when the destination of an item is taken.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+	ConflictAsk       = "ask"
+)
+
+// maxRenameAttempts bounds the numeric suffixes tried by the rename policy
+const maxRenameAttempts = 1000
+
+// validateConflictPolicy checks the --on-conflict value and its consistency
+// with --override.
+func validateConflictPolicy() error {
+	if onConflict == "" {
+		return nil
+	}
+	if !slices.Contains([]string{ConflictSkip, ConflictOverwrite, ConflictRename, ConflictAsk}, onConflict) {
+		return fmt.Errorf("invalid --on-conflict '%s' (expected skip, overwrite, rename or ask)", onConflict)
+	}
+	if override && onConflict != ConflictOverwrite {
+		return fmt.Errorf("--override cannot be combined with --on-conflict=%s", onConflict)
+	}
+	if onConflict == ConflictAsk && !prompt.Interactive() {
+		return fmt.Errorf("--on-conflict=ask requires a terminal, choose another policy")
+	}
+	return nil
+}
+
+// conflictPolicy returns the policy in effect: --on-conflict when given,
+// overwrite in override mode, skip otherwise.
+func conflictPolicy() string {
+	switch {
+	case onConflict != "":
+		return onConflict
+	case override:
+		return ConflictOverwrite
+	default:
+		return ConflictSkip
+	}
+}
+
+// resolveConflict applies the conflict policy to an item whose destination
+// is taken. It returns the path to restore the item to, empty to skip it.
+func resolveConflict(record *journal.MetaData, destination string) (string, error) {
+	policy := conflictPolicy()
+	if policy == ConflictAsk {
+		answer, err := prompt.ReadLine(fmt.Sprintf("%s already exists: [o]verwrite, [r]ename or [s]kip? ", destination))
+		if errors.Is(err, prompt.ErrTimeout) {
+			fmt.Println("No answer received in time, skipping.")
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading answer for %s: %v", record.Item, err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "o", "overwrite":
+			policy = ConflictOverwrite
+		case "r", "rename":
+			policy = ConflictRename
+		default:
+			policy = ConflictSkip
+		}
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		return destination, nil
+	case ConflictRename:
+		return freeName(destination)
+	default:
+		if !silent {
+			fmt.Printf("File %s restoring to %s and already exists in the current directory. Use --override to replace it.\n", record.Item, destination)
+		}
+		return "", nil
+	}
+}
+
+// freeName returns the first name not taken among file(1).txt, file(2).txt
+// and so on for the given path, the numeric suffix going before the extension.
+func freeName(path string) (string, error) {
+	ext := filepath.Ext(path)
+	if ext == filepath.Base(path) {
+		ext = "" // dotfiles such as .bashrc have no extension
+	}
+	stem := strings.TrimSuffix(path, ext)
+
+	for n := 1; n <= maxRenameAttempts; n++ {
+		candidate := fmt.Sprintf("%s(%d)%s", stem, n, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name found for %s after %d attempts", path, maxRenameAttempts)
+}
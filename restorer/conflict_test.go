@@ -0,0 +1,117 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// restoreWithPolicy restores the item of a seeded record over an existing
+// report.txt with the given flags. It returns the working directory and
+// whether the record is still journaled.
+func restoreWithPolicy(t *testing.T, input string, flags ...string) (string, bool) {
+	t.Helper()
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "report.txt")
+	existing := filepath.Join(cfg.WorkingDir, "report.txt")
+	os.WriteFile(existing, []byte("existing"), 0o644)
+	if input != "" {
+		scriptInput(t, input)
+	}
+
+	if err := Flags.Parse(append(flags, record.Item)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { onConflict, override = "", false })
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	_, err := cfg.Journal.Get(record.Item)
+	return cfg.WorkingDir, err == nil
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestOnConflict_Skip(t *testing.T) {
+	dir, journaled := restoreWithPolicy(t, "", "--on-conflict=skip")
+	if readFile(t, dir, "report.txt") != "existing" || !journaled {
+		t.Error("skip must keep the existing file and the journal entry")
+	}
+}
+
+func TestOnConflict_Overwrite(t *testing.T) {
+	dir, journaled := restoreWithPolicy(t, "", "--on-conflict=overwrite")
+	if readFile(t, dir, "report.txt") != "report.txt" || journaled {
+		t.Error("overwrite must replace the existing file and delete the journal entry")
+	}
+}
+
+func TestOnConflict_Rename(t *testing.T) {
+	dir, journaled := restoreWithPolicy(t, "", "--on-conflict=rename")
+	if readFile(t, dir, "report.txt") != "existing" || readFile(t, dir, "report(1).txt") != "report.txt" || journaled {
+		t.Error("rename must restore next to the existing file as report(1).txt")
+	}
+}
+
+func TestOnConflict_Ask(t *testing.T) {
+	dir, journaled := restoreWithPolicy(t, "r\n", "--on-conflict=ask")
+	if readFile(t, dir, "report(1).txt") != "report.txt" || journaled {
+		t.Error("answering rename must restore as report(1).txt")
+	}
+
+	dir, journaled = restoreWithPolicy(t, "s\n", "--on-conflict=ask")
+	if readFile(t, dir, "report.txt") != "existing" || !journaled {
+		t.Error("answering skip must keep the existing file")
+	}
+
+	dir, journaled = restoreWithPolicy(t, "o\n", "--on-conflict=ask")
+	if readFile(t, dir, "report.txt") != "report.txt" || journaled {
+		t.Error("answering overwrite must replace the existing file")
+	}
+}
+
+func TestOnConflict_Invalid(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "report.txt")
+	defer func() { onConflict, override = "", false }()
+
+	for _, flags := range [][]string{{"--on-conflict=merge"}, {"--override", "--on-conflict=rename"}} {
+		onConflict, override = "", false
+		if err := Flags.Parse(append(flags, record.Item)); err != nil {
+			t.Fatal(err)
+		}
+		if err := Command(nil, cfg); err == nil {
+			t.Errorf("expected error for %v", flags)
+		}
+	}
+}
+
+func TestFreeName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "a(1).txt", ".bashrc"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+
+	cases := map[string]string{
+		"a.txt":   "a(2).txt",
+		".bashrc": ".bashrc(1)",
+		"b.txt":   "b(1).txt",
+	}
+	for name, want := range cases {
+		got, err := freeName(filepath.Join(dir, name))
+		if err != nil || got != filepath.Join(dir, want) {
+			t.Errorf("freeName(%s) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+}
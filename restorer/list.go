@@ -0,0 +1,58 @@
+package restorer
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// listRestorable prints the items restore accepts from the current directory,
+// or the whole rubbish with -g, newest first: the key to pass to restore, the
+// path the item would be restored to and when it was tossed. Nothing is
+// changed.
+func listRestorable(cfg *config.Config) error {
+	records, err := retrieveRecords(cfg)
+	if err != nil {
+		return fmt.Errorf("error retrieving local rubbish: %v", err)
+	}
+
+	if len(records) == 0 {
+		fmt.Println("Nothing to restore here.")
+		return nil
+	}
+
+	slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+		return cmp.Compare(b.TossedTime, a.TossedTime)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITEM\tRESTORES TO\tTOSSED")
+	for _, record := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\n",
+			record.Item,
+			displayOrigin(record.Origin, cfg.WorkingDir),
+			cfg.DisplayTime(record.TossedTime).Format(time.DateTime),
+		)
+	}
+	w.Flush()
+
+	fmt.Printf("%d items restorable, run 'rubbish restore <item>' to bring one back.\n", len(records))
+	return nil
+}
+
+// displayOrigin returns the origin relative to the working directory when it
+// lies within it, the absolute origin otherwise.
+func displayOrigin(origin string, workingDir string) string {
+	rel, err := filepath.Rel(workingDir, origin)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return origin
+	}
+	return rel
+}
@@ -0,0 +1,40 @@
+package restorer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_ListShowsLocalItemsOnly(t *testing.T) {
+	cfg := newTestCfg(t)
+	nested := seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "sub", "a.txt"), time.Now().AddDate(0, 0, -1))
+	top := seedTossed(t, cfg, "b.txt", filepath.Join(cfg.WorkingDir, "b.txt"), time.Now())
+	outside := seedTossed(t, cfg, "c.txt", filepath.Join(t.TempDir(), "c.txt"), time.Now())
+
+	listMode = true
+	defer func() { listMode = false }()
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, nested.Item+"  sub/a.txt") || !strings.Contains(out, top.Item) {
+		t.Errorf("expected the local items with their relative origin:\n%s", out)
+	}
+	if strings.Index(out, top.Item) > strings.Index(out, nested.Item) {
+		t.Errorf("expected the newest item first:\n%s", out)
+	}
+	if strings.Contains(out, outside.Item) {
+		t.Errorf("items tossed elsewhere must not be listed:\n%s", out)
+	}
+	if !strings.Contains(out, "2 items restorable") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+	if _, err := cfg.Journal.Get(top.Item); err != nil {
+		t.Errorf("listing must not restore anything: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"rubbish/tosser"
+)
+
+// simulateCrossDevice forces every rename to fail as if the container lived on another filesystem.
+func simulateCrossDevice(t *testing.T) {
+	t.Helper()
+	fsutil.Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { fsutil.Rename = os.Rename })
+}
+
+func TestCommand_CrossDeviceRestoresDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	record := seedTossed(t, cfg, "project", filepath.Join(cfg.WorkingDir, "project"), time.Now())
+	item := cfg.ItemPath(record.Item)
+	os.Remove(item)
+	os.MkdirAll(filepath.Join(item, "nested"), 0o750)
+	os.WriteFile(filepath.Join(item, "nested", "a.txt"), []byte("a"), 0o600)
+	modified := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	os.Chtimes(filepath.Join(item, "nested", "a.txt"), modified, modified)
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	restored := filepath.Join(record.Origin, "nested", "a.txt")
+	info, err := os.Stat(restored)
+	if err != nil {
+		t.Fatalf("nested file not restored: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 || !info.ModTime().Equal(modified) {
+		t.Errorf("metadata not preserved: mode %v, mtime %v", info.Mode().Perm(), info.ModTime())
+	}
+	if _, err := os.Stat(item); !os.IsNotExist(err) {
+		t.Error("container copy must be removed once restored")
+	}
+	if _, err := cfg.Journal.Get(record.Item); err == nil {
+		t.Error("journal record must be removed once restored")
+	}
+}
+
+func TestCommand_CrossDeviceCopyFailureKeepsItem(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can read any file")
+	}
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	record := seedTossed(t, cfg, "broken", filepath.Join(cfg.WorkingDir, "broken"), time.Now())
+	item := cfg.ItemPath(record.Item)
+	os.Remove(item)
+	os.Mkdir(item, 0o755)
+	os.WriteFile(filepath.Join(item, "ok.txt"), []byte("ok"), 0o644)
+	// a file that cannot be read makes the copy fail mid-way
+	os.WriteFile(filepath.Join(item, "secret.txt"), []byte("x"), 0o000)
+	defer os.Chmod(filepath.Join(item, "secret.txt"), 0o644)
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err == nil {
+			t.Error("expected error when the copy fails")
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(item, "ok.txt")); err != nil {
+		t.Errorf("container copy must be kept: %v", err)
+	}
+	if _, err := cfg.Journal.Get(record.Item); err != nil {
+		t.Errorf("journal record must be kept for a retry: %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.WorkingDir); len(entries) != 0 {
+		t.Errorf("no partial copy must be left in the working directory, got %d entries", len(entries))
+	}
+}
+
+func TestCommand_CrossDeviceLeftoversDropTheRecord(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can remove entries of read-only directories")
+	}
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	record := seedTossed(t, cfg, "project", filepath.Join(cfg.WorkingDir, "project"), time.Now())
+	item := cfg.ItemPath(record.Item)
+	os.Remove(item)
+	os.MkdirAll(filepath.Join(item, "locked"), 0o755)
+	os.WriteFile(filepath.Join(item, "locked", "a.txt"), []byte("data"), 0o644)
+	// a read-only directory can't be emptied once copied
+	os.Chmod(filepath.Join(item, "locked"), 0o555)
+	defer os.Chmod(filepath.Join(item, "locked"), 0o755)
+	defer os.Chmod(filepath.Join(cfg.WorkingDir, "project", "locked"), 0o755)
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Errorf("expected the restore to succeed, got %v", err)
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDir, "project", "locked", "a.txt")); err != nil {
+		t.Errorf("the item must be restored: %v", err)
+	}
+	if _, err := cfg.Journal.Get(record.Item); err == nil {
+		t.Error("journal record must be removed once restored")
+	}
+}
+
+func TestCommand_CrossDeviceRestoresSymlink(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	os.Mkdir("reports", 0o755)
+	os.WriteFile(filepath.Join("reports", "2024.pdf"), []byte("pdf"), 0o644)
+	os.Symlink("reports/2024.pdf", "latest")
+
+	tosser.Flags.Parse([]string{"-s"})
+	defer tosser.Flags.Parse([]string{"-s=false"})
+	if err := tosser.Command([]string{"latest"}, cfg); err != nil {
+		t.Fatalf("toss: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 || records[0].LinkTarget != "reports/2024.pdf" {
+		t.Fatalf("expected the link target recorded, got %+v", records)
+	}
+	if target, err := os.Readlink(cfg.ItemPath(records[0].Item)); err != nil || target != "reports/2024.pdf" {
+		t.Errorf("the link must be stored as a link, got %q, %v", target, err)
+	}
+
+	if err := Flags.Parse([]string{records[0].Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if target, err := os.Readlink("latest"); err != nil || target != "reports/2024.pdf" {
+		t.Errorf("expected the restored link to point to reports/2024.pdf, got %q, %v", target, err)
+	}
+	if data, err := os.ReadFile("latest"); err != nil || string(data) != "pdf" {
+		t.Errorf("the restored link must resolve to its target: %v", err)
+	}
+}
+
+func TestCommand_RestoresLostSymlinkFromRecord(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seedTossed(t, cfg, "latest", filepath.Join(cfg.WorkingDir, "latest"), time.Now())
+	record.Type, record.LinkTarget = journal.TypeSymlink, "/srv/current"
+	cfg.Journal.AddRecord(record)
+	os.Remove(cfg.ItemPath(record.Item))
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if target, err := os.Readlink("latest"); err != nil || target != "/srv/current" {
+		t.Errorf("expected the link recreated from its record, got %q, %v", target, err)
+	}
+}
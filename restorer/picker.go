@@ -0,0 +1,110 @@
+package restorer
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"strings"
+	"time"
+)
+
+// pageSize is the number of items displayed on each page of the interactive list
+var pageSize = 10
+
+// restoreFromList lets the user pick the records to restore and restores the selection.
+func restoreFromList(records []*journal.MetaData, cfg *config.Config) error {
+	if len(records) == 0 {
+		fmt.Println("No restorable items found in this directory.")
+		return nil
+	}
+
+	selected, err := pickRecords(records, cfg.Zone())
+	if err != nil {
+		return fmt.Errorf("error reading selection: %v", err)
+	}
+
+	for _, record := range selected {
+		if err := restoreRecord(record, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pickRecords displays the records as a paged, numbered list. The user toggles
+// items by number or range on any page and confirms the whole selection once.
+// Returns the selected records in list order, or nothing if the user quits.
+func pickRecords(records []*journal.MetaData, zone *time.Location) ([]*journal.MetaData, error) {
+	marked := map[int]bool{}
+	pages := (len(records) + pageSize - 1) / pageSize
+	page := 0
+
+	for {
+		start := page * pageSize
+		end := min(start+pageSize, len(records))
+
+		fmt.Printf("Restorable items (page %d/%d, %d marked):\n", page+1, pages, len(marked))
+		for i := start; i < end; i++ {
+			mark := " "
+			if marked[i+1] {
+				mark = "x"
+			}
+			record := records[i]
+			fmt.Printf(" [%s] %d. %s | Origin:%s | Tossed:%s\n", mark, i+1, record.Item, record.Origin,
+				time.Unix(record.TossedTime, 0).In(zone).Format(time.DateTime))
+		}
+
+		answer, err := prompt.ReadLine("Toggle numbers/ranges (e.g. 1,3-5), [n]ext, [p]revious, [d]one, [q]uit: ")
+		if err != nil {
+			return nil, err
+		}
+
+		switch answer = strings.TrimSpace(answer); answer {
+		case "":
+		case "n":
+			page = min(page+1, pages-1)
+		case "p":
+			page = max(page-1, 0)
+		case "q":
+			fmt.Println("Restore cancelled.")
+			return nil, nil
+		case "d":
+			var selected []*journal.MetaData
+			for i, record := range records {
+				if marked[i+1] {
+					selected = append(selected, record)
+				}
+			}
+
+			if len(selected) == 0 {
+				fmt.Println("Nothing selected to restore.")
+				return nil, nil
+			}
+
+			ok, err := prompt.Confirm(fmt.Sprintf("Restore %d selected items?", len(selected)))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				fmt.Println("Restore cancelled.")
+				return nil, nil
+			}
+			return selected, nil
+		default:
+			selection, err := prompt.ParseSelection(answer, len(records))
+			if err != nil {
+				fmt.Printf("Invalid selection: %v\n", err)
+				continue
+			}
+			for _, i := range selection {
+				if marked[i] {
+					delete(marked, i)
+				} else {
+					marked[i] = true
+				}
+			}
+		}
+	}
+}
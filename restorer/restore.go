@@ -1,19 +1,37 @@
 package restorer
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"rubbish/color"
 	"rubbish/config"
+	"rubbish/fsutil"
 	"rubbish/journal"
-	"slices"
+	"rubbish/prompt"
 )
 
 var (
-	Flags         = flag.NewFlagSet("restore", flag.ExitOnError)
-	override bool = false
-	silent   bool = false
+	Flags                = flag.NewFlagSet("restore", flag.ExitOnError)
+	override        bool = false
+	silent          bool = false
+	interactiveList bool = false
+	byPosition      int  = 0
+	globalLookup    bool = false
+	restoreDate          = ""
+	restoreBatch         = ""
+	dryRun          bool = false
+	restoreAll      bool = false
+	listMode        bool = false // listMode prints the restorable items instead of restoring anything
+	onConflict           = ""    // onConflict is the policy applied to taken destinations, see conflictPolicy
+	targetDir            = ""    // targetDir replaces the destination directory of the restored items when set
+	binName              = ""    // binName is the named bin to restore from, empty for the default bin
+
+	// planned counts the moves and conflicts reported in dry-run mode
+	planned struct{ moves, conflicts int }
 )
 
 func init() {
@@ -21,9 +39,27 @@ func init() {
 	// Flags.BoolVar(&override, "o", false, "Override existing files during restoration (alias for --override)")
 	Flags.BoolVar(&silent, "silent", false, "Suppress output messages")
 	// Flags.BoolVar(&silent, "s", false, "Suppress output messages (alias for --silent)")
+	Flags.BoolVar(&interactiveList, "interactive-list", false, "Pick the items to restore from a paged list")
+	Flags.IntVar(&byPosition, "p", 0, "Restore the item at the given position, oldest tossed first (1-based, negative from the newest).")
+	Flags.DurationVar(&prompt.Timeout, "confirm-timeout", 0, "Decline confirmations left unanswered for this long (e.g. 30s), 0 waits forever.")
+	Flags.BoolVar(&restoreAll, "all", false, "Restore every item tossed from the current directory tree to its origin.")
+	Flags.BoolVar(&listMode, "list", false, "List the items restorable from the current directory, with the key to pass to restore.")
+	Flags.BoolVar(&dryRun, "dry-run", false, "Print each move and conflict without restoring anything.")
+	Flags.StringVar(&restoreBatch, "batch", "", "Restore every item of the given toss batch to its origin (see 'rubbish status --batches').")
+	Flags.StringVar(&restoreDate, "date", "", "Restore the working directory tree as it was on the given date (YYYY-MM-DD).")
+	Flags.BoolVar(&globalLookup, "g", false, "Resolve items against the whole rubbish instead of the current directory.")
+	Flags.StringVar(&onConflict, "on-conflict", "", "What to do when the destination exists: skip, overwrite, rename (file(1).txt) or ask (default skip, overwrite with --override).")
+	Flags.StringVar(&targetDir, "to", "", "Restore into the given directory, created if missing, keeping the original names and, for --all, --date and --batch, the paths below the working directory.")
+	Flags.StringVar(&binName, "bin", "", "Restore from the named bin instead of the default one.")
 
 	Flags.Usage = func() {
 		fmt.Println("Usage: rubbish restore [options] <file1> <file2> ...")
+		fmt.Println("       rubbish restore --interactive-list")
+		fmt.Println("       rubbish restore [-g] -p=<position>")
+		fmt.Println("       rubbish restore --date=<YYYY-MM-DD>")
+		fmt.Println("       rubbish restore --batch=<id>")
+		fmt.Println("       rubbish restore --all")
+		fmt.Println("       rubbish restore [-g] --list")
 		fmt.Println("Options:")
 		Flags.PrintDefaults()
 	}
@@ -35,10 +71,29 @@ func Command(args []string, cfg *config.Config) error {
 		return fmt.Errorf("error parsing flags")
 	}
 
-	if len(Flags.Args()) == 0 {
+	if len(Flags.Args()) == 0 && !interactiveList && byPosition == 0 && restoreDate == "" && restoreBatch == "" && !restoreAll && !listMode {
 		return fmt.Errorf("no files specified to restore")
 	}
 
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	if listMode {
+		return listRestorable(cfg)
+	}
+
+	if err := validateConflictPolicy(); err != nil {
+		return err
+	}
+
+	if interactiveList && !prompt.Interactive() {
+		return fmt.Errorf("interactive list requires a terminal, specify the items to restore instead")
+	}
+
 	if override {
 		fmt.Println("Override mode enabled. Existing files will be replaced.")
 	}
@@ -47,57 +102,242 @@ func Command(args []string, cfg *config.Config) error {
 		fmt.Println("Silent mode enabled. No output will be displayed.")
 	}
 
-	local_rubbish, err := cfg.Journal.FilterPath(cfg.WorkingDir)
+	if dryRun {
+		fmt.Println("Dry run: nothing will be restored.")
+		planned.moves, planned.conflicts = 0, 0
+		defer func() {
+			fmt.Printf("Dry run: %d items would be restored, %d conflicts.\n", planned.moves, planned.conflicts)
+		}()
+	}
+
+	if restoreDate != "" {
+		date, err := parseDate(restoreDate)
+		if err != nil {
+			return err
+		}
+		return restoreSnapshot(date, cfg)
+	}
+
+	if restoreBatch != "" {
+		return restoreBatchItems(restoreBatch, cfg)
+	}
+
+	if restoreAll {
+		return restoreAllItems(cfg)
+	}
+
+	if byPosition != 0 {
+		dir := cfg.WorkingDir
+		if globalLookup {
+			dir = ""
+		}
+		record, err := cfg.Journal.RecordAtPosition(dir, byPosition)
+		if err != nil {
+			return err
+		}
+		return restoreRecord(record, cfg)
+	}
+
+	local_rubbish, err := retrieveRecords(cfg)
 
 	if err != nil {
 		return fmt.Errorf("error retrieving local rubbish: %v", err)
 	}
 
+	if interactiveList {
+		return restoreFromList(local_rubbish, cfg)
+	}
+
 	for _, file := range Flags.Args() {
 		if file == "" {
 			return fmt.Errorf("no files specified to restore")
 		}
 
-		//Validate if the file exists in the local rubbish
-		if !slices.ContainsFunc(local_rubbish, func(record *journal.MetaData) bool {
-			return record.Item == file
-		}) {
+		// Find the record in the local rubbish, by key, unique key prefix or original name
+		record, err := journal.Resolve(local_rubbish, file)
+		if errors.Is(err, journal.ErrItemNotFound) {
 			fmt.Printf("File %s doesn't belong to this directory rubbish.\n", file)
 			continue
 		}
+		if err != nil {
+			return err
+		}
 
-		// Find the record in the local rubbish
-		record_index := slices.IndexFunc(local_rubbish, func(item *journal.MetaData) bool {
-			return item.Item == file
-		})
-
-		if record_index < 0 {
-			fmt.Printf("File %s not found in the trash.\n", file)
-			continue
+		if err := restoreRecord(record, cfg); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		record := local_rubbish[record_index]
-		original_file := path.Base(record.Origin)
+// previewRestore prints the move restoreTo would perform, flagging the
+// destinations already taken, and counts it in the dry-run summary.
+func previewRestore(record *journal.MetaData, original_file string, cfg *config.Config) {
+	source := cfg.ItemPath(record.Item)
 
-		// Check if a file with the same name exists in the current directory
-		if _, err := os.Stat(original_file); err == nil && !override {
-			if !silent {
-				fmt.Printf("File %s restoring to %s and already exists in the current directory. Use --override to replace it.\n", file, original_file)
-			}
-			continue
+	if mergesInto(record, original_file) {
+		fmt.Printf("Would restore %s -> %s (merged around the excluded paths)\n", source, original_file)
+		planned.moves++
+		return
+	}
+
+	_, err := os.Lstat(original_file)
+	if err != nil {
+		fmt.Printf("Would restore %s -> %s\n", source, original_file)
+		planned.moves++
+		return
+	}
+
+	switch conflictPolicy() {
+	case ConflictOverwrite:
+		fmt.Printf("Would restore %s -> %s (replacing the existing file)\n", source, original_file)
+		planned.moves++
+	case ConflictRename:
+		renamed, err := freeName(original_file)
+		if err != nil {
+			fmt.Printf("Conflict: %s -> %s already exists, %v\n", source, original_file, err)
+			planned.conflicts++
+			return
 		}
+		fmt.Printf("Would restore %s -> %s (%s already exists)\n", source, renamed, original_file)
+		planned.moves++
+	case ConflictAsk:
+		fmt.Printf("Conflict: %s -> %s already exists, it would be asked what to do\n", source, original_file)
+		planned.conflicts++
+	default:
+		fmt.Printf("Conflict: %s -> %s already exists, it would be skipped without --override\n", source, original_file)
+		planned.conflicts++
+	}
+}
 
-		// Restore the file
-		if err := os.Rename(path.Join(cfg.ContainerPath, record.Item), original_file); err != nil {
-			return fmt.Errorf("error restoring file %s: %v", file, err)
+// retrieveRecords returns the records eligible for restoration, those tossed
+// from the working directory or, with -g, every record in the journal.
+func retrieveRecords(cfg *config.Config) ([]*journal.MetaData, error) {
+	if globalLookup {
+		return cfg.Journal.List()
+	}
+	return cfg.Journal.FilterPath(cfg.WorkingDir)
+}
+
+// restoreRecord moves the item of the record back into the current directory
+// and removes its journal entry. Existing files are only replaced in override mode.
+func restoreRecord(record *journal.MetaData, cfg *config.Config) error {
+	destination, err := destinationOf(record, path.Base(record.Origin), filepath.Dir(record.Origin))
+	if err != nil {
+		return err
+	}
+	_, err = restoreTo(record, destination, cfg)
+	return err
+}
+
+// destinationOf returns where the item of the record is restored: the given
+// default or, with --to, the path of its origin relative to base in the
+// target directory, which is created when missing. Origins outside base keep
+// their whole absolute path under the target directory.
+func destinationOf(record *journal.MetaData, fallback string, base string) (string, error) {
+	if targetDir == "" {
+		return fallback, nil
+	}
+
+	if !dryRun {
+		if err := os.MkdirAll(targetDir, 0o755); err != nil {
+			return "", fmt.Errorf("error creating target directory %s: %v", targetDir, err)
 		}
+	}
+	rel, err := filepath.Rel(base, record.Origin)
+	if !withinDir(record.Origin, base) {
+		rel, err = filepath.Rel("/", record.Origin)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error locating %s in the target directory: %v", record.Origin, err)
+	}
+	return filepath.Join(targetDir, rel), nil
+}
+
+// restoreItem moves the item of the record out of the container to
+// destination, extracting compressed items. The compressed archive is only
+// removed once fully extracted. Symlinks are recreated from their recorded
+// target, so they survive a container on another device or a lost link.
+// Deduplicated files still linked to another item are restored as copies.
+// Directories tossed with --exclude are merged back into their origin.
+func restoreItem(record *journal.MetaData, destination string, cfg *config.Config) error {
+	if record.Type == journal.TypeSymlink && record.LinkTarget != "" {
+		return fsutil.Relink(cfg.ItemPath(record.Item), destination, record.LinkTarget)
+	}
+	if mergesInto(record, destination) {
+		return fsutil.Merge(cfg.ItemPath(record.Item), destination)
+	}
+
+	if fsutil.Shared(cfg.ItemPath(record.Item)) {
+		// A deduplicated item must not keep sharing its content once restored
+		return fsutil.Detach(cfg.ItemPath(record.Item), destination)
+	}
+	if !record.Compressed {
+		return fsutil.Move(cfg.ItemPath(record.Item), destination)
+	}
 
-		if err := cfg.Journal.Delete(record.Item); err != nil {
-			return fmt.Errorf("error deleting journal record for file %s: %v", file, err)
+	if err := fsutil.Decompress(cfg.ItemPath(record.Item), destination); err != nil {
+		return err
+	}
+	return os.Remove(cfg.ItemPath(record.Item))
+}
+
+// mergesInto reports whether the item of the record is merged into
+// destination rather than conflicting with it: the item is a directory
+// tossed with --exclude and destination is its origin, still holding the
+// excluded paths.
+func mergesInto(record *journal.MetaData, destination string) bool {
+	if len(record.Excluded) == 0 {
+		return false
+	}
+	if abs, err := filepath.Abs(destination); err != nil || abs != record.Origin {
+		return false
+	}
+	info, err := os.Lstat(destination)
+	return err == nil && info.IsDir()
+}
+
+// restoreTo moves the item of the record to original_file and removes its
+// journal entry. A taken destination is handled by the conflict policy. It
+// reports whether the item was restored.
+func restoreTo(record *journal.MetaData, original_file string, cfg *config.Config) (bool, error) {
+	file := record.Item
+
+	if dryRun {
+		previewRestore(record, original_file, cfg)
+		return false, nil
+	}
+
+	// Check if a file with the same name exists in the current directory
+	if _, err := os.Lstat(original_file); err == nil && !mergesInto(record, original_file) {
+		if original_file, err = resolveConflict(record, original_file); err != nil || original_file == "" {
+			return false, err
 		}
+	}
 
-		fmt.Println("Restoring file:", file)
+	// Restore the file
+	// A copy failing across devices leaves the item and its record in place,
+	// so the restore can be retried. Once complete, the leftovers of the item
+	// in the container are orphans and the record goes.
+	err := restoreItem(record, original_file, cfg)
+	if errors.Is(err, fsutil.ErrSourceLeft) {
+		color.Warnf("%s is restored, but %v\n", file, err)
+	} else if err != nil {
+		return false, fmt.Errorf("error restoring file %s: %v", file, err)
 	}
+	cfg.PruneItemParents(record.Item)
 
-	return nil
+	if err := cfg.Journal.Delete(record.Item); err != nil {
+		return true, fmt.Errorf("error deleting journal record for file %s: %v", file, err)
+	}
+
+	if err := cfg.RemoveTrashInfo(record.Item); err != nil {
+		return true, err
+	}
+
+	if !silent {
+		fmt.Println("Restoring file:", file)
+	}
+	return true, nil
 }
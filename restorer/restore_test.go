@@ -0,0 +1,378 @@
+package restorer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+)
+
+// newTestCfg builds a config with a fresh container and journal, and moves the
+// process into a separate working directory where items get restored.
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	work := t.TempDir()
+	t.Chdir(work)
+
+	if err := Flags.Parse(nil); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	return &config.Config{ContainerPath: container, Journal: j, WorkingDir: work, WipeoutTime: 30}
+}
+
+// seed adds a journaled item to the container as if it had been tossed from the working dir.
+func seed(t *testing.T, cfg *config.Config, name string) *journal.MetaData {
+	t.Helper()
+	record := &journal.MetaData{
+		Item:        name + "_ABCDEF",
+		Origin:      filepath.Join(cfg.WorkingDir, name),
+		Type:        journal.TypeFile,
+		WipeoutTime: 30,
+		TossedTime:  time.Now().Add(-time.Hour).Unix(),
+	}
+	if err := os.WriteFile(cfg.ItemPath(record.Item), []byte(name), 0o644); err != nil {
+		t.Fatalf("seed %s: %v", name, err)
+	}
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		t.Fatalf("journal %s: %v", name, err)
+	}
+	return record
+}
+
+func scriptInput(t *testing.T, script string) {
+	t.Helper()
+	origInput, origInteractive := prompt.Input, prompt.Interactive
+	prompt.Input = strings.NewReader(script)
+	prompt.Interactive = func() bool { return true }
+	t.Cleanup(func() {
+		prompt.Input, prompt.Interactive = origInput, origInteractive
+	})
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestCommand_InteractiveListMultiPage(t *testing.T) {
+	cfg := newTestCfg(t)
+	interactiveList = true
+	defer func() { interactiveList = false }()
+
+	for i := 0; i < 25; i++ {
+		seed(t, cfg, fmt.Sprintf("item%02d.txt", i))
+	}
+
+	// mark 1-2 on the first page, 12 on the second, 25 on the last, toggle 2 off again
+	scriptInput(t, "1-2\nn\n12\nn\n25\np\n2\nd\ny\n")
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "page 3/3") {
+		t.Errorf("expected three pages, got: %s", out)
+	}
+
+	for _, name := range []string{"item00.txt", "item11.txt", "item24.txt"} {
+		if _, err := os.Stat(filepath.Join(cfg.WorkingDir, name)); err != nil {
+			t.Errorf("expected %s restored: %v", name, err)
+		}
+		if _, err := cfg.Journal.Get(name + "_ABCDEF"); err == nil {
+			t.Errorf("expected journal entry of %s removed", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDir, "item01.txt")); !os.IsNotExist(err) {
+		t.Errorf("item01.txt was toggled off and must not be restored")
+	}
+	if count, _ := cfg.Journal.Count(); count != 22 {
+		t.Errorf("expected 22 items left in journal, got %d", count)
+	}
+}
+
+func TestCommand_InteractiveListSkipsCollisions(t *testing.T) {
+	cfg := newTestCfg(t)
+	interactiveList = true
+	defer func() { interactiveList = false }()
+
+	seed(t, cfg, "taken.txt")
+	os.WriteFile(filepath.Join(cfg.WorkingDir, "taken.txt"), []byte("mine"), 0o644)
+
+	scriptInput(t, "1\nd\ny\n")
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "already exists") {
+		t.Errorf("expected collision notice, got: %s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.WorkingDir, "taken.txt")); string(data) != "mine" {
+		t.Errorf("existing file must not be replaced")
+	}
+}
+
+func TestCommand_InteractiveListRequiresTerminal(t *testing.T) {
+	cfg := newTestCfg(t)
+	interactiveList = true
+	defer func() { interactiveList = false }()
+
+	orig := prompt.Interactive
+	prompt.Interactive = func() bool { return false }
+	defer func() { prompt.Interactive = orig }()
+
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "requires a terminal") {
+		t.Fatalf("expected terminal error, got: %v", err)
+	}
+}
+
+func TestCommand_ByPosition(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt")
+	seed(t, cfg, "b.txt")
+	seed(t, cfg, "c.txt")
+	byPosition = -1
+	defer func() { byPosition = 0 }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if _, err := os.Stat("c.txt"); err != nil {
+		t.Errorf("last item should be restored: %v", err)
+	}
+	if _, err := os.Stat("a.txt"); err == nil {
+		t.Error("only the selected item must be restored")
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("expected 2 records left, got %d", count)
+	}
+}
+
+func TestCommand_ByPositionOutOfRange(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt")
+	byPosition = 2
+	defer func() { byPosition = 0 }()
+
+	err := Command(nil, cfg)
+	if err == nil || err.Error() != "invalid item position: 2" {
+		t.Fatalf("expected info's out of range error, got %v", err)
+	}
+}
+
+func TestCommand_ByPositionGlobal(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "far.txt")
+	cfg.Journal.Delete(record.Item)
+	record.Origin = filepath.Join(t.TempDir(), "far.txt")
+	cfg.Journal.AddRecord(record)
+	byPosition = 1
+
+	if err := Command(nil, cfg); err == nil {
+		t.Error("items from other directories must not be resolved without -g")
+	}
+
+	globalLookup = true
+	defer func() { byPosition, globalLookup = 0, false }()
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if _, err := os.Stat("far.txt"); err != nil {
+		t.Errorf("item should be restored with -g: %v", err)
+	}
+}
+
+func TestCommand_DryRunReportsMovesAndConflicts(t *testing.T) {
+	cfg := newTestCfg(t)
+	free := seed(t, cfg, "free.txt")
+	taken := seed(t, cfg, "taken.txt")
+	os.WriteFile("taken.txt", []byte("mine"), 0o644)
+	if err := Flags.Parse([]string{"--dry-run", free.Item, taken.Item}); err != nil {
+		t.Fatal(err)
+	}
+	defer Flags.Parse([]string{"--dry-run=false"})
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Would restore "+filepath.Join(cfg.ContainerPath, free.Item)+" -> free.txt") {
+		t.Errorf("expected the planned move: %s", out)
+	}
+	if !strings.Contains(out, "Conflict: "+filepath.Join(cfg.ContainerPath, taken.Item)+" -> taken.txt") {
+		t.Errorf("expected the conflict flagged: %s", out)
+	}
+	if !strings.Contains(out, "Dry run: 1 items would be restored, 1 conflicts.") {
+		t.Errorf("missing dry run summary: %s", out)
+	}
+	if _, err := os.Stat("free.txt"); err == nil {
+		t.Error("nothing must be restored in dry run")
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("journal must not change in dry run, got %d records", count)
+	}
+}
+
+func TestCommand_XDGRemovesTrashInfo(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.TrashMode = config.TrashModeXDG
+	for _, dir := range []string{cfg.FilesPath(), cfg.InfoPath()} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record := seed(t, cfg, "a.txt")
+	if err := cfg.WriteTrashInfo(record); err != nil {
+		t.Fatalf("writing trash info: %v", err)
+	}
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if data, err := os.ReadFile("a.txt"); err != nil || string(data) != "a.txt" {
+		t.Errorf("item not restored from files/: %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.InfoPath()); len(entries) != 0 {
+		t.Errorf("trash info must be removed with the item, got %d entries", len(entries))
+	}
+}
+
+func TestCommand_RecreatesSymlink(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := &journal.MetaData{
+		Item:        "latest_ABCDEF",
+		Origin:      filepath.Join(cfg.WorkingDir, "latest"),
+		Type:        journal.TypeSymlink,
+		LinkTarget:  "../reports/2024.pdf",
+		WipeoutTime: 30,
+		TossedTime:  time.Now().Unix(),
+	}
+	if err := os.Symlink(record.LinkTarget, cfg.ItemPath(record.Item)); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Journal.AddRecord(record)
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if target, err := os.Readlink("latest"); err != nil || target != record.LinkTarget {
+		t.Errorf("expected a link to %s, got %q, %v", record.LinkTarget, target, err)
+	}
+	if _, err := os.Lstat(cfg.ItemPath(record.Item)); !os.IsNotExist(err) {
+		t.Errorf("the stored link must be removed, got %v", err)
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected the record removed, got %d", count)
+	}
+}
+
+func TestCommand_ByPrefix(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt")
+	other := seed(t, cfg, "b.txt")
+
+	if err := Flags.Parse([]string{"a.txt_AB"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if _, err := os.Stat("a.txt"); err != nil {
+		t.Errorf("the item with the unique prefix must be restored: %v", err)
+	}
+
+	// Both remaining keys start with the prefix
+	seed(t, cfg, "b.txt_extra")
+	if err := Flags.Parse([]string{"b.txt_"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	err := Command(nil, cfg)
+	if !errors.Is(err, journal.ErrAmbiguousItem) || !strings.Contains(err.Error(), other.Item) {
+		t.Errorf("expected the ambiguous prefix to list its candidates, got %v", err)
+	}
+}
+
+func TestCommand_MergesExcludedDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seedTossed(t, cfg, "project", filepath.Join(cfg.WorkingDir, "project"), time.Now())
+	record.Type, record.Excluded = journal.TypeDirectory, []string{"node_modules"}
+	cfg.Journal.AddRecord(record)
+
+	item := cfg.ItemPath(record.Item)
+	os.Remove(item)
+	os.MkdirAll(filepath.Join(item, "src"), 0o755)
+	os.WriteFile(filepath.Join(item, "src", "main.go"), []byte("package main"), 0o644)
+	os.MkdirAll(filepath.Join("project", "node_modules"), 0o755)
+	os.WriteFile(filepath.Join("project", "node_modules", "index.js"), []byte("js"), 0o644)
+
+	if err := Flags.Parse([]string{record.Item}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, name := range []string{"src/main.go", "node_modules/index.js"} {
+		if _, err := os.Stat(filepath.Join("project", name)); err != nil {
+			t.Errorf("%s must be in the merged directory: %v", name, err)
+		}
+	}
+	if _, err := os.Lstat(item); !os.IsNotExist(err) {
+		t.Errorf("the stored directory must be removed, got %v", err)
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected the record removed, got %d", count)
+	}
+}
@@ -0,0 +1,131 @@
+package restorer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"sort"
+	"strings"
+	"time"
+)
+
+// parseDate parses a --date value, as the end of that day in local time so
+// every item tossed during the day is included.
+func parseDate(value string) (time.Time, error) {
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", value)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// snapshotRecords returns the records tossed from the working directory subtree
+// before the given time, or at any time when before is zero. When an origin was
+// tossed several times, only its most recent version is kept. Records are
+// sorted by origin so parents come first.
+func snapshotRecords(records []*journal.MetaData, workingDir string, before time.Time) []*journal.MetaData {
+	latest := map[string]*journal.MetaData{}
+
+	for _, record := range records {
+		if (!before.IsZero() && record.TossedTime >= before.Unix()) || !withinDir(record.Origin, workingDir) {
+			continue
+		}
+		if current, ok := latest[record.Origin]; !ok || record.TossedTime > current.TossedTime {
+			latest[record.Origin] = record
+		}
+	}
+
+	selected := make([]*journal.MetaData, 0, len(latest))
+	for _, record := range latest {
+		selected = append(selected, record)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Origin < selected[j].Origin
+	})
+	return selected
+}
+
+// withinDir reports whether path is located inside dir.
+func withinDir(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// restoreSnapshot restores every item tossed from the working directory subtree
+// up to the given time to its origin, recreating the missing directories.
+// Nothing is restored if any origin is taken and override mode is disabled.
+func restoreSnapshot(before time.Time, cfg *config.Config) error {
+	local_rubbish, err := cfg.Journal.FilterPath(cfg.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("error retrieving local rubbish: %v", err)
+	}
+
+	records := snapshotRecords(local_rubbish, cfg.WorkingDir, before)
+	if len(records) == 0 {
+		fmt.Println("No items tossed from this directory up to the given date.")
+		return nil
+	}
+
+	_, err = restoreToOrigins(records, cfg)
+	return err
+}
+
+// restoreToOrigins restores the records to their origin, recreating the missing
+// directories. Nothing is restored if any origin is taken, unless override mode
+// or a conflict policy is given, or if two records share their destination.
+// It returns the number of restored items.
+func restoreToOrigins(records []*journal.MetaData, cfg *config.Config) (int, error) {
+	destinations := make([]string, len(records))
+	taken := make(map[string]bool, len(records))
+	for i, record := range records {
+		destination, err := destinationOf(record, record.Origin, cfg.WorkingDir)
+		if err != nil {
+			return 0, err
+		}
+		// A second item would replace the first one, whose record is gone by then
+		if taken[destination] {
+			return 0, fmt.Errorf("nothing restored, several items would be restored to %s", destination)
+		}
+		taken[destination] = true
+		destinations[i] = destination
+	}
+
+	if dryRun {
+		for i, record := range records {
+			previewRestore(record, destinations[i], cfg)
+		}
+		return 0, nil
+	}
+
+	// Without an explicit policy, a taken origin aborts the whole restore
+	if onConflict == "" && !override {
+		var conflicts []string
+		for i, destination := range destinations {
+			if _, err := os.Lstat(destination); err == nil && !mergesInto(records[i], destination) {
+				conflicts = append(conflicts, destination)
+			}
+		}
+		if len(conflicts) > 0 {
+			return 0, fmt.Errorf("nothing restored, these files already exist (use --override to replace them): %s",
+				strings.Join(conflicts, ", "))
+		}
+	}
+
+	restored := 0
+	for i, record := range records {
+		if err := os.MkdirAll(filepath.Dir(destinations[i]), 0o755); err != nil {
+			return restored, fmt.Errorf("error recreating directory of %s: %v", destinations[i], err)
+		}
+		ok, err := restoreTo(record, destinations[i], cfg)
+		if ok {
+			restored++
+		}
+		if err != nil {
+			return restored, err
+		}
+	}
+
+	return restored, nil
+}
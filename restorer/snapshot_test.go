@@ -0,0 +1,87 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// seedTossed adds an item tossed from origin at the given time.
+func seedTossed(t *testing.T, cfg *config.Config, name string, origin string, tossed time.Time) *journal.MetaData {
+	t.Helper()
+	record := seed(t, cfg, name)
+	record.Origin = origin
+	record.TossedTime = tossed.Unix()
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		t.Fatalf("journal %s: %v", name, err)
+	}
+	return record
+}
+
+func TestCommand_DateRestoresTreeAsItWas(t *testing.T) {
+	cfg := newTestCfg(t)
+	old := seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "sub", "deep", "a.txt"), time.Now().AddDate(0, 0, -10))
+	recent := seedTossed(t, cfg, "b.txt", filepath.Join(cfg.WorkingDir, "b.txt"), time.Now())
+	outside := seedTossed(t, cfg, "c.txt", filepath.Join(t.TempDir(), "c.txt"), time.Now().AddDate(0, 0, -10))
+
+	restoreDate = time.Now().AddDate(0, 0, -5).Format(time.DateOnly)
+	defer func() { restoreDate = "" }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if data, err := os.ReadFile(old.Origin); err != nil || string(data) != "a.txt" {
+		t.Errorf("old item not restored to its origin: %v", err)
+	}
+	for _, record := range []*journal.MetaData{recent, outside} {
+		if _, err := os.Stat(record.Origin); err == nil {
+			t.Errorf("%s must not be restored", record.Origin)
+		}
+		if _, err := cfg.Journal.Get(record.Item); err != nil {
+			t.Errorf("%s must stay in the journal: %v", record.Item, err)
+		}
+	}
+}
+
+func TestCommand_DateAbortsOnConflict(t *testing.T) {
+	cfg := newTestCfg(t)
+	tossed := time.Now().AddDate(0, 0, -3)
+	first := seedTossed(t, cfg, "a.txt", filepath.Join(cfg.WorkingDir, "a.txt"), tossed)
+	taken := seedTossed(t, cfg, "z.txt", filepath.Join(cfg.WorkingDir, "z.txt"), tossed)
+	os.WriteFile(taken.Origin, []byte("mine"), 0o644)
+
+	restoreDate = time.Now().Format(time.DateOnly)
+	defer func() { restoreDate = "" }()
+
+	err := Command(nil, cfg)
+	if err == nil || !strings.Contains(err.Error(), taken.Origin) {
+		t.Fatalf("expected conflict error naming %s, got %v", taken.Origin, err)
+	}
+	if _, err := os.Stat(first.Origin); err == nil {
+		t.Error("nothing must be restored when a conflict is found")
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("journal must be untouched, got %d records", count)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	got, err := parseDate("2024-01-15")
+	if err != nil {
+		t.Fatalf("parseDate error: %v", err)
+	}
+	if want := time.Date(2024, 1, 16, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("parseDate = %v, want end of day %v", got, want)
+	}
+	if _, err := parseDate("15/01/2024"); err == nil {
+		t.Error("expected error for a malformed date")
+	}
+}
@@ -0,0 +1,141 @@
+package restorer
+
+import (
+	"os"
+	"path/filepath"
+	"rubbish/journal"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_ToCreatesTargetDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "report.txt")
+	target := filepath.Join(t.TempDir(), "staging", "inspect")
+
+	if err := Flags.Parse([]string{"--to=" + target, record.Item}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { targetDir = "" }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if data, err := os.ReadFile(filepath.Join(target, "report.txt")); err != nil || string(data) != "report.txt" {
+		t.Errorf("item not restored into the target directory: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDir, "report.txt")); !os.IsNotExist(err) {
+		t.Errorf("item must not be restored into the working directory, got %v", err)
+	}
+	if _, err := cfg.Journal.Get(record.Item); err == nil {
+		t.Error("journal entry must be deleted after the restore")
+	}
+}
+
+func TestCommand_ToConflictRespectsOverride(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "report.txt")
+	target := t.TempDir()
+	existing := filepath.Join(target, "report.txt")
+	os.WriteFile(existing, []byte("existing"), 0o644)
+
+	if err := Flags.Parse([]string{"--to=" + target, record.Item}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { targetDir, override = "", false }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(existing); string(data) != "existing" {
+		t.Errorf("conflicting file replaced without --override, got %q", data)
+	}
+	if _, err := cfg.Journal.Get(record.Item); err != nil {
+		t.Errorf("journal entry must be kept when the restore is skipped: %v", err)
+	}
+
+	if err := Flags.Parse([]string{"--to=" + target, "--override", record.Item}); err != nil {
+		t.Fatal(err)
+	}
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error with override: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(existing); string(data) != "report.txt" {
+		t.Errorf("conflicting file not replaced with --override, got %q", data)
+	}
+}
+
+func TestCommand_AllToTargetDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.txt")
+	seed(t, cfg, "b.txt")
+	target := filepath.Join(t.TempDir(), "restored")
+
+	if err := Flags.Parse([]string{"--all", "--to=" + target}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { targetDir, restoreAll = "", false }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(target, name)); err != nil {
+			t.Errorf("%s not restored into the target directory: %v", name, err)
+		}
+	}
+}
+
+func TestCommand_AllToTargetDirectoryKeepsTheTree(t *testing.T) {
+	cfg := newTestCfg(t)
+	seedTossed(t, cfg, "top.txt", filepath.Join(cfg.WorkingDir, "notes.txt"), time.Now())
+	seedTossed(t, cfg, "deep.txt", filepath.Join(cfg.WorkingDir, "sub", "notes.txt"), time.Now())
+	target := filepath.Join(t.TempDir(), "restored")
+
+	if err := Flags.Parse([]string{"--all", "--override", "--to=" + target}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { targetDir, restoreAll, override = "", false, false }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for file, content := range map[string]string{"notes.txt": "top.txt", "sub/notes.txt": "deep.txt"} {
+		if data, err := os.ReadFile(filepath.Join(target, file)); err != nil || string(data) != content {
+			t.Errorf("%s must hold %s, got %q, %v", file, content, data, err)
+		}
+	}
+}
+
+func TestRestoreToOrigins_SharedDestinationRestoresNothing(t *testing.T) {
+	cfg := newTestCfg(t)
+	first := seed(t, cfg, "a.txt")
+	second := seed(t, cfg, "b.txt")
+	second.Origin = first.Origin
+	target := t.TempDir()
+	targetDir = target
+	defer func() { targetDir = "" }()
+
+	if _, err := restoreToOrigins([]*journal.MetaData{first, second}, cfg); err == nil || !strings.Contains(err.Error(), "nothing restored") {
+		t.Fatalf("expected the shared destination refused, got %v", err)
+	}
+	for _, record := range []*journal.MetaData{first, second} {
+		if _, err := os.Lstat(cfg.ItemPath(record.Item)); err != nil {
+			t.Errorf("%s must stay in the container: %v", record.Item, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package service implements the service command, which installs a systemd
+// timer wiping the expired items every cleanup_interval days, or prints the
+// equivalent cron line on systems without systemd.
+package service
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"rubbish/config"
+	"strings"
+)
+
+// Names of the units written by install.
+const (
+	ServiceUnit = "rubbish-wipe.service"
+	TimerUnit   = "rubbish-wipe.timer"
+)
+
+// SystemUnitDir is where the --system units are written.
+const SystemUnitDir = "/etc/systemd/system"
+
+var (
+	Flags           = flag.NewFlagSet("service", flag.ExitOnError)
+	systemWide bool = false // systemWide installs the units for the whole system instead of the user
+
+	// systemctl runs the systemctl command, replaceable for testing
+	systemctl = func(args ...string) error {
+		output, err := exec.Command("systemctl", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	// systemdRunning reports whether systemd manages the system, replaceable for testing
+	systemdRunning = func() bool {
+		_, err := os.Stat("/run/systemd/system")
+		return err == nil
+	}
+
+	// executable resolves the path of the running binary, replaceable for testing
+	executable = os.Executable
+)
+
+func init() {
+	Flags.BoolVar(&systemWide, "system", false, "Install the units in "+SystemUnitDir+" for the whole system (requires root).")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish service wipes the expired items periodically, every cleanup_interval days.\n",
+			"Usage:\n\n",
+			"\trubbish service install [--system]\n",
+			"\trubbish service uninstall [--system]\n\n",
+			"Without systemd, the cron line to add is printed instead.\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+// Command dispatches the install and uninstall subcommands. Options may also
+// be given after the subcommand name.
+func Command(args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		Flags.Usage()
+		return fmt.Errorf("no service subcommand specified (expected install or uninstall)")
+	}
+
+	subcommand := args[0]
+	if err := Flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if Flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(Flags.Args(), " "))
+	}
+
+	if systemWide && os.Geteuid() != 0 {
+		return fmt.Errorf("--system requires root privileges")
+	}
+
+	exe, err := executable()
+	if err != nil {
+		return fmt.Errorf("error locating the rubbish executable: %w", err)
+	}
+
+	switch subcommand {
+	case "install":
+		if !systemdRunning() {
+			fmt.Println("systemd is not running, add this line to your crontab (crontab -e) instead:")
+			fmt.Println(CronLine(exe, cfg.CleanupInterval))
+			return nil
+		}
+		return install(exe, cfg.CleanupInterval)
+	case "uninstall":
+		if !systemdRunning() {
+			fmt.Println("systemd is not running, remove this line from your crontab (crontab -e) instead:")
+			fmt.Println(CronLine(exe, cfg.CleanupInterval))
+			return nil
+		}
+		return uninstall()
+	default:
+		return fmt.Errorf("unknown service subcommand '%s' (expected install or uninstall)", subcommand)
+	}
+}
+
+// unitDir returns the directory of the units, the user's systemd
+// configuration unless --system is given.
+func unitDir() (string, error) {
+	if systemWide {
+		return SystemUnitDir, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating the user configuration directory: %w", err)
+	}
+	return filepath.Join(configDir, "systemd", "user"), nil
+}
+
+// systemctlArgs prefixes the arguments with --user unless --system is given.
+func systemctlArgs(args ...string) []string {
+	if systemWide {
+		return args
+	}
+	return append([]string{"--user"}, args...)
+}
+
+// install writes the service and timer units, reloads the daemon and starts
+// the timer.
+func install(exe string, interval int) error {
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating unit directory: %w", err)
+	}
+
+	units := map[string]string{
+		ServiceUnit: ServiceContent(exe),
+		TimerUnit:   TimerContent(interval),
+	}
+	for name, content := range units {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %w", name, err)
+		}
+	}
+
+	if err := systemctl(systemctlArgs("daemon-reload")...); err != nil {
+		return err
+	}
+	if err := systemctl(systemctlArgs("enable", "--now", TimerUnit)...); err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed %s and %s in %s, wiping every %d days.\n", ServiceUnit, TimerUnit, dir, max(interval, 1))
+	return nil
+}
+
+// uninstall stops the timer, removes the units and reloads the daemon.
+func uninstall() error {
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+
+	// The timer may already be stopped or unknown, the units are removed anyway
+	_ = systemctl(systemctlArgs("disable", "--now", TimerUnit)...)
+
+	for _, name := range []string{TimerUnit, ServiceUnit} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing %s: %w", name, err)
+		}
+	}
+
+	if err := systemctl(systemctlArgs("daemon-reload")...); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %s and %s from %s.\n", ServiceUnit, TimerUnit, dir)
+	return nil
+}
+
+// wipeCommand is the command line run periodically. The wipe is global so it
+// does not depend on the working directory of the service.
+func wipeCommand(exe string) string {
+	return fmt.Sprintf("%s wipe -g -y", exe)
+}
+
+// ServiceContent returns the oneshot service unit running the wipe.
+func ServiceContent(exe string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Wipe the expired items of the rubbish bin
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, wipeCommand(exe))
+}
+
+// TimerContent returns the timer unit starting the service every interval
+// days, counted from the previous run. Intervals below a day run daily.
+func TimerContent(interval int) string {
+	return fmt.Sprintf(`[Unit]
+Description=Wipe the expired items of the rubbish bin every %[1]d days
+
+[Timer]
+OnBootSec=15min
+OnUnitActiveSec=%[1]dd
+Unit=%[2]s
+
+[Install]
+WantedBy=timers.target
+`, max(interval, 1), ServiceUnit)
+}
+
+// CronLine returns the crontab entry running the wipe every interval days at 03:00.
+func CronLine(exe string, interval int) string {
+	days := "*"
+	if interval > 1 {
+		days = fmt.Sprintf("*/%d", interval)
+	}
+	return fmt.Sprintf("0 3 %s * * %s", days, wipeCommand(exe))
+}
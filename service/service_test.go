@@ -0,0 +1,115 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubSystem fakes a systemd host, recording the systemctl calls.
+func stubSystem(t *testing.T, running bool) *[][]string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls [][]string
+	origCtl, origRunning, origExe := systemctl, systemdRunning, executable
+	systemctl = func(args ...string) error {
+		calls = append(calls, args)
+		return nil
+	}
+	systemdRunning = func() bool { return running }
+	executable = func() (string, error) { return "/usr/local/bin/rubbish", nil }
+	t.Cleanup(func() { systemctl, systemdRunning, executable = origCtl, origRunning, origExe })
+	return &calls
+}
+
+func TestUnitContents(t *testing.T) {
+	service := ServiceContent("/usr/local/bin/rubbish")
+	if !strings.Contains(service, "ExecStart=/usr/local/bin/rubbish wipe -g -y\n") || !strings.Contains(service, "Type=oneshot") {
+		t.Errorf("unexpected service unit:\n%s", service)
+	}
+
+	timer := TimerContent(3)
+	for _, want := range []string{"OnUnitActiveSec=3d\n", "Unit=" + ServiceUnit + "\n", "WantedBy=timers.target\n"} {
+		if !strings.Contains(timer, want) {
+			t.Errorf("timer unit missing %q:\n%s", want, timer)
+		}
+	}
+	if !strings.Contains(TimerContent(0), "OnUnitActiveSec=1d\n") {
+		t.Error("intervals below a day must run daily")
+	}
+
+	if line := CronLine("/usr/local/bin/rubbish", 3); line != "0 3 */3 * * /usr/local/bin/rubbish wipe -g -y" {
+		t.Errorf("unexpected cron line %q", line)
+	}
+	if line := CronLine("/usr/local/bin/rubbish", 1); !strings.HasPrefix(line, "0 3 * * * ") {
+		t.Errorf("unexpected daily cron line %q", line)
+	}
+}
+
+func TestCommand_InstallAndUninstall(t *testing.T) {
+	calls := stubSystem(t, true)
+	cfg := &config.Config{CleanupInterval: 5}
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "systemd", "user")
+
+	if err := Command([]string{"install"}, cfg); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	timer, err := os.ReadFile(filepath.Join(dir, TimerUnit))
+	if err != nil || !strings.Contains(string(timer), "OnUnitActiveSec=5d") {
+		t.Errorf("timer unit not written with the interval: %q, %v", timer, err)
+	}
+	service, err := os.ReadFile(filepath.Join(dir, ServiceUnit))
+	if err != nil || !strings.Contains(string(service), "ExecStart=/usr/local/bin/rubbish wipe -g -y") {
+		t.Errorf("service unit not written with the exec line: %q, %v", service, err)
+	}
+	if !slices.ContainsFunc(*calls, func(args []string) bool { return slices.Equal(args, []string{"--user", "daemon-reload"}) }) {
+		t.Errorf("user daemon not reloaded, calls: %v", *calls)
+	}
+	if !slices.ContainsFunc(*calls, func(args []string) bool {
+		return slices.Equal(args, []string{"--user", "enable", "--now", TimerUnit})
+	}) {
+		t.Errorf("timer not enabled, calls: %v", *calls)
+	}
+
+	if err := Command([]string{"uninstall"}, cfg); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	for _, name := range []string{ServiceUnit, TimerUnit} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s must be removed, got %v", name, err)
+		}
+	}
+}
+
+func TestCommand_WithoutSystemdPrintsCronLine(t *testing.T) {
+	calls := stubSystem(t, false)
+	if err := Command([]string{"install"}, &config.Config{CleanupInterval: 3}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("systemctl must not run without systemd, calls: %v", *calls)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "systemd", "user")); len(entries) != 0 {
+		t.Error("no unit must be written without systemd")
+	}
+}
+
+func TestCommand_Validation(t *testing.T) {
+	stubSystem(t, true)
+	cfg := &config.Config{CleanupInterval: 3}
+	for _, args := range [][]string{{}, {"start"}, {"install", "extra"}} {
+		if err := Command(args, cfg); err == nil {
+			t.Errorf("Command(%q): expected an error", args)
+		}
+	}
+	if os.Geteuid() != 0 {
+		defer func() { systemWide = false }()
+		if err := Command([]string{"install", "--system"}, cfg); err == nil {
+			t.Error("--system must require root")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package settings implements the config command, which shows the effective
+// configuration and checks the configuration files for mistakes.
+package settings
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"rubbish/color"
+	"rubbish/config"
+	"strings"
+	"text/tabwriter"
+)
+
+// ErrInvalidConfig is returned by validate when problems were found.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+var Flags = flag.NewFlagSet("config", flag.ExitOnError)
+
+func init() {
+	Flags.Usage = func() {
+		fmt.Println("Rubbish config shows and checks the configuration read from the files.\n",
+			"Usage:\n\n",
+			"\trubbish config show\n",
+			"\trubbish config validate\n\n",
+			"show prints every setting with its effective value and the file it\n",
+			"comes from. validate reports the unknown keys, the values which are\n",
+			"ignored or out of range and a container that can't be written to.")
+	}
+}
+
+// Command dispatches the show and validate subcommands.
+func Command(args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		Flags.Usage()
+		return fmt.Errorf("no config subcommand specified (expected show or validate)")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args[1:], " "))
+	}
+
+	report, err := config.Inspect(cfg.Files)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "show":
+		show(report)
+		return nil
+	case "validate":
+		return validate(report)
+	default:
+		return fmt.Errorf("unknown config subcommand '%s' (expected show or validate)", args[0])
+	}
+}
+
+// show prints the settings as INI lines annotated with their source.
+func show(report *config.Report) {
+	if len(report.Config.Files) == 0 {
+		fmt.Println("# No configuration file found, running on the defaults")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	section := ""
+	for _, setting := range report.Settings {
+		if setting.Section != section {
+			section = setting.Section
+			fmt.Fprintf(w, "\n[%s]\n", section)
+		}
+		fmt.Fprintf(w, "%s = %s\t# %s\n", setting.Key, setting.Value, setting.Source)
+	}
+	w.Flush()
+}
+
+// validate prints the problems of the configuration, a container the user
+// can't write to included, failing when there are.
+func validate(report *config.Report) error {
+	problems := report.Warnings
+	container := config.NormalizePath(report.Config.ContainerPath)
+	if err := config.CheckContainer(container); err != nil && !errors.Is(err, config.ErrContainerMissing) {
+		problems = append(problems, "container_path "+err.Error())
+	}
+
+	for _, problem := range problems {
+		color.Warnf("%s\n", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d problems found", ErrInvalidConfig, len(problems))
+	}
+
+	fmt.Printf("Configuration is valid (%s).\n", sources(report.Config.Files))
+	return nil
+}
+
+// sources describes the files the configuration was read from.
+func sources(files []string) string {
+	if len(files) == 0 {
+		return "defaults only"
+	}
+	return "read from " + strings.Join(files, ", ")
+}
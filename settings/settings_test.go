@@ -0,0 +1,101 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"strings"
+	"testing"
+)
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func readConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "rubbish.cfg")
+	os.WriteFile(file, []byte(content), 0o644)
+	cfg, err := config.Read([]string{file})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return cfg
+}
+
+func TestCommand_Show(t *testing.T) {
+	cfg := readConfig(t, "container_path = "+t.TempDir()+"\nwipeout_time = 12\n")
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{"show"}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	sources := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if setting, source, ok := strings.Cut(line, "#"); ok {
+			sources[strings.Join(strings.Fields(setting), " ")] = strings.TrimSpace(source)
+		}
+	}
+	want := map[string]string{
+		"wipeout_time = 12":   cfg.Files[0],
+		"max_retention = 365": config.SourceDefault,
+		"timeout = 5":         config.SourceDefault,
+	}
+	for setting, source := range want {
+		if sources[setting] != source {
+			t.Errorf("expected %q from %s, got %q in:\n%s", setting, source, sources[setting], out)
+		}
+	}
+	if !strings.Contains(out, "\n[notifications]\n") {
+		t.Errorf("expected a notifications section in:\n%s", out)
+	}
+}
+
+func TestCommand_Validate(t *testing.T) {
+	cfg := readConfig(t, "container_path = "+t.TempDir()+"\n")
+	out := captureStdout(t, func() {
+		if err := Command([]string{"validate"}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Configuration is valid") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	cfg = readConfig(t, "container_path = "+t.TempDir()+"\nwipeout = 3\n")
+	if err := Command([]string{"validate"}, cfg); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	if err := Command([]string{"edit"}, cfg); err == nil {
+		t.Error("expected an unknown subcommand to fail")
+	}
+}
+
+func TestCommand_ValidateReadOnlyContainer(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	container := t.TempDir()
+	os.Chmod(container, 0o500)
+	t.Cleanup(func() { os.Chmod(container, 0o700) })
+
+	cfg := readConfig(t, "container_path = "+container+"\n")
+	if err := Command([]string{"validate"}, cfg); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected the read-only container reported, got %v", err)
+	}
+}
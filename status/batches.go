@@ -0,0 +1,70 @@
+package status
+
+import (
+	"cmp"
+	"fmt"
+	"rubbish/journal"
+	"slices"
+	"strings"
+	"time"
+)
+
+// batchGroup holds the records tossed in the same invocation.
+type batchGroup struct {
+	ID      string
+	Tossed  int64
+	Records []*journal.MetaData
+}
+
+// groupBatches groups the records by batch, oldest batch first. Records without
+// a batch are gathered in a last group with an empty ID.
+func groupBatches(records []*journal.MetaData) []*batchGroup {
+	groups := map[string]*batchGroup{}
+	var order []*batchGroup
+
+	for _, record := range records {
+		group, ok := groups[record.Batch]
+		if !ok {
+			group = &batchGroup{ID: record.Batch, Tossed: record.TossedTime}
+			groups[record.Batch] = group
+			order = append(order, group)
+		}
+		group.Tossed = min(group.Tossed, record.TossedTime)
+		group.Records = append(group.Records, record)
+	}
+
+	slices.SortStableFunc(order, func(a, b *batchGroup) int {
+		switch {
+		case a.ID == "" || b.ID == "":
+			return strings.Compare(b.ID, a.ID) // unbatched last
+		case a.Tossed != b.Tossed:
+			return cmp.Compare(a.Tossed, b.Tossed)
+		default:
+			return strings.Compare(a.ID, b.ID)
+		}
+	})
+	return order
+}
+
+// printBatches displays the records grouped by the toss invocation they belong to.
+func printBatches(records []*journal.MetaData, zone *time.Location) {
+	if len(records) == 0 {
+		fmt.Println("No rubbish found.")
+		return
+	}
+
+	groups := groupBatches(records)
+	for _, group := range groups {
+		id := group.ID
+		if id == "" {
+			id = "(none)"
+		}
+		fmt.Printf("Batch %s | Tossed:%s | Items: %d\n", id,
+			time.Unix(group.Tossed, 0).In(zone).Format(time.DateTime), len(group.Records))
+		for _, record := range group.Records {
+			fmt.Printf(" > %s (%s)\n", record.Item, record.Origin)
+		}
+	}
+
+	fmt.Printf("Batches: %d | Total: %d\n", len(groups), len(records))
+}
@@ -0,0 +1,138 @@
+package status
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// wipeInHeader is the header of the column painted by printColumns
+const wipeInHeader = "WIPE-IN"
+
+// stdoutTerminal reports whether stdout is a terminal, replaceable for testing
+var stdoutTerminal = func() bool { return color.Terminal(os.Stdout) }
+
+// columnar reports whether the items are listed as aligned columns: as
+// requested with --columns when given, when stdout is a terminal otherwise.
+func columnar() bool {
+	explicit := false
+	Flags.Visit(func(f *flag.Flag) {
+		if f.Name == "columns" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return columnsMode
+	}
+	return stdoutTerminal()
+}
+
+// printColumns displays the records, up to --limit, as a table of aligned
+// columns and returns the number of wipeable records. Every record counts,
+// listed or not. The remaining time is colored by how close the wipeout is.
+func printColumns(records []*journal.MetaData, sizes map[string]int64, cfg *config.Config) int {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	notes := checkOrigin || checkDevice
+	header := "ITEM\tORIGIN\tTOSSED\t" + wipeInHeader + "\tSIZE"
+	if notes {
+		header += "\tNOTES"
+	}
+	fmt.Fprintln(w, header)
+
+	wipeables := 0
+	var remaining []time.Duration
+	for i, record := range records {
+		if record.IsWipeable() {
+			wipeables++
+		}
+		if limit > 0 && i >= limit {
+			continue
+		}
+
+		remaining = append(remaining, record.RemainingTime())
+		row := []string{
+			record.Item,
+			originColumn(record.Origin, cfg.WorkingDir, absoluteMode),
+			time.Unix(record.TossedTime, 0).In(cfg.Zone()).Format("2006-01-02 15:04"),
+			humanizeRemaining(record.RemainingTime()),
+			config.ReadableSize(uint64(sizes[record.Item])),
+		}
+		if notes {
+			row = append(row, itemNotes(record, cfg))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	// The colors are applied once aligned, as tabwriter would count the
+	// escape sequences as part of the cells
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	column := utf8.RuneCountInString(lines[0][:strings.Index(lines[0], wipeInHeader)])
+	fmt.Println(lines[0])
+	for i, line := range lines[1:] {
+		fmt.Println(paintCell(line, column, humanizeRemaining(remaining[i]), remainingColor(remaining[i])))
+	}
+
+	if limit > 0 && len(records) > limit {
+		fmt.Printf("…and %d more\n", len(records)-limit)
+	}
+	return wipeables
+}
+
+// originColumn returns the origin relative to the working directory when
+// within it, as is otherwise or when absolute.
+func originColumn(origin string, workingDir string, absolute bool) string {
+	if absolute || !journal.IsWithin(origin, workingDir) {
+		return origin
+	}
+	if rel, err := filepath.Rel(workingDir, origin); err == nil {
+		return rel
+	}
+	return origin
+}
+
+// itemNotes lists the --check-origin and --check-device findings of the record.
+func itemNotes(record *journal.MetaData, cfg *config.Config) string {
+	var notes []string
+	if checkOrigin && record.OriginTaken() {
+		notes = append(notes, "origin taken")
+	}
+	if checkDevice && crossDevice(record.Origin, cfg.ContainerPath) {
+		notes = append(notes, "cross device")
+	}
+	return strings.Join(notes, ", ")
+}
+
+// remainingColor returns the color of a remaining time: red once wipeable,
+// yellow within a day, green beyond.
+func remainingColor(remaining time.Duration) string {
+	switch {
+	case remaining <= 0:
+		return color.Red
+	case remaining <= day:
+		return color.Yellow
+	default:
+		return color.Green
+	}
+}
+
+// paintCell paints the text starting at the rune column of the aligned line.
+func paintCell(line string, column int, text string, code string) string {
+	runes := []rune(line)
+	end := column + utf8.RuneCountInString(text)
+	if end > len(runes) {
+		return line
+	}
+	return string(runes[:column]) + color.Paint(os.Stdout, code, string(runes[column:end])) + string(runes[end:])
+}
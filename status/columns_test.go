@@ -0,0 +1,85 @@
+package status
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	orig := stdoutTerminal
+	stdoutTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdoutTerminal = orig })
+}
+
+func TestCommand_ColumnsOnTerminal(t *testing.T) {
+	cfg := newTestConfig(t)
+	globalLookup = false
+	stubTerminal(t, true)
+
+	// Once --columns is given, the terminal no longer decides
+	columnsMode = true
+	defer func() { columnsMode = false }()
+
+	cfg.Journal.AddRecord(md("old.txt_ABCDEF", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 48*time.Hour))
+	cfg.Journal.AddRecord(md("report-final.pdf_GHIJKL", filepath.Join(cfg.WorkingDir, "docs", "report-final.pdf"), 10, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command returned error: %v", err)
+		}
+	})
+
+	lines := strings.Split(out, "\n")
+	header := lines[0]
+	for _, column := range []string{"ITEM", "ORIGIN", "TOSSED", "WIPE-IN", "SIZE"} {
+		if !strings.Contains(header, column) {
+			t.Fatalf("expected the %s column in the header, got %q", column, header)
+		}
+	}
+
+	rows := map[string]string{}
+	for _, line := range lines[1:3] {
+		rows[strings.Fields(line)[0]] = line
+	}
+	cells := map[string][]string{
+		"old.txt_ABCDEF":          {"old.txt", "Wipeable"},
+		"report-final.pdf_GHIJKL": {filepath.Join("docs", "report-final.pdf"), "d"},
+	}
+	for item, want := range cells {
+		row, ok := rows[item]
+		if !ok {
+			t.Fatalf("expected a row for %s, got:\n%s", item, out)
+		}
+		if !strings.HasPrefix(row[strings.Index(header, "ORIGIN"):], want[0]+" ") {
+			t.Errorf("the origin of %s is not aligned with its header:\n%s\n%s", item, header, row)
+		}
+		if cell := row[strings.Index(header, "WIPE-IN"):strings.Index(header, "SIZE")]; !strings.HasSuffix(strings.TrimSpace(cell), want[1]) {
+			t.Errorf("the remaining time of %s is not aligned with its header:\n%s\n%s", item, header, row)
+		}
+	}
+}
+
+func TestCommand_ColumnsOnlyOnTerminal(t *testing.T) {
+	cfg := newTestConfig(t)
+	globalLookup = false
+	stubTerminal(t, false)
+
+	cfg.Journal.AddRecord(md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 48*time.Hour))
+
+	out := captureStdout(t, func() { Command(nil, cfg) })
+	if strings.Contains(out, "WIPE-IN") || !strings.Contains(out, " > old.txt | Tossed:") {
+		t.Errorf("expected the single line format when piped, got:\n%s", out)
+	}
+
+	if err := Flags.Parse([]string{"--columns"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	defer Flags.Parse([]string{"--columns=false"})
+	out = captureStdout(t, func() { Command(nil, cfg) })
+	if !strings.Contains(out, "WIPE-IN") {
+		t.Errorf("expected --columns to force the columns when piped, got:\n%s", out)
+	}
+}
@@ -0,0 +1,98 @@
+package status
+
+import (
+	"encoding/json"
+	"io"
+	"rubbish/journal"
+	"time"
+)
+
+// Output formats accepted by the --output flag.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// jsonRecord is the machine readable representation of a rubbish item.
+type jsonRecord struct {
+	Item             string `json:"item"`
+	Origin           string `json:"origin"`
+	TossedTime       string `json:"tossed_time"`
+	WipeoutTime      string `json:"wipeout_time"`
+	Wipeable         bool   `json:"wipeable"`
+	RemainingSeconds int64  `json:"remaining_seconds"`
+	Size             int64  `json:"size"`
+}
+
+// jsonSummary holds the totals of the records in the json output.
+type jsonSummary struct {
+	Total    int   `json:"total"`
+	Wipeable int   `json:"wipeable"`
+	BinSize  int64 `json:"bin_size"`
+}
+
+// jsonSize is the document written for -s when --output=json is used: the
+// bin size measured on disk and the number, wipeable count and size of the
+// items, the whole bin alone or the selected items with filters. The toss
+// times of the oldest and newest items are only given for the whole bin.
+type jsonSize struct {
+	Total      int    `json:"total"`
+	Wipeable   int    `json:"wipeable"`
+	Size       int64  `json:"size"`
+	BinSize    int64  `json:"bin_size"`
+	OldestToss string `json:"oldest_toss,omitempty"`
+	NewestToss string `json:"newest_toss,omitempty"`
+}
+
+// jsonStatus is the document written to stdout when --output=json is used.
+type jsonStatus struct {
+	Items   []jsonRecord `json:"items"`
+	Summary jsonSummary  `json:"summary"`
+}
+
+// newJSONRecord converts the record, using item as the displayed item name and
+// rendering times in the given zone.
+func newJSONRecord(record *journal.MetaData, item string, size int64, zone *time.Location) jsonRecord {
+	tossed := time.Unix(record.TossedTime, 0).In(zone)
+	remaining := max(record.RemainingTime(), 0)
+
+	return jsonRecord{
+		Item:             item,
+		Origin:           record.Origin,
+		TossedTime:       tossed.Format(time.RFC3339),
+		WipeoutTime:      record.WipeoutDate().In(zone).Format(time.RFC3339),
+		Wipeable:         record.IsWipeable(),
+		RemainingSeconds: int64(remaining.Seconds()),
+		Size:             size,
+	}
+}
+
+// newJSONStatus builds the json document of the records and their summary.
+// Sizes are looked up by item key, records without an entry are reported as empty.
+func newJSONStatus(records []*journal.MetaData, items []string, sizes map[string]int64, binSize int64, zone *time.Location) jsonStatus {
+	doc := jsonStatus{
+		Items:   make([]jsonRecord, 0, len(records)),
+		Summary: jsonSummary{Total: len(records), BinSize: binSize},
+	}
+
+	for i, record := range records {
+		entry := newJSONRecord(record, items[i], sizes[record.Item], zone)
+		if entry.Wipeable {
+			doc.Summary.Wipeable++
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+	return doc
+}
+
+// writeJSON prints the records and their summary as a single json document.
+func writeJSON(w io.Writer, records []*journal.MetaData, items []string, sizes map[string]int64, binSize int64, zone *time.Location) error {
+	return encodeJSON(w, newJSONStatus(records, items, sizes, binSize, zone))
+}
+
+// encodeJSON prints the document indented, as every json output of status.
+func encodeJSON(w io.Writer, doc any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,49 @@
+package status
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"strconv"
+	"time"
+)
+
+// lastSeenState is the journal state holding when status last listed the items
+const lastSeenState = "last_status_seen"
+
+// lastSeen returns when status previously listed the items, the zero time
+// when it never did or --reset forgot it. Markers saved as whole seconds by
+// earlier versions are still read.
+func lastSeen(cfg *config.Config) (time.Time, error) {
+	value, err := cfg.Journal.State(lastSeenState)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading the last status run: %w", err)
+	}
+	if value == nil {
+		return time.Time{}, nil
+	}
+
+	if seen, err := time.Parse(time.RFC3339Nano, string(value)); err == nil {
+		return seen, nil
+	}
+	seconds, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid last status run '%s', clear it with --reset: %w", value, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// markSeen records now as the last time status listed the items.
+func markSeen(cfg *config.Config, now time.Time) error {
+	if err := cfg.Journal.SetState(lastSeenState, []byte(now.Format(time.RFC3339Nano))); err != nil {
+		return fmt.Errorf("error saving the last status run: %w", err)
+	}
+	return nil
+}
+
+// tossedSince reports whether the record was tossed after seen. The toss
+// times are whole seconds, so an item tossed within the second of the
+// previous run is shown again rather than missed.
+func tossedSince(record *journal.MetaData, seen time.Time) bool {
+	return record.TossedTime >= seen.Unix()
+}
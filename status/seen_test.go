@@ -0,0 +1,137 @@
+package status
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_SinceLast(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.AddRecord(md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 30, 3*time.Hour))
+	cfg.Journal.AddRecord(md("new.txt", filepath.Join(cfg.WorkingDir, "new.txt"), 30, time.Hour))
+
+	// The previous run happened two hours ago
+	previous := time.Now().Add(-2 * time.Hour).Unix()
+	cfg.Journal.SetState(lastSeenState, []byte(strconv.FormatInt(previous, 10)))
+
+	stubTerminal(t, true)
+	sinceLast = true
+	defer func() { sinceLast = false }()
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "new.txt") || strings.Contains(out, "old.txt") {
+		t.Errorf("expected only the item tossed since the previous run, got: %s", out)
+	}
+	if !strings.Contains(out, "Showing items tossed since") {
+		t.Errorf("expected the previous run reported, got: %s", out)
+	}
+
+	if seen, err := lastSeen(cfg); err != nil || seen.Unix() <= previous {
+		t.Errorf("the marker must be moved to this run, got %v, %v", seen, err)
+	}
+
+	// Nothing was tossed since
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "No rubbish found.") {
+		t.Errorf("expected nothing new, got: %s", out)
+	}
+}
+
+func TestCommand_SinceLastReset(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.AddRecord(md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 30, 3*time.Hour))
+	cfg.Journal.SetState(lastSeenState, []byte(strconv.FormatInt(time.Now().Unix(), 10)))
+
+	resetSeen = true
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	resetSeen = false
+
+	if value, _ := cfg.Journal.State(lastSeenState); value != nil {
+		t.Errorf("expected the marker cleared, got %s", value)
+	}
+
+	sinceLast = true
+	defer func() { sinceLast = false }()
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "old.txt") {
+		t.Errorf("without a previous run every item must be shown, got: %s", out)
+	}
+}
+
+func TestCommand_SinceLastInvalidMarker(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.SetState(lastSeenState, []byte("yesterday"))
+
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "--reset") {
+		t.Errorf("expected the invalid marker reported with its fix, got %v", err)
+	}
+}
+
+func TestCommand_SinceLastSameSecond(t *testing.T) {
+	cfg := newTestConfig(t)
+	tossed := time.Now().Truncate(time.Second)
+	record := md("new.txt", filepath.Join(cfg.WorkingDir, "new.txt"), 30, 0)
+	record.TossedTime = tossed.Unix()
+	cfg.Journal.AddRecord(record)
+
+	// The previous run happened later within the second of the toss
+	markSeen(cfg, tossed.Add(500*time.Millisecond))
+
+	sinceLast = true
+	defer func() { sinceLast = false }()
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "new.txt") {
+		t.Errorf("an item tossed within the second of the previous run must be shown, got: %s", out)
+	}
+}
+
+func TestCommand_SinceLastOnlyDisplayedListingsMark(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.AddRecord(md("new.txt", filepath.Join(cfg.WorkingDir, "new.txt"), 30, time.Hour))
+
+	run := func() {
+		t.Helper()
+		captureStdout(t, func() {
+			if err := Command(nil, cfg); err != nil {
+				t.Fatalf("Command error: %v", err)
+			}
+		})
+	}
+
+	outputFormat = OutputJSON
+	stubTerminal(t, true)
+	run()
+	outputFormat = OutputText
+	if value, _ := cfg.Journal.State(lastSeenState); value != nil {
+		t.Errorf("JSON runs must leave the marker, got %s", value)
+	}
+
+	// A text listing piped to another program moves it too
+	stubTerminal(t, false)
+	run()
+	if value, _ := cfg.Journal.State(lastSeenState); value == nil {
+		t.Error("a piped text listing must move the marker")
+	}
+}
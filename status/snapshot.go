@@ -0,0 +1,112 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// snapshotKey identifies a record across snapshots, by container key and origin.
+type snapshotKey struct {
+	Item   string
+	Origin string
+}
+
+// writeSnapshot saves every record in the journal to file, using the json output format.
+// Items are stored with their container key so they can be matched later on.
+func writeSnapshot(cfg *config.Config, file string, binSize int64) error {
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	items := make([]string, len(records))
+	sizes := make(map[string]int64, len(records))
+	for i, record := range records {
+		items[i] = record.Item
+		sizes[record.Item] = config.RecordSize(cfg, record)
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("error creating snapshot %s: %w", file, err)
+	}
+
+	if err := writeJSON(out, records, items, sizes, binSize, cfg.Zone()); err != nil {
+		out.Close()
+		return fmt.Errorf("error writing snapshot %s: %w", file, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot %s: %w", file, err)
+	}
+
+	fmt.Printf("Snapshot of %d items written to %s\n", len(records), file)
+	return nil
+}
+
+// readSnapshot loads the items stored in a snapshot file.
+func readSnapshot(file string) ([]jsonRecord, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot %s: %w", file, err)
+	}
+
+	var doc jsonStatus
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", file, err)
+	}
+	return doc.Items, nil
+}
+
+// diffSnapshot returns the records added to the journal and the snapshot items
+// no longer present in it, both in their original order.
+func diffSnapshot(previous []jsonRecord, current []*journal.MetaData) (added []*journal.MetaData, removed []jsonRecord) {
+	before := make(map[snapshotKey]bool, len(previous))
+	for _, item := range previous {
+		before[snapshotKey{item.Item, item.Origin}] = true
+	}
+
+	now := make(map[snapshotKey]bool, len(current))
+	for _, record := range current {
+		key := snapshotKey{record.Item, record.Origin}
+		now[key] = true
+		if !before[key] {
+			added = append(added, record)
+		}
+	}
+
+	for _, item := range previous {
+		if !now[snapshotKey{item.Item, item.Origin}] {
+			removed = append(removed, item)
+		}
+	}
+
+	return added, removed
+}
+
+// printDiff reports the items tossed and removed since the snapshot was taken.
+func printDiff(cfg *config.Config, file string) error {
+	previous, err := readSnapshot(file)
+	if err != nil {
+		return err
+	}
+
+	current, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	added, removed := diffSnapshot(previous, current)
+
+	for _, record := range added {
+		fmt.Printf(" + %s (%s)\n", record.Item, record.Origin)
+	}
+	for _, item := range removed {
+		fmt.Printf(" - %s (%s)\n", item.Item, item.Origin)
+	}
+
+	fmt.Printf("Added: %d | Removed: %d since %s\n", len(added), len(removed), file)
+	return nil
+}
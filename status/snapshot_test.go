@@ -0,0 +1,81 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/tosser"
+	"rubbish/wipe"
+)
+
+func TestSnapshotThenDiff(t *testing.T) {
+	cfg := newTestConfig(t)
+	snapshot := filepath.Join(t.TempDir(), "snapshot.json")
+
+	gone := md("gone.txt", filepath.Join(cfg.WorkingDir, "gone.txt"), 1, 48*time.Hour)
+	kept := md("kept.txt", filepath.Join(cfg.WorkingDir, "kept.txt"), 10, time.Hour)
+	for _, record := range []string{gone.Item, kept.Item} {
+		os.WriteFile(filepath.Join(cfg.ContainerPath, record), []byte("x"), 0o644)
+	}
+	cfg.Journal.AddRecord(gone)
+	cfg.Journal.AddRecord(kept)
+
+	snapshotFile = snapshot
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("snapshot error: %v", err)
+		}
+	})
+	snapshotFile = ""
+	if !strings.Contains(out, "Snapshot of 2 items") {
+		t.Errorf("unexpected snapshot output: %s", out)
+	}
+
+	// toss a new file and wipe the expired item
+	source := filepath.Join(t.TempDir(), "fresh.txt")
+	os.WriteFile(source, []byte("fresh"), 0o644)
+	captureStdout(t, func() {
+		if err := tosser.Toss(source, cfg); err != nil {
+			t.Fatalf("toss: %v", err)
+		}
+		wipe.Flags.Parse([]string{"-g", "-y"})
+		defer wipe.Flags.Parse([]string{"-g=false", "-y=false"})
+		if err := wipe.Command(nil, cfg); err != nil {
+			t.Fatalf("wipe: %v", err)
+		}
+	})
+
+	diffFile = snapshot
+	defer func() { diffFile = "" }()
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("diff error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, " + fresh.txt_") || !strings.Contains(out, "("+source+")") {
+		t.Errorf("tossed item not reported as added: %s", out)
+	}
+	if !strings.Contains(out, " - "+gone.Item+" ("+gone.Origin+")") {
+		t.Errorf("wiped item not reported as removed: %s", out)
+	}
+	if strings.Contains(out, kept.Item) {
+		t.Errorf("unchanged item must not be reported: %s", out)
+	}
+	if !strings.Contains(out, "Added: 1 | Removed: 1") {
+		t.Errorf("unexpected diff summary: %s", out)
+	}
+}
+
+func TestCommand_DiffMissingSnapshot(t *testing.T) {
+	cfg := newTestConfig(t)
+	diffFile = filepath.Join(t.TempDir(), "missing.json")
+	defer func() { diffFile = "" }()
+
+	if err := Command(nil, cfg); err == nil {
+		t.Fatal("expected error for a missing snapshot")
+	}
+}
@@ -1,37 +1,139 @@
 package status
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"path"
 	"rubbish/config"
+	"rubbish/format"
+	"rubbish/fsutil"
 	"rubbish/journal"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 )
 
 var (
-	Flags             = flag.NewFlagSet("status", flag.ExitOnError)
-	globalLookup bool = false
-	sizeOnly     bool = false
-	wipeableOnly bool = false
+	Flags              = flag.NewFlagSet("status", flag.ExitOnError)
+	globalLookup  bool = false
+	sizeOnly      bool = false
+	wipeableOnly  bool = false
+	checkDevice   bool = false
+	checkOrigin   bool = false // checkOrigin marks with ! the items whose origin is taken
+	usageMode     bool = false
+	outputFormat       = OutputText
+	batchesMode   bool = false
+	sortBy             = SortName
+	snapshotFile       = ""
+	diffFile           = ""
+	tossedAfter        = ""
+	tossedBefore       = ""
+	typeFilter         = ""
+	usageDepth         = 1
+	binName            = ""    // binName is the named bin to show, empty for the default bin
+	quietMode     bool = false // quietMode prints nothing and reports the wipeable items through the exit code
+	threshold          = 0     // threshold is the number of wipeable items tolerated by --quiet
+	limit              = 0     // limit is the number of items displayed, 0 displaying them all
+	treeMode      bool = false // treeMode displays the items under their origin directories
+	formatText         = ""    // formatText is the --format template printing each item, empty for the default listing
+	sinceLast     bool = false // sinceLast displays only the items tossed since the previous status run
+	resetSeen     bool = false // resetSeen clears the marker of the previous status run
+	columnsMode   bool = false // columnsMode lists the items as aligned columns, see columnar
+	watchMode     bool = false // watchMode displays the status again every watchInterval, see watch
+	watchInterval      = 2 * time.Second
+	absoluteMode  bool = false // absoluteMode displays the origins as they are, not relative to the working directory
+
+	// crossDevice tells an origin restored into another filesystem than the
+	// container, replaceable for testing
+	crossDevice = fsutil.CrossDevice
 )
 
 func init() {
 	Flags.BoolVar(&globalLookup, "g", false, "Display rubbish status globally")
+	Flags.StringVar(&binName, "bin", "", "Display the named bin instead of the default one.")
 	Flags.BoolVar(&sizeOnly, "s", false, "Display the rubbish bin size only.")
 	Flags.BoolVar(&wipeableOnly, "w", false, "Display only wipeable rubbish items.")
+	Flags.StringVar(&outputFormat, "output", OutputText, "Output format: text or json.")
+	Flags.BoolVar(&usageMode, "usage", false, "Display the bin size taken by each top-level origin directory.")
+	Flags.BoolVar(&usageMode, "breakdown", false, "Alias of --usage.")
+	Flags.IntVar(&usageDepth, "depth", 1, "Number of origin path components grouped together by --usage.")
+	Flags.StringVar(&sortBy, "sort", SortName, "Sort items by name, size (largest first) or remaining time (closest to wipeout first).")
+	Flags.IntVar(&limit, "limit", 0, "Display only the first `N` items once sorted, the totals still covering them all.")
+	Flags.IntVar(&limit, "n", 0, "Alias of --limit.")
+	Flags.StringVar(&formatText, "format", "", "Print each item through the given Go `template`, e.g. '{{.Item}} {{.RemainingDays}}'.")
+	Flags.BoolVar(&treeMode, "tree", false, "Display the items as a tree of their origin directories.")
+	Flags.BoolVar(&treeMode, "group-by-origin", false, "Alias of --tree.")
+	Flags.BoolVar(&columnsMode, "columns", false, "List the items as aligned, colored columns, the default when stdout is a terminal (--columns=false for one line per item).")
+	Flags.BoolVar(&watchMode, "watch", false, "Display the status again every --interval until interrupted.")
+	Flags.DurationVar(&watchInterval, "interval", 2*time.Second, "Refresh `interval` of --watch, e.g. 5s.")
+	Flags.BoolVar(&absoluteMode, "absolute", false, "Display the absolute origin of the items instead of their path relative to the working directory.")
+	Flags.BoolVar(&batchesMode, "batches", false, "Display the items grouped by the toss invocation they belong to.")
+	Flags.StringVar(&snapshotFile, "snapshot", "", "Write the current record set to the given file.")
+	Flags.StringVar(&diffFile, "diff", "", "Report the items added and removed since the given snapshot file.")
+	Flags.BoolVar(&sinceLast, "since-last", false, "Display only the items tossed since the previous status run.")
+	Flags.BoolVar(&resetSeen, "reset", false, "Forget the previous status run, so the next --since-last displays every item.")
+	Flags.StringVar(&tossedAfter, "after", "", "Display only the items tossed on or after the given date (YYYY-MM-DD).")
+	Flags.StringVar(&tossedBefore, "before", "", "Display only the items tossed before the given date (YYYY-MM-DD).")
+	Flags.StringVar(&typeFilter, "type", "", "Display only the items of the given type: file, dir, symlink or other.")
+	Flags.BoolVar(&checkDevice, "check-device", false, "Mark items whose origin is on a different device than the container.")
+	Flags.BoolVar(&checkOrigin, "check-origin", false, "Mark with ! the items whose origin is taken, restoring them there would conflict.")
+	Flags.BoolVar(&quietMode, "quiet", false, "Print nothing, exit with code 4 when more than --threshold items are wipeable.")
+	Flags.IntVar(&threshold, "threshold", 0, "Number of wipeable items tolerated by --quiet.")
 
 	// configure the command options and flags
 	Flags.Usage = func() {
 		fmt.Println("Rubbish Status shows the current state of rubbish container.\n",
 			"Usage:\n\n",
-			"\trubbish status [options]\n\n",
+			"\trubbish status [options]\n",
+			"\trubbish status --quiet [--threshold=N]\n",
+			"\trubbish status --watch [--interval=5s]\n\n",
+			"With --quiet nothing is printed and the exit code tells the state of the\n",
+			"whole bin: 0 when at most N items are wipeable (none by default), 4 when\n",
+			"more are, 2 on any other error.\n\n",
 			"Options:")
 		Flags.PrintDefaults()
 	}
 }
 
+// Sorting criteria accepted by the --sort flag.
+const (
+	SortName      = "name"
+	SortSize      = "size"
+	SortRemaining = "remaining"
+)
+
+// ErrWipeablePending is returned by --quiet when more wipeable items than the
+// threshold are pending. The caller maps it to a dedicated exit code.
+var ErrWipeablePending = errors.New("wipeable items pending")
+
+// MachineOutput reports whether the requested output is meant for scripts,
+// in which case nothing else must be written to stdout.
+func MachineOutput() bool {
+	return outputFormat == OutputJSON || quietMode || formatText != ""
+}
+
+// checkWipeable counts the wipeable items of the bin, from the single journal
+// pass of Stats, and returns ErrWipeablePending when there are more than the
+// threshold.
+func checkWipeable(cfg *config.Config, threshold int) error {
+	if threshold < 0 {
+		return fmt.Errorf("invalid --threshold %d, expected 0 or more", threshold)
+	}
+
+	_, count, _, _, _, err := cfg.Journal.Stats()
+	if err != nil {
+		return fmt.Errorf("error counting wipeable items: %w", err)
+	}
+	if count > threshold {
+		return fmt.Errorf("%w: %d over a threshold of %d", ErrWipeablePending, count, threshold)
+	}
+	return nil
+}
+
 // The status command is intended to show the current state of the rubbish,
 // including the number of items, their retention times, and any other relevant
 // metadata that can help users understand what is currently in the rubbish.
@@ -40,15 +142,89 @@ func Command(args []string, cfg *config.Config) error {
 	var records []*journal.MetaData
 	var err error
 
+	if binName != "" {
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	if quietMode {
+		return checkWipeable(cfg, threshold)
+	}
+
+	if outputFormat != OutputText && outputFormat != OutputJSON {
+		return fmt.Errorf("invalid output format '%s' (expected text or json)", outputFormat)
+	}
+
+	if sortBy != SortName && sortBy != SortSize && sortBy != SortRemaining {
+		return fmt.Errorf("invalid sort criteria '%s' (expected name, size or remaining)", sortBy)
+	}
+
+	if limit < 0 {
+		return fmt.Errorf("invalid --limit %d, expected 0 or more", limit)
+	}
+
+	var tmpl *template.Template
+	if formatText != "" {
+		if outputFormat == OutputJSON || treeMode || batchesMode {
+			return fmt.Errorf("--format cannot be combined with --output=json, --tree or --batches")
+		}
+		if tmpl, err = format.Parse(formatText); err != nil {
+			return err
+		}
+	}
+
+	after, before, err := cfg.TossedRange(tossedAfter, tossedBefore)
+	if err != nil {
+		return err
+	}
+	dateFiltered := !after.IsZero() || !before.IsZero()
+	filtered := dateFiltered || typeFilter != ""
+
+	if typeFilter != "" {
+		if _, err := journal.ParseType(typeFilter); err != nil {
+			return err
+		}
+	}
+
+	if resetSeen {
+		if err := cfg.Journal.ClearState(lastSeenState); err != nil {
+			return fmt.Errorf("error clearing the last status run: %w", err)
+		}
+		fmt.Println("Previous status run forgotten, --since-last displays every item again.")
+		return nil
+	}
+
+	seen, err := lastSeen(cfg)
+	if err != nil {
+		return err
+	}
+	filtered = filtered || sinceLast
+
 	totalSize, err := config.BinSize(cfg)
 
 	if err != nil {
 		return fmt.Errorf("error retrieving rubbish bin size: %w", err)
 	}
 
-	if sizeOnly {
+	if sizeOnly && !filtered {
+		if outputFormat == OutputJSON {
+			return writeStatsJSON(cfg, totalSize)
+		}
 		fmt.Printf("Rubbish bin size: %s\n", config.ReadableSize(uint64(totalSize)))
-		return nil
+		return printStats(cfg)
+	}
+
+	if usageMode {
+		return printUsage(cfg, usageDepth)
+	}
+
+	if snapshotFile != "" {
+		return writeSnapshot(cfg, snapshotFile, totalSize)
+	}
+
+	if diffFile != "" {
+		return printDiff(cfg, diffFile)
 	}
 
 	records, err = retrieveJournalRecords(cfg)
@@ -57,9 +233,84 @@ func Command(args []string, cfg *config.Config) error {
 		return fmt.Errorf("error retrieving rubbish items: %w", err)
 	}
 
+	if dateFiltered {
+		records = journal.TossedBetween(records, after, before)
+	}
+
+	if sinceLast {
+		records = slices.DeleteFunc(records, func(record *journal.MetaData) bool {
+			return !tossedSince(record, seen)
+		})
+	}
+
+	if sizeOnly {
+		var size int64
+		wipeable := 0
+		for _, record := range records {
+			size += config.RecordSize(cfg, record)
+			if record.IsWipeable() {
+				wipeable++
+			}
+		}
+		if outputFormat == OutputJSON {
+			return encodeJSON(os.Stdout, jsonSize{Total: len(records), Wipeable: wipeable, Size: size, BinSize: totalSize})
+		}
+		fmt.Printf("Rubbish size of the %d selected items: %s\n", len(records), config.ReadableSize(uint64(size)))
+		return nil
+	}
+
+	if batchesMode {
+		printBatches(records, cfg.Zone())
+		return nil
+	}
+
+	sizes := make(map[string]int64, len(records))
+	for _, record := range records {
+		sizes[record.Item] = config.RecordSize(cfg, record)
+	}
+	switch sortBy {
+	case SortSize:
+		slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+			return cmp.Compare(sizes[b.Item], sizes[a.Item])
+		})
+	case SortRemaining:
+		slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+			return cmp.Compare(a.RemainingTime(), b.RemainingTime())
+		})
+	}
+
+	if tmpl != nil {
+		if limit > 0 && len(records) > limit {
+			records = records[:limit]
+		}
+		return format.Write(os.Stdout, tmpl, records, cfg)
+	}
+
+	if outputFormat == OutputJSON {
+		items := make([]string, len(records))
+		for i, record := range records {
+			items[i] = record.Item
+			if !globalLookup {
+				items[i] = relativePath(record, cfg.WorkingDir)
+			}
+		}
+		return writeJSON(os.Stdout, records, items, sizes, totalSize, cfg.Zone())
+	}
+
+	// Only the text listings move the marker of --since-last, piped or not;
+	// --watch refreshes would otherwise hide what was tossed since the last run
+	if !watchMode {
+		if err := markSeen(cfg, now()); err != nil {
+			return err
+		}
+	}
+
 	if globalLookup {
 		fmt.Println("Showing global rubbish status")
 	}
+	if sinceLast && !seen.IsZero() {
+		fmt.Printf("Showing items tossed since %s\n", seen.In(cfg.Zone()).Format(time.DateTime))
+	}
 
 	count := len(records)
 	wipeables := 0
@@ -71,40 +322,156 @@ func Command(args []string, cfg *config.Config) error {
 
 	println("Rubbish:")
 
-	for _, record := range records {
+	if treeMode {
+		wipeables = printTree(records, sizes, cfg)
+	} else if columnar() {
+		wipeables = printColumns(records, sizes, cfg)
+	} else {
+		wipeables = printFlat(records, displayNames(records, cfg), sizes, cfg)
+	}
 
-		if !globalLookup {
-			// Update the item name to reflect that is relative to the working directory
-			record.Item = relativePath(record, cfg.WorkingDir)
-		}
+	fmt.Printf("Total: %d | Wipable: %d | Bin Size: %s", count, wipeables, config.ReadableSize(uint64(totalSize)))
+	if orphans, err := config.Orphans(cfg); err == nil && len(orphans) > 0 {
+		fmt.Printf(" | Orphans: %d (see 'rubbish wipe --orphans')", len(orphans))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// printStats summarizes the records of the whole bin from a single pass over
+// the journal, the sizes being those recorded at toss time.
+func printStats(cfg *config.Config) error {
+	total, wipeable, size, oldest, newest, err := cfg.Journal.Stats()
+	if err != nil {
+		return fmt.Errorf("error summarizing rubbish items: %w", err)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	fmt.Printf("Items: %d | Wipeable: %d | Tossed size: %s | Tossed between %s and %s\n",
+		total, wipeable, config.ReadableSize(uint64(size)),
+		oldest.In(cfg.Zone()).Format(time.DateOnly), newest.In(cfg.Zone()).Format(time.DateOnly))
+	return nil
+}
 
+// writeStatsJSON prints the summary of printStats, with the bin size measured
+// on disk, as a json document.
+func writeStatsJSON(cfg *config.Config, binSize int64) error {
+	total, wipeable, size, oldest, newest, err := cfg.Journal.Stats()
+	if err != nil {
+		return fmt.Errorf("error summarizing rubbish items: %w", err)
+	}
+
+	doc := jsonSize{Total: total, Wipeable: wipeable, Size: size, BinSize: binSize}
+	if total > 0 {
+		doc.OldestToss = oldest.In(cfg.Zone()).Format(time.RFC3339)
+		doc.NewestToss = newest.In(cfg.Zone()).Format(time.RFC3339)
+	}
+	return encodeJSON(os.Stdout, doc)
+}
+
+// printFlat displays one line per record under its name, up to --limit, and
+// returns the number of wipeable records. Every record counts, listed or not.
+func printFlat(records []*journal.MetaData, names []string, sizes map[string]int64, cfg *config.Config) int {
+	wipeables := 0
+	for i, record := range records {
 		if record.IsWipeable() {
 			wipeables++
 		}
+		if limit > 0 && i >= limit {
+			continue
+		}
 
-		fmt.Println(" > " + String(record))
+		fmt.Println(" " + bullet(record) + " " + itemLine(record, names[i], sizes[record.Item], cfg))
 	}
+	if limit > 0 && len(records) > limit {
+		fmt.Printf("…and %d more\n", len(records)-limit)
+	}
+	return wipeables
+}
 
-	fmt.Printf("Total: %d | Wipable: %d | Bin Size: %s\n", count, wipeables, config.ReadableSize(uint64(totalSize)))
+// bullet returns the marker printed before the record, ! instead of > when
+// --check-origin finds its origin taken.
+func bullet(record *journal.MetaData) string {
+	if checkOrigin && record.OriginTaken() {
+		return "!"
+	}
+	return ">"
+}
 
-	return nil
+// displayName returns the name the record is listed under: its origin with
+// --absolute, its item with -g, its path relative to the working directory
+// otherwise. The record is left untouched.
+func displayName(record *journal.MetaData, cfg *config.Config) string {
+	switch {
+	case absoluteMode:
+		return record.Origin
+	case globalLookup:
+		return record.Item
+	default:
+		return relativePath(record, cfg.WorkingDir)
+	}
+}
+
+// displayNames returns the display name of each record, in order, without
+// writing them back into the records, which may be rendered again.
+func displayNames(records []*journal.MetaData, cfg *config.Config) []string {
+	names := make([]string, len(records))
+	for i, record := range records {
+		names[i] = displayName(record, cfg)
+	}
+	return names
+}
+
+// itemLine describes the record, listed under name, with its size, marking
+// the origins on another device with --check-device.
+func itemLine(record *journal.MetaData, name string, size int64, cfg *config.Config) string {
+	line := describe(record, name) + " | Size:" + config.ReadableSize(uint64(size))
+	if checkDevice && crossDevice(record.Origin, cfg.ContainerPath) {
+		line += " | CrossDevice"
+	}
+	return line
 }
 
 func retrieveJournalRecords(cfg *config.Config) ([]*journal.MetaData, error) {
 	var (
-		records []*journal.MetaData
-		err     error
+		records  []*journal.MetaData
+		err      error
+		itemType uint
 	)
 
+	if typeFilter != "" {
+		if itemType, err = journal.ParseType(typeFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	// -w selects among the wipeable items, scoped to the working directory
+	// unless -g is given as well.
 	switch {
-	case globalLookup:
-		records, err = cfg.Journal.List()
 	case wipeableOnly:
 		records, err = cfg.Journal.FilterWipeable()
+		if err == nil && !globalLookup {
+			records = slices.DeleteFunc(records, func(record *journal.MetaData) bool {
+				return !journal.IsWithin(record.Origin, cfg.WorkingDir)
+			})
+		}
+	case globalLookup && typeFilter != "":
+		return cfg.Journal.FilterByType(itemType)
+	case globalLookup:
+		records, err = cfg.Journal.List()
 	default:
 		records, err = cfg.Journal.FilterPath(cfg.WorkingDir)
 	}
 
+	if err == nil && typeFilter != "" {
+		records = slices.DeleteFunc(records, func(record *journal.MetaData) bool {
+			return record.Type != itemType
+		})
+	}
+
 	return records, err
 }
 
@@ -117,19 +484,47 @@ func relativePath(record *journal.MetaData, workingDir string) string {
 }
 
 func String(record *journal.MetaData) string {
+	return describe(record, record.Item)
+}
+
+// describe formats the record as String does, listed under name.
+func describe(record *journal.MetaData, name string) string {
 	const msg = "%s | Tossed:%v | %s"
 
 	remaining := record.RemainingTime()
-	var remain_msg string
+	remain_msg := humanizeRemaining(remaining)
+	if remaining > 0 {
+		remain_msg = "WipeIn:" + remain_msg
+	}
+
+	return fmt.Sprintf(msg, name, record.TossElapsed().Round(time.Second), remain_msg)
+}
+
+// Units of the long remaining times, a month being the mean Gregorian one.
+const (
+	day   = 24 * time.Hour
+	week  = 7 * day
+	month = 2629746 * time.Second
+	year  = 12 * month
+)
 
+// humanizeRemaining formats the time left before an item becomes wipeable:
+// the rounded duration below a day, then days up to a month, weeks up to
+// three months, months up to a year and years beyond. Items with no time
+// left are Wipeable.
+func humanizeRemaining(d time.Duration) string {
 	switch {
-	case remaining.Hours() > 24.0:
-		remain_msg = fmt.Sprintf("WipeIn:%.01fd", remaining.Hours()/24.0)
-	case remaining.Hours() > 0:
-		remain_msg = fmt.Sprintf("WipeIn:%v", remaining.Round(time.Second))
+	case d <= 0:
+		return "Wipeable"
+	case d <= day:
+		return d.Round(time.Second).String()
+	case d <= 30*day:
+		return fmt.Sprintf("%.01fd", float64(d)/float64(day))
+	case d <= 3*month:
+		return fmt.Sprintf("%.01fw", float64(d)/float64(week))
+	case d < year:
+		return fmt.Sprintf("%.01fmo", float64(d)/float64(month))
 	default:
-		remain_msg = "Wipeable"
+		return fmt.Sprintf("%.01fy", float64(d)/float64(year))
 	}
-
-	return fmt.Sprintf(msg, record.Item, record.TossElapsed().Round(time.Second), remain_msg)
 }
@@ -2,6 +2,9 @@ package status
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -102,6 +105,54 @@ func TestCommand_LocalRecords(t *testing.T) {
 	}
 }
 
+func TestPrintFlat_Absolute(t *testing.T) {
+	cfg := newTestConfig(t)
+	globalLookup = false
+	origin := filepath.Join(cfg.WorkingDir, "sub/new.txt")
+	records := []*journal.MetaData{md("new.txt_ABCDEF", origin, 10, 2*time.Hour)}
+
+	out := captureStdout(t, func() { printFlat(records, displayNames(records, cfg), nil, cfg) })
+	if !strings.Contains(out, " > sub/new.txt_ABCDEF | Tossed:") {
+		t.Errorf("expected the path relative to the working directory by default, got: %s", out)
+	}
+
+	absoluteMode = true
+	defer func() { absoluteMode = false }()
+	out = captureStdout(t, func() { printFlat(records, displayNames(records, cfg), nil, cfg) })
+	if !strings.Contains(out, " > "+origin+" | Tossed:") {
+		t.Errorf("expected the absolute origin with --absolute, got: %s", out)
+	}
+
+	if records[0].Item != "new.txt_ABCDEF" || records[0].Origin != origin {
+		t.Errorf("expected the record to be left untouched, got %+v", records[0])
+	}
+}
+
+func TestRender_SameRecordsTwice(t *testing.T) {
+	cfg := newTestConfig(t)
+	globalLookup = false
+	records := []*journal.MetaData{
+		md("new.txt_ABCDEF", filepath.Join(cfg.WorkingDir, "sub/new.txt"), 10, 2*time.Hour),
+		md("deep.txt_ABCDEF", filepath.Join(cfg.WorkingDir, "a/b/deep.txt"), 1, 48*time.Hour),
+	}
+
+	renders := map[string]func(){
+		"flat":    func() { printFlat(records, displayNames(records, cfg), nil, cfg) },
+		"columns": func() { printColumns(records, nil, cfg) },
+		"tree":    func() { printTree(records, nil, cfg) },
+	}
+	for name, render := range renders {
+		first := captureStdout(t, render)
+		second := captureStdout(t, render)
+		if first != second {
+			t.Errorf("%s: expected the same output twice, got:\n%s\nthen:\n%s", name, first, second)
+		}
+	}
+	if records[0].Item != "new.txt_ABCDEF" || records[1].Item != "deep.txt_ABCDEF" {
+		t.Errorf("expected the items to be left untouched, got %s and %s", records[0].Item, records[1].Item)
+	}
+}
+
 func TestCommand_GlobalRecords(t *testing.T) {
 	cfg := newTestConfig(t)
 	globalLookup = true
@@ -143,6 +194,29 @@ func TestCommand_GlobalRecords(t *testing.T) {
 // 	}
 // }
 
+func TestCommand_ReportsOrphans(t *testing.T) {
+	cfg := newTestConfig(t)
+	os.Remove(cfg.WorkingDir)
+	cfg.WorkingDir = t.TempDir()
+	globalLookup = true
+	defer func() { globalLookup = false }()
+
+	r := md("kept.txt", filepath.Join(cfg.WorkingDir, "kept.txt"), 10, time.Hour)
+	cfg.Journal.AddRecord(r)
+	os.WriteFile(filepath.Join(cfg.ContainerPath, r.Item), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(cfg.ContainerPath, "lost_QWERTY"), []byte("x"), 0o644)
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "| Orphans: 1") {
+		t.Errorf("expected orphan count in totals line, got: %s", out)
+	}
+}
+
 func TestStringFormatting(t *testing.T) {
 	// Remaining > 24h triggers day format
 	m := md("file.txt", "/origin/file.txt", 10, 48*time.Hour) // 8 days remain
@@ -162,3 +236,516 @@ func TestStringFormatting(t *testing.T) {
 		t.Errorf("expected duration style remaining, got: %s", s2)
 	}
 }
+
+func TestCommand_CheckDeviceMarksCrossDevice(t *testing.T) {
+	cfg := newTestConfig(t)
+	checkDevice = true
+	defer func() { checkDevice = false }()
+
+	// simulate the "elsewhere" subtree living on another filesystem
+	elsewhere := filepath.Join(cfg.WorkingDir, "elsewhere")
+	origCrossDevice := crossDevice
+	crossDevice = func(origin, container string) bool {
+		return strings.HasPrefix(origin, elsewhere)
+	}
+	defer func() { crossDevice = origCrossDevice }()
+
+	cfg.Journal.AddRecord(md("same.txt", filepath.Join(cfg.WorkingDir, "same.txt"), 5, time.Hour))
+	cfg.Journal.AddRecord(md("far.txt", filepath.Join(elsewhere, "far.txt"), 5, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.Contains(line, "same.txt") && strings.Contains(line, "CrossDevice"):
+			t.Errorf("same device item marked as cross device: %s", line)
+		case strings.Contains(line, "far.txt") && !strings.Contains(line, "CrossDevice"):
+			t.Errorf("cross device item not marked: %s", line)
+		}
+	}
+}
+
+func TestCommand_CheckOriginMarksConflicts(t *testing.T) {
+	cfg := newTestConfig(t)
+	os.WriteFile(filepath.Join(cfg.WorkingDir, "taken.txt"), []byte("new"), 0o644)
+	cfg.Journal.AddRecord(md("taken.txt", filepath.Join(cfg.WorkingDir, "taken.txt"), 5, time.Hour))
+	cfg.Journal.AddRecord(md("free.txt", filepath.Join(cfg.WorkingDir, "free.txt"), 5, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if strings.Contains(out, " ! ") {
+		t.Errorf("origins must only be checked with --check-origin: %s", out)
+	}
+
+	checkOrigin = true
+	defer func() { checkOrigin = false }()
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.Contains(line, "taken.txt") && !strings.HasPrefix(line, " ! "):
+			t.Errorf("conflicting item not marked: %s", line)
+		case strings.Contains(line, "free.txt") && !strings.HasPrefix(line, " > "):
+			t.Errorf("free item marked: %s", line)
+		}
+	}
+}
+
+func TestCommand_OutputJSON(t *testing.T) {
+	cfg := newTestConfig(t)
+	outputFormat = OutputJSON
+	defer func() { outputFormat = OutputText }()
+
+	cfg.Journal.AddRecord(md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 48*time.Hour))
+	cfg.Journal.AddRecord(md("new.txt", filepath.Join(cfg.WorkingDir, "sub/new.txt"), 10, 2*time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	var doc jsonStatus
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("stdout is not pure json: %v\n%s", err, out)
+	}
+	if doc.Summary.Total != 2 || doc.Summary.Wipeable != 1 {
+		t.Errorf("unexpected summary: %+v", doc.Summary)
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("json output must not contain ANSI codes: %s", out)
+	}
+
+	for _, item := range doc.Items {
+		switch item.Item {
+		case "old.txt":
+			if !item.Wipeable || item.RemainingSeconds != 0 {
+				t.Errorf("old.txt should be wipeable: %+v", item)
+			}
+		case "sub/new.txt":
+			if item.Wipeable || item.RemainingSeconds <= 0 {
+				t.Errorf("new.txt should not be wipeable: %+v", item)
+			}
+			if _, err := time.Parse(time.RFC3339, item.TossedTime); err != nil {
+				t.Errorf("tossed_time is not RFC3339: %s", item.TossedTime)
+			}
+		default:
+			t.Errorf("unexpected item %q", item.Item)
+		}
+	}
+}
+
+func TestCommand_InvalidOutput(t *testing.T) {
+	cfg := newTestConfig(t)
+	outputFormat = "yaml"
+	defer func() { outputFormat = OutputText }()
+	if err := Command(nil, cfg); err == nil {
+		t.Fatal("expected error for invalid output format")
+	}
+}
+
+func TestCommand_BatchesGroupsItems(t *testing.T) {
+	cfg := newTestConfig(t)
+	globalLookup, batchesMode = true, true
+	defer func() { globalLookup, batchesMode = false, false }()
+
+	for _, r := range []struct{ item, batch string }{
+		{"a.txt", "20240115-093000-AAAA"},
+		{"b.txt", "20240115-093000-AAAA"},
+		{"c.txt", "20240116-100000-BBBB"},
+		{"old.txt", ""},
+	} {
+		record := md(r.item, filepath.Join(cfg.WorkingDir, r.item), 10, time.Hour)
+		record.Batch = r.batch
+		cfg.Journal.AddRecord(record)
+	}
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !regexp.MustCompile(`Batch 20240115-093000-AAAA \| Tossed:.* \| Items: 2\n > a.txt .*\n > b.txt `).MatchString(out) {
+		t.Errorf("items of a batch must be listed together: %s", out)
+	}
+	if !strings.Contains(out, "Batch (none)") || strings.Index(out, "Batch (none)") < strings.Index(out, "BBBB") {
+		t.Errorf("unbatched items must be listed last: %s", out)
+	}
+	if !strings.Contains(out, "Batches: 3 | Total: 4") {
+		t.Errorf("unexpected totals: %s", out)
+	}
+}
+
+func TestCommand_SortBySizeLargestFirst(t *testing.T) {
+	cfg := newTestConfig(t)
+	sortBy = SortSize
+	defer func() { sortBy = SortName }()
+
+	for item, size := range map[string]int64{"small.txt": 10, "huge.iso": 4 << 20, "mid.log": 2048} {
+		record := md(item, filepath.Join(cfg.WorkingDir, item), 10, time.Hour)
+		record.Size = size
+		cfg.Journal.AddRecord(record)
+	}
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	huge, mid, small := strings.Index(out, "huge.iso"), strings.Index(out, "mid.log"), strings.Index(out, "small.txt")
+	if huge < 0 || !(huge < mid && mid < small) {
+		t.Errorf("expected items ordered largest first: %s", out)
+	}
+	if !strings.Contains(out, "huge.iso | Tossed:") || !strings.Contains(out, "| Size:4.0 MB") {
+		t.Errorf("expected the stored size to be displayed: %s", out)
+	}
+}
+
+func TestCommand_LimitKeepsTotals(t *testing.T) {
+	cfg := newTestConfig(t)
+	sortBy, limit = SortRemaining, 2
+	defer func() { sortBy, limit = SortName, 0 }()
+
+	cfg.Journal.AddRecord(md("later.txt", filepath.Join(cfg.WorkingDir, "later.txt"), 20, time.Hour))
+	cfg.Journal.AddRecord(md("soon.txt", filepath.Join(cfg.WorkingDir, "soon.txt"), 2, time.Hour))
+	cfg.Journal.AddRecord(md("gone.txt", filepath.Join(cfg.WorkingDir, "gone.txt"), 1, 48*time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	gone, soon := strings.Index(out, "gone.txt"), strings.Index(out, "soon.txt")
+	if gone < 0 || soon < gone || strings.Contains(out, "later.txt") {
+		t.Errorf("expected the 2 items closest to wipeout only: %s", out)
+	}
+	if !strings.Contains(out, "…and 1 more\nTotal: 3 | Wipable: 1") {
+		t.Errorf("expected the footer and the totals of every item: %s", out)
+	}
+}
+
+func TestCommand_FormatComposesWithFilters(t *testing.T) {
+	cfg := newTestConfig(t)
+	typeFilter, formatText = "dir", "{{.Item}} {{.TypeName}} {{.Wipeable}}"
+	defer func() { typeFilter, formatText = "", "" }()
+
+	file := md("a.txt", filepath.Join(cfg.WorkingDir, "a.txt"), 10, time.Hour)
+	file.Type = journal.TypeFile
+	dir := md("build", filepath.Join(cfg.WorkingDir, "build"), 1, 48*time.Hour)
+	dir.Type = journal.TypeDirectory
+	cfg.Journal.AddRecord(file)
+	cfg.Journal.AddRecord(dir)
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if out != "build dir true\n" {
+		t.Errorf("expected only the formatted directory, got %q", out)
+	}
+
+	formatText = "{{.Item"
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err == nil {
+			t.Error("expected an invalid template to be rejected")
+		}
+	})
+	if out != "" {
+		t.Errorf("nothing must be printed before the template is validated, got %q", out)
+	}
+}
+
+func TestCommand_InvalidSort(t *testing.T) {
+	cfg := newTestConfig(t)
+	sortBy = "date"
+	defer func() { sortBy = SortName }()
+	if err := Command(nil, cfg); err == nil {
+		t.Fatal("expected error for an unsupported sort criteria")
+	}
+}
+
+func TestCommand_TossedDateRange(t *testing.T) {
+	cfg := newTestConfig(t)
+	day := 24 * time.Hour
+	for _, record := range []*journal.MetaData{
+		md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 10*day),
+		md("mid.txt", filepath.Join(cfg.WorkingDir, "mid.txt"), 30, 5*day),
+		md("new.txt", filepath.Join(cfg.WorkingDir, "new.txt"), 30, time.Hour),
+	} {
+		if err := cfg.Journal.AddRecord(record); err != nil {
+			t.Fatalf("add %s: %v", record.Item, err)
+		}
+	}
+
+	tossedAfter = time.Now().Add(-7 * day).Format(time.DateOnly)
+	tossedBefore = time.Now().Add(-2 * day).Format(time.DateOnly)
+	defer func() { tossedAfter, tossedBefore = "", "" }()
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "mid.txt") || strings.Contains(out, "old.txt") || strings.Contains(out, "new.txt") {
+		t.Errorf("only mid.txt is in range, got: %s", out)
+	}
+	if !strings.Contains(out, "Total: 1 | Wipable: 0") {
+		t.Errorf("totals must reflect the filtered items, got: %s", out)
+	}
+
+	tossedBefore, wipeableOnly = "", true
+	defer func() { wipeableOnly = false }()
+	tossedAfter = time.Now().Add(-20 * day).Format(time.DateOnly)
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "old.txt") || !strings.Contains(out, "Total: 1 | Wipable: 1") {
+		t.Errorf("date range must compose with -w, got: %s", out)
+	}
+}
+
+func TestCommand_InvalidDateRange(t *testing.T) {
+	cfg := newTestConfig(t)
+	defer func() { tossedAfter, tossedBefore = "", "" }()
+
+	tossedAfter = "15/01/2024"
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "--after") {
+		t.Errorf("expected malformed --after error, got %v", err)
+	}
+
+	tossedAfter, tossedBefore = "2024-01-15", "2024-01-10"
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "empty date range") {
+		t.Errorf("expected empty range error, got %v", err)
+	}
+}
+
+func TestCommand_TypeFilter(t *testing.T) {
+	cfg := newTestConfig(t)
+	file := md("notes.txt", filepath.Join(cfg.WorkingDir, "notes.txt"), 30, time.Hour)
+	dir := md("photos", filepath.Join(cfg.WorkingDir, "photos"), 30, time.Hour)
+	dir.Type = journal.TypeDirectory
+	link := md("latest", "/elsewhere/latest", 30, time.Hour)
+	link.Type = journal.TypeSymlink
+	file.Type = journal.TypeFile
+	for _, record := range []*journal.MetaData{file, dir, link} {
+		if err := cfg.Journal.AddRecord(record); err != nil {
+			t.Fatalf("add %s: %v", record.Item, err)
+		}
+	}
+	defer func() { typeFilter, globalLookup = "", false }()
+
+	typeFilter = "dir"
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "photos") || strings.Contains(out, "notes.txt") || !strings.Contains(out, "Total: 1") {
+		t.Errorf("expected the local directory only, got: %s", out)
+	}
+
+	typeFilter, globalLookup = "symlink", true
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "latest") || strings.Contains(out, "photos") || !strings.Contains(out, "Total: 1") {
+		t.Errorf("expected the symlink only, got: %s", out)
+	}
+
+	typeFilter = "pipe"
+	if err := Command(nil, cfg); err == nil {
+		t.Error("expected error for an unknown type")
+	}
+}
+
+func TestHumanizeRemaining(t *testing.T) {
+	cases := []struct {
+		remaining time.Duration
+		want      string
+	}{
+		{0, "Wipeable"},
+		{-time.Hour, "Wipeable"},
+		{90 * time.Minute, "1h30m0s"},
+		{12*time.Hour + 400*time.Millisecond, "12h0m0s"},
+		{8 * day, "8.0d"},
+		{30 * day, "30.0d"},
+		{6 * week, "6.0w"},
+		{5 * month, "5.0mo"},
+		{year, "1.0y"},
+		{year + year/5, "1.2y"},
+	}
+	for _, c := range cases {
+		if got := humanizeRemaining(c.remaining); got != c.want {
+			t.Errorf("humanizeRemaining(%v) = %s, want %s", c.remaining, got, c.want)
+		}
+	}
+}
+
+func TestCommand_WipeableOnly(t *testing.T) {
+	cfg := newTestConfig(t)
+	records := []*journal.MetaData{
+		md("local_due.txt", filepath.Join(cfg.WorkingDir, "local_due.txt"), 1, 48*time.Hour),
+		md("local_kept.txt", filepath.Join(cfg.WorkingDir, "local_kept.txt"), 30, time.Hour),
+		md("elsewhere_due.txt", "/elsewhere/elsewhere_due.txt", 1, 48*time.Hour),
+	}
+	for _, record := range records {
+		if err := cfg.Journal.AddRecord(record); err != nil {
+			t.Fatalf("add %s: %v", record.Item, err)
+		}
+	}
+	wipeableOnly = true
+	defer func() { wipeableOnly, globalLookup = false, false }()
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "local_due.txt") || strings.Contains(out, "local_kept.txt") || strings.Contains(out, "elsewhere_due.txt") {
+		t.Errorf("-w must list the wipeable items of the working directory only, got: %s", out)
+	}
+
+	globalLookup = true
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "local_due.txt") || !strings.Contains(out, "elsewhere_due.txt") || strings.Contains(out, "local_kept.txt") {
+		t.Errorf("-g -w must list every wipeable item, got: %s", out)
+	}
+	if !strings.Contains(out, "Total: 2 | Wipable: 2") {
+		t.Errorf("totals must count the wipeable items, got: %s", out)
+	}
+}
+
+func TestCheckWipeable_Threshold(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := checkWipeable(cfg, 0); err != nil {
+		t.Fatalf("an empty bin must be healthy, got %v", err)
+	}
+
+	cfg.Journal.AddRecord(md("due1.txt", "/elsewhere/due1.txt", 1, 48*time.Hour))
+	cfg.Journal.AddRecord(md("due2.txt", filepath.Join(cfg.WorkingDir, "due2.txt"), 1, 48*time.Hour))
+	cfg.Journal.AddRecord(md("kept.txt", "/elsewhere/kept.txt", 30, time.Hour))
+
+	if err := checkWipeable(cfg, 0); !errors.Is(err, ErrWipeablePending) {
+		t.Errorf("expected ErrWipeablePending, got %v", err)
+	}
+	if err := checkWipeable(cfg, 1); !errors.Is(err, ErrWipeablePending) {
+		t.Errorf("2 wipeable items exceed a threshold of 1, got %v", err)
+	}
+	if err := checkWipeable(cfg, 2); err != nil {
+		t.Errorf("2 wipeable items are within a threshold of 2, got %v", err)
+	}
+	if err := checkWipeable(cfg, -1); err == nil || errors.Is(err, ErrWipeablePending) {
+		t.Errorf("expected a negative threshold to be rejected, got %v", err)
+	}
+}
+
+func TestCommand_QuietPrintsNothing(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.AddRecord(md("due.txt", "/elsewhere/due.txt", 1, 48*time.Hour))
+	Flags.Parse([]string{"--quiet"})
+	defer Flags.Parse([]string{"--quiet=false", "--threshold=0"})
+
+	if !MachineOutput() {
+		t.Error("--quiet must keep the notices out of the output")
+	}
+
+	var err error
+	out := captureStdout(t, func() { err = Command(nil, cfg) })
+	if !errors.Is(err, ErrWipeablePending) {
+		t.Errorf("expected ErrWipeablePending, got %v", err)
+	}
+	if out != "" {
+		t.Errorf("--quiet must print nothing, got %q", out)
+	}
+
+	Flags.Parse([]string{"--quiet", "--threshold=1"})
+	if err := Command(nil, cfg); err != nil {
+		t.Errorf("1 wipeable item is within --threshold=1, got %v", err)
+	}
+}
+
+func TestCommand_SizeOnlySummarizesBin(t *testing.T) {
+	cfg := newTestConfig(t)
+	sizeOnly = true
+	defer func() { sizeOnly = false }()
+
+	old := md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 72*time.Hour)
+	old.Size = 1024
+	recent := md("new.txt", "/elsewhere/new.txt", 10, time.Hour)
+	recent.Size = 1024
+	cfg.Journal.AddRecord(old)
+	cfg.Journal.AddRecord(recent)
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command returned error: %v", err)
+		}
+	})
+
+	want := fmt.Sprintf("Items: 2 | Wipeable: 1 | Tossed size: %s | Tossed between %s and %s",
+		config.ReadableSize(2048),
+		time.Unix(old.TossedTime, 0).Format(time.DateOnly), time.Unix(recent.TossedTime, 0).Format(time.DateOnly))
+	if !strings.Contains(out, "Rubbish bin size:") || !strings.Contains(out, want) {
+		t.Errorf("expected the bin summary %q, got:\n%s", want, out)
+	}
+}
+
+func TestCommand_SizeOnlyOutputJSON(t *testing.T) {
+	cfg := newTestConfig(t)
+	sizeOnly, outputFormat = true, OutputJSON
+	defer func() { sizeOnly, outputFormat = false, OutputText }()
+
+	old := md("old.txt", filepath.Join(cfg.WorkingDir, "old.txt"), 1, 72*time.Hour)
+	old.Size, old.Type = 1024, journal.TypeFile
+	recent := md("new.txt", "/elsewhere/new.txt", 10, time.Hour)
+	recent.Size, recent.Type = 1024, journal.TypeDirectory
+	cfg.Journal.AddRecord(old)
+	cfg.Journal.AddRecord(recent)
+
+	run := func() jsonSize {
+		t.Helper()
+		out := captureStdout(t, func() {
+			if err := Command(nil, cfg); err != nil {
+				t.Fatalf("Command returned error: %v", err)
+			}
+		})
+		var doc jsonSize
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("invalid json output %q: %v", out, err)
+		}
+		return doc
+	}
+
+	doc := run()
+	if doc.Total != 2 || doc.Wipeable != 1 || doc.Size != 2048 || doc.OldestToss == "" || doc.NewestToss == "" {
+		t.Errorf("expected the bin summary, got %+v", doc)
+	}
+
+	typeFilter, globalLookup = "file", true
+	defer func() { typeFilter, globalLookup = "", false }()
+	if doc := run(); doc.Total != 1 || doc.Wipeable != 1 || doc.OldestToss != "" {
+		t.Errorf("expected the selected item only, got %+v", doc)
+	}
+}
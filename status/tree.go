@@ -0,0 +1,99 @@
+package status
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"strings"
+)
+
+// originNode is a directory of the origin tree, holding the records tossed
+// from it and its subdirectories holding records further down.
+type originNode struct {
+	Name     string
+	Records  []*journal.MetaData
+	Children []*originNode
+}
+
+// child returns the subdirectory of the node with the given name, adding it
+// when missing.
+func (n *originNode) child(name string) *originNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &originNode{Name: name}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// collapse merges every directory holding no record and a single
+// subdirectory with that subdirectory, so common prefixes take one line.
+func (n *originNode) collapse() {
+	for len(n.Records) == 0 && len(n.Children) == 1 {
+		only := n.Children[0]
+		n.Name = path.Join(n.Name, only.Name)
+		n.Records, n.Children = only.Records, only.Children
+	}
+	for _, child := range n.Children {
+		child.collapse()
+	}
+	slices.SortFunc(n.Children, func(a, b *originNode) int { return strings.Compare(a.Name, b.Name) })
+}
+
+// originTree groups the records under the directories of their origin,
+// relative to the working directory unless global. The records keep their
+// order within a directory.
+func originTree(records []*journal.MetaData, workingDir string, global bool) *originNode {
+	root := &originNode{Name: "/"}
+	if !global {
+		root.Name = "."
+	}
+
+	for _, record := range records {
+		dir := path.Dir(record.Origin)
+		if !global {
+			if rel, err := filepath.Rel(workingDir, dir); err == nil {
+				dir = rel
+			}
+		}
+
+		node := root
+		for _, name := range strings.Split(dir, "/") {
+			if name != "" && name != "." {
+				node = node.child(name)
+			}
+		}
+		node.Records = append(node.Records, record)
+	}
+
+	root.collapse()
+	return root
+}
+
+// printTree displays the records indented under their origin directories and
+// returns the number of wipeable records.
+func printTree(records []*journal.MetaData, sizes map[string]int64, cfg *config.Config) int {
+	wipeables := 0
+	var walk func(node *originNode, depth int)
+	walk = func(node *originNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		fmt.Printf("%s%s/\n", indent, strings.TrimSuffix(node.Name, "/"))
+		for _, record := range node.Records {
+			if record.IsWipeable() {
+				wipeables++
+			}
+			fmt.Printf("%s  %s %s\n", indent, bullet(record), itemLine(record, record.Item, sizes[record.Item], cfg))
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+
+	walk(originTree(records, cfg.WorkingDir, globalLookup || absoluteMode), 0)
+	return wipeables
+}
@@ -0,0 +1,59 @@
+package status
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_TreeGroupsByOrigin(t *testing.T) {
+	cfg := newTestConfig(t)
+	treeMode = true
+	defer func() { treeMode = false }()
+
+	work := cfg.WorkingDir
+	cfg.Journal.AddRecord(md("top.txt", filepath.Join(work, "top.txt"), 10, time.Hour))
+	cfg.Journal.AddRecord(md("a.txt", filepath.Join(work, "src", "deep", "pkg", "a.txt"), 10, time.Hour))
+	cfg.Journal.AddRecord(md("b.txt", filepath.Join(work, "src", "deep", "pkg", "b.txt"), 1, 48*time.Hour))
+	cfg.Journal.AddRecord(md("c.txt", filepath.Join(work, "src", "other", "c.txt"), 10, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	want := regexp.MustCompile(`^\./
+  > top\.txt \| .*
+  src/
+    deep/pkg/
+      > a\.txt \| .*
+      > b\.txt \| .*
+    other/
+      > c\.txt \| .*
+Total: 4 \| Wipable: 1 \|`)
+	if !want.MatchString(out) {
+		t.Errorf("unexpected tree:\n%s", out)
+	}
+}
+
+func TestCommand_TreeGlobalShowsAbsoluteOrigins(t *testing.T) {
+	cfg := newTestConfig(t)
+	treeMode, globalLookup = true, true
+	defer func() { treeMode, globalLookup = false, false }()
+
+	cfg.Journal.AddRecord(md("a.txt", "/srv/data/a.txt", 10, time.Hour))
+	cfg.Journal.AddRecord(md("b.txt", "/srv/logs/b.txt", 10, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "/srv/\n  data/\n    > a.txt | ") || !strings.Contains(out, "  logs/\n    > b.txt | ") {
+		t.Errorf("expected the absolute origins with their common prefix collapsed:\n%s", out)
+	}
+}
@@ -0,0 +1,117 @@
+package status
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// usageGroup aggregates the items tossed from the same origin directory prefix.
+type usageGroup struct {
+	Dir   string
+	Count int
+	Size  int64
+}
+
+// topLevelDir returns the first depth directories of the origin below the
+// home directory (as "~/<dir>") or below the root (as "/<dir>"). Items tossed
+// from shallower directories are grouped under their own directory, "~" and
+// "/" for the home and the root.
+func topLevelDir(origin string, home string, depth int) string {
+	base, label := "/", "/"
+
+	if home != "" && strings.HasPrefix(origin, home+string(filepath.Separator)) {
+		base, label = home, "~"
+	}
+
+	rel, err := filepath.Rel(base, filepath.Dir(origin))
+	if err != nil || rel == "." {
+		return label
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	return filepath.Join(append([]string{label}, parts[:min(depth, len(parts))]...)...)
+}
+
+// usageBreakdown groups the records by the first depth directories of their
+// origin, sorted from the largest contribution to the smallest.
+func usageBreakdown(records []*journal.MetaData, sizes map[string]int64, home string, depth int) []usageGroup {
+	groups := map[string]*usageGroup{}
+
+	for _, record := range records {
+		dir := topLevelDir(record.Origin, home, depth)
+		group, ok := groups[dir]
+		if !ok {
+			group = &usageGroup{Dir: dir}
+			groups[dir] = group
+		}
+		group.Count++
+		group.Size += sizes[record.Item]
+	}
+
+	result := make([]usageGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+
+	slices.SortFunc(result, func(a, b usageGroup) int {
+		if c := cmp.Compare(b.Size, a.Size); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Dir, b.Dir)
+	})
+
+	return result
+}
+
+// percentage returns the share of size over total, zero when the bin is empty.
+func percentage(size int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(size) * 100 / float64(total)
+}
+
+// printUsage displays how much of the bin is taken by each origin directory,
+// grouped by their first depth path components. Like the listing, it covers
+// the items tossed from the working directory tree, the whole bin with -g.
+func printUsage(cfg *config.Config, depth int) error {
+	if depth < 1 {
+		return fmt.Errorf("invalid depth %d, expected 1 or more", depth)
+	}
+
+	records, err := retrieveJournalRecords(cfg)
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No rubbish found.")
+		return nil
+	}
+
+	var total int64
+	sizes := make(map[string]int64, len(records))
+	for _, record := range records {
+		sizes[record.Item] = config.RecordSize(cfg, record)
+		total += sizes[record.Item]
+	}
+
+	home, _ := os.UserHomeDir()
+
+	fmt.Println("Rubbish usage by origin:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, group := range usageBreakdown(records, sizes, home, depth) {
+		fmt.Fprintf(w, " > %s\t%s\t%.1f%%\t%d items\n", group.Dir, config.ReadableSize(uint64(group.Size)), percentage(group.Size, total), group.Count)
+	}
+	w.Flush()
+
+	fmt.Printf("Total: %d | Bin Size: %s\n", len(records), config.ReadableSize(uint64(total)))
+	return nil
+}
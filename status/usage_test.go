@@ -0,0 +1,147 @@
+package status
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/journal"
+)
+
+func TestTopLevelDir(t *testing.T) {
+	cases := []struct{ origin, want string }{
+		{"/home/user/Downloads/a.txt", "~/Downloads"},
+		{"/home/user/Downloads/deep/b.txt", "~/Downloads"},
+		{"/home/user/c.txt", "~"},
+		{"/tmp/build/d.o", "/tmp"},
+		{"/e.txt", "/"},
+		{"/home/username/f.txt", "/home"},
+	}
+	for _, c := range cases {
+		if got := topLevelDir(c.origin, "/home/user", 1); got != c.want {
+			t.Errorf("topLevelDir(%s) = %s, want %s", c.origin, got, c.want)
+		}
+	}
+}
+
+func TestTopLevelDir_Depth(t *testing.T) {
+	cases := []struct {
+		origin string
+		depth  int
+		want   string
+	}{
+		{"/home/user/Downloads/isos/linux/a.iso", 2, "~/Downloads/isos"},
+		{"/home/user/Downloads/isos/linux/a.iso", 3, "~/Downloads/isos/linux"},
+		{"/home/user/Downloads/b.txt", 3, "~/Downloads"},
+		{"/var/log/app/c.log", 2, "/var/log"},
+		{"/home/user/d.txt", 2, "~"},
+	}
+	for _, c := range cases {
+		if got := topLevelDir(c.origin, "/home/user", c.depth); got != c.want {
+			t.Errorf("topLevelDir(%s, %d) = %s, want %s", c.origin, c.depth, got, c.want)
+		}
+	}
+}
+
+func TestCommand_UsageInvalidDepth(t *testing.T) {
+	cfg := newTestConfig(t)
+	usageMode, usageDepth = true, 0
+	defer func() { usageMode, usageDepth = false, 1 }()
+
+	if err := Command(nil, cfg); err == nil {
+		t.Error("expected error for a depth below 1")
+	}
+}
+
+func TestUsageBreakdown_GroupsAndSorts(t *testing.T) {
+	sizes := map[string]int64{"a": 100, "b": 300, "c": 50, "d": 50}
+	groups := usageBreakdown(
+		[]*journal.MetaData{
+			md("a", "/home/user/Downloads/a", 1, time.Hour),
+			md("b", "/home/user/Downloads/x/b", 1, time.Hour),
+			md("c", "/tmp/c", 1, time.Hour),
+			md("d", "/home/user/d", 1, time.Hour),
+		}, sizes, "/home/user", 1)
+
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Dir != "~/Downloads" || groups[0].Size != 400 || groups[0].Count != 2 {
+		t.Errorf("unexpected largest group: %+v", groups[0])
+	}
+}
+
+func TestCommand_UsagePercentagesSumTo100(t *testing.T) {
+	cfg := newTestConfig(t)
+	usageMode, globalLookup = true, true
+	defer func() { usageMode, globalLookup = false, false }()
+
+	home, _ := os.UserHomeDir()
+	for i, origin := range []string{
+		filepath.Join(home, "Downloads", "a.bin"),
+		filepath.Join(home, "Documents", "b.bin"),
+		"/tmp/c.bin",
+	} {
+		item := filepath.Base(origin) + "_ABCDEF"
+		os.WriteFile(filepath.Join(cfg.ContainerPath, item), bytes.Repeat([]byte{'x'}, (i+1)*333), 0o644)
+		cfg.Journal.AddRecord(md(item, origin, 5, time.Hour))
+	}
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	for _, dir := range []string{"~/Downloads", "~/Documents", "/tmp"} {
+		if !strings.Contains(out, dir) {
+			t.Errorf("missing group %s: %s", dir, out)
+		}
+	}
+
+	var sum float64
+	for _, m := range regexp.MustCompile(`([0-9.]+)%`).FindAllStringSubmatch(out, -1) {
+		v, _ := strconv.ParseFloat(m[1], 64)
+		sum += v
+	}
+	if sum < 99.8 || sum > 100.2 {
+		t.Errorf("percentages sum to %.2f, expected ~100: %s", sum, out)
+	}
+	if strings.Index(out, "/tmp") > strings.Index(out, "~/Downloads") {
+		t.Errorf("expected largest group first: %s", out)
+	}
+}
+
+func TestCommand_UsageScopedToWorkingDir(t *testing.T) {
+	cfg := newTestConfig(t)
+	usageMode = true
+	defer func() { usageMode = false }()
+
+	cfg.Journal.AddRecord(md("here.bin", filepath.Join(cfg.WorkingDir, "sub", "here.bin"), 5, time.Hour))
+	cfg.Journal.AddRecord(md("there.bin", "/elsewhere/there.bin", 5, time.Hour))
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if strings.Contains(out, "/elsewhere") || !strings.Contains(out, "Total: 1 ") {
+		t.Errorf("expected only the items of the working directory tree, got: %s", out)
+	}
+
+	globalLookup = true
+	defer func() { globalLookup = false }()
+	out = captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "/elsewhere") || !strings.Contains(out, "Total: 2 ") {
+		t.Errorf("expected every item with -g, got: %s", out)
+	}
+}
@@ -0,0 +1,77 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"time"
+)
+
+var (
+	// now is the clock of the --watch header, replaceable for testing
+	now = time.Now
+
+	// newTicker returns the channel --watch refreshes on and the function
+	// stopping it, replaceable for testing
+	newTicker = func(interval time.Duration) (<-chan time.Time, func()) {
+		ticker := time.NewTicker(interval)
+		return ticker.C, ticker.Stop
+	}
+)
+
+// CommandContext runs the status command like Command. With --watch, the
+// status is displayed again every --interval until ctx is cancelled.
+func CommandContext(ctx context.Context, args []string, cfg *config.Config) error {
+	if !watchMode {
+		return Command(args, cfg)
+	}
+	if watchInterval <= 0 {
+		return fmt.Errorf("invalid --interval %s, expected a positive duration", watchInterval)
+	}
+	if MachineOutput() || snapshotFile != "" || diffFile != "" || sinceLast || resetSeen {
+		return fmt.Errorf("--watch cannot be combined with --output=json, --format, --quiet, --snapshot, --diff, --since-last or --reset")
+	}
+	return watch(ctx, args, cfg)
+}
+
+// watch displays the status on every tick. Between two of them the journal
+// is closed and the container lock released, so other rubbish processes can
+// change the bin and the next display reads their changes from a freshly
+// opened journal. A tick finding the bin in use is skipped.
+func watch(ctx context.Context, args []string, cfg *config.Config) error {
+	ticks, stop := newTicker(watchInterval)
+	defer stop()
+
+	for {
+		color.ClearScreen(os.Stdout)
+		fmt.Printf("Every %s: rubbish status, Ctrl-C to stop\t%s\n\n", watchInterval, now().In(cfg.Zone()).Format(time.DateTime))
+		if err := Command(args, cfg); err != nil {
+			return err
+		}
+
+		for {
+			if err := cfg.Release(); err != nil {
+				return fmt.Errorf("error closing the journal: %w", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticks:
+			}
+
+			err := cfg.Reacquire()
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, journal.ErrBusy) {
+				return fmt.Errorf("error reopening the journal: %w", err)
+			}
+			fmt.Printf("The bin is in use by another rubbish process, retrying in %s\n", watchInterval)
+		}
+	}
+}
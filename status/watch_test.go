@@ -0,0 +1,86 @@
+package status
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// fakeTicker replaces the --watch ticker by the returned channel.
+func fakeTicker(t *testing.T) chan time.Time {
+	t.Helper()
+	ticks := make(chan time.Time)
+	orig := newTicker
+	newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	t.Cleanup(func() { newTicker = orig })
+	return ticks
+}
+
+func TestCommandContext_WatchRendersEveryTick(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Journal.AddRecord(md("first.txt_ABCDEF", cfg.WorkingDir+"/first.txt", 30, time.Hour))
+	watchMode = true
+	defer func() { watchMode = false }()
+	Flags.Parse([]string{"--columns=false"})
+	defer Flags.Parse([]string{"--columns=false"})
+
+	origNow := now
+	now = func() time.Time { return time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC) }
+	defer func() { now = origNow }()
+	ticks := fakeTicker(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Another process tosses an item between the two renders, once the
+	// watching one released the bin
+	go func() {
+		other := &config.Config{ContainerPath: cfg.ContainerPath, Journal: &journal.Journal{Path: cfg.Journal.Path}}
+		for {
+			if err := other.Reacquire(); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		other.Journal.AddRecord(md("second.txt_ABCDEF", cfg.WorkingDir+"/second.txt", 30, time.Minute))
+		other.Release()
+
+		ticks <- time.Time{}
+		cancel()
+	}()
+
+	var err error
+	out := captureStdout(t, func() { err = CommandContext(ctx, nil, cfg) })
+	if err != nil {
+		t.Fatalf("CommandContext returned error: %v", err)
+	}
+
+	renders := strings.Split(out, "Every 2s: rubbish status")
+	if len(renders) != 3 {
+		t.Fatalf("expected two renders, got %d in:\n%s", len(renders)-1, out)
+	}
+	if !strings.Contains(renders[1], "2026-10-17 09:30:00") {
+		t.Errorf("expected the header to show the injected clock, got:\n%s", renders[1])
+	}
+	if !strings.Contains(renders[1], "first.txt") || strings.Contains(renders[1], "second.txt") {
+		t.Errorf("expected the first render to list first.txt only, got:\n%s", renders[1])
+	}
+	if !strings.Contains(renders[2], "second.txt") {
+		t.Errorf("expected the second render to read the journal again, got:\n%s", renders[2])
+	}
+}
+
+func TestCommandContext_WatchRejectsMachineOutput(t *testing.T) {
+	cfg := newTestConfig(t)
+	watchMode, outputFormat = true, OutputJSON
+	defer func() { watchMode, outputFormat = false, OutputText }()
+
+	err := CommandContext(context.Background(), nil, cfg)
+	if err == nil || !strings.Contains(err.Error(), "--watch cannot be combined") {
+		t.Errorf("expected --watch --output=json to be rejected, got %v", err)
+	}
+}
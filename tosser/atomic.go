@@ -0,0 +1,108 @@
+package tosser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+)
+
+// checkAll validates every path before --atomic moves any of them: each must
+// exist and be writable, as must its parent, unless --force is given. All the
+// failing paths are reported together.
+func checkAll(paths []string) error {
+	var problems []error
+	for _, path := range paths {
+		path, err := resolveLink(path)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		if _, err := os.Lstat(path); err != nil {
+			problems = append(problems, fmt.Errorf("invalid rubbish to toss '%s': %w", path, err))
+			continue
+		}
+		if err := validateAccess(path); err != nil && (!force || !errors.Is(err, errNoWritePermission)) {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("nothing tossed, %d of %d items can't be tossed:\n%w", len(problems), len(paths), errors.Join(problems...))
+}
+
+// rollBack moves the items tossed so far back to their origin, newest first,
+// and removes their records, reporting each item brought back unless silent.
+// It returns the cause of the rollback joined with the items which could not
+// be rolled back.
+func rollBack(cause error, tossed []*journal.MetaData, cfg *config.Config) error {
+	var failures []error
+	for i := len(tossed) - 1; i >= 0; i-- {
+		record := tossed[i]
+		if err := untoss(record, cfg); err != nil {
+			failures = append(failures, fmt.Errorf("error rolling back %s, left in the rubbish as %s: %w", record.Origin, record.Item, err))
+			continue
+		}
+		if !silentMode {
+			fmt.Printf("Rolled back '%s'.\n", record.Origin)
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Join(cause, fmt.Errorf("unable to roll back %d of %d tossed items:\n%w", len(failures), len(tossed), errors.Join(failures...)))
+	}
+	if len(tossed) > 0 {
+		return fmt.Errorf("%w\nrolled back %d tossed items", cause, len(tossed))
+	}
+	return cause
+}
+
+// untoss moves the item of the record back to its origin, decompressing it
+// when needed, and drops its record, trash info and emptied parents.
+func untoss(record *journal.MetaData, cfg *config.Config) error {
+	stored := cfg.ItemPath(record.Item)
+
+	// The origin of a directory tossed with --exclude still holds the
+	// excluded entries, the item is merged back around them
+	merging := false
+	if info, err := os.Lstat(record.Origin); err == nil {
+		if len(record.Excluded) == 0 || !info.IsDir() {
+			return fmt.Errorf("%s already exists", record.Origin)
+		}
+		merging = true
+	}
+
+	var err error
+	switch {
+	case merging:
+		err = fsutil.Merge(stored, record.Origin)
+	case record.Type == journal.TypeSymlink && record.LinkTarget != "":
+		err = fsutil.Relink(stored, record.Origin, record.LinkTarget)
+	case record.Compressed:
+		if err = fsutil.Decompress(stored, record.Origin); err == nil {
+			err = os.Remove(stored)
+		}
+	case fsutil.Shared(stored):
+		err = fsutil.Detach(stored, record.Origin)
+	default:
+		err = fsutil.Move(stored, record.Origin)
+	}
+
+	// The item is back at its origin, its leftovers are orphans of the container
+	if errors.Is(err, fsutil.ErrSourceLeft) {
+		color.Warnf("%s is rolled back, but %v\n", record.Origin, err)
+	} else if err != nil {
+		return err
+	}
+
+	if err := cfg.Journal.Delete(record.Item); err != nil {
+		return err
+	}
+	cfg.PruneItemParents(record.Item)
+	return cfg.RemoveTrashInfo(record.Item)
+}
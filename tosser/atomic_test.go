@@ -0,0 +1,104 @@
+package tosser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/prompt"
+)
+
+func TestCommand_AtomicRollsBackOnFailure(t *testing.T) {
+	cfg := newTestCfg(t)
+	atomic = true
+	defer func() { atomic = false }()
+
+	src := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		paths = append(paths, filepath.Join(src, name))
+		os.WriteFile(paths[len(paths)-1], []byte(name), 0o644)
+	}
+
+	// The third item passes the checks but can't be moved
+	orig := fsutil.Rename
+	fsutil.Rename = func(from, to string) error {
+		if filepath.Base(from) == "c.txt" {
+			return errors.New("device busy")
+		}
+		return orig(from, to)
+	}
+	defer func() { fsutil.Rename = orig }()
+
+	var err error
+	out := captureStdout(t, func() { err = Command(paths, cfg) })
+	if err == nil || !strings.Contains(err.Error(), "device busy") || !strings.Contains(err.Error(), "rolled back 2 tossed items") {
+		t.Fatalf("expected the failure and the rollback reported, got %v", err)
+	}
+
+	for _, path := range paths {
+		if content, err := os.ReadFile(path); err != nil || string(content) != filepath.Base(path) {
+			t.Errorf("%s must be back at its origin: %v", path, err)
+		}
+	}
+	for _, path := range paths[:2] {
+		if !strings.Contains(out, "Rolled back '"+path+"'") {
+			t.Errorf("expected %s reported as rolled back, got: %s", path, out)
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected no record left, got %d", count)
+	}
+	if items, _ := config.ContainerItems(cfg); len(items) != 0 {
+		t.Errorf("expected an empty container, got %v", items)
+	}
+}
+
+func TestCommand_AtomicRollsBackOnDecline(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxFilesWarn = 3
+	atomic = true
+	defer func() { atomic = false }()
+
+	small := makeTree(t, filepath.Join(cfg.WorkingDir, "small"), 3)
+	large := makeTree(t, filepath.Join(cfg.WorkingDir, "large"), 4)
+
+	orig := prompt.Input
+	prompt.Input = strings.NewReader("n\n")
+	defer func() { prompt.Input = orig }()
+
+	var err error
+	captureStdout(t, func() { err = Command([]string{small, large}, cfg) })
+	if err == nil || !strings.Contains(err.Error(), "declined") || !strings.Contains(err.Error(), "rolled back 1 tossed items") {
+		t.Fatalf("expected the decline to roll the toss back, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(small, "f0")); err != nil {
+		t.Errorf("the small directory must be back at its origin: %v", err)
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected no record left, got %d", count)
+	}
+}
+
+func TestCommand_AtomicChecksEveryItemFirst(t *testing.T) {
+	cfg := newTestCfg(t)
+	atomic, silentMode = true, true
+	defer func() { atomic, silentMode = false, false }()
+
+	src := t.TempDir()
+	present := filepath.Join(src, "present.txt")
+	os.WriteFile(present, []byte("data"), 0o644)
+	missing := filepath.Join(src, "missing.txt")
+
+	err := Command([]string{present, missing}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "nothing tossed") || !strings.Contains(err.Error(), "missing.txt") {
+		t.Fatalf("expected the missing item reported before anything is moved, got %v", err)
+	}
+	if _, err := os.Stat(present); err != nil {
+		t.Errorf("no item must be tossed when one fails the checks: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package tosser
+
+import (
+	"fmt"
+	"rubbish/config"
+	"time"
+)
+
+// latestBatchState is the journal state holding the batch of the last toss
+const latestBatchState = "latest_batch"
+
+// sessionBatch returns the batch identifier for the current toss invocation.
+// With reuse, the latest batch is continued instead, falling back to a new
+// batch when there is none yet. The batch only becomes the latest one with
+// keepBatch, once an item was tossed into it.
+func sessionBatch(cfg *config.Config, reuse bool) (string, error) {
+	if reuse {
+		latest, err := cfg.Journal.State(latestBatchState)
+		if err != nil {
+			return "", fmt.Errorf("error reading latest toss batch: %w", err)
+		}
+		if latest != nil {
+			return string(latest), nil
+		}
+	}
+
+	return newBatchID(time.Now()), nil
+}
+
+// keepBatch records the batch as the latest one, continued by
+// --into-latest-session.
+func keepBatch(cfg *config.Config, id string) error {
+	if err := cfg.Journal.SetState(latestBatchState, []byte(id)); err != nil {
+		return fmt.Errorf("error saving toss batch: %w", err)
+	}
+	return nil
+}
+
+// newBatchID builds a batch identifier which sorts by creation time, e.g. 20240115-093000-K3P9.
+func newBatchID(now time.Time) string {
+	return now.Format("20060102-150405") + "-" + NameSufix(4)
+}
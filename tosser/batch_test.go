@@ -0,0 +1,53 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewBatchID_SortsByTime(t *testing.T) {
+	id := newBatchID(time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local))
+	if !regexp.MustCompile(`^20240115-093000-[A-Z0-9]{4}$`).MatchString(id) {
+		t.Errorf("unexpected batch id %q", id)
+	}
+}
+
+func TestCommand_IntoLatestSessionReusesBatch(t *testing.T) {
+	cfg := newTestCfg(t)
+	silentMode = true
+	defer func() { silentMode, latestSession = false, false }()
+
+	toss := func(name string) string {
+		t.Helper()
+		src := filepath.Join(t.TempDir(), name)
+		os.WriteFile(src, []byte(name), 0o644)
+		if err := Command([]string{src}, cfg); err != nil {
+			t.Fatalf("toss %s: %v", name, err)
+		}
+		record, err := cfg.Journal.Get(filepath.Base(findTossed(t, cfg.ContainerPath, name+"_")))
+		if err != nil {
+			t.Fatalf("journal %s: %v", name, err)
+		}
+		return record.Batch
+	}
+
+	first := toss("a.txt")
+	second := toss("b.txt")
+	if first == second {
+		t.Fatalf("separate tosses must get their own batch, both got %s", first)
+	}
+
+	// A toss where every path fails starts no batch
+	latestSession = false
+	if err := Command([]string{filepath.Join(t.TempDir(), "missing.txt")}, cfg); err == nil {
+		t.Fatal("expected tossing a missing file to fail")
+	}
+
+	latestSession = true
+	if third := toss("c.txt"); third != second {
+		t.Errorf("expected --into-latest-session to reuse batch %s, got %s", second, third)
+	}
+}
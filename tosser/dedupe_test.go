@@ -0,0 +1,92 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rubbish/config"
+)
+
+func TestCommand_DedupeLinksIdenticalContent(t *testing.T) {
+	cfg := newTestCfg(t)
+	dedupe, silentMode = true, true
+	defer func() { dedupe, silentMode = false, false }()
+
+	src := t.TempDir()
+	contents := map[string]string{"a.iso": "same content", "b.iso": "same content", "c.iso": "other"}
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var paths []string
+	for name, content := range contents {
+		paths = append(paths, filepath.Join(src, name))
+		os.WriteFile(filepath.Join(src, name), []byte(content), 0o644)
+		os.Chtimes(filepath.Join(src, name), mtime, mtime)
+	}
+
+	for _, path := range paths {
+		if err := Command([]string{path}, cfg); err != nil {
+			t.Fatalf("Command(%s): %v", path, err)
+		}
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s must be tossed, got %v", path, err)
+		}
+	}
+
+	records, _ := cfg.Journal.List()
+	stored := make(map[string]os.FileInfo)
+	hashes := make(map[string]string)
+	for _, record := range records {
+		info, err := os.Stat(cfg.ItemPath(record.Item))
+		if err != nil {
+			t.Fatalf("stored %s: %v", record.Item, err)
+		}
+		stored[filepath.Base(record.Origin)] = info
+		hashes[filepath.Base(record.Origin)] = record.Hash
+	}
+
+	if hashes["a.iso"] == "" || hashes["a.iso"] != hashes["b.iso"] || hashes["a.iso"] == hashes["c.iso"] {
+		t.Errorf("expected equal hashes for equal content only, got %v", hashes)
+	}
+	if !os.SameFile(stored["a.iso"], stored["b.iso"]) {
+		t.Error("identical content must be stored once, as a single inode")
+	}
+	if os.SameFile(stored["a.iso"], stored["c.iso"]) {
+		t.Error("different content must be stored apart")
+	}
+
+	if size, err := config.BinSize(cfg); err != nil || size != int64(len("same content")+len("other")) {
+		t.Errorf("expected the shared content counted once, got %d, %v", size, err)
+	}
+}
+
+func TestCommand_DedupeKeepsDifferentAttributesApart(t *testing.T) {
+	cfg := newTestCfg(t)
+	dedupe, silentMode = true, true
+	defer func() { dedupe, silentMode = false, false }()
+
+	src := t.TempDir()
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	public, private := filepath.Join(src, "a"), filepath.Join(src, "b")
+	os.WriteFile(public, []byte("same content"), 0o644)
+	os.Chtimes(public, mtime, mtime)
+	os.WriteFile(private, []byte("same content"), 0o600)
+
+	if err := Command([]string{public, private}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 2 {
+		t.Fatalf("expected two records, got %d", len(records))
+	}
+	for _, record := range records {
+		info, err := os.Stat(cfg.ItemPath(record.Item))
+		if err != nil {
+			t.Fatalf("stored %s: %v", record.Item, err)
+		}
+		if filepath.Base(record.Origin) == "b" && (info.Mode().Perm() != 0o600 || info.ModTime().Equal(mtime)) {
+			t.Errorf("the private file must keep its own mode and time, got %v %v", info.Mode(), info.ModTime())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package tosser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// globs is a repeatable flag collecting glob patterns.
+type globs []string
+
+func (g *globs) String() string {
+	if g == nil {
+		return ""
+	}
+	return strings.Join(*g, ",")
+}
+
+func (g *globs) Set(value string) error {
+	*g = append(*g, value)
+	return nil
+}
+
+// validate checks every glob is well formed, before anything is tossed.
+func (g globs) validate() error {
+	for _, glob := range g {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid --exclude pattern '%s': %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// match reports whether the entry at rel, a slash separated path relative
+// to a tossed directory, matches a glob by its whole path or by its name.
+func (g globs) match(rel string) bool {
+	for _, glob := range g {
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
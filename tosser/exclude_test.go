@@ -0,0 +1,186 @@
+package tosser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"rubbish/fsutil"
+)
+
+func TestCommand_ExcludeLeavesMatchesAtOrigin(t *testing.T) {
+	cfg := newTestCfg(t)
+	excludes, silentMode, autoConfirm = globs{"node_modules", "*.log"}, true, true
+	defer func() { excludes, silentMode, autoConfirm = nil, false, false }()
+
+	project := filepath.Join(t.TempDir(), "project")
+	files := map[string]string{
+		"README":                  "readme",
+		"src/main.go":             "package main",
+		"build/out.log":           "log",
+		"node_modules/x/index.js": "js",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(project, name)), 0o755)
+		os.WriteFile(filepath.Join(project, name), []byte(content), 0o644)
+	}
+
+	if err := Command([]string{project}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %d", len(records))
+	}
+	record := records[0]
+	if !slices.Equal(record.Excluded, []string{"build/out.log", "node_modules"}) {
+		t.Errorf("unexpected exclusions %v", record.Excluded)
+	}
+	if record.Size != int64(len("readme")+len("package main")) {
+		t.Errorf("the size must only count the stored files, got %d", record.Size)
+	}
+
+	stored := cfg.ItemPath(record.Item)
+	for _, name := range []string{"README", "src/main.go", "build"} {
+		if _, err := os.Lstat(filepath.Join(stored, name)); err != nil {
+			t.Errorf("%s must be stored: %v", name, err)
+		}
+		if name != "build" {
+			if _, err := os.Lstat(filepath.Join(project, name)); !os.IsNotExist(err) {
+				t.Errorf("%s must be gone from the origin, got %v", name, err)
+			}
+		}
+	}
+	for _, name := range []string{"build/out.log", "node_modules/x/index.js"} {
+		if _, err := os.Lstat(filepath.Join(project, name)); err != nil {
+			t.Errorf("%s must stay at the origin: %v", name, err)
+		}
+		if _, err := os.Lstat(filepath.Join(stored, name)); !os.IsNotExist(err) {
+			t.Errorf("%s must not be stored, got %v", name, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(project, "src")); !os.IsNotExist(err) {
+		t.Errorf("emptied directories must be removed from the origin, got %v", err)
+	}
+}
+
+func TestCommand_ExcludeWithoutMatchTossesWholeDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	excludes, silentMode, autoConfirm = globs{"*.tmp"}, true, true
+	defer func() { excludes, silentMode, autoConfirm = nil, false, false }()
+
+	project := filepath.Join(t.TempDir(), "project")
+	os.MkdirAll(project, 0o755)
+	os.WriteFile(filepath.Join(project, "a.txt"), []byte("a"), 0o644)
+
+	if err := Command([]string{project}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Lstat(project); !os.IsNotExist(err) {
+		t.Errorf("the directory must be tossed as a whole, got %v", err)
+	}
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 || records[0].Excluded != nil {
+		t.Errorf("expected a record without exclusions, got %+v", records)
+	}
+}
+
+func TestCommand_ExcludeInvalidPattern(t *testing.T) {
+	cfg := newTestCfg(t)
+	excludes = globs{"[a-"}
+	defer func() { excludes = nil }()
+
+	file := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(file, []byte("a"), 0o644)
+
+	if err := Command([]string{file}, cfg); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+	if _, err := os.Lstat(file); err != nil {
+		t.Errorf("nothing must be tossed on an invalid pattern: %v", err)
+	}
+}
+
+func TestGlobs_Match(t *testing.T) {
+	g := globs{"*.o", "build/cache"}
+	cases := map[string]bool{
+		"main.o":          true,
+		"lib/util.o":      true,
+		"build/cache":     true,
+		"src/build/cache": false,
+		"main.go":         false,
+	}
+	for rel, want := range cases {
+		if got := g.match(rel); got != want {
+			t.Errorf("match(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestCommand_AtomicRollBackMergesExcludedDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	excludes, atomic, silentMode, autoConfirm = globs{"*.log"}, true, true, true
+	defer func() { excludes, atomic, silentMode, autoConfirm = nil, false, false, false }()
+
+	src := t.TempDir()
+	project := filepath.Join(src, "project")
+	os.MkdirAll(project, 0o755)
+	os.WriteFile(filepath.Join(project, "main.go"), []byte("package main"), 0o644)
+	os.WriteFile(filepath.Join(project, "run.log"), []byte("log"), 0o644)
+	broken := filepath.Join(src, "broken.txt")
+	os.WriteFile(broken, []byte("b"), 0o644)
+
+	// The second item passes the checks but can't be moved
+	orig := fsutil.Rename
+	fsutil.Rename = func(from, to string) error {
+		if from == broken {
+			return errors.New("device busy")
+		}
+		return orig(from, to)
+	}
+	defer func() { fsutil.Rename = orig }()
+
+	if err := Command([]string{project, broken}, cfg); err == nil || !strings.Contains(err.Error(), "rolled back 1 tossed items") {
+		t.Fatalf("expected the directory rolled back, got %v", err)
+	}
+	for _, name := range []string{"main.go", "run.log"} {
+		if _, err := os.Stat(filepath.Join(project, name)); err != nil {
+			t.Errorf("%s must be back at the origin: %v", name, err)
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected no record left, got %d", count)
+	}
+}
+
+func TestCommand_ExcludeJournalsItemWithLeftovers(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can remove entries of read-only directories")
+	}
+	cfg := newTestCfg(t)
+	excludes, silentMode, autoConfirm = globs{"*.log"}, true, true
+	defer func() { excludes, silentMode, autoConfirm = nil, false, false }()
+
+	project := filepath.Join(cfg.WorkingDir, "project")
+	locked := filepath.Join(project, "locked")
+	os.MkdirAll(locked, 0o755)
+	os.WriteFile(filepath.Join(locked, "a.txt"), []byte("data"), 0o644)
+	os.WriteFile(filepath.Join(project, "run.log"), []byte("log"), 0o644)
+	os.Chmod(locked, 0o555)
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	if err := Command([]string{project}, cfg); err != nil {
+		t.Fatalf("expected the leftovers to be reported only, got %v", err)
+	}
+
+	moved := findTossed(t, cfg.ContainerPath, "project_")
+	t.Cleanup(func() { os.Chmod(filepath.Join(moved, "locked"), 0o755) })
+	record, err := cfg.Journal.Get(filepath.Base(moved))
+	if err != nil || len(record.Excluded) != 1 {
+		t.Errorf("the stored copy must be journaled with its exclusion, got %v, %v", record, err)
+	}
+}
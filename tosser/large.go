@@ -0,0 +1,54 @@
+package tosser
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/prompt"
+	"strconv"
+)
+
+// confirmLarge asks before tossing a directory holding more files or bytes
+// than the configured warning thresholds. Directories below the thresholds,
+// or with the thresholds disabled, are accepted without asking. Entries which
+// can't be read are left out of the count rather than failing the toss.
+func confirmLarge(dir string, cfg *config.Config) (bool, error) {
+	limit := cfg.MaxFilesWarn
+	if maxFilesWarn >= 0 {
+		limit = maxFilesWarn
+	}
+	if limit <= 0 && cfg.MaxBytesWarn == 0 {
+		return true, nil
+	}
+
+	files, size, err := fsutil.TreeStats(dir)
+	if err != nil {
+		return false, err
+	}
+
+	if (limit <= 0 || files <= limit) && (cfg.MaxBytesWarn == 0 || uint64(size) <= cfg.MaxBytesWarn) {
+		return true, nil
+	}
+
+	return prompt.Confirm(fmt.Sprintf("This will toss %s files (%s). Continue?",
+		groupThousands(files), config.ReadableSize(uint64(size))))
+}
+
+// groupThousands formats n with commas between the groups of three digits.
+func groupThousands(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits
+}
+
+// reportScan prints the progress of a long directory walk in verbose mode.
+func reportScan(root string, files int, size int64) {
+	fmt.Printf("  scanning %s: %s files, %s so far\n", root, groupThousands(files), config.ReadableSize(uint64(size)))
+}
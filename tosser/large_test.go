@@ -0,0 +1,122 @@
+package tosser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rubbish/prompt"
+)
+
+func TestGroupThousands(t *testing.T) {
+	cases := map[int]string{0: "0", 999: "999", 1000: "1,000", 12430: "12,430", 1234567: "1,234,567", -4500: "-4,500"}
+	for n, want := range cases {
+		if got := groupThousands(n); got != want {
+			t.Errorf("groupThousands(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+// makeTree creates a directory holding the given number of one byte files.
+func makeTree(t *testing.T, dir string, files int) string {
+	t.Helper()
+	os.MkdirAll(dir, 0o755)
+	for i := range files {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), []byte("x"), 0o644)
+	}
+	return dir
+}
+
+func TestCommand_LargeDirectoryAsksConfirmation(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxFilesWarn = 3
+	small := makeTree(t, filepath.Join(cfg.WorkingDir, "small"), 3)
+	large := makeTree(t, filepath.Join(cfg.WorkingDir, "large"), 4)
+
+	orig := prompt.Input
+	defer func() { prompt.Input = orig }()
+
+	// the small directory must not be asked about, the large one is declined
+	prompt.Input = strings.NewReader("n\n")
+	out := captureStdout(t, func() {
+		if err := Command([]string{small, large}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "This will toss 4 files (4 bytes). Continue? [y/N]") {
+		t.Errorf("expected a confirmation for the large directory only: %s", out)
+	}
+	if _, err := os.Stat(small); !os.IsNotExist(err) {
+		t.Error("small directory must be tossed")
+	}
+	if _, err := os.Stat(large); err != nil {
+		t.Error("declined directory must be kept")
+	}
+
+	// -y skips the question
+	autoConfirm = true
+	defer func() { autoConfirm = false }()
+	prompt.Input = strings.NewReader("")
+	captureStdout(t, func() {
+		if err := Command([]string{large}, cfg); err != nil {
+			t.Fatalf("Command error with -y: %v", err)
+		}
+	})
+	if _, err := os.Stat(large); !os.IsNotExist(err) {
+		t.Error("large directory must be tossed with -y")
+	}
+}
+
+func TestCommand_MaxFilesWarnFlagOverridesConfig(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxFilesWarn = 100
+	dir := makeTree(t, filepath.Join(cfg.WorkingDir, "dir"), 2)
+
+	maxFilesWarn = 1
+	defer func() { maxFilesWarn = -1 }()
+	orig := prompt.Input
+	prompt.Input = strings.NewReader("y\n")
+	defer func() { prompt.Input = orig }()
+
+	out := captureStdout(t, func() {
+		if err := Command([]string{dir}, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "This will toss 2 files") {
+		t.Errorf("expected the flag threshold to apply: %s", out)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("confirmed directory must be tossed")
+	}
+}
+
+// captureStdout runs fn while capturing stdout, returning printed text
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestConfirmLarge_CountsWhatCanBeRead(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxFilesWarn = 3
+	dir := makeTree(t, filepath.Join(cfg.WorkingDir, "partial"), 2)
+	locked := makeTree(t, filepath.Join(dir, "locked"), 5)
+	os.Chmod(locked, 0)
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	ok, err := confirmLarge(dir, cfg)
+	if err != nil || !ok {
+		t.Errorf("the readable files are below the threshold, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,223 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"rubbish/fsutil"
+)
+
+// simulateCrossDevice forces every rename to fail as if the container lived on another filesystem.
+func simulateCrossDevice(t *testing.T) {
+	t.Helper()
+	fsutil.Rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { fsutil.Rename = os.Rename })
+}
+
+func findTossed(t *testing.T, container string, prefix string) string {
+	t.Helper()
+	entries, _ := os.ReadDir(container)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), prefix) {
+			return filepath.Join(container, e.Name())
+		}
+	}
+	t.Fatalf("no tossed item with prefix %s in container", prefix)
+	return ""
+}
+
+func TestToss_CrossDeviceFile(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	src := filepath.Join(cfg.WorkingDir, "report.txt")
+	os.WriteFile(src, []byte("content"), 0o640)
+	mtime := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	os.Chtimes(src, mtime, mtime)
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("expected source removed, got err=%v", err)
+	}
+
+	moved := findTossed(t, cfg.ContainerPath, "report.txt_")
+	info, err := os.Stat(moved)
+	if err != nil {
+		t.Fatalf("stat moved: %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("permissions not preserved: %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("modification time not preserved: %v != %v", info.ModTime(), mtime)
+	}
+	if data, _ := os.ReadFile(moved); string(data) != "content" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if _, err := cfg.Journal.Get(filepath.Base(moved)); err != nil {
+		t.Errorf("journal record missing: %v", err)
+	}
+}
+
+func TestToss_CrossDeviceDirectory(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	src := filepath.Join(cfg.WorkingDir, "project")
+	os.MkdirAll(filepath.Join(src, "nested"), 0o755)
+	os.WriteFile(filepath.Join(src, "nested", "a.txt"), []byte("a"), 0o644)
+	os.Symlink("nested/a.txt", filepath.Join(src, "link"))
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	moved := findTossed(t, cfg.ContainerPath, "project_")
+	if data, _ := os.ReadFile(filepath.Join(moved, "nested", "a.txt")); string(data) != "a" {
+		t.Errorf("nested file not copied: %q", data)
+	}
+	if target, err := os.Readlink(filepath.Join(moved, "link")); err != nil || target != "nested/a.txt" {
+		t.Errorf("symlink not recreated: %q %v", target, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("expected source directory removed")
+	}
+}
+
+func TestToss_CrossDeviceCopyFailureLeavesNoEntry(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	src := filepath.Join(t.TempDir(), "broken")
+	os.MkdirAll(src, 0o755)
+	os.WriteFile(filepath.Join(src, "ok.txt"), []byte("ok"), 0o644)
+	// a file that cannot be read makes the copy fail mid-way
+	os.WriteFile(filepath.Join(src, "secret.txt"), []byte("x"), 0o000)
+	defer os.Chmod(filepath.Join(src, "secret.txt"), 0o644)
+	if os.Getuid() == 0 {
+		t.Skip("root can read any file")
+	}
+
+	if err := Toss(src, cfg); err == nil {
+		t.Fatal("expected error when the copy fails")
+	}
+
+	if _, err := os.Stat(filepath.Join(src, "ok.txt")); err != nil {
+		t.Errorf("source must be left untouched: %v", err)
+	}
+	entries, _ := os.ReadDir(cfg.ContainerPath)
+	for _, e := range entries {
+		if e.Name() != ".journal" {
+			t.Errorf("unexpected leftover in container: %s", e.Name())
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected no journal entries, got %d", count)
+	}
+}
+
+func TestToss_CrossDeviceKeepsFileSparse(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	const size = 64 << 20
+	src := filepath.Join(t.TempDir(), "disk.img")
+	f, _ := os.Create(src)
+	f.WriteAt([]byte("head"), 0)
+	f.WriteAt([]byte("tail"), size-4)
+	f.Close()
+
+	srcBlocks := allocatedBlocks(t, src)
+	if srcBlocks*512 >= size/2 {
+		t.Skip("filesystem does not support sparse files")
+	}
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	moved := findTossed(t, cfg.ContainerPath, "disk.img_")
+	info, _ := os.Stat(moved)
+	if info.Size() != size {
+		t.Fatalf("size not preserved: %d", info.Size())
+	}
+	if blocks := allocatedBlocks(t, moved); blocks > srcBlocks*2+64 {
+		t.Errorf("copy is not sparse: %d blocks allocated, source had %d", blocks, srcBlocks)
+	}
+
+	data, _ := os.ReadFile(moved)
+	if string(data[:4]) != "head" || string(data[size-4:]) != "tail" || data[size/2] != 0 {
+		t.Error("sparse copy content differs from the source")
+	}
+}
+
+func allocatedBlocks(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	return info.Sys().(*syscall.Stat_t).Blocks
+}
+
+func TestToss_CrossDeviceJournalsItemWithLeftovers(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can remove entries of read-only directories")
+	}
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	src := filepath.Join(cfg.WorkingDir, "project")
+	locked := filepath.Join(src, "locked")
+	os.MkdirAll(locked, 0o755)
+	os.WriteFile(filepath.Join(locked, "a.txt"), []byte("data"), 0o644)
+	os.Chmod(locked, 0o555)
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	moved := findTossed(t, cfg.ContainerPath, "project_")
+	t.Cleanup(func() { os.Chmod(filepath.Join(moved, "locked"), 0o755) })
+	if _, err := cfg.Journal.Get(filepath.Base(moved)); err != nil {
+		t.Errorf("the complete copy must be journaled: %v", err)
+	}
+}
+
+func TestToss_CrossDeviceLeavesSpecialFilesAtOrigin(t *testing.T) {
+	cfg := newTestCfg(t)
+	simulateCrossDevice(t)
+
+	src := filepath.Join(cfg.WorkingDir, "project")
+	os.MkdirAll(src, 0o755)
+	os.WriteFile(filepath.Join(src, "main.go"), []byte("package main"), 0o644)
+	if err := syscall.Mkfifo(filepath.Join(src, "pipe"), 0o644); err != nil {
+		t.Skipf("named pipes not supported: %v", err)
+	}
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(src, "pipe")); err != nil {
+		t.Errorf("the named pipe must stay at the origin: %v", err)
+	}
+	moved := findTossed(t, cfg.ContainerPath, "project_")
+	record, err := cfg.Journal.Get(filepath.Base(moved))
+	if err != nil {
+		t.Fatalf("journal record missing: %v", err)
+	}
+	if len(record.Excluded) != 1 || record.Excluded[0] != "pipe" {
+		t.Errorf("expected the named pipe recorded as left at the origin, got %v", record.Excluded)
+	}
+}
@@ -0,0 +1,67 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommand_Policy(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Policies = map[string]int{"temp": 1, "archive": 180}
+	policyName, silentMode = "archive", true
+	defer func() { policyName, silentMode = "", false }()
+
+	src := filepath.Join(t.TempDir(), "report.pdf")
+	os.WriteFile(src, []byte("pdf"), 0o644)
+	if err := Command([]string{src}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 || records[0].Policy != "archive" || records[0].WipeoutTime != 180 {
+		t.Errorf("expected the archive policy applied, got %+v", records)
+	}
+}
+
+func TestCommand_PolicyErrors(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Policies = map[string]int{"temp": 1}
+	defer func() { policyName, retentionUntil = "", "" }()
+
+	src := filepath.Join(t.TempDir(), "x.txt")
+	os.WriteFile(src, []byte("x"), 0o644)
+
+	policyName = "forever"
+	if err := Command([]string{src}, cfg); err == nil {
+		t.Error("expected an unknown policy to be rejected")
+	}
+
+	policyName, retentionUntil = "temp", "2099-01-01"
+	if err := Command([]string{src}, cfg); err == nil {
+		t.Error("expected error when combining --policy and --until")
+	}
+	retentionUntil = ""
+
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("nothing must be tossed on a policy error: %v", err)
+	}
+}
+
+func TestCommand_PolicyOverridesRetention(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Policies = map[string]int{"temp": 1}
+	policyName, retentionTime, silentMode = "temp", 30, true
+	defer func() { policyName, retentionTime, silentMode = "", -1, false }()
+
+	src := filepath.Join(t.TempDir(), "x.txt")
+	os.WriteFile(src, []byte("x"), 0o644)
+	if err := Command([]string{src}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 || records[0].WipeoutTime != 1 {
+		t.Errorf("expected the policy to override -r, got %+v", records)
+	}
+}
@@ -0,0 +1,46 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// pruneBoundary returns the directory the pruning of the parents of origin
+// stops at: the deepest of the working and home directories holding origin.
+// It returns an empty string for origins outside of both, never pruned.
+func pruneBoundary(origin string, cfg *config.Config) string {
+	boundary := ""
+	candidates := []string{cfg.WorkingDir}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, home)
+	}
+	for _, dir := range candidates {
+		if dir != "" && journal.IsWithin(origin, dir) && len(dir) > len(boundary) {
+			boundary = filepath.Clean(dir)
+		}
+	}
+	return boundary
+}
+
+// pruneParents removes the parents of the tossed origin left empty, walking
+// up until a directory still holding entries, the boundary or a directory
+// holding the container. Only empty directories can be removed, so those
+// emptied by the toss are the only ones to go. It returns the removed
+// directories.
+func pruneParents(origin string, cfg *config.Config) []string {
+	boundary := pruneBoundary(origin, cfg)
+	if boundary == "" {
+		return nil
+	}
+
+	var pruned []string
+	for dir := filepath.Dir(origin); dir != boundary && journal.IsWithin(dir, boundary); dir = filepath.Dir(dir) {
+		if journal.IsWithin(cfg.ContainerPath, dir) || os.Remove(dir) != nil {
+			break
+		}
+		pruned = append(pruned, dir)
+	}
+	return pruned
+}
@@ -0,0 +1,89 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommand_PruneEmptyParentsUpToWorkingDir(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.WorkingDir = t.TempDir()
+	pruneEmpty, silentMode = true, true
+	defer func() { pruneEmpty, silentMode = false, false }()
+
+	nested := filepath.Join(cfg.WorkingDir, "a", "b", "c")
+	os.MkdirAll(nested, 0o755)
+	file := filepath.Join(nested, "only.txt")
+	os.WriteFile(file, []byte("only"), 0o644)
+
+	if err := Command([]string{file}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(cfg.WorkingDir, "a")); !os.IsNotExist(err) {
+		t.Errorf("the emptied parents must be pruned, got %v", err)
+	}
+	if _, err := os.Stat(cfg.WorkingDir); err != nil {
+		t.Errorf("the working directory must be kept: %v", err)
+	}
+}
+
+func TestCommand_PruneEmptyParentsStopsAtNonEmpty(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.WorkingDir = t.TempDir()
+	pruneEmpty, silentMode = true, true
+	defer func() { pruneEmpty, silentMode = false, false }()
+
+	os.MkdirAll(filepath.Join(cfg.WorkingDir, "a", "b"), 0o755)
+	os.MkdirAll(filepath.Join(cfg.WorkingDir, "a", "empty"), 0o755)
+	file := filepath.Join(cfg.WorkingDir, "a", "b", "only.txt")
+	os.WriteFile(file, []byte("only"), 0o644)
+
+	if err := Command([]string{file}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(cfg.WorkingDir, "a", "b")); !os.IsNotExist(err) {
+		t.Errorf("the emptied parent must be pruned, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDir, "a", "empty")); err != nil {
+		t.Errorf("a directory not emptied by the toss must be kept: %v", err)
+	}
+}
+
+func TestCommand_PruneEmptyParentsOutsideBoundary(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.WorkingDir = t.TempDir()
+	pruneEmpty, silentMode = true, true
+	defer func() { pruneEmpty, silentMode = false, false }()
+
+	outside := filepath.Join(t.TempDir(), "a")
+	os.MkdirAll(outside, 0o755)
+	file := filepath.Join(outside, "only.txt")
+	os.WriteFile(file, []byte("only"), 0o644)
+
+	if err := Command([]string{file}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("parents outside the working and home directories must be kept: %v", err)
+	}
+}
+
+func TestCommand_KeepsEmptyParentsByDefault(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.WorkingDir = t.TempDir()
+	silentMode = true
+	defer func() { silentMode = false }()
+
+	nested := filepath.Join(cfg.WorkingDir, "a")
+	os.MkdirAll(nested, 0o755)
+	file := filepath.Join(nested, "only.txt")
+	os.WriteFile(file, []byte("only"), 0o644)
+
+	if err := Command([]string{file}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Stat(nested); err != nil {
+		t.Errorf("empty parents must be kept without --prune-empty-parents: %v", err)
+	}
+}
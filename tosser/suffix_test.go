@@ -0,0 +1,94 @@
+package tosser
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// constantSource makes every generated suffix identical.
+type constantSource struct{}
+
+func (constantSource) Int63() int64 { return 0 }
+func (constantSource) Seed(int64)   {}
+
+func TestToss_SameNameManyTimesLosesNothing(t *testing.T) {
+	cfg := newTestCfg(t)
+
+	const total = 1000
+	for i := range total {
+		src := filepath.Join(t.TempDir(), "same.txt")
+		os.WriteFile(src, []byte(strconv.Itoa(i)), 0o644)
+		if err := Toss(src, cfg); err != nil {
+			t.Fatalf("Toss #%d: %v", i, err)
+		}
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil || len(records) != total {
+		t.Fatalf("expected %d journal records, got %d (%v)", total, len(records), err)
+	}
+
+	seen := map[string]bool{}
+	for _, record := range records {
+		data, err := os.ReadFile(filepath.Join(cfg.ContainerPath, record.Item))
+		if err != nil {
+			t.Fatalf("item %s missing: %v", record.Item, err)
+		}
+		seen[string(data)] = true
+	}
+	if len(seen) != total {
+		t.Errorf("expected %d distinct contents in the container, got %d", total, len(seen))
+	}
+}
+
+func TestToss_SuffixCollisionRetries(t *testing.T) {
+	cfg := newTestCfg(t)
+	orig := rng
+	defer func() { rng = orig }()
+	rng = rand.New(rand.NewSource(42))
+	first := filepath.Join(t.TempDir(), "dup.txt")
+	os.WriteFile(first, []byte("first"), 0o644)
+	if err := Toss(first, cfg); err != nil {
+		t.Fatalf("first toss: %v", err)
+	}
+
+	// replaying the same sequence produces the taken suffix first
+	rng = rand.New(rand.NewSource(42))
+	second := filepath.Join(t.TempDir(), "dup.txt")
+	os.WriteFile(second, []byte("second"), 0o644)
+	if err := Toss(second, cfg); err != nil {
+		t.Fatalf("second toss: %v", err)
+	}
+
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("expected both items journaled, got %d", count)
+	}
+}
+
+func TestToss_SuffixAttemptsExhausted(t *testing.T) {
+	cfg := newTestCfg(t)
+	orig := rng
+	defer func() { rng = orig }()
+	rng = rand.New(constantSource{})
+
+	first := filepath.Join(t.TempDir(), "dup.txt")
+	os.WriteFile(first, []byte("first"), 0o644)
+	if err := Toss(first, cfg); err != nil {
+		t.Fatalf("first toss: %v", err)
+	}
+
+	second := filepath.Join(t.TempDir(), "dup.txt")
+	os.WriteFile(second, []byte("second"), 0o644)
+	if err := Toss(second, cfg); err == nil {
+		t.Fatal("expected error once every attempt collides")
+	}
+	if _, err := os.Stat(second); err != nil {
+		t.Errorf("the source must be left in place: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.ContainerPath, "dup.txt_AAAAAA")); string(data) != "first" {
+		t.Errorf("the first item must not be clobbered, got %q", data)
+	}
+}
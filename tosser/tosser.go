@@ -1,32 +1,92 @@
 package tosser
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
 	"os"
 	"path"
 	"path/filepath"
+	"rubbish/color"
 	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"rubbish/trashinfo"
 	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
+// maxSuffixAttempts bounds the retries looking for a free container name
+const maxSuffixAttempts = 10
+
+// errNoWritePermission reports an item or parent directory the user may not
+// modify, which --force accepts
+var errNoWritePermission = errors.New("no write permission")
+
 var (
-	Flags             = flag.NewFlagSet("toss", flag.ExitOnError)
-	retentionTime int = -1
-	silentMode    bool
+	Flags              = flag.NewFlagSet("toss", flag.ExitOnError)
+	retentionTime  int = -1
+	retentionUntil string
+	policyName     string // policyName is the retention policy of the [policies] section applied, empty for none
+	silentMode     bool
+	latestSession  bool
+	autoConfirm    bool
+	maxFilesWarn   int = -1
+	verbose        bool
+	force          bool   // force tosses items failing the write permission checks
+	binName        string // binName is the named bin to toss into, empty for the default bin
+	fromStdin      bool   // fromStdin reads the paths to toss from the standard input, one per line
+	fromFile       string // fromFile is the file listing the paths to toss, one per line
+	compress       bool   // compress stores the items as compressed archives, overriding the compress setting
+	atomic         bool   // atomic tosses every item or none, rolling back the tossed ones on failure
+	followSymlinks bool   // followSymlinks tosses the targets of the symlinks given instead of the links
+	dedupe         bool   // dedupe hard links the files whose content is already in the bin
+	excludes       globs  // excludes are the globs of the directory entries left at the origin
+	pruneEmpty     bool   // pruneEmpty removes the origin parents left empty once the items are tossed
+
+	// stdin is the source of the --stdin paths, replaceable for testing
+	stdin io.Reader = os.Stdin
+
+	// rng generates the name suffixes, seeded once so suffixes generated in
+	// a quick succession are not correlated
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rngMu sync.Mutex
 )
 
 func init() {
 	Flags.IntVar(&retentionTime, "r", -1, "Time to retain the file before it is wiped out from the filesystem.")
+	Flags.StringVar(&policyName, "policy", "", "Keep the file for the number of days of the named retention policy of the [policies] section, overriding -r.")
+	Flags.StringVar(&retentionUntil, "until", "", "Keep the file until the given date (YYYY-MM-DD) instead of a number of days.")
 	Flags.BoolVar(&silentMode, "s", false, "Silent mode. Suppress non-error messages.")
+	Flags.BoolVar(&force, "f", false, "Skip the write permission checks, with a warning. Missing files still fail.")
+	Flags.BoolVar(&force, "force", false, "Skip the write permission checks, with a warning. Missing files still fail.")
+	Flags.StringVar(&binName, "bin", "", "Toss into the named bin instead of the default one.")
+	Flags.BoolVar(&verbose, "verbose", false, "Report the progress of each item and of long directory scans, then the elapsed time.")
+	Flags.BoolVar(&autoConfirm, "y", false, "Toss large directories without asking for confirmation.")
+	Flags.IntVar(&maxFilesWarn, "max-files-warn", -1, "Ask before tossing a directory holding more files than this, overriding max_files_warn (0 never asks).")
+	Flags.BoolVar(&compress, "compress", false, "Store the items gzip compressed, overriding the compress setting. Already compressed formats are moved as is.")
+	Flags.BoolVar(&fromStdin, "stdin", false, "Toss the paths read from the standard input, one per line, instead of the arguments.")
+	Flags.StringVar(&fromFile, "from-file", "", "Toss the paths listed in the given file, one per line, instead of the arguments.")
+	Flags.Var(&excludes, "exclude", "Leave the entries of tossed directories matching the glob, by relative path or name, at the origin. Repeatable.")
+	Flags.BoolVar(&dedupe, "dedupe", false, "Store files whose content is already in the bin as hard links to it instead of a second copy.")
+	Flags.BoolVar(&followSymlinks, "follow-symlinks", false, "Toss the targets of the symlinks given instead of the links themselves.")
+	Flags.BoolVar(&pruneEmpty, "prune-empty-parents", false, "Remove the origin directories left empty by the toss, up to the working or home directory.")
+	Flags.BoolVar(&atomic, "atomic", false, "Toss every item or none: check them all first and move the tossed ones back if one fails.")
+	Flags.BoolVar(&latestSession, "into-latest-session", false, "Add the items to the batch of the previous toss instead of a new one.")
 
 	Flags.Usage = func() {
 		fmt.Println("Toss moves the specified files to the rubbish bin.\n\n",
 			"Usage:\n\n",
-			"\trubbish toss [options] <file1> <file2> ...\n\n",
+			"\trubbish toss [options] <file1> <file2> ...\n",
+			"\tfind . -name '*.tmp' | rubbish toss --stdin\n\n",
 			"Options:")
 		Flags.PrintDefaults()
 	}
@@ -50,39 +110,225 @@ func init() {
 //   - cfg: Application configuration containing default settings and journal
 //
 // Returns an error if no files are specified, if any file cannot be accessed,
-// or if the tossing operation fails for any item.
+// or if the tossing operation fails for any item. Paths listed with --stdin or
+// --from-file are all attempted, their failures reported together at the end.
+// With --atomic every item is checked before any is moved, and a failure
+// moves the items already tossed back to their origin.
 func Command(args []string, cfg *config.Config) error {
+	return CommandContext(context.Background(), args, cfg)
+}
+
+// CommandContext runs the toss command like Command, stopping between items
+// once ctx is cancelled. The item being tossed is always completed, so the
+// journal and the container agree on every item handled.
+func CommandContext(ctx context.Context, args []string, cfg *config.Config) error {
+	listed := fromStdin || fromFile != ""
+	if listed {
+		var err error
+		if args, err = listedPaths(); err != nil {
+			return err
+		}
+	}
+
 	if len(args) == 0 {
 		return fmt.Errorf("no files or directory specified to toss")
 	}
 
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	if compressing(cfg) && cfg.XDG() {
+		return fmt.Errorf("compression is not supported in %s trash mode", config.TrashModeXDG)
+	}
+
+	if err := excludes.validate(); err != nil {
+		return err
+	}
+
+	if verbose && silentMode {
+		return fmt.Errorf("the -s and --verbose options cannot be combined")
+	}
+
 	if retentionTime >= 0 {
 		cfg.WipeoutTime = retentionTime
 	}
 
-	for _, file := range args {
-		if _, err := os.Stat(file); err != nil {
-			return fmt.Errorf("invalid rubbish to toss '%s': %w", file, err)
+	// A policy overrides wipeout_time and -r alike
+	if policyName != "" {
+		if retentionUntil != "" {
+			return fmt.Errorf("the --policy and --until options cannot be combined")
+		}
+		days, err := cfg.Policy(policyName)
+		if err != nil {
+			return err
+		}
+		cfg.WipeoutTime = days
+	}
+
+	// wipeableAt is the absolute expiry requested with --until, zero when the
+	// retention is relative to the toss time
+	var wipeableAt time.Time
+	if retentionUntil != "" {
+		if retentionTime >= 0 {
+			return fmt.Errorf("the -r and --until options cannot be combined")
+		}
+
+		until, clamped, err := untilDate(retentionUntil, cfg.MaxRetention, time.Now())
+		if err != nil {
+			return err
+		}
+		if clamped && !silentMode {
+			fmt.Printf("Retention limited to the maximum of %d days, until %s.\n", cfg.MaxRetention, until.Format(time.DateOnly))
+		}
+
+		wipeableAt = until
+		cfg.WipeoutTime = int(math.Ceil(time.Until(until).Hours() / 24))
+	}
+
+	if atomic {
+		if err := checkAll(args); err != nil {
+			return err
+		}
+	}
+
+	batch, err := sessionBatch(cfg, latestSession)
+	if err != nil {
+		return err
+	}
+
+	start, tossed := time.Now(), 0
+	if verbose {
+		fsutil.Progress = reportScan
+		defer func() { fsutil.Progress = nil }()
+	}
+
+	// failures collects the errors of the listed paths, which do not stop
+	// the batch; any other error aborts the toss at once, rolling back the
+	// tossed items with --atomic.
+	var (
+		failures  []error
+		completed []*journal.MetaData
+	)
+	fail := func(err error) error {
+		if atomic {
+			return rollBack(err, completed, cfg)
+		}
+		if !listed {
+			return err
+		}
+		failures = append(failures, err)
+		return nil
+	}
+
+	var stopped error
+	for i, file := range args {
+		if ctx.Err() != nil {
+			stopped = fmt.Errorf("toss interrupted with %d items left: %w", len(args)-i, ctx.Err())
+			if atomic {
+				return rollBack(stopped, completed, cfg)
+			}
+			break
+		}
+
+		file, err := resolveLink(file)
+		if err != nil {
+			if err := fail(err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		info, err := os.Lstat(file)
+		if err != nil {
+			if err := fail(fmt.Errorf("invalid rubbish to toss '%s': %w", file, err)); err != nil {
+				return err
+			}
+			continue
 		}
 
-		if err := Toss(file, cfg); err != nil {
-			return fmt.Errorf("error tossing rubbish %s: %w", file, err)
+		if info.IsDir() && !autoConfirm && !silentMode {
+			ok, err := confirmLarge(file, cfg)
+			if err != nil {
+				if err := fail(fmt.Errorf("error confirming toss of %s: %w", file, err)); err != nil {
+					return err
+				}
+				continue
+			}
+			if !ok && atomic {
+				return rollBack(fmt.Errorf("toss of '%s' declined, nothing tossed", file), completed, cfg)
+			}
+			if !ok {
+				fmt.Printf("Skipping '%s' as per user confirmation.\n", file)
+				continue
+			}
 		}
 
+		// The record of an item stored whose origin could not be removed
+		// comes with the error, it is rolled back like the others
+		record, err := tossItem(file, cfg, batch, wipeableAt)
+		if record != nil {
+			completed = append(completed, record)
+
+			// A toss failing for every path leaves the latest batch alone
+			if len(completed) == 1 {
+				if err := keepBatch(cfg, batch); err != nil {
+					if atomic {
+						return rollBack(err, completed, cfg)
+					}
+					return err
+				}
+			}
+		}
+		if err != nil {
+			if err := fail(fmt.Errorf("error tossing rubbish %s: %w", file, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		tossed++
+
 		if silentMode {
 			continue
 		}
 
-		fmt.Printf("\033[32mTossed\033[0m '%s' to rubbish bin. ", file)
-		if cfg.WipeoutTime == 0 {
+		if verbose {
+			fmt.Printf("[%d/%d] ", i+1, len(args))
+		}
+		fmt.Printf("%s '%s' to rubbish bin. ", color.Paint(os.Stdout, color.Green, "Tossed"), file)
+		if !wipeableAt.IsZero() {
+			fmt.Printf("Wipeout on %s.\n", wipeableAt.Format(time.DateOnly))
+		} else if cfg.WipeoutTime == 0 {
 			fmt.Println("Wipeout immediate.")
 		} else {
 			fmt.Printf("Wipeout after %d days.\n", cfg.WipeoutTime)
 		}
+		if len(record.Excluded) > 0 {
+			fmt.Printf("Left %d excluded paths in '%s'.\n", len(record.Excluded), file)
+		}
+	}
+
+	// Parents are pruned once every item is tossed, as they may hold several
+	// of them and a rollback needs them in place
+	if pruneEmpty {
+		for _, record := range completed {
+			for _, dir := range pruneParents(record.Origin, cfg) {
+				if !silentMode {
+					fmt.Printf("Removed empty directory '%s'.\n", dir)
+				}
+			}
+		}
 	}
 
 	if silentMode {
-		return nil
+		return errors.Join(stopped, failed(failures, len(args)))
+	}
+
+	if verbose {
+		fmt.Printf("Tossed %d of %d items in %s.\n", tossed, len(args), time.Since(start).Round(time.Millisecond))
 	}
 
 	if size, err := config.BinSize(cfg); err != nil {
@@ -91,19 +337,170 @@ func Command(args []string, cfg *config.Config) error {
 		fmt.Printf("Bin size: %s\n", config.ReadableSize(uint64(size)))
 	}
 
-	return nil
+	return errors.Join(stopped, failed(failures, len(args)))
+}
+
+// listedPaths reads the paths given with --stdin or --from-file.
+func listedPaths() ([]string, error) {
+	if fromStdin && fromFile != "" {
+		return nil, fmt.Errorf("the --stdin and --from-file options cannot be combined")
+	}
+
+	if fromStdin {
+		paths, err := readPaths(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading paths from standard input: %w", err)
+		}
+		return paths, nil
+	}
+
+	file, err := os.Open(fromFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening path list: %w", err)
+	}
+	defer file.Close()
+
+	paths, err := readPaths(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading path list %s: %w", fromFile, err)
+	}
+	return paths, nil
+}
+
+// readPaths returns the newline delimited paths of r. Blank lines are
+// skipped, the other lines are kept as is but for a trailing carriage return.
+func readPaths(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
 }
 
+// resolveLink returns the target of the path with --follow-symlinks when it
+// is a symlink, the path itself otherwise.
+func resolveLink(path string) (string, error) {
+	if !followSymlinks {
+		return path, nil
+	}
+	if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving symlink %s: %w", path, err)
+	}
+	return target, nil
+}
+
+// failed aggregates the errors of the listed paths which could not be tossed.
+func failed(failures []error, total int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unable to toss %d of %d items:\n%w", len(failures), total, errors.Join(failures...))
+}
+
+// untilDate parses an expiry date (YYYY-MM-DD, local time) which must be in the
+// future. Dates beyond the maximum retention are clamped to it, reported by the
+// second return value. A maxRetention of zero or less disables the clamp.
+func untilDate(value string, maxRetention int, now time.Time) (time.Time, bool, error) {
+	until, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid --until date '%s', expected YYYY-MM-DD: %w", value, err)
+	}
+
+	if !until.After(now) {
+		return time.Time{}, false, fmt.Errorf("the --until date %s is not in the future", value)
+	}
+
+	if maxRetention > 0 {
+		limit := now.Add(time.Duration(maxRetention*24) * time.Hour)
+		if until.After(limit) {
+			return limit, true, nil
+		}
+	}
+
+	return until, false, nil
+}
+
+// NameSufix returns a random string of the given size made of upper case
+// letters and digits, used to keep the container names unique.
 func NameSufix(size uint) string {
 	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, size)
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	rngMu.Lock()
+	defer rngMu.Unlock()
 	for i := range b {
-		b[i] = charset[r.Intn(len(charset))]
+		b[i] = charset[rng.Intn(len(charset))]
 	}
 	return string(b)
 }
 
+// destinationFor returns the container item for the absolute origin, a name
+// which is neither taken in the container nor in the journal. The flat layout
+// retries with a fresh suffix on collision. The mirrored layout uses the
+// origin path itself, suffixed only when an item is already stored there, and
+// falls back to a flat name when a parent of that path is an item or the path
+// is reserved by the container.
+// In xdg mode the name must not have a .trashinfo file either.
+func destinationFor(origin string, cfg *config.Config) (string, error) {
+	name := filepath.Base(origin)
+	if cfg.Mirrored() {
+		mirrored := config.MirroredItem(origin)
+		if !cfg.Reserved(mirrored) && parentsFree(mirrored, cfg) {
+			if itemFree(mirrored, cfg) {
+				return mirrored, nil
+			}
+			name = mirrored
+		}
+	}
+
+	for range maxSuffixAttempts {
+		item := name + "_" + NameSufix(6)
+		if itemFree(item, cfg) {
+			return item, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free name found for %s in the rubbish bin after %d attempts", origin, maxSuffixAttempts)
+}
+
+// itemFree reports whether item names nothing in the container nor in the journal.
+func itemFree(item string, cfg *config.Config) bool {
+	if _, err := os.Lstat(cfg.ItemPath(item)); !os.IsNotExist(err) {
+		return false
+	}
+	if cfg.XDG() {
+		if _, err := os.Lstat(trashinfo.File(cfg.InfoPath(), item)); !os.IsNotExist(err) {
+			return false
+		}
+	}
+	_, err := cfg.Journal.Get(item)
+	return errors.Is(err, journal.ErrItemNotFound)
+}
+
+// parentsFree reports whether the parents of a mirrored item can hold it:
+// none of them is an item or anything but a directory.
+func parentsFree(item string, cfg *config.Config) bool {
+	for parent := path.Dir(item); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if _, err := cfg.Journal.Get(parent); !errors.Is(err, journal.ErrItemNotFound) {
+			return false
+		}
+		if info, err := os.Lstat(cfg.ItemPath(parent)); err == nil && !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
 // checkWritePermission checks if the current user has write permission on the given file info.
 // It returns true if write permission is granted, false otherwise.
 func checkWritePermission(uid, gid int, fileUID, fileGID int, mode os.FileMode) bool {
@@ -143,7 +540,7 @@ func validateParentDirAccess(item string, uid, gid int) error {
 	}
 
 	if !checkWritePermission(uid, gid, int(stat.Uid), int(stat.Gid), info.Mode()) {
-		return fmt.Errorf("no write permission for parent directory of %s", item)
+		return fmt.Errorf("%w for parent directory of %s", errNoWritePermission, item)
 	}
 
 	return nil
@@ -160,10 +557,15 @@ func validateAccess(item string) error {
 	}
 
 	// Get file ownership and permissions
-	info, err := os.Stat(item)
+	info, err := os.Lstat(item)
 	if err != nil {
 		return fmt.Errorf("cannot access item %s: %w", item, err)
 	}
+
+	// A symlink's own permissions are meaningless, only its directory matters
+	if info.Mode()&os.ModeSymlink != 0 {
+		return validateParentDirAccess(item, uid, gid)
+	}
 	stat, ok := info.Sys().(*syscall.Stat_t)
 	if !ok {
 		return fmt.Errorf("cannot get detailed file information for %s", item)
@@ -175,7 +577,7 @@ func validateAccess(item string) error {
 
 	// Check if user has write permission on the file
 	if !checkWritePermission(uid, gid, fileUID, fileGID, fileMode) {
-		return fmt.Errorf("no write permission for item %s", item)
+		return fmt.Errorf("%w for item %s", errNoWritePermission, item)
 	}
 
 	// Check if user has write permission on the parent directory
@@ -183,22 +585,188 @@ func validateAccess(item string) error {
 }
 
 func Toss(item string, cfg *config.Config) error {
+	_, err := tossItem(item, cfg, "", time.Time{})
+	return err
+}
+
+// tossItem moves the item into the container like Toss and returns its record,
+// part of the given batch and wipeable at the given time unless it is zero.
+func tossItem(item string, cfg *config.Config, batch string, wipeableAt time.Time) (*journal.MetaData, error) {
 	if err := validateAccess(item); err != nil {
-		return err
+		if !force || !errors.Is(err, errNoWritePermission) {
+			return nil, err
+		}
+		color.Warnf("%v, tossing anyway as --force is given\n", err)
 	}
 
-	destination := path.Join(cfg.ContainerPath, filepath.Base(item+"_"+NameSufix(6)))
+	origin, err := filepath.Abs(item)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for %s: %w", item, err)
+	}
 
-	if err := cfg.Journal.AddFileByName(filepath.Base(destination), item, cfg.WipeoutTime); err != nil {
-		return fmt.Errorf("error adding item to rubbish journal: %v", err)
+	name, err := destinationFor(origin, cfg)
+	if err != nil {
+		return nil, err
+	}
+	destination := cfg.ItemPath(name)
+
+	// The type and size are read from the origin, so the metadata must be
+	// generated before the item is moved into the container.
+	record, err := journal.GenerateMetadata(name, origin, cfg.WipeoutTime)
+	if err != nil {
+		return nil, fmt.Errorf("error adding item to rubbish journal: %v", err)
+	}
+	if !wipeableAt.IsZero() {
+		record.WipeableAt = wipeableAt.Unix()
+	}
+	record.Batch = batch
+	record.Policy = policyName
+	record.Compressed = compressing(cfg) && compressible(record)
+
+	// Only part of a directory with exclusions is stored, copied as is
+	partial := record.Type == journal.TypeDirectory && len(excludes) > 0
+	if partial {
+		record.Compressed = false
 	}
 
-	if err := os.Rename(item, destination); err != nil {
-		if errj := cfg.Journal.Delete(filepath.Base(destination)); errj != nil {
-			return fmt.Errorf("error deleting journal entry for %s due to unable to move to rubbish bin: %w", item, errj)
+	// Deduplicated files are stored as is, so later duplicates can link them
+	if dedupe && record.Type == journal.TypeFile {
+		if record.Hash, err = fsutil.FileHash(origin); err != nil {
+			return nil, fmt.Errorf("error hashing %s: %w", item, err)
 		}
-		return fmt.Errorf("error moving item to rubbish bin: %v", err)
+		record.Compressed = false
 	}
 
-	return nil
+	if err := cfg.WriteTrashInfo(record); err != nil {
+		return nil, err
+	}
+
+	// The item is journaled only once it is in the container, so a failed
+	// move never leaves a record behind. A crash in between leaves an orphan
+	// item instead, which wipe --orphans clears.
+	moved, linked := false, false
+	rollback := func(cause error) error {
+		if moved && (record.Compressed || linked) {
+			os.Remove(destination)
+		} else if moved && len(record.Excluded) > 0 {
+			if err := fsutil.Merge(destination, origin); err != nil && !errors.Is(err, fsutil.ErrSourceLeft) {
+				cause = fmt.Errorf("%v, %s is left in the container as %s: %v", cause, item, name, err)
+			}
+		} else if moved {
+			if err := fsutil.Move(destination, origin); err != nil && !errors.Is(err, fsutil.ErrSourceLeft) {
+				cause = fmt.Errorf("%v, %s is left in the container as %s: %v", cause, item, name, err)
+			}
+		}
+		cfg.PruneItemParents(name)
+		if err := cfg.RemoveTrashInfo(name); err != nil {
+			color.Warnf("%v\n", err)
+		}
+		return cause
+	}
+
+	err = os.MkdirAll(filepath.Dir(destination), 0o755)
+	if err == nil && record.Hash != "" {
+		linked = linkDuplicate(record, destination, cfg)
+	}
+	if err == nil && !linked && record.Compressed {
+		err = fsutil.Compress(item, destination)
+		if errors.Is(err, fsutil.ErrNotCompressible) {
+			record.Compressed = false
+		}
+	}
+	if err == nil && partial {
+		record.Excluded, err = fsutil.MoveExcluding(item, destination, excludes.match)
+	} else if err == nil && !linked && !record.Compressed && record.Type == journal.TypeDirectory {
+		// The special files a move across devices leaves in place are
+		// recorded as excluded, so restore merges the item back around them
+		record.Excluded, err = fsutil.MoveExcluding(item, destination, nil)
+	} else if err == nil && !linked && !record.Compressed {
+		err = fsutil.Move(item, destination)
+	}
+	// A move across devices failing to remove all of the origin stored the
+	// item whole nonetheless, only the leftovers are reported
+	if errors.Is(err, fsutil.ErrSourceLeft) {
+		color.Warnf("%s is in the rubbish bin, but %v\n", item, err)
+		err = nil
+	}
+	if err != nil {
+		// A partial move failing once committed has emptied part of the origin
+		moved = len(record.Excluded) > 0
+		return nil, rollback(fmt.Errorf("error moving item to rubbish bin: %v", err))
+	}
+	moved = true
+
+	// The excluded entries are not part of the item, nor of its size
+	if len(record.Excluded) > 0 {
+		if record.Size, err = fsutil.TreeSize(destination); err != nil {
+			return nil, rollback(fmt.Errorf("error measuring %s: %v", item, err))
+		}
+	}
+
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		return nil, rollback(fmt.Errorf("error adding item to rubbish journal: %v", err))
+	}
+
+	// The compressed or linked copy is complete and journaled, the item can go
+	if record.Compressed || linked {
+		if err := os.RemoveAll(item); err != nil {
+			return record, fmt.Errorf("error removing %s once stored into the rubbish bin: %v", item, err)
+		}
+	}
+
+	return record, nil
+}
+
+// linkDuplicate hard links destination to a stored item holding the content
+// of the record's file, reporting whether it did. The linked file takes the
+// mode, owner and times of the stored one, so only a duplicate sharing them
+// is linked. A duplicate which can't be linked, e.g. gone from the container,
+// leaves the file to be stored as usual.
+func linkDuplicate(record *journal.MetaData, destination string, cfg *config.Config) bool {
+	duplicates, err := cfg.Journal.FilterByHash(record.Hash)
+	if err != nil {
+		return false
+	}
+	origin, err := os.Lstat(record.Origin)
+	if err != nil {
+		return false
+	}
+
+	for _, duplicate := range duplicates {
+		if duplicate.Compressed || duplicate.Size != record.Size {
+			continue
+		}
+		stored, err := os.Lstat(cfg.ItemPath(duplicate.Item))
+		if err != nil || !fsutil.SameAttributes(origin, stored) {
+			continue
+		}
+		if err := os.Link(cfg.ItemPath(duplicate.Item), destination); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compressing reports whether the tossed items are compressed: as requested
+// with --compress when given, as set by compress otherwise.
+func compressing(cfg *config.Config) bool {
+	explicit := false
+	Flags.Visit(func(f *flag.Flag) {
+		if f.Name == "compress" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return compress
+	}
+	return cfg.Compress
+}
+
+// compressible reports whether the item of the record is worth compressing:
+// a file or directory whose name is not that of a compressed format.
+func compressible(record *journal.MetaData) bool {
+	if record.Type != journal.TypeFile && record.Type != journal.TypeDirectory {
+		return false
+	}
+	return !fsutil.AlreadyCompressed(record.Origin)
 }
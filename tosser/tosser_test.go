@@ -1,14 +1,19 @@
 package tosser
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"rubbish/config"
+	"rubbish/fsutil"
 	"rubbish/journal"
+	"rubbish/trashinfo"
 )
 
 func newTestCfg(t *testing.T) *config.Config {
@@ -334,3 +339,468 @@ func TestValidateParentDirAccess_NonExistentParentDir(t *testing.T) {
 		t.Errorf("unexpected error message: %v", err)
 	}
 }
+
+func TestToss_DirectoryTreeJournalsWipeoutTime(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.WipeoutTime = 9
+
+	src := filepath.Join(t.TempDir(), "tree")
+	if err := os.MkdirAll(filepath.Join(src, "a", "b"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	os.WriteFile(filepath.Join(src, "a", "b", "leaf.txt"), []byte("leaf"), 0o644)
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	// the journal write is synchronous, the record must be there as soon as Toss returns
+	records, err := cfg.Journal.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected a single record for the directory, got %d", len(records))
+	}
+	if records[0].WipeoutTime != 9 {
+		t.Errorf("expected wipeout time 9, got %d", records[0].WipeoutTime)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, records[0].Item, "a", "b", "leaf.txt")); err != nil {
+		t.Errorf("expected directory tree moved into container: %v", err)
+	}
+}
+
+func TestToss_ManyFilesAllJournaled(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := t.TempDir()
+
+	const total = 200
+	for i := 0; i < total; i++ {
+		p := filepath.Join(src, "small_"+strconv.Itoa(i)+".txt")
+		os.WriteFile(p, []byte("x"), 0o644)
+		if err := Toss(p, cfg); err != nil {
+			t.Fatalf("Toss %s: %v", p, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(cfg.ContainerPath)
+	moved := 0
+	for _, e := range entries {
+		if e.Name() == ".journal" {
+			continue
+		}
+		moved++
+		if _, err := cfg.Journal.Get(e.Name()); err != nil {
+			t.Errorf("moved file %s has no journal record: %v", e.Name(), err)
+		}
+	}
+	if moved != total {
+		t.Errorf("expected %d moved files, got %d", total, moved)
+	}
+	if count, _ := cfg.Journal.Count(); count != total {
+		t.Errorf("expected %d journal records, got %d", total, count)
+	}
+}
+
+func TestToss_XDGWritesTrashInfo(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.TrashMode = config.TrashModeXDG
+	for _, dir := range []string{cfg.FilesPath(), cfg.InfoPath()} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := filepath.Join(cfg.WorkingDir, "sample.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write src: %v", err)
+	}
+
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss returned error: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %d", len(records))
+	}
+	if _, err := os.Stat(cfg.ItemPath(records[0].Item)); err != nil {
+		t.Errorf("item must be stored under files/: %v", err)
+	}
+	info, err := trashinfo.Read(trashinfo.File(cfg.InfoPath(), records[0].Item), "/")
+	if err != nil {
+		t.Fatalf("reading trash info: %v", err)
+	}
+	if info.Path != src || info.DeletionDate.Unix() != records[0].TossedTime {
+		t.Errorf("unexpected trash info %+v", info)
+	}
+}
+
+func TestToss_StoresTypeOfOrigin(t *testing.T) {
+	cfg := newTestCfg(t)
+	dir := filepath.Join(cfg.WorkingDir, "photos")
+	if err := os.MkdirAll(filepath.Join(dir, "2024"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(cfg.WorkingDir, "target.txt")
+	os.WriteFile(target, []byte("x"), 0o644)
+	link := filepath.Join(cfg.WorkingDir, "latest")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []string{dir, link} {
+		if err := Toss(item, cfg); err != nil {
+			t.Fatalf("Toss(%s): %v", item, err)
+		}
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := map[string]uint{dir: journal.TypeDirectory, link: journal.TypeSymlink}
+	for _, record := range records {
+		if record.Type != want[record.Origin] {
+			t.Errorf("%s stored as %s, want %s", record.Origin, journal.TypeName(record.Type), journal.TypeName(want[record.Origin]))
+		}
+	}
+	if len(records) != 2 {
+		t.Errorf("expected 2 records, got %d", len(records))
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("tossing the symlink must not touch its target: %v", err)
+	}
+}
+
+func TestCommand_Symlinks(t *testing.T) {
+	silentMode = true
+	defer func() { silentMode = false }()
+
+	for _, follow := range []bool{false, true} {
+		cfg := newTestCfg(t)
+		dir := t.TempDir()
+		target := filepath.Join(dir, "target.txt")
+		os.WriteFile(target, []byte("x"), 0o644)
+		link := filepath.Join(dir, "latest")
+		os.Symlink("target.txt", link)
+
+		followSymlinks = follow
+		if err := Command([]string{link}, cfg); err != nil {
+			t.Fatalf("follow=%v: Command: %v", follow, err)
+		}
+		followSymlinks = false
+
+		records, _ := cfg.Journal.List()
+		if len(records) != 1 {
+			t.Fatalf("follow=%v: expected 1 record, got %d", follow, len(records))
+		}
+		record := records[0]
+
+		tossed, kept := link, target
+		want := journal.MetaData{Origin: link, Type: journal.TypeSymlink, LinkTarget: "target.txt"}
+		if follow {
+			tossed, kept = target, link
+			resolved, _ := filepath.EvalSymlinks(dir)
+			want = journal.MetaData{Origin: filepath.Join(resolved, "target.txt"), Type: journal.TypeFile}
+		}
+		if record.Origin != want.Origin || record.Type != want.Type || record.LinkTarget != want.LinkTarget {
+			t.Errorf("follow=%v: got origin %s, type %s, link target %q, want %s, %s, %q", follow,
+				record.Origin, journal.TypeName(record.Type), record.LinkTarget, want.Origin, journal.TypeName(want.Type), want.LinkTarget)
+		}
+		if _, err := os.Lstat(tossed); !os.IsNotExist(err) {
+			t.Errorf("follow=%v: %s must be tossed, got %v", follow, tossed, err)
+		}
+		if _, err := os.Lstat(kept); err != nil {
+			t.Errorf("follow=%v: %s must be left alone: %v", follow, kept, err)
+		}
+	}
+}
+
+func TestCommand_DanglingSymlink(t *testing.T) {
+	cfg := newTestCfg(t)
+	silentMode = true
+	defer func() { silentMode = false }()
+
+	link := filepath.Join(t.TempDir(), "dangling")
+	os.Symlink("nowhere", link)
+
+	followSymlinks = true
+	if err := Command([]string{link}, cfg); err == nil {
+		t.Error("a dangling symlink has no target to toss")
+	}
+	followSymlinks = false
+
+	if err := Command([]string{link}, cfg); err != nil {
+		t.Fatalf("the dangling link itself must be tossed: %v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("expected the link tossed, got %v", err)
+	}
+}
+
+func TestToss_MirroredLayout(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Layout = config.LayoutMirrored
+	src := t.TempDir()
+	write := func(name string) string {
+		file := filepath.Join(src, name)
+		os.MkdirAll(filepath.Dir(file), 0o755)
+		if err := os.WriteFile(file, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	seen := map[string]bool{}
+	tossed := func(origin string) *journal.MetaData {
+		t.Helper()
+		if err := Toss(origin, cfg); err != nil {
+			t.Fatalf("Toss(%s): %v", origin, err)
+		}
+		records, _ := cfg.Journal.List()
+		for _, record := range records {
+			if record.Origin == origin && !seen[record.Item] {
+				seen[record.Item] = true
+				return record
+			}
+		}
+		t.Fatalf("no record for %s", origin)
+		return nil
+	}
+
+	first := tossed(write("docs/report.txt"))
+	want := config.MirroredItem(filepath.Join(src, "docs/report.txt"))
+	if first.Item != want {
+		t.Errorf("item = %s, want the mirrored origin %s", first.Item, want)
+	}
+	if data, err := os.ReadFile(cfg.ItemPath(want)); err != nil || string(data) != "docs/report.txt" {
+		t.Errorf("item not stored at its mirrored path: %q, %v", data, err)
+	}
+
+	// The same origin tossed again keeps its path, suffixed
+	second := tossed(write("docs/report.txt"))
+	if !strings.HasPrefix(second.Item, want+"_") || len(second.Item) != len(want)+7 {
+		t.Errorf("second toss of the same origin = %s, want %s_XXXXXX", second.Item, want)
+	}
+
+	// Below a tossed directory the item cannot nest, so it is stored flat
+	write("project/main.go")
+	dir := tossed(filepath.Join(src, "project"))
+	if dir.Item != config.MirroredItem(filepath.Join(src, "project")) {
+		t.Errorf("directory item = %s", dir.Item)
+	}
+	os.WriteFile(cfg.ItemPath(dir.Item+"/main.go"), []byte("x"), 0o644)
+	nested := tossed(write("project/main.go"))
+	if strings.Contains(nested.Item, "/") || !strings.HasPrefix(nested.Item, "main.go_") {
+		t.Errorf("item below a tossed directory = %s, want a flat name", nested.Item)
+	}
+}
+
+func TestCommand_Verbose(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt"} {
+		file := filepath.Join(src, name)
+		os.WriteFile(file, []byte(name), 0o644)
+		files = append(files, file)
+	}
+
+	verbose, silentMode = true, true
+	if err := Command(files, cfg); err == nil {
+		t.Error("expected error combining -s and --verbose")
+	}
+	silentMode = false
+	defer func() { verbose = false }()
+
+	out := captureStdout(t, func() {
+		if err := Command(files, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	for _, want := range []string{"[1/2] Tossed '" + files[0] + "'", "[2/2] Tossed '" + files[1] + "'", "Tossed 2 of 2 items in "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCommand_NamedBin(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(src, []byte("notes"), 0o644)
+	binName, silentMode = "work", true
+	defer func() { binName, silentMode = "", false }()
+
+	if err := Command([]string{src}, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	if records, _ := cfg.Journal.List(); len(records) != 0 {
+		t.Errorf("the default bin must stay empty, got %d records", len(records))
+	}
+	work, _ := cfg.ForBin("work")
+	records, _ := work.Journal.List()
+	if len(records) != 1 {
+		t.Fatalf("expected one record in the work bin, got %d", len(records))
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, "bins", "work", records[0].Item)); err != nil {
+		t.Errorf("item must be stored in the bin directory: %v", err)
+	}
+}
+
+func TestToss_ForceSkipsPermissionChecks(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root passes the permission checks")
+	}
+	cfg := newTestCfg(t)
+	src := filepath.Join(t.TempDir(), "readonly.txt")
+	if err := os.WriteFile(src, []byte("test"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Toss(src, cfg); err == nil || !strings.Contains(err.Error(), "no write permission") {
+		t.Fatalf("expected a permission error without --force, got %v", err)
+	}
+
+	force = true
+	defer func() { force = false }()
+
+	if err := Toss(filepath.Join(t.TempDir(), "missing.txt"), cfg); err == nil {
+		t.Error("--force must not hide a missing file")
+	}
+	if err := Toss(src, cfg); err != nil {
+		t.Fatalf("Toss with --force: %v", err)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("read-only file must be tossed with --force, got %v", err)
+	}
+}
+
+func TestToss_FailedMoveLeavesNoRecord(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := filepath.Join(t.TempDir(), "stuck.txt")
+	os.WriteFile(src, []byte("data"), 0o644)
+
+	orig := fsutil.Rename
+	fsutil.Rename = func(string, string) error { return errors.New("device busy") }
+	defer func() { fsutil.Rename = orig }()
+
+	if err := Toss(src, cfg); err == nil || !strings.Contains(err.Error(), "device busy") {
+		t.Fatalf("expected the move failure, got %v", err)
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("a failed move must leave no record, got %d", count)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("the item must stay at its origin: %v", err)
+	}
+}
+
+func TestToss_FailedJournalMovesItemBack(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := filepath.Join(t.TempDir(), "unjournaled.txt")
+	os.WriteFile(src, []byte("data"), 0o644)
+	cfg.Journal.Close()
+
+	if err := Toss(src, cfg); err == nil || !strings.Contains(err.Error(), "journal") {
+		t.Fatalf("expected the journal failure, got %v", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("the item must be moved back to its origin: %v", err)
+	}
+	if items, _ := config.ContainerItems(cfg); len(items) != 0 {
+		t.Errorf("the container must be left empty, got %v", items)
+	}
+}
+
+func TestCommand_PathsFromStdin(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := t.TempDir()
+	a, b := filepath.Join(src, "a.tmp"), filepath.Join(src, "with space.tmp")
+	os.WriteFile(a, []byte("a"), 0o644)
+	os.WriteFile(b, []byte("b"), 0o644)
+	missing := filepath.Join(src, "missing.tmp")
+
+	stdin = strings.NewReader(a + "\n\n   \n" + missing + "\r\n" + b)
+	fromStdin, silentMode = true, true
+	defer func() { stdin, fromStdin, silentMode = os.Stdin, false, false }()
+
+	// The positional arguments are ignored
+	err := Command([]string{"ignored"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "unable to toss 1 of 3 items") || !strings.Contains(err.Error(), "missing.tmp") {
+		t.Fatalf("expected the missing path reported at the end, got %v", err)
+	}
+
+	for _, file := range []string{a, b} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s must be tossed despite the failure, got %v", file, err)
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("expected 2 records, got %d", count)
+	}
+}
+
+func TestCommand_PathsFromFile(t *testing.T) {
+	cfg := newTestCfg(t)
+	src := t.TempDir()
+	a := filepath.Join(src, "a.tmp")
+	os.WriteFile(a, []byte("a"), 0o644)
+	list := filepath.Join(t.TempDir(), "list.txt")
+	os.WriteFile(list, []byte(a+"\n"), 0o644)
+
+	fromFile, silentMode = list, true
+	defer func() { fromFile, silentMode = "", false }()
+
+	if err := Command(nil, cfg); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("%s must be tossed, got %v", a, err)
+	}
+
+	fromStdin = true
+	defer func() { fromStdin = false }()
+	if err := Command(nil, cfg); err == nil {
+		t.Error("expected --stdin and --from-file to be rejected together")
+	}
+}
+
+func TestCommandContext_StopsBetweenItems(t *testing.T) {
+	cfg := newTestCfg(t)
+	silentMode = true
+	defer func() { silentMode = false }()
+
+	src := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		paths = append(paths, filepath.Join(src, name))
+		os.WriteFile(paths[len(paths)-1], []byte(name), 0o644)
+	}
+
+	// The interruption arrives while the first item is moved
+	ctx, cancel := context.WithCancel(context.Background())
+	orig := fsutil.Rename
+	fsutil.Rename = func(from, to string) error {
+		cancel()
+		return orig(from, to)
+	}
+	defer func() { fsutil.Rename = orig }()
+
+	err := CommandContext(ctx, paths, cfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the toss to be interrupted, got %v", err)
+	}
+
+	if count, _ := cfg.Journal.Count(); count != 1 {
+		t.Errorf("expected only the item in flight tossed, got %d records", count)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("the item in flight must be completed, got %v", err)
+	}
+	for _, path := range paths[1:] {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s must be left alone: %v", path, err)
+		}
+	}
+}
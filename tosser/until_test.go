@@ -0,0 +1,74 @@
+package tosser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUntilDate(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+
+	got, clamped, err := untilDate("2025-03-31", 365, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, 3, 31, 0, 0, 0, 0, time.Local); !got.Equal(want) || clamped {
+		t.Errorf("untilDate = %v (clamped=%v), want %v", got, clamped, want)
+	}
+
+	got, clamped, err = untilDate("2030-01-01", 10, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := now.Add(10 * 24 * time.Hour); !got.Equal(want) || !clamped {
+		t.Errorf("expected clamp to %v, got %v (clamped=%v)", want, got, clamped)
+	}
+
+	for _, bad := range []string{"2025-02-01", "tomorrow", "2025-13-01"} {
+		if _, _, err := untilDate(bad, 365, now); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCommand_UntilStoresWipeableAt(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxRetention = 365
+	silentMode = true
+	until := time.Now().AddDate(0, 0, 20)
+	retentionUntil = until.Format(time.DateOnly)
+	defer func() { silentMode, retentionUntil = false, "" }()
+
+	src := filepath.Join(t.TempDir(), "quarter.txt")
+	os.WriteFile(src, []byte("q"), 0o644)
+
+	if err := Command([]string{src}, cfg); err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+
+	records, _ := cfg.Journal.List()
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %d", len(records))
+	}
+	want, _ := time.ParseInLocation(time.DateOnly, retentionUntil, time.Local)
+	if got := records[0].WipeoutDate(); !got.Equal(want) {
+		t.Errorf("wipeout date = %v, want %v", got, want)
+	}
+	if records[0].WipeoutTime != 20 {
+		t.Errorf("expected wipeout time of 20 days, got %d", records[0].WipeoutTime)
+	}
+}
+
+func TestCommand_UntilAndRetentionConflict(t *testing.T) {
+	cfg := newTestCfg(t)
+	retentionTime, retentionUntil = 3, time.Now().AddDate(0, 0, 5).Format(time.DateOnly)
+	defer func() { retentionTime, retentionUntil = -1, "" }()
+
+	src := filepath.Join(t.TempDir(), "x.txt")
+	os.WriteFile(src, []byte("x"), 0o644)
+	if err := Command([]string{src}, cfg); err == nil {
+		t.Fatal("expected error when combining -r and --until")
+	}
+}
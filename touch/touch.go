@@ -0,0 +1,93 @@
+// Package touch implements the touch command, which changes how long tossed
+// items are kept before they become wipeable.
+package touch
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"rubbish/config"
+	"rubbish/journal"
+	"time"
+)
+
+var (
+	Flags   *flag.FlagSet = flag.NewFlagSet("touch", flag.ExitOnError)
+	days    int           = -1    // days is the number of days from now the items stay before being wipeable
+	reset   bool          = false // reset tells to restart the items' retention from now instead of their toss
+	binName string        = ""    // binName is the named bin holding the items, empty for the default bin
+)
+
+func init() {
+	Flags.IntVar(&days, "days", -1, "Keep the items this number of `days` from now before they become wipeable.")
+	Flags.BoolVar(&reset, "reset", false, "Restart the retention from now, as if the items were tossed again.")
+	Flags.StringVar(&binName, "bin", "", "Touch items of the named bin instead of the default one.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish touch changes when tossed items become wipeable.\n",
+			"Usage:\n\n",
+			"\trubbish touch --days=N [options] <item> [item...]\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+func Command(args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no item to touch")
+	}
+	if days < 0 {
+		return fmt.Errorf("--days is required and can't be negative")
+	}
+
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, item := range args {
+		record, err := Touch(cfg, item, days, reset, now)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is wipeable on %s.\n", item, record.WipeoutDate().In(cfg.Zone()).Format(time.DateOnly))
+	}
+	return nil
+}
+
+// Touch makes an item wipeable at least the given number of days after now,
+// rounded up to whole days of retention. The retention is stretched from the
+// toss of the item, or restarted from now when reset is set; either way it
+// can't go past max_retention. It returns the updated record.
+func Touch(cfg *config.Config, item string, days int, reset bool, now time.Time) (*journal.MetaData, error) {
+	record, err := cfg.Journal.Get(item)
+	if err != nil {
+		return nil, err
+	}
+
+	tossed := record.TossedTime
+	if reset {
+		tossed = now.Unix()
+	}
+	elapsed := time.Duration(now.Unix()-tossed) * time.Second
+	wipeoutTime := int(math.Ceil(elapsed.Hours()/24)) + days
+
+	if cfg.MaxRetention > 0 && wipeoutTime > cfg.MaxRetention {
+		return nil, fmt.Errorf("%s can't be kept %d days after its toss, the maximum retention is %d days",
+			item, wipeoutTime, cfg.MaxRetention)
+	}
+
+	err = cfg.Journal.Update(item, func(m *journal.MetaData) {
+		m.TossedTime = tossed
+		m.WipeoutTime = wipeoutTime
+		m.WipeableAt = 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error touching %s: %w", item, err)
+	}
+
+	return cfg.Journal.Get(item)
+}
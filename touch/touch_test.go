@@ -0,0 +1,77 @@
+package touch
+
+import (
+	"errors"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/journal"
+	"testing"
+	"time"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &config.Config{ContainerPath: container, Journal: j}
+}
+
+func TestTouch_Extends(t *testing.T) {
+	cfg := newTestCfg(t)
+	now := time.Now()
+	tossed := now.Add(-10 * 24 * time.Hour).Unix()
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "a.txt_ABCDEF", WipeoutTime: 15, TossedTime: tossed, WipeableAt: now.Unix()})
+
+	if _, err := Touch(cfg, "a.txt_ABCDEF", 30, false, now); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+
+	record, err := cfg.Journal.Get("a.txt_ABCDEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.WipeoutTime != 40 || record.TossedTime != tossed || record.WipeableAt != 0 {
+		t.Errorf("expected 40 days from the toss, got %+v", record)
+	}
+	if remaining := record.WipeoutDate().Sub(time.Unix(now.Unix(), 0)); remaining != 30*24*time.Hour {
+		t.Errorf("expected the item wipeable in 30 days, got %v", remaining)
+	}
+}
+
+func TestTouch_Reset(t *testing.T) {
+	cfg := newTestCfg(t)
+	now := time.Now()
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "a.txt_ABCDEF", WipeoutTime: 15, TossedTime: now.Add(-100 * 24 * time.Hour).Unix()})
+	cfg.MaxRetention = 30
+
+	if _, err := Touch(cfg, "a.txt_ABCDEF", 20, true, now); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+
+	record, _ := cfg.Journal.Get("a.txt_ABCDEF")
+	if record.WipeoutTime != 20 || record.TossedTime != now.Unix() {
+		t.Errorf("expected the retention restarted for 20 days, got %+v", record)
+	}
+}
+
+func TestTouch_RespectsMaxRetention(t *testing.T) {
+	cfg := newTestCfg(t)
+	now := time.Now()
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "a.txt_ABCDEF", WipeoutTime: 15, TossedTime: now.Add(-10 * 24 * time.Hour).Unix()})
+	cfg.MaxRetention = 30
+
+	if _, err := Touch(cfg, "a.txt_ABCDEF", 25, false, now); err == nil {
+		t.Error("expected 35 days of retention to be rejected")
+	}
+	if record, _ := cfg.Journal.Get("a.txt_ABCDEF"); record.WipeoutTime != 15 {
+		t.Errorf("a rejected touch must leave the record alone, got %+v", record)
+	}
+
+	if _, err := Touch(cfg, "missing", 5, false, now); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package trashinfo reads and writes the .trashinfo files of the
+// FreeDesktop.org Trash specification, which describe where a trashed item
+// came from and when it was deleted.
+package trashinfo
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Extension is the suffix of the info file describing a trashed item.
+const Extension = ".trashinfo"
+
+// dateLayout is the DeletionDate format mandated by the specification,
+// expressed in local time.
+const dateLayout = "2006-01-02T15:04:05"
+
+const header = "[Trash Info]"
+
+// Info is the content of a .trashinfo file.
+type Info struct {
+	// Path is the original location of the item
+	Path string
+
+	// DeletionDate is the moment the item was trashed
+	DeletionDate time.Time
+}
+
+// File returns the path of the info file describing item inside infoDir.
+func File(infoDir string, item string) string {
+	return filepath.Join(infoDir, item+Extension)
+}
+
+// Write creates the info file of item. The file is created exclusively, as
+// the specification uses it to reserve the item name in the trash.
+func Write(infoDir string, item string, info Info) error {
+	file, err := os.OpenFile(File(infoDir, item), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating trash info for %s: %w", item, err)
+	}
+
+	location := url.URL{Path: info.Path}
+	_, err = fmt.Fprintf(file, "%s\nPath=%s\nDeletionDate=%s\n",
+		header, location.EscapedPath(), info.DeletionDate.Local().Format(dateLayout))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(File(infoDir, item))
+		return fmt.Errorf("error writing trash info for %s: %w", item, err)
+	}
+	return nil
+}
+
+// Read parses the info file at path. Relative item paths are resolved against
+// topDir, the directory the trash belongs to.
+func Read(path string, topDir string) (Info, error) {
+	var info Info
+
+	file, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != header {
+		return info, fmt.Errorf("%s: missing %s header", path, header)
+	}
+
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+		case "Path":
+			if info.Path, err = url.PathUnescape(strings.TrimSpace(value)); err != nil {
+				return info, fmt.Errorf("%s: invalid Path: %w", path, err)
+			}
+		case "DeletionDate":
+			if info.DeletionDate, err = time.ParseInLocation(dateLayout, strings.TrimSpace(value), time.Local); err != nil {
+				return info, fmt.Errorf("%s: invalid DeletionDate: %w", path, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return info, err
+	}
+
+	if info.Path == "" {
+		return info, fmt.Errorf("%s: missing Path", path)
+	}
+	if !filepath.IsAbs(info.Path) {
+		info.Path = filepath.Join(topDir, info.Path)
+	}
+	return info, nil
+}
+
+// Remove deletes the info file of item, if any.
+func Remove(infoDir string, item string) error {
+	if err := os.Remove(File(infoDir, item)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing trash info for %s: %w", item, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package trashinfo
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+	deleted := time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local)
+	info := Info{Path: "/home/user/my notes/100%.txt", DeletionDate: deleted}
+
+	if err := Write(dir, "100%.txt_ABCDEF", info); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	data, err := os.ReadFile(File(dir, "100%.txt_ABCDEF"))
+	if err != nil {
+		t.Fatalf("reading info file: %v", err)
+	}
+	want := "[Trash Info]\nPath=/home/user/my%20notes/100%25.txt\nDeletionDate=2024-01-15T09:30:00\n"
+	if string(data) != want {
+		t.Errorf("unexpected info file:\n%s\nwant:\n%s", data, want)
+	}
+
+	got, err := Read(File(dir, "100%.txt_ABCDEF"), "/")
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if got.Path != info.Path || !got.DeletionDate.Equal(deleted) {
+		t.Errorf("Read = %+v, want %+v", got, info)
+	}
+
+	if err := Write(dir, "100%.txt_ABCDEF", info); err == nil {
+		t.Error("Write must not replace an existing info file")
+	}
+}
+
+func TestRead_RelativePathAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := File(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	info, err := Read(write("rel", "[Trash Info]\nPath=docs/a.txt\nDeletionDate=2024-01-15T09:30:00\n"), "/media/usb")
+	if err != nil || info.Path != "/media/usb/docs/a.txt" {
+		t.Errorf("relative path resolved to %q (%v)", info.Path, err)
+	}
+
+	for name, content := range map[string]string{
+		"header": "Path=/a\n",
+		"path":   "[Trash Info]\nDeletionDate=2024-01-15T09:30:00\n",
+		"date":   "[Trash Info]\nPath=/a\nDeletionDate=yesterday\n",
+	} {
+		if _, err := Read(write(name, content), "/"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
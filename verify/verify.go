@@ -0,0 +1,214 @@
+// Package verify implements the verify command, which cross-checks the
+// journal against the container.
+package verify
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"strings"
+)
+
+// ErrInconsistent is returned when the journal and the container disagree.
+var ErrInconsistent = errors.New("journal and container are inconsistent")
+
+var (
+	Flags   *flag.FlagSet = flag.NewFlagSet("verify", flag.ExitOnError)
+	fix     bool          = false // fix indicates whether to repair the inconsistencies found
+	binName string        = ""    // binName is the named bin to verify, empty for the default bin
+)
+
+func init() {
+	Flags.BoolVar(&fix, "fix", false, "Delete the dangling records and ask whether to journal or remove each orphan.")
+	Flags.StringVar(&binName, "bin", "", "Verify the named bin instead of the default one.")
+
+	Flags.Usage = func() {
+		fmt.Println("Rubbish verify reports the records whose item is missing from the container\n",
+			"(dangling) and the container entries without a record (orphans).\n",
+			"It fails when any is left, so it can be used as a check.\n\n",
+			"Usage:\n\n",
+			"\trubbish verify [options]\n\n",
+			"Options:")
+		Flags.PrintDefaults()
+	}
+}
+
+func Command(args []string, cfg *config.Config) error {
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	records, items, err := contents(cfg)
+	if err != nil {
+		return err
+	}
+
+	dangling := danglingRecords(cfg, records)
+	orphans := orphanItems(records, items)
+
+	for _, record := range dangling {
+		fmt.Printf("Dangling record: %s (origin: %s), its item is missing from the container\n", record.Item, record.Origin)
+	}
+	for _, orphan := range orphans {
+		fmt.Printf("Orphan: %s, no journal record\n", orphan)
+	}
+	fmt.Printf("Checked %d records and %d container items: %s, %s.\n",
+		len(records), len(items), plural(len(dangling), "dangling record"), plural(len(orphans), "orphan"))
+
+	if fix {
+		dangling = fixDangling(cfg, dangling)
+		if orphans, err = fixOrphans(cfg, orphans); err != nil {
+			return err
+		}
+	}
+
+	if len(dangling) > 0 || len(orphans) > 0 {
+		return fmt.Errorf("%w: %s, %s left", ErrInconsistent, plural(len(dangling), "dangling record"), plural(len(orphans), "orphan"))
+	}
+	return nil
+}
+
+// Inconsistencies returns the dangling records and the orphans of the bin,
+// as verify reports them, without repairing anything.
+func Inconsistencies(cfg *config.Config) ([]*journal.MetaData, []string, error) {
+	records, items, err := contents(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return danglingRecords(cfg, records), orphanItems(records, items), nil
+}
+
+// contents returns the journal records and the container items of the bin.
+func contents(cfg *config.Config) ([]*journal.MetaData, []string, error) {
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving rubbish items: %w", err)
+	}
+	// A named bin whose items were all restored may have no directory left
+	items, err := config.ContainerItems(cfg)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("error reading rubbish container: %w", err)
+	}
+	return records, items, nil
+}
+
+// danglingRecords returns the records whose item is missing from the container.
+func danglingRecords(cfg *config.Config, records []*journal.MetaData) []*journal.MetaData {
+	var dangling []*journal.MetaData
+	for _, record := range records {
+		if _, err := os.Lstat(cfg.ItemPath(record.Item)); os.IsNotExist(err) {
+			dangling = append(dangling, record)
+		}
+	}
+	return dangling
+}
+
+// orphanItems returns the container items without a record.
+func orphanItems(records []*journal.MetaData, items []string) []string {
+	known := make(map[string]bool, len(records))
+	for _, record := range records {
+		known[record.Item] = true
+	}
+
+	var orphans []string
+	for _, item := range items {
+		if !known[item] {
+			orphans = append(orphans, item)
+		}
+	}
+	return orphans
+}
+
+// fixDangling deletes the dangling records, returning those left.
+func fixDangling(cfg *config.Config, dangling []*journal.MetaData) []*journal.MetaData {
+	var left []*journal.MetaData
+	for _, record := range dangling {
+		if err := cfg.Journal.Delete(record.Item); err != nil {
+			color.Errorf("could not delete record %s: %v\n", record.Item, err)
+			left = append(left, record)
+			continue
+		}
+		if err := cfg.RemoveTrashInfo(record.Item); err != nil {
+			color.Warnf("could not remove the trash info of %s: %v\n", record.Item, err)
+		}
+		fmt.Printf("Deleted dangling record %s.\n", record.Item)
+	}
+	return left
+}
+
+// fixOrphans asks for each orphan whether to journal it, remove it or keep
+// it, returning those left. Without a terminal every orphan is kept.
+func fixOrphans(cfg *config.Config, orphans []string) ([]string, error) {
+	if len(orphans) > 0 && !prompt.Interactive() {
+		fmt.Println("Orphans are only fixed on a terminal, keeping them.")
+		return orphans, nil
+	}
+
+	var left []string
+	for _, orphan := range orphans {
+		size, _ := config.ItemSize(cfg, orphan)
+		answer, err := prompt.ReadLine(fmt.Sprintf("Orphan %s (%s): [j]ournal, [r]emove or [s]kip? ", orphan, config.ReadableSize(uint64(size))))
+		if errors.Is(err, prompt.ErrTimeout) {
+			fmt.Println("No answer received in time, skipping.")
+			answer = "s"
+		} else if err != nil {
+			return left, fmt.Errorf("error reading answer for %s: %w", orphan, err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "j", "journal":
+			err = journalOrphan(cfg, orphan)
+			if err == nil {
+				fmt.Printf("Journaled %s, as if tossed from %s.\n", orphan, cfg.WorkingDir)
+			}
+		case "r", "remove":
+			err = os.RemoveAll(cfg.ItemPath(orphan))
+			if err == nil {
+				cfg.PruneItemParents(orphan)
+				fmt.Printf("Removed orphan %s.\n", orphan)
+			}
+		default:
+			fmt.Printf("Keeping orphan %s.\n", orphan)
+			left = append(left, orphan)
+			continue
+		}
+
+		if err != nil {
+			color.Errorf("could not fix %s: %v\n", orphan, err)
+			left = append(left, orphan)
+		}
+	}
+	return left, nil
+}
+
+// journalOrphan records an orphan with the default retention. Its origin is
+// unknown, so it is recorded as tossed from the working directory.
+func journalOrphan(cfg *config.Config, orphan string) error {
+	record, err := journal.GenerateMetadata(orphan, cfg.ItemPath(orphan), cfg.WipeoutTime)
+	if err != nil {
+		return err
+	}
+	record.Origin = filepath.Join(cfg.WorkingDir, filepath.Base(orphan))
+
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		return err
+	}
+	return cfg.WriteTrashInfo(record)
+}
+
+// plural formats a count of things, adding an s unless there is one.
+func plural(count int, thing string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", thing)
+	}
+	return fmt.Sprintf("%d %ss", count, thing)
+}
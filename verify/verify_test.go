@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	container := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(container, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &config.Config{ContainerPath: container, Journal: j, WorkingDir: t.TempDir(), WipeoutTime: 30}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func scriptInput(t *testing.T, script string) {
+	t.Helper()
+	origInput, origInteractive := prompt.Input, prompt.Interactive
+	prompt.Input = strings.NewReader(script)
+	prompt.Interactive = func() bool { return true }
+	t.Cleanup(func() { prompt.Input, prompt.Interactive = origInput, origInteractive })
+}
+
+// seedInconsistencies stores a consistent item, a record without its item
+// and two items without a record.
+func seedInconsistencies(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	for _, item := range []string{"ok_ABCDEF", "orphan1", "orphan2"} {
+		os.WriteFile(cfg.ItemPath(item), []byte(item), 0o644)
+	}
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "ok_ABCDEF", Origin: "/tmp/ok"})
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "gone_ABCDEF", Origin: "/tmp/gone"})
+}
+
+func TestCommand_ReportsInconsistencies(t *testing.T) {
+	cfg := newTestCfg(t)
+	seedInconsistencies(t, cfg)
+
+	var err error
+	out := captureStdout(t, func() { err = Command(nil, cfg) })
+
+	if !errors.Is(err, ErrInconsistent) {
+		t.Fatalf("expected ErrInconsistent, got %v", err)
+	}
+	for _, want := range []string{
+		"Dangling record: gone_ABCDEF (origin: /tmp/gone)",
+		"Orphan: orphan1",
+		"Orphan: orphan2",
+		"Checked 2 records and 3 container items: 1 dangling record, 2 orphans.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "ok_ABCDEF") {
+		t.Errorf("consistent items must not be reported: %s", out)
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("verify must not change anything without --fix, got %d records", count)
+	}
+}
+
+func TestCommand_Consistent(t *testing.T) {
+	cfg := newTestCfg(t)
+	os.WriteFile(cfg.ItemPath("ok_ABCDEF"), nil, 0o644)
+	cfg.Journal.AddRecord(&journal.MetaData{Item: "ok_ABCDEF", Origin: "/tmp/ok"})
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Errorf("expected a consistent bin, got %v", err)
+		}
+	})
+}
+
+func TestCommand_Fix(t *testing.T) {
+	cfg := newTestCfg(t)
+	seedInconsistencies(t, cfg)
+	scriptInput(t, "j\nr\n")
+	fix = true
+	defer func() { fix = false }()
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("expected every inconsistency fixed, got %v", err)
+		}
+	})
+
+	if _, err := cfg.Journal.Get("gone_ABCDEF"); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("the dangling record must be deleted, got %v", err)
+	}
+	record, err := cfg.Journal.Get("orphan1")
+	if err != nil || record.Origin != filepath.Join(cfg.WorkingDir, "orphan1") || record.WipeoutTime != 30 {
+		t.Errorf("orphan1 must be journaled from the working directory, got %+v, %v", record, err)
+	}
+	if _, err := os.Lstat(cfg.ItemPath("orphan2")); !os.IsNotExist(err) {
+		t.Errorf("orphan2 must be removed, got %v", err)
+	}
+}
+
+func TestCommand_FixKeepsOrphansWithoutTerminal(t *testing.T) {
+	cfg := newTestCfg(t)
+	seedInconsistencies(t, cfg)
+	orig := prompt.Interactive
+	prompt.Interactive = func() bool { return false }
+	defer func() { prompt.Interactive = orig }()
+	fix = true
+	defer func() { fix = false }()
+
+	var err error
+	captureStdout(t, func() { err = Command(nil, cfg) })
+	if !errors.Is(err, ErrInconsistent) || !strings.Contains(err.Error(), "0 dangling records, 2 orphans left") {
+		t.Errorf("expected the orphans left, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package wipe
+
+import (
+	"cmp"
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"time"
+)
+
+// ageRange bounds the time elapsed since the selected items were tossed.
+type ageRange struct {
+	olderThan, newerThan time.Duration
+	hasOlder, hasNewer   bool // hasOlder and hasNewer tell which bounds were given
+}
+
+// parseAgeRange parses the --older-than and --newer-than ages.
+func parseAgeRange(older string, newer string) (ageRange, error) {
+	var ages ageRange
+	var err error
+
+	ages.hasOlder, ages.hasNewer = older != "", newer != ""
+	if older != "" {
+		if ages.olderThan, err = config.ParseAge(older); err != nil {
+			return ages, fmt.Errorf("--older-than: %v", err)
+		}
+	}
+	if newer != "" {
+		if ages.newerThan, err = config.ParseAge(newer); err != nil {
+			return ages, fmt.Errorf("--newer-than: %v", err)
+		}
+	}
+	if older != "" && newer != "" && ages.olderThan >= ages.newerThan {
+		return ages, fmt.Errorf("empty age range: --older-than %s is not below --newer-than %s", older, newer)
+	}
+
+	return ages, nil
+}
+
+// set reports whether the range selects the items by age.
+func (ages ageRange) set() bool {
+	return ages.hasOlder || ages.hasNewer
+}
+
+// filter returns the records tossed within the range.
+func (ages ageRange) filter(records []*journal.MetaData) []*journal.MetaData {
+	if !ages.set() {
+		return records
+	}
+
+	return slices.DeleteFunc(records, func(record *journal.MetaData) bool {
+		elapsed := record.TossElapsed()
+		return (ages.hasOlder && elapsed <= ages.olderThan) || (ages.hasNewer && elapsed >= ages.newerThan)
+	})
+}
+
+// keepNewest returns the records left once the keep most recently tossed ones
+// are spared, newest first. A zero keep spares none.
+func keepNewest(records []*journal.MetaData, keep int) []*journal.MetaData {
+	if keep == 0 {
+		return records
+	}
+
+	slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+		return cmp.Compare(b.TossedTime, a.TossedTime)
+	})
+	return records[min(keep, len(records)):]
+}
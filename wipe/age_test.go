@@ -0,0 +1,129 @@
+package wipe
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAgeRange(t *testing.T) {
+	ages, err := parseAgeRange("2w", "30d")
+	if err != nil || ages.olderThan != 14*24*time.Hour || ages.newerThan != 30*24*time.Hour {
+		t.Fatalf("unexpected range %+v, %v", ages, err)
+	}
+	if ages, _ := parseAgeRange("", ""); ages.set() {
+		t.Error("no age given must leave the range unset")
+	}
+	if ages, _ := parseAgeRange("0d", ""); !ages.set() {
+		t.Error("a zero age is still a bound")
+	}
+
+	for _, args := range [][2]string{{"soon", ""}, {"", "-1d"}, {"30d", "2w"}, {"1d", "1d"}} {
+		if _, err := parseAgeRange(args[0], args[1]); err == nil {
+			t.Errorf("parseAgeRange(%q, %q) expected error", args[0], args[1])
+		}
+	}
+}
+
+func TestCommand_WipesByAge(t *testing.T) {
+	cfg := newTestCfg(t)
+	// None of them is wipeable by its own wipe time
+	old := seed(t, cfg, "old.log", 10, 365, 40*24*time.Hour)
+	mid := seed(t, cfg, "mid.log", 10, 365, 10*24*time.Hour)
+	recent := seed(t, cfg, "recent.log", 10, 365, time.Hour)
+
+	wiped := func(args ...string) []string {
+		t.Helper()
+		Flags.Parse(append([]string{"-g", "-y"}, args...))
+		defer Flags.Parse([]string{"-g=false", "-y=false", "--older-than=", "--newer-than="})
+
+		captureStdout(t, func() {
+			if err := Command(nil, cfg); err != nil {
+				t.Fatalf("Command %v: %v", args, err)
+			}
+		})
+
+		var gone []string
+		for _, item := range []string{old.Item, mid.Item, recent.Item} {
+			if _, err := os.Lstat(cfg.ItemPath(item)); os.IsNotExist(err) {
+				gone = append(gone, item)
+			}
+		}
+		return gone
+	}
+
+	if gone := wiped("--older-than=30d"); len(gone) != 1 || gone[0] != old.Item {
+		t.Fatalf("--older-than=30d must wipe only %s, wiped %v", old.Item, gone)
+	}
+	// Both remaining items are within the range, only the selected one goes
+	if gone := wiped("--older-than=30m", "--newer-than=2w", mid.Item); len(gone) != 2 || gone[1] != mid.Item {
+		t.Fatalf("the selected item within the range must be wiped, wiped %v", gone)
+	}
+	if gone := wiped("--newer-than=2h"); len(gone) != 3 {
+		t.Fatalf("--newer-than=2h must wipe %s, wiped %v", recent.Item, gone)
+	}
+}
+
+func TestCommand_AgeRejectsOtherModes(t *testing.T) {
+	cfg := newTestCfg(t)
+	Flags.Parse([]string{"--older-than=1d", "--empty"})
+	defer Flags.Parse([]string{"--older-than=", "--empty=false"})
+
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "--older-than") {
+		t.Errorf("expected --older-than to be rejected with --empty, got %v", err)
+	}
+}
+
+func TestCommand_KeepSparesNewest(t *testing.T) {
+	cfg := newTestCfg(t)
+	var records []string
+	for i, name := range []string{"a.log", "b.log", "c.log", "d.log", "e.log"} {
+		// a.log is the oldest, e.log the newest; none is wipeable by its wipe time
+		records = append(records, seed(t, cfg, name, 10, 365, time.Duration(5-i)*24*time.Hour).Item)
+	}
+
+	Flags.Parse([]string{"-g", "-y", "--keep=2"})
+	defer Flags.Parse([]string{"-g=false", "-y=false", "--keep=0"})
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+
+	for i, item := range records {
+		_, err := os.Lstat(cfg.ItemPath(item))
+		if i < 3 && !os.IsNotExist(err) {
+			t.Errorf("%s is among the 3 oldest and must be wiped", item)
+		}
+		if i >= 3 && err != nil {
+			t.Errorf("%s is among the 2 newest and must be kept: %v", item, err)
+		}
+	}
+}
+
+func TestCommand_KeepComposesWithAge(t *testing.T) {
+	cfg := newTestCfg(t)
+	old := seed(t, cfg, "old.log", 10, 365, 40*24*time.Hour)
+	older := seed(t, cfg, "older.log", 10, 365, 50*24*time.Hour)
+	recent := seed(t, cfg, "recent.log", 10, 365, time.Hour)
+
+	Flags.Parse([]string{"-g", "-y", "--keep=1", "--older-than=30d"})
+	defer Flags.Parse([]string{"-g=false", "-y=false", "--keep=0", "--older-than="})
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+
+	if _, err := os.Lstat(cfg.ItemPath(older.Item)); !os.IsNotExist(err) {
+		t.Errorf("%s must be wiped", older.Item)
+	}
+	for _, item := range []string{old.Item, recent.Item} {
+		if _, err := os.Lstat(cfg.ItemPath(item)); err != nil {
+			t.Errorf("%s must be kept: %v", item, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package wipe
+
+import (
+	"fmt"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"strconv"
+	"time"
+)
+
+// lastAutoWipeState is the journal state holding the time of the last auto-wipe
+const lastAutoWipeState = "last_auto_wipe"
+
+// AutoWipe runs the opt-in wipe of expired items: when auto_wipe is enabled
+// and cleanup_interval days have passed since the previous run, every
+// wipeable item and every item kept over max_retention days is removed
+// without asking. It returns the wiped records, none when the run is not due.
+// The items staged by a wipe longer than undo_window ago are purged first,
+// whether auto_wipe is enabled or not.
+func AutoWipe(cfg *config.Config, now time.Time) ([]*journal.MetaData, error) {
+	if _, err := PurgePending(cfg, now.Add(-undoWindow(cfg))); err != nil {
+		color.Warnf("%v\n", err)
+	}
+
+	if !cfg.AutoWipe {
+		return nil, nil
+	}
+
+	due, err := autoWipeDue(cfg, now)
+	if err != nil || !due {
+		return nil, err
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving items from journal: %v", err)
+	}
+
+	maxAge := time.Duration(cfg.MaxRetention) * 24 * time.Hour
+	var wiped []*journal.MetaData
+	for _, record := range records {
+		overRetention := cfg.MaxRetention > 0 && now.Sub(time.Unix(record.TossedTime, 0)) > maxAge
+		if !record.IsWipeable() && !overRetention {
+			continue
+		}
+		if err := removeItem(record, cfg); err != nil {
+			color.Warnf("could not auto-wipe %s: %v\n", record.Item, err)
+			continue
+		}
+		wiped = append(wiped, record)
+	}
+
+	if err := cfg.Journal.SetState(lastAutoWipeState, []byte(strconv.FormatInt(now.Unix(), 10))); err != nil {
+		return wiped, fmt.Errorf("error saving auto-wipe time: %v", err)
+	}
+	return wiped, nil
+}
+
+// autoWipeDue reports whether cleanup_interval days have passed since the
+// last auto-wipe. The first run is always due.
+func autoWipeDue(cfg *config.Config, now time.Time) (bool, error) {
+	data, err := cfg.Journal.State(lastAutoWipeState)
+	if err != nil {
+		return false, fmt.Errorf("error reading auto-wipe time: %v", err)
+	}
+	if len(data) == 0 {
+		return true, nil
+	}
+
+	last, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		// A corrupt timestamp must not block the wipe forever
+		return true, nil
+	}
+
+	interval := time.Duration(cfg.CleanupInterval) * 24 * time.Hour
+	return now.Sub(time.Unix(last, 0)) >= interval, nil
+}
@@ -0,0 +1,68 @@
+package wipe
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAutoWipe_RunsWhenDue(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.AutoWipe, cfg.CleanupInterval, cfg.MaxRetention = true, 3, 60
+	due := seed(t, cfg, "due.log", 10, 1, 48*time.Hour)
+	kept := seed(t, cfg, "kept.log", 10, 30, time.Hour)
+	capped := seed(t, cfg, "capped.log", 10, 365, 90*24*time.Hour)
+	now := time.Now()
+
+	setLast := func(last time.Time) {
+		if err := cfg.Journal.SetState(lastAutoWipeState, []byte(strconv.FormatInt(last.Unix(), 10))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The previous run is too recent
+	setLast(now.Add(-2 * 24 * time.Hour))
+	wiped, err := AutoWipe(cfg, now)
+	if err != nil || len(wiped) != 0 {
+		t.Fatalf("auto-wipe must not run before cleanup_interval, wiped %d, %v", len(wiped), err)
+	}
+	if _, err := cfg.Journal.Get(due.Item); err != nil {
+		t.Errorf("%s must be kept until the run is due: %v", due.Item, err)
+	}
+
+	setLast(now.Add(-4 * 24 * time.Hour))
+	wiped, err = AutoWipe(cfg, now)
+	if err != nil {
+		t.Fatalf("AutoWipe: %v", err)
+	}
+	if len(wiped) != 2 {
+		t.Errorf("expected the wipeable item and the one over max_retention, wiped %d", len(wiped))
+	}
+	for _, record := range []string{due.Item, capped.Item} {
+		if _, err := cfg.Journal.Get(record); err == nil {
+			t.Errorf("%s must be auto-wiped", record)
+		}
+	}
+	if _, err := cfg.Journal.Get(kept.Item); err != nil {
+		t.Errorf("%s is neither wipeable nor over max_retention: %v", kept.Item, err)
+	}
+
+	if data, _ := cfg.Journal.State(lastAutoWipeState); string(data) != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("last run must be recorded, got %q", data)
+	}
+}
+
+func TestAutoWipe_DisabledOrFirstRun(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.CleanupInterval = 3
+	due := seed(t, cfg, "due.log", 10, 1, 48*time.Hour)
+
+	if wiped, err := AutoWipe(cfg, time.Now()); err != nil || len(wiped) != 0 {
+		t.Fatalf("auto-wipe must not run unless enabled, wiped %d, %v", len(wiped), err)
+	}
+
+	cfg.AutoWipe = true
+	if wiped, err := AutoWipe(cfg, time.Now()); err != nil || len(wiped) != 1 || wiped[0].Item != due.Item {
+		t.Fatalf("the first enabled run is due, got %v, %v", wiped, err)
+	}
+}
@@ -0,0 +1,37 @@
+package wipe
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// previewWipe lists the records a wipe with the same options would remove,
+// either the selected files or every candidate, without touching the
+// container nor the journal.
+func previewWipe(records []*journal.MetaData, files []string, cfg *config.Config) error {
+	fmt.Println("Dry run: nothing will be wiped.")
+
+	if len(files) > 0 {
+		selected := make([]*journal.MetaData, 0, len(files))
+		for _, file := range files {
+			record, err := selectRecord(records, file)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, record)
+		}
+		records = selected
+	}
+
+	var total int64
+	for _, record := range records {
+		size, _ := config.ItemSize(cfg, record.Item)
+		total += size
+		fmt.Printf("Would wipe %s (origin: %s, size: %s, tossed %s ago)\n",
+			record.Item, record.Origin, config.ReadableSize(uint64(size)), age(record))
+	}
+
+	fmt.Printf("Dry run: %d items would be wiped, %s in total.\n", len(records), config.ReadableSize(uint64(total)))
+	return nil
+}
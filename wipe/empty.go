@@ -0,0 +1,99 @@
+package wipe
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"slices"
+	"time"
+)
+
+// emptyBin removes everything stored in the container, including orphan files
+// without a journal entry, and clears the journal in one shot after a single
+// confirmation. The journal directory itself is preserved.
+func emptyBin(cfg *config.Config) error {
+	before, err := config.BinSize(cfg)
+	if err != nil {
+		return fmt.Errorf("error retrieving rubbish bin size: %v", err)
+	}
+
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return fmt.Errorf("error retrieving items from journal: %v", err)
+	}
+
+	items, err := config.ContainerItems(cfg)
+	if err != nil {
+		return fmt.Errorf("error reading rubbish container: %v", err)
+	}
+
+	if len(items) == 0 && len(records) == 0 {
+		fmt.Println("The rubbish bin is already empty.")
+		return nil
+	}
+
+	if !autoAcknowledge {
+		ok, err := prompt.Confirm(fmt.Sprintf("Permanently wipe all %d items (%s) from the rubbish bin?",
+			len(items), config.ReadableSize(uint64(before))))
+		if err != nil {
+			return fmt.Errorf("error confirming empty: %v", err)
+		}
+		if !ok {
+			fmt.Println("Empty cancelled as per user confirmation.")
+			return nil
+		}
+	}
+
+	var failed []string
+	for _, item := range items {
+		if err := destroy(cfg.ItemPath(item), cfg); err != nil {
+			fmt.Printf("Error wiping %s: %v\n", item, err)
+			failed = append(failed, item)
+			continue
+		}
+		cfg.PruneItemParents(item)
+		if err := cfg.RemoveTrashInfo(item); err != nil {
+			fmt.Printf("Error wiping %s: %v\n", item, err)
+		}
+	}
+
+	if len(failed) == 0 {
+		err = cfg.Journal.Clear()
+	} else {
+		// keep tracking the items that could not be removed
+		for _, record := range records {
+			if !slices.Contains(failed, record.Item) {
+				if derr := cfg.Journal.Delete(record.Item); derr != nil && err == nil {
+					err = derr
+				}
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error clearing journal: %v", err)
+	}
+
+	orphans := 0
+	for _, item := range items {
+		if !slices.ContainsFunc(records, func(r *journal.MetaData) bool { return r.Item == item }) {
+			orphans++
+		}
+	}
+
+	if reportFile != "" {
+		wiped := slices.DeleteFunc(records, func(r *journal.MetaData) bool { return slices.Contains(failed, r.Item) })
+		if rerr := writeReport(reportFile, reportFormat, wiped, time.Now()); rerr != nil {
+			return fmt.Errorf("error writing wipe report: %v", rerr)
+		}
+	}
+
+	after, _ := config.BinSize(cfg)
+	fmt.Printf("Emptied rubbish bin: %d items wiped (%d orphans), %s freed.\n",
+		len(items)-len(failed), orphans, config.ReadableSize(uint64(before-after)))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to wipe %d items: %v", len(failed), failed)
+	}
+	return nil
+}
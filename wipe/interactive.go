@@ -0,0 +1,92 @@
+package wipe
+
+import (
+	"context"
+	"fmt"
+	"rubbish/config"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"strings"
+)
+
+// selectFromList displays the wipe candidates as a numbered list and reads the
+// numbers and ranges (e.g. 1,3,5-7) of the records to wipe. An empty answer
+// cancels the selection. Invalid selections are asked again.
+func selectFromList(records []*journal.MetaData, cfg *config.Config) ([]*journal.MetaData, error) {
+	fmt.Println("Wipe candidates:")
+	for i, record := range records {
+		fmt.Printf(" %d. %s | Origin:%s | Size:%s | Tossed %s ago\n", i+1, record.Item, record.Origin,
+			config.ReadableSize(uint64(config.RecordSize(cfg, record))), age(record))
+	}
+
+	for {
+		answer, err := prompt.ReadLine("Numbers/ranges to wipe (e.g. 1,3,5-7), empty to cancel: ")
+		if err != nil {
+			return nil, err
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return nil, nil
+		}
+
+		selection, err := prompt.ParseSelection(answer, len(records))
+		if err != nil {
+			fmt.Printf("Invalid selection: %v\n", err)
+			continue
+		}
+
+		selected := make([]*journal.MetaData, len(selection))
+		for i, position := range selection {
+			selected[i] = records[position-1]
+		}
+		return selected, nil
+	}
+}
+
+// wipeFromList lets the user pick the records to wipe and, after a single
+// confirmation covering the whole selection, wipes them.
+func wipeFromList(ctx context.Context, records []*journal.MetaData, cfg *config.Config) ([]*journal.MetaData, error) {
+	selected, err := selectFromList(records, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error reading selection: %v", err)
+	}
+	if len(selected) == 0 {
+		fmt.Println("Nothing selected to wipe.")
+		return nil, nil
+	}
+
+	if dryRun {
+		return nil, previewWipe(selected, nil, cfg)
+	}
+
+	if !autoAcknowledge {
+		var total int64
+		for _, record := range selected {
+			total += config.RecordSize(cfg, record)
+		}
+		ok, err := prompt.Confirm(fmt.Sprintf("Permanently wipe the %d selected items (%s)?",
+			len(selected), config.ReadableSize(uint64(total))))
+		if err != nil {
+			return nil, fmt.Errorf("error confirming wipe: %v", err)
+		}
+		if !ok {
+			fmt.Println("Wipe cancelled as per user confirmation.")
+			return nil, nil
+		}
+	}
+
+	var wiped []*journal.MetaData
+	startProgress(len(selected))
+	for i, record := range selected {
+		if err := interrupted(ctx, len(selected)-i); err != nil {
+			return wiped, err
+		}
+		if err := wipeItem(record, cfg); err != nil {
+			fmt.Printf("Error wiping %s: %v\n", record.Item, err)
+			continue
+		}
+		wiped = append(wiped, record)
+	}
+	return wiped, nil
+}
@@ -0,0 +1,89 @@
+package wipe
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/prompt"
+)
+
+func TestCommand_InteractiveWipesSelection(t *testing.T) {
+	cfg := newTestCfg(t)
+	t.Chdir(cfg.WorkingDir)
+	var items []string
+	for _, name := range []string{"a.log", "b.log", "c.log", "d.log"} {
+		items = append(items, seed(t, cfg, name, 10, 1, 72*time.Hour).Item)
+	}
+	seed(t, cfg, "fresh.log", 10, 30, time.Hour)
+
+	interactive = true
+	defer func() { interactive = false }()
+	origInteractive := prompt.Interactive
+	prompt.Interactive = func() bool { return true }
+	defer func() { prompt.Interactive = origInteractive }()
+
+	// an out of range selection is asked again before 1,3-4 is confirmed
+	scriptInput(t, "9\n1,3-4\ny\n")
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if strings.Contains(out, "fresh.log") {
+		t.Errorf("items not wipeable yet must not be listed without -f: %s", out)
+	}
+	if !strings.Contains(out, "Invalid selection") || !strings.Contains(out, "wipe the 3 selected items") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	for i, item := range items {
+		_, err := os.Stat(cfg.ItemPath(item))
+		if kept := i == 1; kept != (err == nil) {
+			t.Errorf("%s: kept=%v, stat error %v", item, kept, err)
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 2 {
+		t.Errorf("expected 2 records left, got %d", count)
+	}
+}
+
+func TestCommand_InteractiveDeclined(t *testing.T) {
+	cfg := newTestCfg(t)
+	t.Chdir(cfg.WorkingDir)
+	record := seed(t, cfg, "a.log", 10, 30, time.Hour)
+
+	interactive, forceWipeout = true, true
+	defer func() { interactive, forceWipeout = false, false }()
+	origInteractive := prompt.Interactive
+	prompt.Interactive = func() bool { return true }
+	defer func() { prompt.Interactive = origInteractive }()
+
+	scriptInput(t, "1\nn\n")
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "1. "+record.Item) || !strings.Contains(out, "Wipe cancelled") {
+		t.Errorf("-f must list items not wipeable yet and the decline be honored: %s", out)
+	}
+	if _, err := os.Stat(cfg.ItemPath(record.Item)); err != nil {
+		t.Errorf("declined item must be kept: %v", err)
+	}
+}
+
+func TestCommand_InteractiveRequiresTerminal(t *testing.T) {
+	cfg := newTestCfg(t)
+	interactive = true
+	defer func() { interactive = false }()
+	origInteractive := prompt.Interactive
+	prompt.Interactive = func() bool { return false }
+	defer func() { prompt.Interactive = origInteractive }()
+
+	if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "terminal") {
+		t.Errorf("expected terminal error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package wipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+// seedFrom seeds an item tossed from the given origin.
+func seedFrom(t *testing.T, cfg *config.Config, name string, origin string, wipeDays int) *journal.MetaData {
+	t.Helper()
+	record := seed(t, cfg, name, 10, wipeDays, 48*time.Hour)
+	record.Origin = origin
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		t.Fatalf("add %s: %v", name, err)
+	}
+	return record
+}
+
+func TestCommand_OriginWipesOnlyItemsFromIt(t *testing.T) {
+	cfg := newTestCfg(t)
+	tmp := t.TempDir()
+	wipeable := seedFrom(t, cfg, "a.log", filepath.Join(tmp, "cache", "a.log"), 1)
+	pending := seedFrom(t, cfg, "b.log", filepath.Join(tmp, "cache", "sub", "b.log"), 30)
+	sibling := seedFrom(t, cfg, "c.log", filepath.Join(tmp, "cache-old", "c.log"), 1)
+	local := seed(t, cfg, "d.log", 10, 1, 48*time.Hour)
+
+	Flags.Parse([]string{"-y", "--origin=" + filepath.Join(tmp, "cache")})
+	defer Flags.Parse([]string{"-y=false", "-f=false", "--origin="})
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	assertWiped(t, cfg, map[*journal.MetaData]bool{wipeable: true, pending: false, sibling: false, local: false})
+
+	// -f wipes the items of the origin whatever their wipe time
+	Flags.Parse([]string{"-f"})
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	assertWiped(t, cfg, map[*journal.MetaData]bool{pending: true, sibling: false, local: false})
+}
+
+func TestCommand_OriginConflicts(t *testing.T) {
+	cfg := newTestCfg(t)
+	Flags.Parse([]string{"-g", "--origin=/tmp"})
+	defer Flags.Parse([]string{"-g=false", "--origin="})
+
+	if err := Command(nil, cfg); err == nil {
+		t.Error("expected --origin and -g to be rejected together")
+	}
+}
+
+// assertWiped checks whether the item of each record was wiped or kept.
+func assertWiped(t *testing.T, cfg *config.Config, want map[*journal.MetaData]bool) {
+	t.Helper()
+	for record, wiped := range want {
+		_, err := os.Lstat(cfg.ItemPath(record.Item))
+		if wiped && !os.IsNotExist(err) {
+			t.Errorf("%s must be wiped", record.Origin)
+		}
+		if !wiped && err != nil {
+			t.Errorf("%s must be kept: %v", record.Origin, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package wipe
+
+import (
+	"fmt"
+	"rubbish/config"
+	"rubbish/prompt"
+)
+
+// wipeOrphans offers to remove the container entries without a journal record.
+// These are invisible to status, wipe and restore and would otherwise leak disk forever.
+func wipeOrphans(cfg *config.Config) error {
+	orphans, err := config.Orphans(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphan files found in the rubbish container.")
+		return nil
+	}
+
+	fmt.Printf("Found %d orphan files without a journal entry.\n", len(orphans))
+
+	removed := 0
+	for _, orphan := range orphans {
+		size, _ := config.ItemSize(cfg, orphan)
+
+		if !autoAcknowledge {
+			ok, err := prompt.Confirm(fmt.Sprintf("Remove orphan '%s' (size: %s)?", orphan, config.ReadableSize(uint64(size))))
+			if err != nil {
+				return fmt.Errorf("error confirming removal of %s: %v", orphan, err)
+			}
+			if !ok {
+				fmt.Printf("Skipping %s as per user confirmation.\n", orphan)
+				continue
+			}
+		}
+
+		if err := destroy(cfg.ItemPath(orphan), cfg); err != nil {
+			fmt.Printf("Error removing %s: %v\n", orphan, err)
+			continue
+		}
+		cfg.PruneItemParents(orphan)
+		removed++
+		fmt.Printf("Removed orphan %s.\n", orphan)
+	}
+
+	fmt.Printf("Removed %d of %d orphan files.\n", removed, len(orphans))
+	return nil
+}
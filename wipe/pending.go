@@ -0,0 +1,206 @@
+package wipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// pendingEntry is the description of a staged item, enough to journal it back
+// into its bin.
+type pendingEntry struct {
+	Bin    string            `json:"bin,omitempty"`
+	Record *journal.MetaData `json:"record"`
+}
+
+// stagingBatch is the pending directory of the running wipe, named after the
+// time its first item was staged. Every item of a wipe is staged in the same
+// batch, so --undo brings back the whole wipe.
+var stagingBatch string
+
+// staging reports whether the wiped items are staged for undo rather than
+// removed at once. Shredded items are never staged.
+func staging(cfg *config.Config) bool {
+	return cfg.UndoWindow > 0 && !shredding(cfg)
+}
+
+// undoWindow returns how long the staged items can be brought back.
+func undoWindow(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.UndoWindow) * time.Minute
+}
+
+// stageItem moves a wiped item, with its record, into the batch of the
+// running wipe.
+func stageItem(record *journal.MetaData, rubbishFile string, cfg *config.Config) error {
+	if stagingBatch == "" {
+		stagingBatch = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	batch := filepath.Join(cfg.PendingPath(), stagingBatch)
+	if err := os.MkdirAll(batch, 0o700); err != nil {
+		return fmt.Errorf("error creating pending directory: %v", err)
+	}
+
+	entry, err := os.MkdirTemp(batch, "item-")
+	if err != nil {
+		return fmt.Errorf("error creating pending entry: %v", err)
+	}
+
+	data, err := json.Marshal(pendingEntry{Bin: cfg.Bin, Record: record})
+	if err == nil {
+		err = os.WriteFile(filepath.Join(entry, config.PendingRecord), data, 0o600)
+	}
+	if err == nil {
+		err = fsutil.Move(rubbishFile, filepath.Join(entry, config.PendingItem))
+	}
+	if err != nil {
+		os.RemoveAll(entry)
+		return fmt.Errorf("error staging %s: %v", record.Item, err)
+	}
+	return nil
+}
+
+// pendingBatches returns the names of the staged batches with their staging
+// time, oldest first. Unknown entries of the pending directory are ignored.
+func pendingBatches(cfg *config.Config) ([]string, map[string]time.Time, error) {
+	entries, err := os.ReadDir(cfg.PendingPath())
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading pending directory: %v", err)
+	}
+
+	var names []string
+	staged := make(map[string]time.Time)
+	for _, entry := range entries {
+		nanos, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		names = append(names, entry.Name())
+		staged[entry.Name()] = time.Unix(0, nanos)
+	}
+
+	slices.SortFunc(names, func(a, b string) int { return staged[a].Compare(staged[b]) })
+	return names, staged, nil
+}
+
+// PurgePending removes for good the batches of wiped items staged before the
+// cutoff. It returns the number of purged items.
+func PurgePending(cfg *config.Config, cutoff time.Time) (int, error) {
+	names, staged, err := pendingBatches(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, name := range names {
+		if !staged[name].Before(cutoff) {
+			continue
+		}
+
+		batch := filepath.Join(cfg.PendingPath(), name)
+		entries, _ := os.ReadDir(batch)
+		if err := os.RemoveAll(batch); err != nil {
+			return purged, fmt.Errorf("error purging wiped items: %v", err)
+		}
+		purged += len(entries)
+	}
+	return purged, nil
+}
+
+// Undo brings back the items of the last wipe, when it happened less than
+// undo_window minutes before now: each item is moved back into its bin and
+// journaled again. Items whose name has since been taken stay staged.
+func Undo(cfg *config.Config, now time.Time) ([]*journal.MetaData, error) {
+	names, staged, err := pendingBatches(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("nothing to undo, no wiped item is pending")
+	}
+
+	last := names[len(names)-1]
+	if now.Sub(staged[last]) > undoWindow(cfg) {
+		return nil, fmt.Errorf("the last wipe, at %s, is past the %d minutes undo window",
+			staged[last].In(cfg.Zone()).Format(time.DateTime), cfg.UndoWindow)
+	}
+
+	batch := filepath.Join(cfg.PendingPath(), last)
+	entries, err := os.ReadDir(batch)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pending wipe: %v", err)
+	}
+
+	var restored []*journal.MetaData
+	var failed []string
+	for _, entry := range entries {
+		record, err := unstageItem(filepath.Join(batch, entry.Name()), cfg)
+		if err != nil {
+			fmt.Printf("Error restoring %s: %v\n", entry.Name(), err)
+			failed = append(failed, entry.Name())
+			continue
+		}
+		restored = append(restored, record)
+	}
+
+	if len(failed) > 0 {
+		return restored, fmt.Errorf("unable to restore %d items, they stay pending", len(failed))
+	}
+	if err := os.Remove(batch); err != nil {
+		return restored, fmt.Errorf("error removing pending wipe: %v", err)
+	}
+	return restored, nil
+}
+
+// unstageItem moves a staged item back into the container of its bin and
+// journals its record again.
+func unstageItem(entry string, cfg *config.Config) (*journal.MetaData, error) {
+	data, err := os.ReadFile(filepath.Join(entry, config.PendingRecord))
+	if err != nil {
+		return nil, fmt.Errorf("error reading staged record: %v", err)
+	}
+
+	var staged pendingEntry
+	if err := json.Unmarshal(data, &staged); err != nil || staged.Record == nil {
+		return nil, fmt.Errorf("invalid staged record %s", filepath.Join(entry, config.PendingRecord))
+	}
+	record := staged.Record
+
+	bin, err := cfg.ForBin(staged.Bin)
+	if err != nil {
+		return nil, err
+	}
+
+	target := bin.ItemPath(record.Item)
+	if _, err := os.Lstat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists in the container", record.Item)
+	}
+	if _, err := bin.Journal.Get(record.Item); err == nil {
+		return nil, fmt.Errorf("%s is already journaled", record.Item)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return nil, fmt.Errorf("error creating %s: %v", filepath.Dir(target), err)
+	}
+	if err := fsutil.Move(filepath.Join(entry, config.PendingItem), target); err != nil {
+		return nil, fmt.Errorf("error moving %s back: %v", record.Item, err)
+	}
+	if err := bin.Journal.AddRecord(record); err != nil {
+		return nil, fmt.Errorf("error journaling %s: %v", record.Item, err)
+	}
+	if err := bin.WriteTrashInfo(record); err != nil {
+		return nil, fmt.Errorf("error writing trash info of %s: %v", record.Item, err)
+	}
+
+	return record, os.RemoveAll(entry)
+}
@@ -0,0 +1,135 @@
+package wipe
+
+import (
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUndo_WithinWindow(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.UndoWindow = 60
+	first := seed(t, cfg, "first.log", 10, 1, 48*time.Hour)
+	second := seed(t, cfg, "second.log", 20, 1, 48*time.Hour)
+	stagingBatch = ""
+	defer func() { stagingBatch = "" }()
+
+	for _, record := range []string{first.Item, second.Item} {
+		stored, err := cfg.Journal.Get(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := removeItem(stored, cfg); err != nil {
+			t.Fatalf("removeItem: %v", err)
+		}
+	}
+	if _, err := os.Lstat(cfg.ItemPath(first.Item)); !os.IsNotExist(err) {
+		t.Fatalf("%s must leave the container, got %v", first.Item, err)
+	}
+	if _, err := cfg.Journal.Get(first.Item); err == nil {
+		t.Fatalf("%s must leave the journal", first.Item)
+	}
+
+	restored, err := Undo(cfg, time.Now().Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("expected the 2 items of the wipe back, got %d", len(restored))
+	}
+	for _, record := range []string{first.Item, second.Item} {
+		if _, err := os.Lstat(cfg.ItemPath(record)); err != nil {
+			t.Errorf("%s must be back in the container: %v", record, err)
+		}
+		if back, err := cfg.Journal.Get(record); err != nil || back.Origin != filepath.Join(cfg.WorkingDir, strings.TrimSuffix(record, "_ABCDEF")) {
+			t.Errorf("%s must be journaled back, got %+v, %v", record, back, err)
+		}
+	}
+
+	if _, err := Undo(cfg, time.Now()); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("a second undo must have nothing left, got %v", err)
+	}
+}
+
+func TestUndo_AfterWindow(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.UndoWindow = 10
+	record := seed(t, cfg, "late.log", 10, 1, 48*time.Hour)
+	stagingBatch = ""
+	defer func() { stagingBatch = "" }()
+
+	if err := removeItem(record, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+
+	later := time.Now().Add(11 * time.Minute)
+	if _, err := Undo(cfg, later); err == nil || !strings.Contains(err.Error(), "undo window") {
+		t.Fatalf("undo past the window must fail, got %v", err)
+	}
+	if _, err := os.Lstat(cfg.ItemPath(record.Item)); !os.IsNotExist(err) {
+		t.Errorf("%s must stay wiped, got %v", record.Item, err)
+	}
+
+	// A purge within the window keeps the staged items
+	if purged, err := PurgePending(cfg, time.Now().Add(-10*time.Minute)); err != nil || purged != 0 {
+		t.Fatalf("nothing must be purged within the window, purged %d, %v", purged, err)
+	}
+	if purged, err := PurgePending(cfg, later.Add(-10*time.Minute)); err != nil || purged != 1 {
+		t.Fatalf("expected the staged item purged, purged %d, %v", purged, err)
+	}
+	if _, err := Undo(cfg, later); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("nothing must be left after the purge, got %v", err)
+	}
+}
+
+func TestRemoveItem_ShreddedItemsAreNotStaged(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.UndoWindow, cfg.DefaultShred = 60, true
+	record := seed(t, cfg, "secret.txt", 10, 1, 48*time.Hour)
+	stagingBatch = ""
+	defer func() { stagingBatch = "" }()
+
+	if err := removeItem(record, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+	if _, err := os.Lstat(cfg.PendingPath()); !os.IsNotExist(err) {
+		t.Errorf("shredded items must be removed at once, got %v", err)
+	}
+}
+
+func TestBinSize_CountsStagedItems(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.UndoWindow = 60
+	record := seed(t, cfg, "staged.log", 10, 1, 48*time.Hour)
+	seed(t, cfg, "kept.log", 20, 1, 48*time.Hour)
+	stagingBatch = ""
+	defer func() { stagingBatch = "" }()
+
+	if err := removeItem(record, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+	if size, err := config.BinSize(cfg); err != nil || size != 30 {
+		t.Errorf("the staged item must still count in the bin size, got %d, %v", size, err)
+	}
+	if pending, err := config.PendingSize(cfg); err != nil || pending != 10 {
+		t.Errorf("expected the staged item pending, got %d, %v", pending, err)
+	}
+
+	other, err := cfg.ForBin("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := config.PendingSize(other); err != nil || pending != 0 {
+		t.Errorf("the staged item must only count in its own bin, got %d, %v", pending, err)
+	}
+
+	if _, err := PurgePending(cfg, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := config.BinSize(cfg); err != nil || size != 20 {
+		t.Errorf("a purged item must no longer count, got %d, %v", size, err)
+	}
+}
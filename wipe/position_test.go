@@ -0,0 +1,78 @@
+package wipe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/journal"
+)
+
+func TestCommand_PositionWipesOneItem(t *testing.T) {
+	cfg := newTestCfg(t)
+	oldest := seed(t, cfg, "oldest.txt", 10, 1, 72*time.Hour)
+	middle := seed(t, cfg, "middle.txt", 10, 1, 48*time.Hour)
+	newest := seed(t, cfg, "newest.txt", 10, 1, 24*time.Hour)
+	defer Flags.Parse([]string{"-p=0"})
+
+	// confirmed at the prompt
+	scriptInput(t, "y\n")
+	Flags.Parse([]string{"-p=2"})
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Are you sure you want to wipe '"+middle.Item+"'") {
+		t.Errorf("expected a confirmation of %s, got: %s", middle.Item, out)
+	}
+	assertWiped(t, cfg, map[*journal.MetaData]bool{oldest: false, middle: true, newest: false})
+
+	// negative positions count from the newest, -1 being the newest
+	Flags.Parse([]string{"-p=-1", "-y"})
+	defer Flags.Parse([]string{"-y=false"})
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	assertWiped(t, cfg, map[*journal.MetaData]bool{oldest: false, newest: true})
+}
+
+func TestCommand_PositionOutOfRange(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "only.txt", 10, 1, 48*time.Hour)
+	defer Flags.Parse([]string{"-p=0", "-y=false"})
+
+	for _, position := range []string{"2", "-2"} {
+		Flags.Parse([]string{"-y", "-p=" + position})
+		captureStdout(t, func() {
+			if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "invalid item position") {
+				t.Errorf("-p=%s: expected an invalid position error, got %v", position, err)
+			}
+		})
+	}
+	assertWiped(t, cfg, map[*journal.MetaData]bool{record: false})
+}
+
+func TestCommand_PositionRequiresWipeableUnlessForced(t *testing.T) {
+	cfg := newTestCfg(t)
+	pending := seed(t, cfg, "pending.txt", 10, 30, time.Hour)
+	Flags.Parse([]string{"-y", "-p=1"})
+	defer Flags.Parse([]string{"-p=0", "-y=false", "-f=false"})
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err == nil || !strings.Contains(err.Error(), "use -f") {
+			t.Errorf("expected the pending item to be refused, got %v", err)
+		}
+	})
+	assertWiped(t, cfg, map[*journal.MetaData]bool{pending: false})
+
+	Flags.Parse([]string{"-f"})
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+	assertWiped(t, cfg, map[*journal.MetaData]bool{pending: true})
+}
@@ -0,0 +1,90 @@
+package wipe
+
+import (
+	"cmp"
+	"fmt"
+	"rubbish/color"
+	"rubbish/config"
+	"rubbish/journal"
+	"slices"
+	"time"
+)
+
+// EnforceQuota applies the bin limits of the configuration. Items kept longer
+// than max_retention days are wiped whatever their own retention, then the
+// oldest wipeable items are evicted until the bin fits in max_size. Every
+// eviction is reported on stderr. The evicted records are returned.
+//
+// Evicted items are removed at once, never staged for undo. The wiped items
+// already pending undo are left out of the size compared with max_size: no
+// eviction frees them, they go with the undo window.
+func EnforceQuota(cfg *config.Config) ([]*journal.MetaData, error) {
+	records, err := cfg.Journal.List()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving items from journal: %v", err)
+	}
+
+	slices.SortStableFunc(records, func(a, b *journal.MetaData) int {
+		return cmp.Compare(a.TossedTime, b.TossedTime)
+	})
+
+	var evicted, kept []*journal.MetaData
+
+	maxAge := time.Duration(cfg.MaxRetention) * 24 * time.Hour
+	for _, record := range records {
+		if cfg.MaxRetention > 0 && record.TossElapsed() > maxAge {
+			if evict(record, cfg, fmt.Sprintf("kept over max_retention of %d days", cfg.MaxRetention)) {
+				evicted = append(evicted, record)
+				continue
+			}
+		}
+		kept = append(kept, record)
+	}
+
+	if cfg.MaxSize == 0 {
+		return evicted, nil
+	}
+
+	size, err := config.BinSize(cfg)
+	if err != nil {
+		return evicted, fmt.Errorf("error retrieving rubbish bin size: %v", err)
+	}
+	pending, err := config.PendingSize(cfg)
+	if err != nil {
+		return evicted, fmt.Errorf("error retrieving the size of the wiped items pending undo: %v", err)
+	}
+	size -= pending
+
+	for _, record := range kept {
+		if uint64(size) <= cfg.MaxSize {
+			break
+		}
+		if !record.IsWipeable() {
+			continue
+		}
+
+		itemSize, _ := config.ItemSize(cfg, record.Item)
+		if evict(record, cfg, fmt.Sprintf("bin over max_size of %s", config.ReadableSize(cfg.MaxSize))) {
+			evicted = append(evicted, record)
+			size -= itemSize
+		}
+	}
+
+	if uint64(size) > cfg.MaxSize {
+		color.Warnf("rubbish bin size %s still exceeds max_size of %s, no wipeable items left to evict\n",
+			config.ReadableSize(uint64(size)), config.ReadableSize(cfg.MaxSize))
+	}
+
+	return evicted, nil
+}
+
+// evict removes the record's item, warning about it with the reason.
+func evict(record *journal.MetaData, cfg *config.Config, reason string) bool {
+	if err := discardItem(record, cfg, false); err != nil {
+		color.Warnf("could not evict %s: %v\n", record.Item, err)
+		return false
+	}
+
+	color.Warnf("evicted %s (origin: %s), %s\n", record.Item, record.Origin, reason)
+	return true
+}
@@ -0,0 +1,118 @@
+package wipe
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnforceQuota_WipesItemsOverMaxRetention(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxRetention = 30
+	stale := seed(t, cfg, "stale.log", 10, 365, 40*24*time.Hour)
+	fresh := seed(t, cfg, "fresh.log", 10, 365, 10*24*time.Hour)
+
+	evicted, err := EnforceQuota(cfg)
+	if err != nil {
+		t.Fatalf("EnforceQuota error: %v", err)
+	}
+
+	if len(evicted) != 1 || evicted[0].Item != stale.Item {
+		t.Fatalf("expected only %s evicted, got %v", stale.Item, evicted)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, stale.Item)); !os.IsNotExist(err) {
+		t.Errorf("item over max_retention must be wiped, stat err: %v", err)
+	}
+	if _, err := cfg.Journal.Get(fresh.Item); err != nil {
+		t.Errorf("item within max_retention must be kept: %v", err)
+	}
+}
+
+func TestEnforceQuota_EvictsOldestWipeableOverMaxSize(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxSize = 250
+	oldest := seed(t, cfg, "oldest.log", 100, 1, 5*24*time.Hour)
+	older := seed(t, cfg, "older.log", 100, 1, 4*24*time.Hour)
+	pinned := seed(t, cfg, "pinned.log", 100, 30, 6*24*time.Hour)
+	newer := seed(t, cfg, "newer.log", 100, 1, 3*24*time.Hour)
+
+	evicted, err := EnforceQuota(cfg)
+	if err != nil {
+		t.Fatalf("EnforceQuota error: %v", err)
+	}
+
+	// 400 bytes in the bin, two wipeable items must go, oldest first
+	if len(evicted) != 2 || evicted[0].Item != oldest.Item || evicted[1].Item != older.Item {
+		t.Fatalf("expected %s and %s evicted, got %v", oldest.Item, older.Item, evicted)
+	}
+	for _, kept := range []string{pinned.Item, newer.Item} {
+		if _, err := cfg.Journal.Get(kept); err != nil {
+			t.Errorf("%s must be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestEnforceQuota_UndoWindowDoesNotCascade(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxSize, cfg.UndoWindow = 250, 60
+	stagingBatch = ""
+	defer func() { stagingBatch = "" }()
+
+	// An item wiped by hand stays pending undo
+	wiped := seed(t, cfg, "wiped.log", 300, 1, 6*24*time.Hour)
+	if err := removeItem(wiped, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+	oldest := seed(t, cfg, "oldest.log", 100, 1, 5*24*time.Hour)
+	seed(t, cfg, "older.log", 100, 1, 4*24*time.Hour)
+	seed(t, cfg, "newer.log", 100, 1, 3*24*time.Hour)
+
+	for range 2 {
+		if _, err := EnforceQuota(cfg); err != nil {
+			t.Fatalf("EnforceQuota error: %v", err)
+		}
+	}
+
+	// The pending item is left out and the eviction is not staged
+	records, _ := cfg.Journal.List()
+	if len(records) != 2 {
+		t.Errorf("expected a single eviction over two runs, %d items left", len(records))
+	}
+	if pending, err := config.PendingSize(cfg); err != nil || pending != 300 {
+		t.Errorf("the evicted %s must not be staged, pending %d, %v", oldest.Item, pending, err)
+	}
+}
+
+func TestCommand_EnforceQuotaWarnsWhenNothingLeftToEvict(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.MaxSize = 50
+	pinned := seed(t, cfg, "pinned.log", 100, 30, time.Hour)
+	quotaMode = true
+	defer func() { quotaMode = false }()
+
+	orig := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+	w.Close()
+	os.Stderr = orig
+	stderr, _ := io.ReadAll(r)
+
+	if !strings.Contains(out, "nothing evicted") {
+		t.Errorf("expected nothing evicted message: %s", out)
+	}
+	if !strings.Contains(string(stderr), "still exceeds max_size") {
+		t.Errorf("expected warning about the bin staying over max_size: %s", string(stderr))
+	}
+	if _, err := cfg.Journal.Get(pinned.Item); err != nil {
+		t.Errorf("item not wipeable yet must be kept: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package wipe
+
+import (
+	"fmt"
+	"os"
+	"rubbish/config"
+	"rubbish/fsutil"
+	"rubbish/journal"
+)
+
+// reclaimable returns, by item, the bytes freed by wiping the records, as
+// measured in the container. The content of a file deduplicated with --dedupe
+// is only freed when every link to it is among the records, and is counted
+// on the first of them.
+func reclaimable(records []*journal.MetaData, cfg *config.Config) map[string]int64 {
+	type shared struct {
+		first    string
+		size     int64
+		links    uint64
+		selected uint64
+	}
+
+	sizes := make(map[string]int64, len(records))
+	inodes := make(map[uint64]*shared)
+	for _, record := range records {
+		info, err := os.Lstat(cfg.ItemPath(record.Item))
+		if err != nil {
+			continue
+		}
+
+		inode, links := fsutil.HardLinks(info)
+		if !info.Mode().IsRegular() || links < 2 {
+			sizes[record.Item], _ = config.ItemSize(cfg, record.Item)
+			continue
+		}
+		if inodes[inode] == nil {
+			inodes[inode] = &shared{first: record.Item, size: info.Size(), links: links}
+		}
+		inodes[inode].selected++
+	}
+
+	for _, content := range inodes {
+		if content.selected >= content.links {
+			sizes[content.first] = content.size
+		}
+	}
+	return sizes
+}
+
+// freed sums the reclaimable bytes of the given records.
+func freed(records []*journal.MetaData, sizes map[string]int64) int64 {
+	var total int64
+	for _, record := range records {
+		total += sizes[record.Item]
+	}
+	return total
+}
+
+// reclaimNote tells when the space of the wiped items is reclaimed, once
+// the undo window is over for staged items.
+func reclaimNote(cfg *config.Config) string {
+	if !staging(cfg) {
+		return ""
+	}
+	return fmt.Sprintf(" once the %d minutes undo window is over", cfg.UndoWindow)
+}
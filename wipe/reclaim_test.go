@@ -0,0 +1,54 @@
+package wipe
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/journal"
+)
+
+func TestCommand_ReportsFreedSpace(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 2048, 1, 72*time.Hour)
+	b := seed(t, cfg, "b.log", 1024, 1, 48*time.Hour)
+	seed(t, cfg, "kept.log", 4096, 30, time.Hour)
+
+	var want int64
+	for _, record := range []*journal.MetaData{a, b} {
+		size, _ := config.ItemSize(cfg, record.Item)
+		want += size
+	}
+
+	Flags.Parse([]string{"-y"})
+	defer Flags.Parse([]string{"-y=false"})
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command: %v", err)
+		}
+	})
+
+	if line := "Freed " + config.ReadableSize(uint64(want)) + " across 2 items."; !strings.Contains(out, line) {
+		t.Errorf("expected %q, got:\n%s", line, out)
+	}
+}
+
+func TestReclaimable_SharedContent(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.iso", 1000, 1, time.Hour)
+	b := seed(t, cfg, "b.iso", 0, 1, time.Hour)
+	os.Remove(cfg.ItemPath(b.Item))
+	if err := os.Link(cfg.ItemPath(a.Item), cfg.ItemPath(b.Item)); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	if total := freed([]*journal.MetaData{a}, reclaimable([]*journal.MetaData{a}, cfg)); total != 0 {
+		t.Errorf("a content still linked by another item frees nothing, got %d", total)
+	}
+	both := []*journal.MetaData{a, b}
+	if total := freed(both, reclaimable(both, cfg)); total != 1000 {
+		t.Errorf("wiping every link frees the content once, got %d", total)
+	}
+}
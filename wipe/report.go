@@ -0,0 +1,175 @@
+package wipe
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"rubbish/journal"
+	"slices"
+	"time"
+)
+
+// Report formats supported by the deletion manifest.
+const (
+	ReportJSON   = "json"
+	ReportNDJSON = "ndjson"
+	ReportCSV    = "csv"
+)
+
+var reportFormats = []string{ReportJSON, ReportNDJSON, ReportCSV}
+
+// reportHeader is the column order used by the csv manifest.
+var reportHeader = []string{"item", "origin", "type", "tossed_at", "wiped_at"}
+
+// reportEntry is a single line of the deletion manifest. It describes an item
+// that was permanently removed from the rubbish container.
+type reportEntry struct {
+	Item     string `json:"item"`
+	Origin   string `json:"origin"`
+	Type     uint   `json:"type"`
+	TossedAt string `json:"tossed_at"`
+	WipedAt  string `json:"wiped_at"`
+}
+
+func newReportEntry(record *journal.MetaData, wipedAt time.Time) reportEntry {
+	return reportEntry{
+		Item:     record.Item,
+		Origin:   record.Origin,
+		Type:     record.Type,
+		TossedAt: time.Unix(record.TossedTime, 0).Format(time.RFC3339),
+		WipedAt:  wipedAt.Format(time.RFC3339),
+	}
+}
+
+func (e reportEntry) fields() []string {
+	return []string{e.Item, e.Origin, fmt.Sprint(e.Type), e.TossedAt, e.WipedAt}
+}
+
+// validateReportFormat checks the requested manifest format is supported.
+func validateReportFormat(format string) error {
+	if !slices.Contains(reportFormats, format) {
+		return fmt.Errorf("unsupported report format '%s' (expected one of %v)", format, reportFormats)
+	}
+	return nil
+}
+
+// writeReport stores the wiped records in the manifest file using the given format.
+// The ndjson and csv formats append to an existing manifest, the csv header is only
+// written when the file is new or empty. The json format keeps a single array,
+// merging the new entries with the ones already present in the file.
+func writeReport(file string, format string, records []*journal.MetaData, wipedAt time.Time) error {
+	if err := validateReportFormat(format); err != nil {
+		return err
+	}
+
+	entries := make([]reportEntry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, newReportEntry(record, wipedAt))
+	}
+
+	switch format {
+	case ReportJSON:
+		return writeJSONReport(file, entries)
+	case ReportCSV:
+		return writeCSVReport(file, entries)
+	default:
+		return writeNDJSONReport(file, entries)
+	}
+}
+
+func openReport(file string) (*os.File, int64, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error opening report file %s: %w", file, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("error reading report file %s: %w", file, err)
+	}
+	return f, info.Size(), nil
+}
+
+func writeNDJSONReport(file string, entries []reportEntry) error {
+	f, size, err := openReport(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if size > 0 && !endsWithNewline(file, size) {
+		w.WriteString("\n")
+	}
+
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("error writing report entry for %s: %w", entry.Item, err)
+		}
+	}
+	return w.Flush()
+}
+
+func writeCSVReport(file string, entries []reportEntry) error {
+	f, size, err := openReport(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if size > 0 && !endsWithNewline(file, size) {
+		f.WriteString("\n")
+	}
+
+	w := csv.NewWriter(f)
+	if size == 0 {
+		w.Write(reportHeader)
+	}
+	for _, entry := range entries {
+		w.Write(entry.fields())
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSONReport(file string, entries []reportEntry) error {
+	var existing []reportEntry
+
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading report file %s: %w", file, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("report file %s is not a json array: %w", file, err)
+		}
+	}
+
+	data, err = json.MarshalIndent(append(existing, entries...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling report: %w", err)
+	}
+
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// endsWithNewline reports whether the last byte of the file is a newline, so
+// appended entries never get glued to a truncated last line.
+func endsWithNewline(file string, size int64) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil && err != io.EOF {
+		return true
+	}
+	return last[0] == '\n'
+}
@@ -0,0 +1,127 @@
+package wipe
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/journal"
+)
+
+func reportRecords() []*journal.MetaData {
+	return []*journal.MetaData{
+		{Item: "a.txt_AAAAAA", Origin: "/home/user/a.txt", Type: journal.TypeFile, TossedTime: time.Now().Add(-48 * time.Hour).Unix()},
+		{Item: "b_BBBBBB", Origin: "/home/user/b", Type: journal.TypeDirectory, TossedTime: time.Now().Add(-24 * time.Hour).Unix()},
+	}
+}
+
+func TestWriteReport_NDJSONAppends(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wipe.ndjson")
+	now := time.Now()
+
+	if err := writeReport(file, ReportNDJSON, reportRecords(), now); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := writeReport(file, ReportNDJSON, reportRecords()[:1], now); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	data, _ := os.ReadFile(file)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), data)
+	}
+	for _, line := range lines {
+		var entry reportEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line is not a json object: %q: %v", line, err)
+		}
+		if entry.Item == "" || entry.WipedAt == "" {
+			t.Errorf("incomplete entry: %+v", entry)
+		}
+	}
+}
+
+func TestWriteReport_NDJSONAppendsAfterTruncatedLine(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wipe.ndjson")
+	os.WriteFile(file, []byte(`{"item":"old"}`), 0o644)
+
+	if err := writeReport(file, ReportNDJSON, reportRecords(), time.Now()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	data, _ := os.ReadFile(file)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), data)
+	}
+}
+
+func TestWriteReport_CSVHeaderOnce(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wipe.csv")
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := writeReport(file, ReportCSV, reportRecords(), now); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	f, _ := os.Open(file)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected header + 4 rows, got %d", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(reportHeader, ",") {
+		t.Errorf("unexpected header: %v", rows[0])
+	}
+	for _, row := range rows[1:] {
+		if row[0] == "item" {
+			t.Errorf("header repeated in body: %v", row)
+		}
+	}
+	if rows[2][1] != "/home/user/b" {
+		t.Errorf("unexpected origin column: %v", rows[2])
+	}
+}
+
+func TestWriteReport_JSONArrayMerges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wipe.json")
+	now := time.Now()
+
+	if err := writeReport(file, ReportJSON, reportRecords(), now); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := writeReport(file, ReportJSON, reportRecords(), now); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	data, _ := os.ReadFile(file)
+	var entries []reportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("report is not a json array: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	if entries[1].Type != journal.TypeDirectory {
+		t.Errorf("unexpected type: %+v", entries[1])
+	}
+}
+
+func TestWriteReport_UnsupportedFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "wipe.xml")
+	if err := writeReport(file, "xml", reportRecords(), time.Now()); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("report file should not be created for unsupported format")
+	}
+}
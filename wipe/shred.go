@@ -0,0 +1,92 @@
+package wipe
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"rubbish/config"
+	"rubbish/fsutil"
+)
+
+// shredding reports whether the wiped items are shredded: as requested with
+// --shred when given, as set by default_shred otherwise.
+func shredding(cfg *config.Config) bool {
+	explicit := false
+	Flags.Visit(func(f *flag.Flag) {
+		if f.Name == "shred" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return shredMode
+	}
+	return cfg.DefaultShred
+}
+
+// shredItem overwrites every regular file of a container item, the item
+// itself or the files below it, when shredding is enabled. Symlinks are not
+// followed, and files still linked to a deduplicated item are only unlinked,
+// as their content belongs to that item too. Filesystems which do not
+// rewrite data in place are skipped, as overwriting there leaves the
+// original blocks untouched.
+func shredItem(path string, cfg *config.Config) error {
+	if !shredding(cfg) || !shredEffective(path) {
+		return nil
+	}
+
+	return filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || fsutil.Shared(file) {
+			return nil
+		}
+		return shredFile(file, shredPasses)
+	})
+}
+
+// destroy shreds, when enabled, and removes a file or directory of the container.
+func destroy(path string, cfg *config.Config) error {
+	if err := shredItem(path, cfg); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// shredFile overwrites the content of a regular file with random bytes the
+// given number of times, syncing each pass to the disk. The file keeps its
+// length and is not removed.
+func shredFile(path string, passes int) error {
+	if passes < 1 {
+		return fmt.Errorf("invalid number of shred passes %d, expected at least 1", passes)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("error opening %s for shredding: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading size of %s: %w", path, err)
+	}
+
+	for range passes {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("error shredding %s: %w", path, err)
+		}
+		if _, err := io.CopyN(file, rand.Reader, info.Size()); err != nil {
+			return fmt.Errorf("error shredding %s: %w", path, err)
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("error syncing shredded %s: %w", path, err)
+		}
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,28 @@
+//go:build linux
+
+package wipe
+
+import "syscall"
+
+// Magic numbers, see statfs(2), of the filesystems writing modified data to
+// new blocks, where overwriting a file does not destroy its former content.
+const (
+	btrfsMagic = 0x9123683e
+	zfsMagic   = 0x2fc12fc1
+	nilfsMagic = 0x3434
+)
+
+// shredEffective reports whether overwriting the files at path destroys
+// their former content. Filesystems which cannot be identified are shredded.
+func shredEffective(path string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return true
+	}
+
+	switch uint32(stat.Type) {
+	case btrfsMagic, zfsMagic, nilfsMagic:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package wipe
+
+// shredEffective reports whether overwriting the files at path destroys
+// their former content, assumed everywhere but on linux.
+func shredEffective(path string) bool {
+	return true
+}
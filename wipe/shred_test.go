@@ -0,0 +1,103 @@
+package wipe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestShredFile_KeepsLengthChangesContent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret.txt")
+	original := bytes.Repeat([]byte("secret "), 1000)
+	if err := os.WriteFile(file, original, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, passes := range []int{1, 3} {
+		if err := shredFile(file, passes); err != nil {
+			t.Fatalf("shredFile(%d): %v", passes, err)
+		}
+		shredded, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("shredded file must be kept: %v", err)
+		}
+		if len(shredded) != len(original) {
+			t.Errorf("length changed from %d to %d", len(original), len(shredded))
+		}
+		if bytes.Contains(shredded, []byte("secret")) {
+			t.Error("content still readable after shredding")
+		}
+	}
+
+	if err := shredFile(file, 0); err == nil {
+		t.Error("expected error for zero passes")
+	}
+}
+
+func TestRemoveItem_ShredsBeforeUnlinking(t *testing.T) {
+	cfg := newTestCfg(t)
+	if !shredEffective(cfg.ContainerPath) {
+		t.Skip("the container filesystem does not rewrite data in place")
+	}
+	cfg.DefaultShred = true
+	record := seed(t, cfg, "dir", 0, 1, time.Hour)
+	dir := cfg.ItemPath(record.Item)
+	os.Remove(dir)
+	os.MkdirAll(filepath.Join(dir, "nested"), 0o755)
+	files := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "nested", "b.txt")}
+	for _, file := range files {
+		os.WriteFile(file, []byte("plain text content"), 0o600)
+	}
+	os.Symlink(files[0], filepath.Join(dir, "link"))
+
+	var shredded [][]byte
+	removeAll = func(path string) error {
+		for _, file := range files {
+			data, _ := os.ReadFile(file)
+			shredded = append(shredded, data)
+		}
+		return os.RemoveAll(path)
+	}
+	defer func() { removeAll = os.RemoveAll }()
+
+	if err := removeItem(record, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+
+	if len(shredded) != len(files) {
+		t.Fatalf("removal not reached, got %d files", len(shredded))
+	}
+	for i, data := range shredded {
+		if len(data) != len("plain text content") || bytes.Equal(data, []byte("plain text content")) {
+			t.Errorf("%s not shredded before removal: %q", files[i], data)
+		}
+	}
+	if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+		t.Errorf("item must be removed, got %v", err)
+	}
+}
+
+func TestRemoveItem_KeepsContentOfLinkedItem(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.DefaultShred = true
+	record := seed(t, cfg, "a.iso", 0, 1, time.Hour)
+	os.WriteFile(cfg.ItemPath(record.Item), []byte("shared content"), 0o600)
+	duplicate := seed(t, cfg, "b.iso", 0, 1, time.Hour)
+	os.Remove(cfg.ItemPath(duplicate.Item))
+	if err := os.Link(cfg.ItemPath(record.Item), cfg.ItemPath(duplicate.Item)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeItem(record, cfg); err != nil {
+		t.Fatalf("removeItem: %v", err)
+	}
+
+	if _, err := os.Lstat(cfg.ItemPath(record.Item)); !os.IsNotExist(err) {
+		t.Errorf("item must be removed, got %v", err)
+	}
+	if data, err := os.ReadFile(cfg.ItemPath(duplicate.Item)); err != nil || string(data) != "shared content" {
+		t.Errorf("the linked item must keep its content, got %q, %v", data, err)
+	}
+}
@@ -1,14 +1,19 @@
 package wipe
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"rubbish/color"
 	"rubbish/config"
 	"rubbish/journal"
-	"slices"
+	"rubbish/prompt"
+	"time"
 )
 
 var (
@@ -16,6 +21,29 @@ var (
 	forceWipeout    bool          = false // completeWipeout indicates whether to perform a complete wipe of the rubbish container
 	autoAcknowledge bool          = false // autoAcknowledge indicates whether to automatically acknowledge the wipe operation by the user
 	globalWipeout   bool          = false // globalWipeout indicates whether to perform a global wipe of all items in the journal
+	reportFile      string        = ""    // reportFile is the path of the deletion manifest, empty when no report is requested
+	reportFormat    string        = ReportNDJSON
+	emptyMode       bool          = false // emptyMode indicates whether to remove everything in the container at once
+	orphansMode     bool          = false // orphansMode indicates whether to remove the container files without journal entry
+	quotaMode       bool          = false // quotaMode indicates whether to only enforce max_retention and max_size
+	dryRun          bool          = false // dryRun indicates whether to only list what would be wiped
+	interactive     bool          = false // interactive indicates whether to pick the items to wipe from a numbered list
+	shredMode       bool          = false // shredMode indicates whether to overwrite the files before unlinking them
+	shredPasses     int           = 1     // shredPasses is the number of random overwrites of each shredded file
+	binName         string        = ""    // binName is the named bin to wipe, empty for the default bin
+	verbose         bool          = false // verbose indicates whether to number each wiped item and time the whole wipe
+	undoMode        bool          = false // undoMode indicates whether to bring back the items of the last wipe
+	purgeMode       bool          = false // purgeMode indicates whether to remove for good every staged item
+	olderThan       string        = ""    // olderThan selects the items tossed longer ago than this age, whatever their wipe time
+	newerThan       string        = ""    // newerThan selects the items tossed more recently than this age, whatever their wipe time
+	keepCount       int           = 0     // keepCount is the number of most recently tossed items spared by the wipe, 0 sparing none
+	originDir       string        = ""    // originDir scopes the wipe to the items tossed from within this directory instead of the working directory
+	byPosition      int           = 0     // byPosition selects the item to wipe by its position, oldest tossed first, 0 selecting none
+
+	// progress counts the items wiped out of those selected, for the verbose output
+	progress struct {
+		done, total int
+	}
 )
 
 func init() {
@@ -30,60 +58,262 @@ func init() {
 	Flags.BoolVar(&forceWipeout, "f", false, "Force wipe of the rubbish regardless of their WipeoutTime (default: false).")
 	Flags.BoolVar(&autoAcknowledge, "y", false, "Automatically acknowledge the wipe operation (default: false).")
 	Flags.BoolVar(&globalWipeout, "g", false, "Perform a global wipe of all items in the journal (default: false).")
+	Flags.StringVar(&originDir, "origin", "", "Wipe the items tossed from within the given directory instead of the working directory.")
+	Flags.IntVar(&byPosition, "p", 0, "Wipe the item at the given position, oldest tossed first (1-based, negative from the newest), as numbered by info and restore.")
+	Flags.BoolVar(&emptyMode, "empty", false, "Empty the whole rubbish bin after a single confirmation, including orphan files.")
+	Flags.BoolVar(&interactive, "i", false, "Pick the items to wipe from a numbered list.")
+	Flags.BoolVar(&interactive, "interactive", false, "Pick the items to wipe from a numbered list.")
+	Flags.BoolVar(&shredMode, "shred", false, "Overwrite the files with random bytes before removing them, overriding default_shred.")
+	Flags.IntVar(&shredPasses, "shred-passes", 1, "Number of random overwrites of each shredded file.")
+	Flags.StringVar(&binName, "bin", "", "Wipe the named bin instead of the default one.")
+	Flags.BoolVar(&verbose, "verbose", false, "Number each wiped item and report the elapsed time.")
+	Flags.BoolVar(&undoMode, "undo", false, "Bring back the items of the last wipe, within undo_window minutes.")
+	Flags.BoolVar(&purgeMode, "purge", false, "Remove for good the wiped items staged for undo.")
+	Flags.StringVar(&olderThan, "older-than", "", "Wipe the items tossed longer ago than this age (e.g. 30d, 2w, 12h), whatever their wipe time.")
+	Flags.StringVar(&newerThan, "newer-than", "", "Wipe the items tossed more recently than this age (e.g. 30d, 2w, 12h), whatever their wipe time.")
+	Flags.IntVar(&keepCount, "keep", 0, "Keep the `N` most recently tossed items and wipe the others, whatever their wipe time.")
+	Flags.BoolVar(&dryRun, "dry-run", false, "List the items that would be wiped without removing anything.")
+	Flags.BoolVar(&quotaMode, "enforce-quota", false, "Evict items kept over max_retention days and the oldest wipeable items while the bin exceeds max_size.")
+	Flags.BoolVar(&orphansMode, "orphans", false, "Remove files in the rubbish container that have no journal entry.")
+	Flags.DurationVar(&prompt.Timeout, "confirm-timeout", 0, "Decline confirmations left unanswered for this long (e.g. 30s), 0 waits forever.")
+	Flags.StringVar(&reportFile, "report", "", "Write a manifest of the wiped items to the given file.")
+	Flags.StringVar(&reportFormat, "report-format", ReportNDJSON, "Format of the wipe manifest: json, ndjson or csv.")
+}
+
+// DryRun reports whether the parsed options only list what would be wiped.
+func DryRun() bool {
+	return dryRun
 }
 
 func Command(args []string, cfg *config.Config) error {
-	records, err := getRecords(cfg, globalWipeout, forceWipeout)
+	return CommandContext(context.Background(), args, cfg)
+}
+
+// CommandContext runs the wipe command like Command, stopping between items
+// once ctx is cancelled. The items wiped so far are reported as usual.
+func CommandContext(ctx context.Context, args []string, cfg *config.Config) error {
+	if reportFile != "" {
+		if err := validateReportFormat(reportFormat); err != nil {
+			return err
+		}
+	}
+
+	if binName != "" {
+		var err error
+		if cfg, err = cfg.ForBin(binName); err != nil {
+			return err
+		}
+	}
+
+	if shredPasses < 1 {
+		return fmt.Errorf("invalid --shred-passes %d, expected at least 1", shredPasses)
+	}
+
+	stagingBatch = ""
+
+	if undoMode {
+		return undoLastWipe(cfg)
+	}
+
+	if purgeMode {
+		purged, err := PurgePending(cfg, time.Now())
+		if err == nil {
+			fmt.Printf("Purged %d wiped items.\n", purged)
+		}
+		return err
+	}
 
+	if _, err := PurgePending(cfg, time.Now().Add(-undoWindow(cfg))); err != nil {
+		color.Warnf("%v\n", err)
+	}
+
+	if dryRun && (emptyMode || orphansMode || quotaMode) {
+		return fmt.Errorf("--dry-run cannot be combined with --empty, --orphans or --enforce-quota")
+	}
+
+	ageRange, err := parseAgeRange(olderThan, newerThan)
 	if err != nil {
-		return fmt.Errorf("error retrieving items from journal: %v", err)
+		return err
 	}
 
+	if ageRange.set() && (emptyMode || orphansMode || quotaMode) {
+		return fmt.Errorf("--older-than and --newer-than cannot be combined with --empty, --orphans or --enforce-quota")
+	}
+
+	if keepCount < 0 {
+		return fmt.Errorf("invalid --keep %d, expected 0 or more", keepCount)
+	}
+
+	if keepCount > 0 && (emptyMode || orphansMode || quotaMode || len(Flags.Args()) > 0) {
+		return fmt.Errorf("--keep cannot be combined with --empty, --orphans, --enforce-quota or item names")
+	}
+
+	if interactive && (emptyMode || orphansMode || quotaMode || len(Flags.Args()) > 0) {
+		return fmt.Errorf("--interactive cannot be combined with --empty, --orphans, --enforce-quota or item names")
+	}
+
+	if byPosition != 0 && (emptyMode || orphansMode || quotaMode || interactive || keepCount > 0 || ageRange.set() || len(Flags.Args()) > 0) {
+		return fmt.Errorf("-p cannot be combined with --empty, --orphans, --enforce-quota, --interactive, --keep, --older-than, --newer-than or item names")
+	}
+
+	if originDir != "" && (globalWipeout || emptyMode || orphansMode || quotaMode) {
+		return fmt.Errorf("--origin cannot be combined with -g, --empty, --orphans or --enforce-quota")
+	}
+
+	if interactive && !prompt.Interactive() {
+		return fmt.Errorf("interactive selection requires a terminal, specify the items to wipe instead")
+	}
+
+	if emptyMode {
+		return emptyBin(cfg)
+	}
+
+	if orphansMode {
+		return wipeOrphans(cfg)
+	}
+
+	if quotaMode {
+		evicted, err := EnforceQuota(cfg)
+		if err == nil && len(evicted) == 0 {
+			fmt.Println("Rubbish bin is within its quota, nothing evicted.")
+		}
+		if reportFile != "" && len(evicted) > 0 {
+			if rerr := writeReport(reportFile, reportFormat, evicted, time.Now()); rerr != nil {
+				return fmt.Errorf("error writing wipe report: %v", rerr)
+			}
+		}
+		return err
+	}
+
+	var records []*journal.MetaData
+	if byPosition != 0 {
+		record, err := recordAtPosition(byPosition, cfg)
+		if err != nil {
+			return err
+		}
+		records = []*journal.MetaData{record}
+	} else {
+		records, err = getRecords(cfg, globalWipeout, originDir, forceWipeout || ageRange.set() || keepCount > 0)
+		if err != nil {
+			return fmt.Errorf("error retrieving items from journal: %v", err)
+		}
+	}
+	records = keepNewest(ageRange.filter(records), keepCount)
+
 	if len(records) == 0 {
-		fmt.Println("\033[31mNo valid items found to wipe.\033[0m")
+		fmt.Println(color.Paint(os.Stdout, color.Red, "No valid items found to wipe."))
 		return nil
 	}
 
-	if len(Flags.Args()) > 0 {
-		if err := wipeSelectedFiles(records, Flags.Args(), cfg); err != nil {
-			return fmt.Errorf("error wiping files %s: %v", Flags.Args(), err)
+	if dryRun && !interactive {
+		return previewWipe(records, Flags.Args(), cfg)
+	}
+
+	var wiped []*journal.MetaData
+	start := time.Now()
+	startProgress(0)
+	sizes := reclaimable(records, cfg)
+
+	if interactive {
+		wiped, err = wipeFromList(ctx, records, cfg)
+	} else if byPosition != 0 {
+		wiped, err = wipeSelectedFiles(ctx, records, []string{records[0].Item}, cfg)
+		if err != nil {
+			err = fmt.Errorf("error wiping %s: %w", records[0].Item, err)
+		}
+	} else if len(Flags.Args()) > 0 {
+		wiped, err = wipeSelectedFiles(ctx, records, Flags.Args(), cfg)
+		if err != nil {
+			err = fmt.Errorf("error wiping files %s: %w", Flags.Args(), err)
+		}
+	} else {
+		wiped, err = wipeAllFiles(ctx, records, sizes, cfg)
+		if err != nil {
+			err = fmt.Errorf("error wiping all files: %w", err)
 		}
-		return nil
 	}
 
-	if err := wipeAllFiles(records, cfg); err != nil {
-		return fmt.Errorf("error wiping all files: %v", err)
+	if verbose && progress.total > 0 {
+		fmt.Printf("Wiped %d of %d items in %s.\n", len(wiped), progress.total, time.Since(start).Round(time.Millisecond))
 	}
 
-	return nil
+	if autoAcknowledge && len(wiped) > 0 {
+		fmt.Printf("Freed %s across %d items%s.\n", config.ReadableSize(uint64(freed(wiped, sizes))), len(wiped), reclaimNote(cfg))
+	}
+
+	if len(wiped) > 0 && staging(cfg) {
+		fmt.Printf("Run 'rubbish wipe --undo' within %d minutes to bring them back, they count in the bin size until then.\n", cfg.UndoWindow)
+	}
+
+	// The manifest is written even on a partial wipe, so every removed item is accounted for.
+	if reportFile != "" && len(wiped) > 0 {
+		if rerr := writeReport(reportFile, reportFormat, wiped, time.Now()); rerr != nil {
+			return fmt.Errorf("error writing wipe report: %v", rerr)
+		}
+	}
+
+	return err
+}
+
+// undoLastWipe brings back the items of the last wipe and reports them.
+func undoLastWipe(cfg *config.Config) error {
+	restored, err := Undo(cfg, time.Now())
+	for _, record := range restored {
+		fmt.Printf("Restored %s to the rubbish bin.\n", record.Item)
+	}
+	return err
 }
 
 // confirm prompts the user for confirmation before wiping an item, unless autoAcknowledge is true.
-func confirm(item string) (bool, error) {
+// The prompt describes where the item came from, its size and age so the user
+// can make an informed decision.
+func confirm(record *journal.MetaData, cfg *config.Config) (bool, error) {
 	if autoAcknowledge {
 		return true, nil
 	}
-	fmt.Printf("Are you sure you want to wipe '%s'? [y/N]: ", item)
-	var response string
-	_, err := fmt.Scanln(&response)
 
-	if err != nil {
-		return false, err
-	}
-	if response == "y" || response == "Y" {
-		return true, nil
+	size, _ := config.ItemSize(cfg, record.Item)
+	return prompt.Confirm(fmt.Sprintf("Are you sure you want to wipe '%s' (origin: %s, size: %s, tossed %s ago)?",
+		record.Item, record.Origin, config.ReadableSize(uint64(size)), age(record)))
+}
+
+// age returns how long ago the record was tossed, in days when above a day.
+func age(record *journal.MetaData) string {
+	elapsed := record.TossElapsed()
+	if elapsed.Hours() > 24.0 {
+		return fmt.Sprintf("%.01fd", elapsed.Hours()/24.0)
 	}
-	return false, nil
+	return elapsed.Round(time.Second).String()
 }
 
-func getRecords(cfg *config.Config, global bool, ignoreWipeTime bool) ([]*journal.MetaData, error) {
+// printSummary shows the space the wipe of the records frees before prompting.
+func printSummary(records []*journal.MetaData, sizes map[string]int64, cfg *config.Config) {
+	fmt.Printf("This will free %s across %d items%s.\n",
+		config.ReadableSize(uint64(freed(records, sizes))), len(records), reclaimNote(cfg))
+}
+
+// getRecords returns the wipe candidates: the items of the whole journal when
+// global, those tossed from within origin when given, those tossed from within
+// the working directory otherwise. Unless ignoreWipeTime, only the wipeable
+// ones are kept.
+func getRecords(cfg *config.Config, global bool, origin string, ignoreWipeTime bool) ([]*journal.MetaData, error) {
 	var (
 		records []*journal.MetaData
 		result  []*journal.MetaData
 		err     error
 	)
 
-	if global {
+	if origin != "" {
+		dir, err := filepath.Abs(origin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --origin %s: %v", origin, err)
+		}
+		fmt.Printf("Performing wipeout of items tossed from %s...\n", dir)
+		records, err = cfg.Journal.FilterPath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving items of %s from journal: %v", dir, err)
+		}
+	} else if global {
 		fmt.Println("Performing global wipeout of all items in the journal...")
 		records, err = cfg.Journal.List()
 		if err != nil {
@@ -112,25 +342,73 @@ func getRecords(cfg *config.Config, global bool, ignoreWipeTime bool) ([]*journa
 	return result, nil
 }
 
-func wipeSelectedFiles(records []*journal.MetaData, files []string, cfg *config.Config) error {
-	var record *journal.MetaData
+// recordAtPosition returns the wipe candidate at the given position, oldest
+// tossed first. Every item in scope is numbered, wipeable or not, as info and
+// restore do, but unless -f is given the selected one must be wipeable.
+func recordAtPosition(position int, cfg *config.Config) (*journal.MetaData, error) {
+	dir := cfg.WorkingDir
+	switch {
+	case originDir != "":
+		abs, err := filepath.Abs(originDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --origin %s: %v", originDir, err)
+		}
+		dir = abs
+	case globalWipeout:
+		dir = ""
+	}
 
-	for _, file := range files {
-		record = nil
-		_ = slices.IndexFunc(records, func(element *journal.MetaData) bool {
-			if element.Item == path.Base(file) {
-				record = element
-				return false
-			}
-			return true
-		})
-		if record == nil {
-			return fmt.Errorf("file (%s) not found in the dumpster", file)
+	record, err := cfg.Journal.RecordAtPosition(dir, position)
+	if err != nil {
+		return nil, err
+	}
+	if !forceWipeout && !record.IsWipeable() {
+		return nil, fmt.Errorf("%s is not wipeable before %s, use -f to wipe it anyway",
+			record.Item, record.WipeoutDate().In(cfg.Zone()).Format(time.DateOnly))
+	}
+	return record, nil
+}
+
+// selectRecord returns the record of the wipe candidates designated by the
+// given file name, its base name, a unique prefix of its key or its original
+// basename.
+func selectRecord(records []*journal.MetaData, file string) (*journal.MetaData, error) {
+	record, err := journal.Resolve(records, file)
+	if errors.Is(err, journal.ErrItemNotFound) && path.Base(file) != file {
+		record, err = journal.Resolve(records, path.Base(file))
+	}
+	if errors.Is(err, journal.ErrItemNotFound) {
+		return nil, fmt.Errorf("file (%s) not found in the dumpster", file)
+	}
+	return record, err
+}
+
+// interrupted returns the error stopping a wipe with left items to go once
+// ctx is cancelled, nil otherwise.
+func interrupted(ctx context.Context, left int) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("wipe interrupted with %d items left: %w", left, ctx.Err())
+}
+
+func wipeSelectedFiles(ctx context.Context, records []*journal.MetaData, files []string, cfg *config.Config) ([]*journal.MetaData, error) {
+	var wiped []*journal.MetaData
+	startProgress(len(files))
+
+	for i, file := range files {
+		if err := interrupted(ctx, len(files)-i); err != nil {
+			return wiped, err
 		}
 
-		wipeConfirmed, err := confirm(record.Item)
+		record, err := selectRecord(records, file)
 		if err != nil {
-			return fmt.Errorf("error confirming wipe for %s: %v", record.Item, err)
+			return wiped, err
+		}
+
+		wipeConfirmed, err := confirm(record, cfg)
+		if err != nil {
+			return wiped, fmt.Errorf("error confirming wipe for %s: %v", record.Item, err)
 		}
 		if !wipeConfirmed {
 			fmt.Printf("Skipping %s as per user confirmation.\n", record.Item)
@@ -139,13 +417,63 @@ func wipeSelectedFiles(records []*journal.MetaData, files []string, cfg *config.
 
 		if err := wipeItem(record, cfg); err != nil {
 			fmt.Printf("Error wiping %s: %v\n", record.Item, err)
+			continue
 		}
+		wiped = append(wiped, record)
 	}
 
+	return wiped, nil
+}
+
+// removeAll deletes an item from the container, replaceable for testing
+var removeAll = os.RemoveAll
+
+// restoreRecord journals the record back after its item failed to be removed,
+// unless nothing is left of it in the container, and checks the record resolves
+// to the item again.
+func restoreRecord(record *journal.MetaData, rubbishFile string, cfg *config.Config) error {
+	if _, err := os.Lstat(rubbishFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		return fmt.Errorf("the item is no longer tracked, journal it back failed: %v", err)
+	}
+
+	if restored, err := cfg.Journal.Get(record.Item); err != nil || !reflect.DeepEqual(restored, record) {
+		return fmt.Errorf("the item is no longer tracked, journaled record does not match %s", rubbishFile)
+	}
 	return nil
 }
 
 func wipeItem(record *journal.MetaData, cfg *config.Config) error {
+	if err := removeItem(record, cfg); err != nil {
+		return err
+	}
+
+	if verbose {
+		progress.done++
+		fmt.Printf("[%d/%d] wiped %s\n", progress.done, progress.total, record.Item)
+		return nil
+	}
+	fmt.Printf("Wiped %s successfully.\n", record.Item)
+	return nil
+}
+
+// startProgress resets the verbose counter for a wipe of total items.
+func startProgress(total int) {
+	progress.done, progress.total = 0, total
+}
+
+// removeItem deletes the record and its item from the container, staging
+// the item for undo when the undo window is enabled.
+func removeItem(record *journal.MetaData, cfg *config.Config) error {
+	return discardItem(record, cfg, staging(cfg))
+}
+
+// discardItem deletes the record and its item from the container, moving the
+// item to the pending directory rather than removing it when stage is set.
+func discardItem(record *journal.MetaData, cfg *config.Config, stage bool) error {
 	if record == nil {
 		return fmt.Errorf("record is nil, cannot wipe")
 	}
@@ -153,28 +481,49 @@ func wipeItem(record *journal.MetaData, cfg *config.Config) error {
 		return fmt.Errorf("config is nil, cannot wipe")
 	}
 
-	rubbishFile := filepath.Join(cfg.ContainerPath, record.Item)
+	rubbishFile := cfg.ItemPath(record.Item)
+
+	// Keep an untouched copy of the record, so a failed removal can journal
+	// the still present item back under its exact container name.
+	original := *record
 
 	if err := cfg.Journal.Delete(record.Item); err != nil {
 		return fmt.Errorf("error deleting record for %s: %v", record.Item, err)
 	}
 
-	if err := os.RemoveAll(rubbishFile); err != nil {
-		cfg.Journal.AddRecord(record) // Re-add the record if removal fails
+	err := shredItem(rubbishFile, cfg)
+	if err == nil && stage {
+		err = stageItem(&original, rubbishFile, cfg)
+	} else if err == nil {
+		err = removeAll(rubbishFile)
+	}
+	if err != nil {
+		if rerr := restoreRecord(&original, rubbishFile, cfg); rerr != nil {
+			return fmt.Errorf("error removing rubbish file %s: %v (%v)", rubbishFile, err, rerr)
+		}
 		return fmt.Errorf("error removing rubbish file %s: %v", rubbishFile, err)
 	}
+	cfg.PruneItemParents(record.Item)
 
-	fmt.Printf("Wiped %s successfully.\n", record.Item)
-	return nil
+	return cfg.RemoveTrashInfo(record.Item)
 }
 
-func wipeAllFiles(records []*journal.MetaData, cfg *config.Config) error {
+func wipeAllFiles(ctx context.Context, records []*journal.MetaData, sizes map[string]int64, cfg *config.Config) ([]*journal.MetaData, error) {
+	var wiped []*journal.MetaData
+
+	if !autoAcknowledge {
+		printSummary(records, sizes, cfg)
+	}
+	startProgress(len(records))
 
-	for _, record := range records {
+	for i, record := range records {
+		if err := interrupted(ctx, len(records)-i); err != nil {
+			return wiped, err
+		}
 
-		wipeConfirmed, err := confirm(record.Item)
+		wipeConfirmed, err := confirm(record, cfg)
 		if err != nil {
-			return fmt.Errorf("error confirming wipe for %s: %v", record.Item, err)
+			return wiped, fmt.Errorf("error confirming wipe for %s: %v", record.Item, err)
 		}
 
 		if !wipeConfirmed {
@@ -184,8 +533,10 @@ func wipeAllFiles(records []*journal.MetaData, cfg *config.Config) error {
 
 		if err := wipeItem(record, cfg); err != nil {
 			fmt.Printf("Error wiping %s: %v\n", record.Item, err)
+			continue
 		}
+		wiped = append(wiped, record)
 	}
 
-	return nil
+	return wiped, nil
 }
@@ -0,0 +1,440 @@
+package wipe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rubbish/config"
+	"rubbish/info"
+	"rubbish/journal"
+	"rubbish/prompt"
+	"rubbish/status"
+)
+
+func newTestCfg(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	j := &journal.Journal{Path: filepath.Join(dir, ".journal")}
+	if err := j.Load(); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	work := filepath.Join(dir, "work")
+	os.MkdirAll(work, 0o755)
+	return &config.Config{ContainerPath: dir, Journal: j, WorkingDir: work, WipeoutTime: 30}
+}
+
+// seed stores an item of the given size in the container, tossed from the working dir.
+func seed(t *testing.T, cfg *config.Config, name string, size int, wipeDays int, tossedAgo time.Duration) *journal.MetaData {
+	t.Helper()
+	record := &journal.MetaData{
+		Item:        name + "_ABCDEF",
+		Origin:      filepath.Join(cfg.WorkingDir, name),
+		Type:        journal.TypeFile,
+		WipeoutTime: wipeDays,
+		TossedTime:  time.Now().Add(-tossedAgo).Unix(),
+	}
+	os.WriteFile(filepath.Join(cfg.ContainerPath, record.Item), bytes.Repeat([]byte{'x'}, size), 0o644)
+	if err := cfg.Journal.AddRecord(record); err != nil {
+		t.Fatalf("add %s: %v", name, err)
+	}
+	return record
+}
+
+func scriptInput(t *testing.T, script string) {
+	t.Helper()
+	orig := prompt.Input
+	prompt.Input = strings.NewReader(script)
+	t.Cleanup(func() { prompt.Input = orig })
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestWipeAllFiles_EnrichedPromptAndSummary(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 2048, 1, 72*time.Hour)
+	b := seed(t, cfg, "b.log", 1024, 1, 48*time.Hour)
+
+	scriptInput(t, "n\ny\n")
+	out := captureStdout(t, func() {
+		if _, err := wipeAllFiles(context.Background(), []*journal.MetaData{a, b}, reclaimable([]*journal.MetaData{a, b}, cfg), cfg); err != nil {
+			t.Fatalf("wipeAllFiles: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "This will free 3.0 KB across 2 items.") {
+		t.Errorf("missing batch summary: %s", out)
+	}
+	if strings.Index(out, "This will free") > strings.Index(out, "Are you sure") {
+		t.Errorf("summary must precede the first prompt: %s", out)
+	}
+	want := "Are you sure you want to wipe 'a.log_ABCDEF' (origin: " + a.Origin + ", size: 2.0 KB, tossed 3.0d ago)? [y/N]: "
+	if !strings.Contains(out, want) {
+		t.Errorf("missing enriched prompt %q in: %s", want, out)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, a.Item)); err != nil {
+		t.Errorf("declined item must be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, b.Item)); !os.IsNotExist(err) {
+		t.Errorf("confirmed item must be wiped")
+	}
+}
+
+func TestWipeAllFiles_AutoAcknowledgeSkipsPrompt(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 72*time.Hour)
+	autoAcknowledge = true
+	defer func() { autoAcknowledge = false }()
+
+	out := captureStdout(t, func() {
+		if _, err := wipeAllFiles(context.Background(), []*journal.MetaData{a}, reclaimable([]*journal.MetaData{a}, cfg), cfg); err != nil {
+			t.Fatalf("wipeAllFiles: %v", err)
+		}
+	})
+	if strings.Contains(out, "Are you sure") || strings.Contains(out, "selected for wipe") {
+		t.Errorf("no prompt expected with -y: %s", out)
+	}
+}
+
+func TestCommand_EmptyRemovesEverythingButJournal(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.log", 100, 30, time.Hour)
+	seed(t, cfg, "b.log", 50, 30, time.Hour)
+	os.MkdirAll(filepath.Join(cfg.ContainerPath, "orphan_dir"), 0o755)
+	os.WriteFile(filepath.Join(cfg.ContainerPath, "orphan_dir", "x"), bytes.Repeat([]byte{'o'}, 25), 0o644)
+	os.RemoveAll(cfg.WorkingDir)
+
+	emptyMode = true
+	defer func() { emptyMode = false }()
+	scriptInput(t, "y\n")
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "3 items wiped (1 orphans), 175 bytes freed") {
+		t.Errorf("unexpected summary: %s", out)
+	}
+	if strings.Count(out, "[y/N]") != 1 {
+		t.Errorf("expected a single confirmation: %s", out)
+	}
+
+	entries, _ := os.ReadDir(cfg.ContainerPath)
+	if len(entries) != 1 || entries[0].Name() != ".journal" {
+		t.Errorf("expected only the journal to remain, got %v", entries)
+	}
+	if count, _ := cfg.Journal.Count(); count != 0 {
+		t.Errorf("expected journal cleared, got %d records", count)
+	}
+}
+
+func TestCommand_EmptyDeclined(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 30, time.Hour)
+	emptyMode = true
+	defer func() { emptyMode = false }()
+	scriptInput(t, "n\n")
+
+	captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, a.Item)); err != nil {
+		t.Errorf("nothing must be wiped when declined: %v", err)
+	}
+	if count, _ := cfg.Journal.Count(); count != 1 {
+		t.Errorf("journal must be kept when declined, got %d", count)
+	}
+}
+
+func TestCommand_OrphansRemovesOnlyUnrecordedFiles(t *testing.T) {
+	cfg := newTestCfg(t)
+	os.Remove(cfg.WorkingDir)
+	cfg.WorkingDir = t.TempDir()
+	kept := seed(t, cfg, "a.log", 10, 30, time.Hour)
+	os.WriteFile(filepath.Join(cfg.ContainerPath, "lost_QWERTY"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(cfg.ContainerPath, "spare_ZXCVBN"), []byte("x"), 0o644)
+	orphansMode = true
+	defer func() { orphansMode = false }()
+	scriptInput(t, "y\nn\n")
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Found 2 orphan files") || !strings.Contains(out, "Removed 1 of 2 orphan files.") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, "lost_QWERTY")); !os.IsNotExist(err) {
+		t.Errorf("confirmed orphan must be removed, stat err: %v", err)
+	}
+	for _, name := range []string{"spare_ZXCVBN", kept.Item, ".journal"} {
+		if _, err := os.Stat(filepath.Join(cfg.ContainerPath, name)); err != nil {
+			t.Errorf("%s must be kept: %v", name, err)
+		}
+	}
+}
+
+func TestWipeAllFiles_ConfirmTimeoutSkips(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	r, w := io.Pipe()
+	defer w.Close()
+	orig := prompt.Input
+	prompt.Input = r
+	prompt.Timeout = 50 * time.Millisecond
+	defer func() { prompt.Input, prompt.Timeout = orig, 0 }()
+
+	records, _ := cfg.Journal.List()
+	out := captureStdout(t, func() {
+		if _, err := wipeAllFiles(context.Background(), records, reclaimable(records, cfg), cfg); err != nil {
+			t.Fatalf("wipeAllFiles error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Skipping "+a.Item) {
+		t.Errorf("expected the item to be skipped: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ContainerPath, a.Item)); err != nil {
+		t.Errorf("item must not be wiped without an answer: %v", err)
+	}
+}
+
+func TestWipeItem_RemovalFailureRestoresExactRecord(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	record.Batch, record.Size, record.WipeableAt = "20240115-093000-AAAA", 10, time.Now().Unix()
+	cfg.Journal.AddRecord(record)
+	original := *record
+
+	removeAll = func(string) error { return os.ErrPermission }
+	defer func() { removeAll = os.RemoveAll }()
+
+	if err := wipeItem(record, cfg); err == nil {
+		t.Fatal("expected error when the item cannot be removed")
+	}
+
+	restored, err := cfg.Journal.Get(original.Item)
+	if err != nil {
+		t.Fatalf("record not journaled back: %v", err)
+	}
+	if !reflect.DeepEqual(*restored, original) {
+		t.Errorf("restored record differs:\n got %+v\nwant %+v", *restored, original)
+	}
+
+	out := captureStdout(t, func() {
+		if err := info.Command([]string{original.Item}, cfg); err != nil {
+			t.Errorf("info cannot resolve the item: %v", err)
+		}
+		status.Flags.Parse([]string{"-g"})
+		defer status.Flags.Parse([]string{"-g=false"})
+		if err := status.Command(nil, cfg); err != nil {
+			t.Errorf("status error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Item: "+original.Item) || !strings.Contains(out, " > "+original.Item+" | ") {
+		t.Errorf("item not resolved by info and status: %s", out)
+	}
+}
+
+func TestWipeItem_RemovalFailureWithItemGoneIsNotRestored(t *testing.T) {
+	cfg := newTestCfg(t)
+	record := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+
+	removeAll = func(path string) error {
+		os.RemoveAll(path)
+		return os.ErrPermission
+	}
+	defer func() { removeAll = os.RemoveAll }()
+
+	wipeItem(record, cfg)
+
+	if _, err := cfg.Journal.Get(record.Item); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("a record without item must not be journaled back, got %v", err)
+	}
+}
+
+func TestCommand_DryRunListsWithoutWiping(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	b := seed(t, cfg, "b.log", 20, 1, 48*time.Hour)
+	c := seed(t, cfg, "c.log", 30, 1, 48*time.Hour)
+	Flags.Parse([]string{"--dry-run", "-g", "-f", b.Item, c.Item})
+	defer Flags.Parse([]string{"--dry-run=false", "-g=false", "-f=false"})
+
+	out := captureStdout(t, func() {
+		if err := Command(nil, cfg); err != nil {
+			t.Fatalf("Command error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "Would wipe "+b.Item) || !strings.Contains(out, "Would wipe "+c.Item) || strings.Contains(out, "Would wipe "+a.Item) {
+		t.Errorf("expected only the selected items listed: %s", out)
+	}
+	if !strings.Contains(out, "Dry run: 2 items would be wiped, 50 bytes in total.") {
+		t.Errorf("missing dry run summary: %s", out)
+	}
+	for _, record := range []*journal.MetaData{a, b, c} {
+		if _, err := os.Stat(filepath.Join(cfg.ContainerPath, record.Item)); err != nil {
+			t.Errorf("%s must not be removed in dry run: %v", record.Item, err)
+		}
+	}
+	if count, _ := cfg.Journal.Count(); count != 3 {
+		t.Errorf("journal must not change in dry run, got %d records", count)
+	}
+}
+
+func TestWipeSelectedFiles_FindsAnyCandidate(t *testing.T) {
+	cfg := newTestCfg(t)
+	seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	b := seed(t, cfg, "b.log", 10, 1, 48*time.Hour)
+	autoAcknowledge = true
+	defer func() { autoAcknowledge = false }()
+
+	records, _ := cfg.Journal.List()
+	captureStdout(t, func() {
+		if _, err := wipeSelectedFiles(context.Background(), records, []string{b.Item}, cfg); err != nil {
+			t.Fatalf("wipeSelectedFiles error: %v", err)
+		}
+	})
+
+	if _, err := cfg.Journal.Get(b.Item); err == nil {
+		t.Errorf("%s must be wiped even when not the first candidate", b.Item)
+	}
+}
+
+func TestWipeSelectedFiles_ByPrefix(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	seed(t, cfg, "a.log.1", 10, 1, 48*time.Hour)
+	autoAcknowledge = true
+	defer func() { autoAcknowledge = false }()
+
+	records, _ := cfg.Journal.List()
+	if _, err := wipeSelectedFiles(context.Background(), records, []string{"a.log"}, cfg); !errors.Is(err, journal.ErrAmbiguousItem) {
+		t.Fatalf("expected a prefix of both keys to be ambiguous, got %v", err)
+	}
+	if _, err := wipeSelectedFiles(context.Background(), records, []string{"z.log"}, cfg); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an unknown name to be reported, got %v", err)
+	}
+
+	captureStdout(t, func() {
+		if _, err := wipeSelectedFiles(context.Background(), records, []string{"a.log_AB"}, cfg); err != nil {
+			t.Fatalf("wipeSelectedFiles error: %v", err)
+		}
+	})
+	if _, err := cfg.Journal.Get(a.Item); err == nil {
+		t.Errorf("%s must be wiped by its unique prefix", a.Item)
+	}
+	if count, _ := cfg.Journal.Count(); count != 1 {
+		t.Errorf("only the matching item must be wiped, %d left", count)
+	}
+}
+
+func TestWipeSelectedFiles_MirroredItem(t *testing.T) {
+	cfg := newTestCfg(t)
+	cfg.Layout = config.LayoutMirrored
+	record := &journal.MetaData{Item: "home/user/docs/a.log", Origin: "/home/user/docs/a.log", Type: journal.TypeFile}
+	os.MkdirAll(filepath.Dir(cfg.ItemPath(record.Item)), 0o755)
+	os.WriteFile(cfg.ItemPath(record.Item), []byte("a"), 0o644)
+	cfg.Journal.AddRecord(record)
+	autoAcknowledge = true
+	defer func() { autoAcknowledge = false }()
+
+	captureStdout(t, func() {
+		if _, err := wipeSelectedFiles(context.Background(), []*journal.MetaData{record}, []string{record.Item}, cfg); err != nil {
+			t.Fatalf("wipeSelectedFiles error: %v", err)
+		}
+	})
+
+	if _, err := cfg.Journal.Get(record.Item); err == nil {
+		t.Errorf("%s must be wiped", record.Item)
+	}
+	if _, err := os.Stat(cfg.ItemPath("home")); !os.IsNotExist(err) {
+		t.Errorf("the directories left empty must be pruned, got %v", err)
+	}
+}
+
+func TestWipeAllFiles_VerboseCountsItems(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	b := seed(t, cfg, "b.log", 10, 1, 48*time.Hour)
+	autoAcknowledge, verbose = true, true
+	defer func() { autoAcknowledge, verbose = false, false }()
+
+	out := captureStdout(t, func() {
+		if _, err := wipeAllFiles(context.Background(), []*journal.MetaData{a, b}, reclaimable([]*journal.MetaData{a, b}, cfg), cfg); err != nil {
+			t.Fatalf("wipeAllFiles: %v", err)
+		}
+	})
+
+	for _, want := range []string{"[1/2] wiped a.log_ABCDEF", "[2/2] wiped b.log_ABCDEF"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "successfully") {
+		t.Errorf("verbose output must replace the per item message:\n%s", out)
+	}
+}
+
+func TestWipeAllFiles_StopsBetweenItems(t *testing.T) {
+	cfg := newTestCfg(t)
+	a := seed(t, cfg, "a.log", 10, 1, 48*time.Hour)
+	b := seed(t, cfg, "b.log", 10, 1, 48*time.Hour)
+	autoAcknowledge = true
+	defer func() { autoAcknowledge = false }()
+
+	// The interruption arrives while the first item is removed
+	ctx, cancel := context.WithCancel(context.Background())
+	removeAll = func(path string) error {
+		cancel()
+		return os.RemoveAll(path)
+	}
+	defer func() { removeAll = os.RemoveAll }()
+
+	var wiped []*journal.MetaData
+	var err error
+	captureStdout(t, func() {
+		wiped, err = wipeAllFiles(ctx, []*journal.MetaData{a, b}, reclaimable([]*journal.MetaData{a, b}, cfg), cfg)
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wipe to be interrupted, got %v", err)
+	}
+	if len(wiped) != 1 || wiped[0].Item != a.Item {
+		t.Errorf("expected only the item in flight wiped, got %v", wiped)
+	}
+	if _, err := cfg.Journal.Get(a.Item); !errors.Is(err, journal.ErrItemNotFound) {
+		t.Errorf("the item in flight must be completed, got %v", err)
+	}
+	if _, err := cfg.Journal.Get(b.Item); err != nil {
+		t.Errorf("the remaining item must be left alone: %v", err)
+	}
+}